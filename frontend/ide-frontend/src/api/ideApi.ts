@@ -1,4 +1,4 @@
-import axios from 'axios'
+import axios, { AxiosError } from 'axios'
 
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8090/api/v1'
 
@@ -16,6 +16,7 @@ export interface Topology {
   profile_type: 'rural' | 'suburban' | 'urban'
   nodes: Node[]
   lines: Line[]
+  version: number
   created_at: string
   updated_at: string
 }
@@ -71,6 +72,29 @@ export interface Profile {
   }
 }
 
+// 拓樸已被其他人修改（HTTP 412），current 為伺服器目前的版本
+export class TopologyConflictError extends Error {
+  current: Topology
+
+  constructor(current: Topology) {
+    super('topology version conflict')
+    this.name = 'TopologyConflictError'
+    this.current = current
+  }
+}
+
+// ifMatch 產生樂觀鎖定用的 If-Match 標頭
+const ifMatch = (version: number) => ({ 'If-Match': `"${version}"` })
+
+// rethrowConflict 將 412 回應轉換為 TopologyConflictError
+function rethrowConflict(error: unknown): never {
+  const axiosError = error as AxiosError<{ current?: Topology }>
+  if (axiosError.response?.status === 412 && axiosError.response.data?.current) {
+    throw new TopologyConflictError(axiosError.response.data.current)
+  }
+  throw error
+}
+
 export const ideApi = {
   // Topology APIs
   async createTopology(data: CreateTopologyRequest): Promise<Topology> {
@@ -83,13 +107,28 @@ export const ideApi = {
     return response.data
   },
 
-  async updateTopology(id: string, data: Partial<CreateTopologyRequest>): Promise<Topology> {
-    const response = await apiClient.put<Topology>(`/topologies/${id}`, data)
-    return response.data
+  // version 為讀取時取得的拓樸版本，伺服器版本不同時拋出 TopologyConflictError
+  async updateTopology(
+    id: string,
+    data: Partial<CreateTopologyRequest>,
+    version: number
+  ): Promise<Topology> {
+    try {
+      const response = await apiClient.put<Topology>(`/topologies/${id}`, data, {
+        headers: ifMatch(version),
+      })
+      return response.data
+    } catch (error) {
+      rethrowConflict(error)
+    }
   },
 
-  async deleteTopology(id: string): Promise<void> {
-    await apiClient.delete(`/topologies/${id}`)
+  async deleteTopology(id: string, version: number): Promise<void> {
+    try {
+      await apiClient.delete(`/topologies/${id}`, { headers: ifMatch(version) })
+    } catch (error) {
+      rethrowConflict(error)
+    }
   },
 
   async listTopologies(): Promise<Topology[]> {
@@ -13,7 +13,7 @@ import ReactFlow, {
 } from 'reactflow'
 import 'reactflow/dist/style.css'
 import { simApi, PowerflowResult } from '../api/simApi'
-import { ideApi } from '../api/ideApi'
+import { ideApi, Topology, TopologyConflictError } from '../api/ideApi'
 import { useFeaturePermission } from '../hooks/useFeaturePermission'
 import CustomNode from './CustomNode'
 import './TopologyCanvas.css'
@@ -44,6 +44,8 @@ function TopologyCanvas({
   const [isRunningSimulation, setIsRunningSimulation] = useState(false)
   const [isSaving, setIsSaving] = useState(false)
   const [isLoading, setIsLoading] = useState(false)
+  // 畫布目前對應的拓樸版本，儲存時作為 If-Match
+  const [loadedVersion, setLoadedVersion] = useState<{ id: string; version: number } | null>(null)
 
   const onConnect = useCallback(
     (params: Connection) => setEdges((eds) => addEdge(params, eds)),
@@ -142,6 +144,43 @@ function TopologyCanvas({
     )
   }, [simulationResult, setNodes])
 
+  // 將伺服器上的拓樸套用到畫布
+  const applyTopology = useCallback(
+    (topology: Topology) => {
+      // 轉換為 React Flow 格式
+      const flowNodes: Node[] = topology.nodes.map((node) => ({
+        id: node.id,
+        type: 'default',
+        position: node.position,
+        data: {
+          label: node.name,
+          type: node.type,
+          properties: node.properties || {},
+        },
+        style: {
+          background: '#2a2a2a',
+          color: '#fff',
+          border: '1px solid #444',
+          borderRadius: '4px',
+          padding: '10px',
+        },
+      }))
+
+      const flowEdges: Edge[] = topology.lines.map((line) => ({
+        id: line.id,
+        source: line.from_node_id,
+        target: line.to_node_id,
+        label: line.name,
+      }))
+
+      setNodes(flowNodes)
+      setEdges(flowEdges)
+      setLoadedVersion({ id: topology.id, version: topology.version })
+      onTopologyIdChange(topology.id)
+    },
+    [setNodes, setEdges, onTopologyIdChange]
+  )
+
   // 儲存拓樸
   const handleSaveTopology = useCallback(async () => {
     if (nodes.length === 0) {
@@ -186,22 +225,34 @@ function TopologyCanvas({
       }
 
       if (currentTopologyId) {
-        // 更新現有拓樸
-        await ideApi.updateTopology(currentTopologyId, topologyData)
+        // 更新現有拓樸；拓樸由其他畫布建立時先取得目前版本
+        const version =
+          loadedVersion?.id === currentTopologyId
+            ? loadedVersion.version
+            : (await ideApi.getTopology(currentTopologyId)).version
+        const result = await ideApi.updateTopology(currentTopologyId, topologyData, version)
+        setLoadedVersion({ id: result.id, version: result.version })
         alert(t('topology.topology_updated'))
       } else {
         // 建立新拓樸
         const result = await ideApi.createTopology(topologyData)
+        setLoadedVersion({ id: result.id, version: result.version })
         onTopologyIdChange(result.id)
         alert(t('topology.topology_saved'))
       }
     } catch (error) {
+      if (error instanceof TopologyConflictError) {
+        // 他人已修改，改為載入伺服器上的最新版本
+        applyTopology(error.current)
+        alert(t('topology.version_conflict'))
+        return
+      }
       console.error('Save failed:', error)
       alert(t('topology.save_failed'))
     } finally {
       setIsSaving(false)
     }
-  }, [nodes, edges, currentTopologyId, onTopologyIdChange, t])
+  }, [nodes, edges, currentTopologyId, loadedVersion, applyTopology, onTopologyIdChange, t])
 
   // 載入拓樸
   const handleLoadTopology = useCallback(async () => {
@@ -215,37 +266,8 @@ function TopologyCanvas({
 
       // 簡單選擇第一個（之後可以改成選擇對話框）
       const topology = topologies[0]
-      onTopologyIdChange(topology.id)
-
-      // 轉換為 React Flow 格式
-      const flowNodes: Node[] = topology.nodes.map((node) => ({
-        id: node.id,
-        type: 'default',
-        position: node.position,
-        data: {
-          label: node.name,
-          type: node.type,
-          properties: node.properties || {},
-        },
-        style: {
-          background: '#2a2a2a',
-          color: '#fff',
-          border: '1px solid #444',
-          borderRadius: '4px',
-          padding: '10px',
-        },
-      }))
-
-      const flowEdges: Edge[] = topology.lines.map((line) => ({
-        id: line.id,
-        source: line.from_node_id,
-        target: line.to_node_id,
-        label: line.name,
-      }))
 
-      setNodes(flowNodes)
-      setEdges(flowEdges)
-      onTopologyIdChange(topology.id)
+      applyTopology(topology)
       alert(t('topology.topology_loaded'))
     } catch (error) {
       console.error('Load failed:', error)
@@ -253,7 +275,7 @@ function TopologyCanvas({
     } finally {
       setIsLoading(false)
     }
-  }, [setNodes, setEdges, onTopologyIdChange, t])
+  }, [applyTopology, t])
 
   // 執行模擬
   const handleRunSimulation = useCallback(async () => {
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/catalog"
+	"github.com/feeder-platform/feeder-ide-api/internal/middleware"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogHandler 處理設備型錄相關的 HTTP 請求
+type CatalogHandler struct {
+	repo catalog.Repository
+}
+
+// NewCatalogHandler 建立新的 CatalogHandler
+func NewCatalogHandler(repo catalog.Repository) *CatalogHandler {
+	return &CatalogHandler{repo: repo}
+}
+
+// CatalogEntryRequest 建立或更新型錄項目的請求
+type CatalogEntryRequest struct {
+	Type         string                 `json:"type"` // 建立時必填，更新時不可變更
+	Name         string                 `json:"name" binding:"required"`
+	Manufacturer string                 `json:"manufacturer,omitempty"`
+	Standard     string                 `json:"standard,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	Properties   map[string]interface{} `json:"properties"`
+	// OrganizationID 建立組織共用的項目（需要組織的 engineer 以上角色），更新時忽略
+	OrganizationID *string `json:"organization_id,omitempty"`
+}
+
+// ListEntries 列出設備型錄
+// @Summary 列出設備型錄
+// @Description 列出目前用戶可見的型錄項目（內建標準項目、自己建立的項目、所屬組織的項目）
+// @Tags catalog
+// @Produce json
+// @Param type query string false "類型：line_code、transformer、switch、pv_inverter、battery、ev_charger"
+// @Param q query string false "搜尋名稱、製造商或標準"
+// @Success 200 {array} catalog.Entry
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/catalog [get]
+func (h *CatalogHandler) ListEntries(c *gin.Context) {
+	filter := catalog.Filter{
+		Type:       c.Query("type"),
+		Query:      c.Query("q"),
+		Visibility: catalogVisibility(c),
+	}
+	if filter.Type != "" && catalog.ElementType(filter.Type) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown catalog type: " + filter.Type})
+		return
+	}
+
+	entries, err := h.repo.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetEntry 取得型錄項目
+// @Summary 取得型錄項目
+// @Tags catalog
+// @Produce json
+// @Param entryId path string true "型錄項目 ID"
+// @Success 200 {object} catalog.Entry
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/catalog/{entryId} [get]
+func (h *CatalogHandler) GetEntry(c *gin.Context) {
+	entry, ok := h.loadEntry(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// CreateEntry 建立型錄項目
+// @Summary 建立型錄項目
+// @Description 建立用戶自己的型錄項目；指定 organization_id 時建立組織成員共用的項目；
+// @Description 未啟用認證時建立的項目為全域項目。節點或線路以 properties.catalog_id 參照
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param entry body CatalogEntryRequest true "型錄項目"
+// @Success 201 {object} catalog.Entry
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/catalog [post]
+func (h *CatalogHandler) CreateEntry(c *gin.Context) {
+	var req CatalogEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := &catalog.Entry{
+		Type:         req.Type,
+		Name:         req.Name,
+		Manufacturer: req.Manufacturer,
+		Standard:     req.Standard,
+		Description:  req.Description,
+		Properties:   req.Properties,
+		Scope:        catalog.ScopeGlobal,
+	}
+	if userID := auth.GetUserID(c); userID != nil {
+		entry.Scope = catalog.ScopeUser
+		entry.OwnerID = userID
+	}
+	if req.OrganizationID != nil {
+		if !requireOrganizationRole(c, *req.OrganizationID, organization.RoleEngineer) {
+			return
+		}
+		entry.Scope = catalog.ScopeOrganization
+		entry.OrganizationID = req.OrganizationID
+	}
+	if err := entry.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.Create(entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateEntry 更新型錄項目
+// @Summary 更新型錄項目
+// @Description 更新名稱、說明與屬性；類型與範圍不可變更，內建項目不可修改。參照此項目的拓樸在下次分析時套用新屬性
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param entryId path string true "型錄項目 ID"
+// @Param entry body CatalogEntryRequest true "型錄項目"
+// @Success 200 {object} catalog.Entry
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/catalog/{entryId} [put]
+func (h *CatalogHandler) UpdateEntry(c *gin.Context) {
+	var req CatalogEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, ok := h.loadEditableEntry(c)
+	if !ok {
+		return
+	}
+	if req.Type != "" && req.Type != entry.Type {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "catalog entry type cannot be changed"})
+		return
+	}
+
+	entry.Name = req.Name
+	entry.Manufacturer = req.Manufacturer
+	entry.Standard = req.Standard
+	entry.Description = req.Description
+	entry.Properties = req.Properties
+	if err := entry.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.Update(entry); err != nil {
+		c.JSON(catalogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteEntry 刪除型錄項目
+// @Summary 刪除型錄項目
+// @Description 刪除後仍參照此項目的元素在分析時改用自身屬性與預設值，寫入拓樸時會被回報為無效參照
+// @Tags catalog
+// @Param entryId path string true "型錄項目 ID"
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/catalog/{entryId} [delete]
+func (h *CatalogHandler) DeleteEntry(c *gin.Context) {
+	entry, ok := h.loadEditableEntry(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(entry.ID); err != nil {
+		c.JSON(catalogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadEntry 取得目前用戶可見的型錄項目，失敗時直接回應錯誤並回傳 false
+func (h *CatalogHandler) loadEntry(c *gin.Context) (*catalog.Entry, bool) {
+	entry, err := h.repo.GetByID(c.Param("entryId"))
+	if err == nil && !entry.VisibleTo(catalogVisibility(c)) {
+		err = catalog.ErrEntryNotFound
+	}
+	if err != nil {
+		c.JSON(catalogErrorStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return entry, true
+}
+
+// loadEditableEntry 取得目前用戶可修改的型錄項目，失敗時直接回應錯誤並回傳 false
+func (h *CatalogHandler) loadEditableEntry(c *gin.Context) (*catalog.Entry, bool) {
+	entry, ok := h.loadEntry(c)
+	if !ok {
+		return nil, false
+	}
+	if !entry.EditableBy(catalogVisibility(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": catalog.ErrReadOnly.Error()})
+		return nil, false
+	}
+	if entry.OrganizationID != nil && !requireOrganizationRole(c, *entry.OrganizationID, organization.RoleEngineer) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// catalogVisibility 目前用戶的型錄可見範圍（含所屬組織的項目）
+func catalogVisibility(c *gin.Context) catalog.Visibility {
+	visibility := catalog.Visibility{UserID: auth.GetUserID(c)}
+	for organizationID := range middleware.GetOrganizationRolesFromContext(c) {
+		visibility.OrganizationIDs = append(visibility.OrganizationIDs, organizationID)
+	}
+	sort.Strings(visibility.OrganizationIDs)
+	return visibility
+}
+
+// catalogErrorStatus 將型錄錯誤對應到 HTTP 狀態碼
+func catalogErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, catalog.ErrEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, catalog.ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, catalog.ErrInvalidEntry):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
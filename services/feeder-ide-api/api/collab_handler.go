@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/catalog"
+	"github.com/feeder-platform/feeder-ide-api/internal/collab"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// collabWriteWait 寫入單一訊息的逾時
+	collabWriteWait = 10 * time.Second
+	// collabPongWait 未收到 pong（或任何訊息）即視為斷線的時間
+	collabPongWait = 60 * time.Second
+	// collabPingPeriod 送出 ping 的間隔，需小於 collabPongWait
+	collabPingPeriod = 50 * time.Second
+	// collabMaxMessageSize 用戶端單一訊息的大小上限
+	collabMaxMessageSize = 1 << 20
+)
+
+// collabUpgrader WebSocket 升級設定；API 以 Bearer token（或一次性連線票券）而非 cookie 認證，
+// 因此與 CORS 設定一致允許任何來源
+var collabUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// CollaborationHandler 處理拓樸即時協作編輯的 WebSocket 連線
+type CollaborationHandler struct {
+	repo    topology.Repository
+	hub     *collab.Hub
+	tickets *collab.Tickets
+}
+
+// NewCollaborationHandler 建立新的 CollaborationHandler；catalogRepo 不為 nil 時操作也會檢查設備型錄參照，
+// orgService 不為 nil 時重新判斷角色會重新讀取組織成員資格
+func NewCollaborationHandler(repo topology.Repository, operations collab.Repository, catalogRepo catalog.Repository, orgService *organization.Service) *CollaborationHandler {
+	validate := func(t *topology.Topology) (*topology.ValidationResult, error) {
+		result := topology.Validate(t)
+		if catalogRepo != nil {
+			violations, err := catalog.CheckReferences(t, catalogRepo, catalog.OwnerVisibility(t))
+			if err != nil {
+				return nil, err
+			}
+			result.Add(violations...)
+		}
+		return result, nil
+	}
+	resolve := func(topologyID string, principal topology.Principal) (topology.Role, error) {
+		if orgService != nil && principal.UserID != nil {
+			roles, err := orgService.GetMemberRoles(*principal.UserID)
+			if err != nil {
+				return topology.RoleNone, err
+			}
+			principal.OrganizationRoles = organization.TopologyRoles(roles)
+		}
+		_, role, err := repo.Authorize(topologyID, principal)
+		return role, err
+	}
+	return &CollaborationHandler{
+		repo:    repo,
+		hub:     collab.NewHub(repo, operations, validate, resolve),
+		tickets: collab.NewTickets(),
+	}
+}
+
+// Revalidate 重新判斷拓樸所有協作連線的角色，失去存取權的連線會被中斷
+func (h *CollaborationHandler) Revalidate(topologyID string) {
+	h.hub.Revalidate(topologyID)
+}
+
+// RevalidateAll 重新判斷所有協作連線的角色
+func (h *CollaborationHandler) RevalidateAll() {
+	h.hub.RevalidateAll()
+}
+
+// CollaborationTicketResponse 協作連線票券
+type CollaborationTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IssueCollaborationTicket 取得協作連線票券
+// @Summary 取得協作連線票券
+// @Description 瀏覽器的 WebSocket 無法設定標頭：先以 Authorization 或 X-Share-Token 標頭取得票券，
+// @Description 再以 /collaborate?ticket= 連線。票券 30 秒內有效且只能使用一次，角色在連線時重新判斷。需要 viewer 以上權限
+// @Tags collaboration
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Success 201 {object} CollaborationTicketResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/collaborate/ticket [post]
+func (h *CollaborationHandler) IssueCollaborationTicket(c *gin.Context) {
+	topo, _, ok := authorizeTopology(c, h.repo, topology.RoleViewer)
+	if !ok {
+		return
+	}
+
+	ticket, expiresAt, err := h.tickets.Issue(topo.ID, collab.Identity{
+		Principal: topologyPrincipal(c),
+		Email:     c.GetString("user_email"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CollaborationTicketResponse{Ticket: ticket, ExpiresAt: expiresAt})
+}
+
+// Collaborate 建立拓樸協作編輯的 WebSocket 連線
+// @Summary 即時協作編輯
+// @Description WebSocket 連線。連線後收到 welcome（client_id、角色、版本、在線成員與鎖定），
+// @Description 接著是完整拓樸（snapshot）或 since 版本之後的操作（op）。用戶端送出 op（元素層級操作）、
+// @Description presence（游標與選取範圍）、lock/unlock（軟鎖定）與 ping；操作由伺服器依序套用、
+// @Description 儲存為新版本並廣播給所有連線（包含送出者），被拒絕時收到 reject。
+// @Description 需要 viewer 以上權限，op 與 lock 需要 editor 以上權限；每次 op 與 lock 前以及共用設定變更時重新判斷角色，
+// @Description 角色變更時收到 role，失去存取權時連線被中斷。瀏覽器以 POST /collaborate/ticket 取得的一次性票券連線
+// @Tags collaboration
+// @Param id path string true "拓樸 ID"
+// @Param since query int false "用戶端已有的拓樸版本，重新連線時用於補齊操作"
+// @Param ticket query string false "一次性連線票券（無法設定 Authorization 或 X-Share-Token 標頭時）"
+// @Success 101
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/collaborate [get]
+func (h *CollaborationHandler) Collaborate(c *gin.Context) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "websocket upgrade required"})
+		return
+	}
+	since := -1
+	if value := c.Query("since"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a non-negative version"})
+			return
+		}
+		since = parsed
+	}
+
+	identity := collab.Identity{
+		Principal: topologyPrincipal(c),
+		Email:     c.GetString("user_email"),
+	}
+	if ticket := c.Query("ticket"); ticket != "" {
+		redeemed, err := h.tickets.Redeem(ticket, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		identity = redeemed
+	}
+
+	topo, role, ok := authorizeTopologyAs(c, h.repo, identity.Principal, topology.RoleViewer)
+	if !ok {
+		return
+	}
+	identity.Role = role
+
+	client, err := h.hub.Join(topo.ID, identity, since)
+	if err != nil {
+		if err == topology.ErrTopologyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := collabUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已回應錯誤
+		h.hub.Leave(client)
+		return
+	}
+
+	go writeCollabMessages(conn, client)
+	h.readCollabMessages(conn, client)
+}
+
+// readCollabMessages 讀取用戶端訊息交給 Hub 處理，連線結束時離開協作工作階段
+func (h *CollaborationHandler) readCollabMessages(conn *websocket.Conn, client *collab.Client) {
+	defer h.hub.Leave(client)
+
+	conn.SetReadLimit(collabMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(collabPongWait))
+
+		var msg collab.ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			h.hub.SendError(client, err)
+			continue
+		}
+		h.hub.Handle(client, &msg)
+	}
+}
+
+// writeCollabMessages 依序寫出 Hub 送給連線的訊息並定期送出 ping；
+// 訊息通道關閉（離開、緩衝已滿或失去存取權）時送出 close frame 並關閉連線
+func writeCollabMessages(conn *websocket.Conn, client *collab.Client) {
+	ticker := time.NewTicker(collabPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case data, open := <-client.Messages():
+			_ = conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if !open {
+				closeMessage := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect to catch up")
+				if client.Revoked() {
+					closeMessage = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "access revoked")
+				}
+				_ = conn.WriteMessage(websocket.CloseMessage, closeMessage)
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/contingency"
+	"github.com/feeder-platform/feeder-ide-api/internal/job"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// contingencySyncLimit 事故元件超過此數量時改為非同步工作執行
+const contingencySyncLimit = 100
+
+// ContingencyHandler 處理 N-1 事故與重新配置相關的 HTTP 請求
+type ContingencyHandler struct {
+	repo        topology.Repository
+	userService *user.Service
+	jobs        *job.Manager
+}
+
+// NewContingencyHandler 建立新的 ContingencyHandler
+func NewContingencyHandler(repo topology.Repository, userService *user.Service, jobs *job.Manager) *ContingencyHandler {
+	return &ContingencyHandler{
+		repo:        repo,
+		userService: userService,
+		jobs:        jobs,
+	}
+}
+
+// RunContingency 執行 N-1 事故分析
+// @Summary 執行 N-1 事故分析
+// @Description 逐一停用供電中的線路與變壓器，閉合常開聯絡開關轉供停電區域並重新執行潮流，依未供電負載與違規數排序事故。事故元件超過 100 個或指定 async=true 時改為提交 contingency 工作並回應 202
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param async query bool false "強制非同步執行"
+// @Param options body contingency.Options false "分析參數"
+// @Success 200 {object} contingency.Result
+// @Success 202 {object} job.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/contingency [post]
+func (h *ContingencyHandler) RunContingency(c *gin.Context) {
+	var opts contingency.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	elements, err := contingency.Elements(topo, opts)
+	if err != nil {
+		c.JSON(contingencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(elements) <= contingencySyncLimit && c.Query("async") != "true" {
+		result, err := contingency.Analyze(c.Request.Context(), topo, opts, nil)
+		if err != nil {
+			c.JSON(contingencyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !chargeSimulation(c, h.userService) {
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	params, err := json.Marshal(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	submitJob(c, h.jobs, jobTypeContingency, topo, params, contingencyJob(topo, opts))
+}
+
+// RunReconfiguration 執行損失最小化的開關重新配置
+// @Summary 執行開關重新配置
+// @Description 以分支交換法選擇開關開閉狀態，在維持輻射狀且不造成停電的前提下使線路損失最低。結果僅為建議，不會修改拓樸
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param options body contingency.ReconfigurationOptions false "分析參數"
+// @Success 200 {object} contingency.ReconfigurationResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/reconfiguration [post]
+func (h *ContingencyHandler) RunReconfiguration(c *gin.Context) {
+	var opts contingency.ReconfigurationOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	result, err := contingency.Reconfigure(c.Request.Context(), topo, opts)
+	if err != nil {
+		c.JSON(contingencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if !chargeSimulation(c, h.userService) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// contingencyErrorStatus 將事故分析錯誤對應到 HTTP 狀態碼
+func contingencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, contingency.ErrInvalidOptions):
+		return http.StatusBadRequest
+	case err == contingency.ErrNoElements, err == contingency.ErrMeshedNetwork, err == contingency.ErrBaseCase:
+		return http.StatusUnprocessableEntity
+	default:
+		return powerflowErrorStatus(err)
+	}
+}
+
+func contingencyJobFactory(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+	var opts contingency.Options
+	if err := decodeJobParams(params, &opts); err != nil {
+		return nil, err
+	}
+	if _, err := contingency.Elements(topo, opts); err != nil {
+		return nil, err
+	}
+	return contingencyJob(topo, opts), nil
+}
+
+// contingencyJob 以事故完成數回報進度的 N-1 分析工作
+func contingencyJob(topo *topology.Topology, opts contingency.Options) job.RunFunc {
+	return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+		return contingency.Analyze(ctx, topo, opts, func(done, total int) {
+			report.Progress(float64(done)/float64(total), fmt.Sprintf("%d of %d contingencies analyzed", done, total))
+		})
+	}
+}
+
+func reconfigurationJob(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+	var opts contingency.ReconfigurationOptions
+	if err := decodeJobParams(params, &opts); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+		return contingency.Reconfigure(ctx, topo, opts)
+	}, nil
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/flisr"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// FLISRHandler 處理 FLISR 模擬相關的 HTTP 請求
+type FLISRHandler struct {
+	repo        topology.Repository
+	userService *user.Service
+	publisher   *flisr.CommandPublisher // 未設定 FEEDER_OS_URL 時為 nil
+}
+
+// NewFLISRHandler 建立新的 FLISRHandler
+func NewFLISRHandler(repo topology.Repository, userService *user.Service, publisher *flisr.CommandPublisher) *FLISRHandler {
+	return &FLISRHandler{
+		repo:        repo,
+		userService: userService,
+		publisher:   publisher,
+	}
+}
+
+// FLISRRequest FLISR 模擬請求
+type FLISRRequest struct {
+	flisr.Options
+	Publish bool `json:"publish,omitempty"` // 將開關命令發布到 feeder-os-controller
+}
+
+// FLISRResponse FLISR 模擬結果
+type FLISRResponse struct {
+	*flisr.Plan
+	Publication *flisr.PublishResult `json:"publication,omitempty"`
+}
+
+// RunFLISR 模擬線路故障的定位、隔離與復電
+// @Summary 模擬 FLISR
+// @Description 指定故障線路，依保護設備跳脫、以自動化開關隔離故障區段、重新投入保護設備、經由聯絡開關在電壓與負載率限制內轉供的順序產生逐步開關操作計畫，包含各步驟恢復的用戶數與時間估計。allow_manual 允許人員操作一般開關進一步縮小停電範圍；publish 會將計畫以命令發布到 feeder-os-controller 的 feeder/{topology_id}/commands/{asset_id}
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param request body FLISRRequest true "故障線路與分析參數"
+// @Success 200 {object} FLISRResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/flisr [post]
+func (h *FLISRHandler) RunFLISR(c *gin.Context) {
+	var req FLISRRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Publish && h.publisher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": flisr.ErrPublisherDisabled.Error()})
+		return
+	}
+
+	// 發布命令會操作現場設備，必須是登入用戶（未登入者在無擁有者的 demo 拓樸上也是 owner）
+	if req.Publish && auth.GetUserID(c) == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to publish switching commands"})
+		return
+	}
+
+	// 發布命令需要 editor 以上權限；僅模擬時 viewer 即可
+	required := topology.RoleViewer
+	if req.Publish {
+		required = topology.RoleEditor
+	}
+	topo, _, ok := authorizeTopology(c, h.repo, required)
+	if !ok {
+		return
+	}
+
+	plan, err := flisr.Simulate(topo, req.Options)
+	if err != nil {
+		c.JSON(flisrErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if !chargeSimulation(c, h.userService) {
+		return
+	}
+
+	response := FLISRResponse{Plan: plan}
+	if req.Publish {
+		publication, err := h.publisher.Publish(c.Request.Context(), plan)
+		if err != nil {
+			c.JSON(flisrErrorStatus(err), gin.H{"error": err.Error(), "plan": plan})
+			return
+		}
+		response.Publication = publication
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// flisrErrorStatus 將 FLISR 錯誤對應到 HTTP 狀態碼
+func flisrErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, flisr.ErrInvalidOptions):
+		return http.StatusBadRequest
+	case errors.Is(err, flisr.ErrPublishFailed):
+		return http.StatusBadGateway
+	case err == flisr.ErrMeshedNetwork, err == flisr.ErrBaseCase:
+		return http.StatusUnprocessableEntity
+	default:
+		return powerflowErrorStatus(err)
+	}
+}
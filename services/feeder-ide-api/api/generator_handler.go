@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/generator"
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// GeneratorHandler 處理合成 feeder 產生的 HTTP 請求
+type GeneratorHandler struct {
+	profileRepo  profiles.Repository
+	topologyRepo topology.Repository
+	userService  *user.Service
+}
+
+// NewGeneratorHandler 建立新的 GeneratorHandler
+func NewGeneratorHandler(profileRepo profiles.Repository, topologyRepo topology.Repository, userService *user.Service) *GeneratorHandler {
+	return &GeneratorHandler{profileRepo: profileRepo, topologyRepo: topologyRepo, userService: userService}
+}
+
+// GenerateFeederRequest 產生合成 feeder 的請求
+type GenerateFeederRequest struct {
+	generator.Options
+	Save bool `json:"save"` // true 時儲存為新拓樸（計入拓樸配額）
+}
+
+// GenerateFeeder 依 profile 產生合成 feeder
+// @Summary 產生合成 feeder
+// @Description 依 profile 的負載組成、典型長度與節點數、DER/EV 滲透率範圍產生輻射狀 feeder（變電所、主幹、分歧線、配電變壓器、負載、DER、EV 充電樁）。相同 seed 與參數產生相同的拓樸；save=true 時儲存為新拓樸並回應 201
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Param type path string true "Profile 類型" Enums(rural, suburban, urban)
+// @Param request body GenerateFeederRequest false "產生參數"
+// @Success 200 {object} generator.Result
+// @Success 201 {object} generator.Result
+// @Header 201 {string} ETag "拓樸版本"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/profiles/{type}/generate [post]
+func (h *GeneratorHandler) GenerateFeeder(c *gin.Context) {
+	profile, err := h.profileRepo.GetByType(c.Param("type"))
+	if err != nil {
+		if err == profiles.ErrProfileNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req GenerateFeederRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := generator.Generate(profile, req.Options)
+	if err != nil {
+		if errors.Is(err, generator.ErrInvalidOptions) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 產生器應只產生合法的拓樸，驗證失敗代表產生邏輯有誤
+	if validation := topology.Validate(result.Topology); !validation.Valid {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "generated topology is invalid", "validation": validation})
+		return
+	}
+
+	if !req.Save {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	userID := auth.GetUserID(c)
+	if !checkTopologyQuota(c, h.userService, userID, nil) {
+		return
+	}
+
+	result.Topology.UserID = userID
+	result.Topology.CreatedAt = time.Now()
+	result.Topology.UpdatedAt = time.Now()
+	if err := h.topologyRepo.Create(result.Topology); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, result.Topology)
+	c.JSON(http.StatusCreated, result)
+}
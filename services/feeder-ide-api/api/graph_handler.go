@@ -0,0 +1,259 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/graph"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphHandler 處理拓樸圖形查詢的 HTTP 請求
+type GraphHandler struct {
+	repo topology.Repository
+}
+
+// NewGraphHandler 建立新的 GraphHandler
+func NewGraphHandler(repo topology.Repository) *GraphHandler {
+	return &GraphHandler{repo: repo}
+}
+
+// LevelsResponse 饋線層級查詢結果
+type LevelsResponse struct {
+	MaxDepth int           `json:"max_depth"`
+	Levels   []graph.Level `json:"levels"`
+}
+
+// IslandsResponse 孤島查詢結果
+type IslandsResponse struct {
+	Count     int            `json:"count"`
+	Energized int            `json:"energized"`
+	Islands   []graph.Island `json:"islands"`
+}
+
+// LoopsResponse 迴路查詢結果
+type LoopsResponse struct {
+	Count  int          `json:"count"`
+	Closed int          `json:"closed"` // 沒有開路開關的迴路數（環狀運轉）
+	Loops  []graph.Loop `json:"loops"`
+}
+
+// Trace 上下游追蹤
+// @Summary 上下游追蹤
+// @Description 由節點或開關追蹤到電源的上游路徑（direction=upstream），或其供電的所有下游節點（direction=downstream，預設）。open / closed 以逗號分隔的開關 ID 覆寫開關狀態
+// @Tags topology-graph
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param from query string true "起點節點 ID"
+// @Param direction query string false "upstream 或 downstream"
+// @Param open query string false "視為開路的開關 ID"
+// @Param closed query string false "視為閉合的開關 ID"
+// @Success 200 {object} graph.Trace
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/trace [get]
+func (h *GraphHandler) Trace(c *gin.Context) {
+	from := c.Query("from")
+	if from == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is required"})
+		return
+	}
+	direction := c.DefaultQuery("direction", graph.DirectionDownstream)
+	if direction != graph.DirectionUpstream && direction != graph.DirectionDownstream {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be upstream or downstream"})
+		return
+	}
+
+	g, ok := h.loadGraph(c)
+	if !ok {
+		return
+	}
+
+	var trace *graph.Trace
+	var err error
+	if direction == graph.DirectionUpstream {
+		trace, err = g.Upstream(from)
+	} else {
+		trace, err = g.Downstream(from)
+	}
+	if err != nil {
+		c.JSON(graphErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// ShortestPath 兩節點間的最短電氣路徑
+// @Summary 最短路徑
+// @Description 以線路長度為權重找出兩節點間的最短路徑；預設不穿過開路開關，ignore_switches=true 時忽略開關狀態
+// @Tags topology-graph
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param from query string true "起點節點 ID"
+// @Param to query string true "終點節點 ID"
+// @Param ignore_switches query bool false "允許穿過開路開關"
+// @Param open query string false "視為開路的開關 ID"
+// @Param closed query string false "視為閉合的開關 ID"
+// @Success 200 {object} graph.Path
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/path [get]
+func (h *GraphHandler) ShortestPath(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	g, ok := h.loadGraph(c)
+	if !ok {
+		return
+	}
+
+	path, err := g.ShortestPath(from, to, graph.PathOptions{
+		IgnoreSwitches: c.Query("ignore_switches") == "true",
+	})
+	if err != nil {
+		c.JSON(graphErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, path)
+}
+
+// Islands 孤島偵測
+// @Summary 孤島偵測
+// @Description 依開關狀態（可用 open / closed 覆寫）找出經閉合開關相連的節點群組，以及是否含有電源
+// @Tags topology-graph
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param open query string false "視為開路的開關 ID"
+// @Param closed query string false "視為閉合的開關 ID"
+// @Success 200 {object} IslandsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/islands [get]
+func (h *GraphHandler) Islands(c *gin.Context) {
+	g, ok := h.loadGraph(c)
+	if !ok {
+		return
+	}
+
+	islands := g.Islands()
+	response := IslandsResponse{Count: len(islands), Islands: islands}
+	for _, island := range islands {
+		if island.Energized {
+			response.Energized++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Loops 迴路偵測
+// @Summary 迴路偵測
+// @Description 找出拓樸的基本迴路；closed 表示迴路上沒有開路開關，網路在此環狀運轉
+// @Tags topology-graph
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param open query string false "視為開路的開關 ID"
+// @Param closed query string false "視為閉合的開關 ID"
+// @Success 200 {object} LoopsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/loops [get]
+func (h *GraphHandler) Loops(c *gin.Context) {
+	g, ok := h.loadGraph(c)
+	if !ok {
+		return
+	}
+
+	loops := g.Loops()
+	response := LoopsResponse{Count: len(loops), Loops: loops}
+	for _, loop := range loops {
+		if loop.Closed {
+			response.Closed++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Levels 饋線層級
+// @Summary 饋線層級
+// @Description 每個節點距電源的層級、沿線路距離與上游節點
+// @Tags topology-graph
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param open query string false "視為開路的開關 ID"
+// @Param closed query string false "視為閉合的開關 ID"
+// @Success 200 {object} LevelsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/levels [get]
+func (h *GraphHandler) Levels(c *gin.Context) {
+	g, ok := h.loadGraph(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, LevelsResponse{MaxDepth: g.MaxDepth(), Levels: g.Levels()})
+}
+
+// loadGraph 載入拓樸並取得圖形；有 open / closed 覆寫時以覆寫後的開關狀態重新建立，不使用快取
+func (h *GraphHandler) loadGraph(c *gin.Context) (*graph.Graph, bool) {
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return nil, false
+	}
+
+	overrides, err := switchOverrides(topo, c.Query("open"), c.Query("closed"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(overrides) == 0 {
+		return graph.For(topo), true
+	}
+	return graph.New(topo.WithSwitchStates(overrides)), true
+}
+
+// switchOverrides 解析以逗號分隔的開路與閉合開關 ID
+func switchOverrides(topo *topology.Topology, open, closed string) (map[string]bool, error) {
+	states := topo.SwitchStates()
+	overrides := make(map[string]bool)
+	for _, group := range []struct {
+		value  string
+		closed bool
+	}{{open, false}, {closed, true}} {
+		for _, id := range strings.Split(group.value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			if _, exists := states[id]; !exists {
+				return nil, fmt.Errorf("%w: %s", graph.ErrInvalidSwitch, id)
+			}
+			if state, exists := overrides[id]; exists && state != group.closed {
+				return nil, fmt.Errorf("switch %s is listed as both open and closed", id)
+			}
+			overrides[id] = group.closed
+		}
+	}
+	return overrides, nil
+}
+
+// graphErrorStatus 將圖形查詢錯誤對應到 HTTP 狀態碼
+func graphErrorStatus(err error) int {
+	switch err {
+	case graph.ErrNodeNotFound:
+		return http.StatusNotFound
+	case graph.ErrNotEnergized, graph.ErrNoPath:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/hostingcapacity"
+	"github.com/feeder-platform/feeder-ide-api/internal/job"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// hostingCapacitySyncLimit 候選節點超過此數量時改為非同步工作執行
+const hostingCapacitySyncLimit = 25
+
+// HostingCapacityHandler 處理容量分析相關的 HTTP 請求
+type HostingCapacityHandler struct {
+	repo        topology.Repository
+	userService *user.Service
+	jobs        *job.Manager
+}
+
+// NewHostingCapacityHandler 建立新的 HostingCapacityHandler
+func NewHostingCapacityHandler(repo topology.Repository, userService *user.Service, jobs *job.Manager) *HostingCapacityHandler {
+	return &HostingCapacityHandler{
+		repo:        repo,
+		userService: userService,
+		jobs:        jobs,
+	}
+}
+
+// RunHostingCapacity 執行容量分析
+// @Summary 執行 DER / EV 容量分析
+// @Description 對每個候選節點逐步增加 DER 注入或 EV 充電負載並重複執行潮流，直到電壓、熱容量或逆送電力限制被觸發。候選節點超過 25 個或指定 async=true 時改為提交 hosting_capacity 工作並回應 202，以 GET /jobs/{jobId} 查詢進度與結果
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param async query bool false "強制非同步執行"
+// @Param options body hostingcapacity.Options false "分析參數"
+// @Success 200 {object} hostingcapacity.Result
+// @Success 202 {object} job.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/hosting-capacity [post]
+func (h *HostingCapacityHandler) RunHostingCapacity(c *gin.Context) {
+	var opts hostingcapacity.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	candidates, err := hostingcapacity.Candidates(topo, opts)
+	if err != nil {
+		c.JSON(hostingCapacityErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(candidates) <= hostingCapacitySyncLimit && c.Query("async") != "true" {
+		result, err := hostingcapacity.Analyze(c.Request.Context(), topo, opts, nil)
+		if err != nil {
+			c.JSON(hostingCapacityErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !chargeSimulation(c, h.userService) {
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// 非同步工作的模擬配額於開始執行時扣除
+	params, err := json.Marshal(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	submitJob(c, h.jobs, jobTypeHostingCapacity, topo, params, hostingCapacityJob(topo, opts))
+}
+
+// hostingCapacityErrorStatus 將容量分析錯誤對應到 HTTP 狀態碼
+func hostingCapacityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, hostingcapacity.ErrInvalidOptions):
+		return http.StatusBadRequest
+	case err == hostingcapacity.ErrNoCandidates, err == hostingcapacity.ErrBaseCase:
+		return http.StatusUnprocessableEntity
+	default:
+		return powerflowErrorStatus(err)
+	}
+}
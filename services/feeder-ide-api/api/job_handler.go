@@ -0,0 +1,420 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/contingency"
+	"github.com/feeder-platform/feeder-ide-api/internal/hostingcapacity"
+	"github.com/feeder-platform/feeder-ide-api/internal/job"
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+	"github.com/feeder-platform/feeder-ide-api/internal/protection"
+	"github.com/feeder-platform/feeder-ide-api/internal/qsts"
+	"github.com/feeder-platform/feeder-ide-api/internal/reliability"
+	"github.com/feeder-platform/feeder-ide-api/internal/shortcircuit"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/gin-gonic/gin"
+)
+
+// 非同步工作類型
+const (
+	jobTypePowerflow              = "powerflow"
+	jobTypeHostingCapacity        = "hosting_capacity"
+	jobTypeReliability            = "reliability"
+	jobTypeShortCircuit           = "short_circuit"
+	jobTypeProtectionCoordination = "protection_coordination"
+	jobTypeQSTS                   = "qsts"
+	jobTypeContingency            = "contingency"
+	jobTypeReconfiguration        = "reconfiguration"
+)
+
+const (
+	defaultJobListLimit = 50
+	maxJobListLimit     = 200
+	sseKeepAlive        = 15 * time.Second
+)
+
+var errInvalidJobParams = errors.New("invalid job params")
+
+// jobFactory 解析工作參數並建立工作內容
+type jobFactory func(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error)
+
+// SubmitJobRequest 提交工作請求
+type SubmitJobRequest struct {
+	Type   string          `json:"type" binding:"required"` // powerflow, hosting_capacity, reliability, short_circuit, protection_coordination, qsts, contingency, reconfiguration
+	Params json.RawMessage `json:"params,omitempty"`        // 對應分析端點的參數
+}
+
+// JobHandler 處理非同步模擬工作相關的 HTTP 請求
+type JobHandler struct {
+	repo      topology.Repository
+	manager   *job.Manager
+	factories map[string]jobFactory
+}
+
+// NewJobHandler 建立新的 JobHandler
+func NewJobHandler(repo topology.Repository, profileRepo profiles.Repository, manager *job.Manager) *JobHandler {
+	return &JobHandler{
+		repo:    repo,
+		manager: manager,
+		factories: map[string]jobFactory{
+			jobTypePowerflow:              powerflowJob,
+			jobTypeHostingCapacity:        hostingCapacityJobFactory,
+			jobTypeReliability:            reliabilityJob(profileRepo),
+			jobTypeShortCircuit:           shortCircuitJob,
+			jobTypeProtectionCoordination: protectionJob,
+			jobTypeQSTS:                   qstsJob(profileRepo),
+			jobTypeContingency:            contingencyJobFactory,
+			jobTypeReconfiguration:        reconfigurationJob,
+		},
+	}
+}
+
+// SubmitJob 提交非同步模擬工作
+// @Summary 提交非同步模擬工作
+// @Description 建立工作並放入佇列，由有限數量的 worker 執行；模擬配額於工作開始執行時扣除。以 GET /jobs/{jobId} 查詢狀態與結果，或以 GET /jobs/{jobId}/events 接收 SSE 事件
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param request body SubmitJobRequest true "工作類型與參數"
+// @Success 202 {object} job.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/jobs [post]
+func (h *JobHandler) SubmitJob(c *gin.Context) {
+	var req SubmitJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	factory, exists := h.factories[req.Type]
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown job type: %s", req.Type)})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	run, err := factory(topo, req.Params)
+	if err != nil {
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	submitJob(c, h.manager, req.Type, topo, req.Params, run)
+}
+
+// ListJobs 列出工作
+// @Summary 列出工作
+// @Description 列出目前用戶的工作（由新到舊，不含結果），可依拓樸與狀態篩選；未登入時只列出以同一共用連結提交的工作（未帶連結時為 demo 工作）
+// @Tags jobs
+// @Produce json
+// @Param topology_id query string false "拓樸 ID"
+// @Param status query string false "狀態（queued, running, succeeded, failed, cancelled）"
+// @Param limit query int false "數量上限（預設 50，最多 200）"
+// @Success 200 {array} job.Job
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	limit := defaultJobListLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		if parsed < maxJobListLimit {
+			limit = parsed
+		} else {
+			limit = maxJobListLimit
+		}
+	}
+
+	jobs, err := h.manager.List(job.Filter{
+		Visibility: jobVisibility(c),
+		TopologyID: c.Query("topology_id"),
+		Status:     c.Query("status"),
+		Limit:      limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob 取得工作狀態與結果
+// @Summary 取得工作
+// @Description 取得工作狀態、進度，成功時包含結果
+// @Tags jobs
+// @Produce json
+// @Param jobId path string true "工作 ID"
+// @Success 200 {object} job.Job
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/jobs/{jobId} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	current, ok := h.loadJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, current)
+}
+
+// CancelJob 取消工作
+// @Summary 取消工作
+// @Description 等待中的工作立即取消；執行中的工作會收到取消通知並在下一個檢查點停止
+// @Tags jobs
+// @Produce json
+// @Param jobId path string true "工作 ID"
+// @Success 200 {object} job.Job
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/jobs/{jobId}/cancel [post]
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	if _, ok := h.loadJob(c); !ok {
+		return
+	}
+
+	cancelled, err := h.manager.Cancel(c.Param("jobId"))
+	if err != nil {
+		if err == job.ErrJobFinished {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err == job.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// StreamJobEvents 以 Server-Sent Events 串流工作事件
+// @Summary 串流工作事件
+// @Description 先送出目前狀態（status），之後送出 progress、partial（部分結果）與 status 事件；工作結束時送出含結果的 done 事件並關閉連線
+// @Tags jobs
+// @Produce text/event-stream
+// @Param jobId path string true "工作 ID"
+// @Success 200 {object} job.Event
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/jobs/{jobId}/events [get]
+func (h *JobHandler) StreamJobEvents(c *gin.Context) {
+	jobID := c.Param("jobId")
+	current, events, unsubscribe, err := h.manager.Subscribe(jobID)
+	if err != nil {
+		if err == job.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer unsubscribe()
+	if !current.VisibleTo(jobVisibility(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": job.ErrJobNotFound.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	if events == nil {
+		c.SSEvent("done", current)
+		return
+	}
+	c.SSEvent(job.EventStatus, statusEvent(current))
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, open := <-events:
+			if !open {
+				if final, err := h.manager.Get(jobID); err == nil {
+					c.SSEvent("done", final)
+				}
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// loadJob 取得目前用戶可存取的工作，失敗時直接回應錯誤並回傳 false
+func (h *JobHandler) loadJob(c *gin.Context) (*job.Job, bool) {
+	current, err := h.manager.Get(c.Param("jobId"))
+	if err == job.ErrJobNotFound || (err == nil && !current.VisibleTo(jobVisibility(c))) {
+		c.JSON(http.StatusNotFound, gin.H{"error": job.ErrJobNotFound.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return current, true
+}
+
+// submitJob 提交工作並回應 202 與 Location
+func submitJob(c *gin.Context, manager *job.Manager, jobType string, topo *topology.Topology, params json.RawMessage, run job.RunFunc) {
+	visibility := jobVisibility(c)
+	submitted, err := manager.Submit(&job.Job{
+		Type:           jobType,
+		TopologyID:     topo.ID,
+		UserID:         visibility.UserID,
+		ShareTokenHash: visibility.ShareTokenHash,
+		Params:         params,
+	}, run)
+	if err != nil {
+		if err == job.ErrQueueFull {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+submitted.ID)
+	c.JSON(http.StatusAccepted, submitted)
+}
+
+// jobVisibility 目前請求的工作存取身分；未登入時以共用連結 token 區分透過不同連結提交的工作
+func jobVisibility(c *gin.Context) job.Visibility {
+	principal := topologyPrincipal(c)
+	if principal.UserID != nil || principal.ShareToken == "" {
+		return job.Visibility{UserID: principal.UserID}
+	}
+	return job.Visibility{ShareTokenHash: topology.HashShareToken(principal.ShareToken)}
+}
+
+func statusEvent(current *job.Job) job.Event {
+	return job.Event{
+		Type:     job.EventStatus,
+		JobID:    current.ID,
+		Status:   current.Status,
+		Progress: current.Progress,
+		Message:  current.Message,
+		Error:    current.Error,
+		Time:     time.Now(),
+	}
+}
+
+// decodeJobParams 解析工作參數，未提供時保留預設值
+func decodeJobParams(params json.RawMessage, target interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, target); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidJobParams, err)
+	}
+	return nil
+}
+
+func powerflowJob(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+	var opts powerflow.Options
+	if err := decodeJobParams(params, &opts); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+		return powerflow.Solve(topo, opts)
+	}, nil
+}
+
+func hostingCapacityJobFactory(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+	var opts hostingcapacity.Options
+	if err := decodeJobParams(params, &opts); err != nil {
+		return nil, err
+	}
+	if _, err := hostingcapacity.Candidates(topo, opts); err != nil {
+		return nil, err
+	}
+	return hostingCapacityJob(topo, opts), nil
+}
+
+// hostingCapacityJob 以節點完成數回報進度的容量分析工作
+func hostingCapacityJob(topo *topology.Topology, opts hostingcapacity.Options) job.RunFunc {
+	return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+		return hostingcapacity.Analyze(ctx, topo, opts, func(done, total int) {
+			report.Progress(float64(done)/float64(total), fmt.Sprintf("%d of %d nodes analyzed", done, total))
+		})
+	}
+}
+
+func reliabilityJob(profileRepo profiles.Repository) jobFactory {
+	return func(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+		var opts reliability.Options
+		if err := decodeJobParams(params, &opts); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+			result, err := reliability.Analyze(topo, opts)
+			if err != nil {
+				return nil, err
+			}
+			if topo.ProfileType != "" {
+				if profile, err := profileRepo.GetByType(topo.ProfileType); err == nil {
+					result.Comparison = reliability.Compare(result.Indices, profile)
+				}
+			}
+			return result, nil
+		}, nil
+	}
+}
+
+func shortCircuitJob(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+	var opts shortcircuit.Options
+	if err := decodeJobParams(params, &opts); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+		return shortcircuit.Analyze(topo, opts)
+	}, nil
+}
+
+func protectionJob(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+	var opts protection.Options
+	if err := decodeJobParams(params, &opts); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+		return protection.Analyze(topo, opts)
+	}, nil
+}
+
+// jobErrorStatus 將提交工作時的參數檢查錯誤對應到 HTTP 狀態碼
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidJobParams), errors.Is(err, qsts.ErrInvalidOptions), errors.Is(err, qsts.ErrInvalidShape):
+		return http.StatusBadRequest
+	case errors.Is(err, contingency.ErrInvalidOptions), err == contingency.ErrNoElements, err == contingency.ErrMeshedNetwork:
+		return contingencyErrorStatus(err)
+	default:
+		return hostingCapacityErrorStatus(err)
+	}
+}
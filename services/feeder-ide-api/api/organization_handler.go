@@ -0,0 +1,357 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/middleware"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler 處理組織（團隊工作區）相關的 HTTP 請求
+type OrganizationHandler struct {
+	service            *organization.Service
+	userService        *user.Service
+	onMembershipChange func()
+}
+
+// NewOrganizationHandler 建立新的 OrganizationHandler
+func NewOrganizationHandler(service *organization.Service, userService *user.Service) *OrganizationHandler {
+	return &OrganizationHandler{
+		service:     service,
+		userService: userService,
+	}
+}
+
+// SetMembershipChangeHook 設置成員新增、角色變更或移除後的 hook（例如重新判斷協作連線的角色）
+func (h *OrganizationHandler) SetMembershipChangeHook(hook func()) {
+	h.onMembershipChange = hook
+}
+
+// membershipChanged 通知組織成員資格已變更
+func (h *OrganizationHandler) membershipChanged() {
+	if h.onMembershipChange != nil {
+		h.onMembershipChange()
+	}
+}
+
+// OrganizationRequest 建立或修改組織的請求
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// OrganizationMemberRequest 新增成員或更新角色的請求
+type OrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required,oneof=admin engineer viewer"`
+}
+
+// OrganizationResponse 組織、目前用戶的角色與組織共用配額
+type OrganizationResponse struct {
+	*organization.Organization
+	Role  organization.Role   `json:"role"`
+	Quota *organization.Quota `json:"quota,omitempty"`
+}
+
+// CreateOrganization 建立組織
+// @Summary 建立組織
+// @Description 建立團隊工作區（免費等級），建立者成為 admin。需要登入
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param request body OrganizationRequest true "組織名稱"
+// @Success 201 {object} OrganizationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := auth.GetUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	org, err := h.service.CreateOrganization(req.Name, *userID)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	quota, err := h.service.GetQuota(org.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, OrganizationResponse{Organization: org, Role: organization.RoleAdmin, Quota: quota})
+}
+
+// ListOrganizations 列出目前用戶所屬的組織
+// @Summary 我的組織
+// @Description 列出目前用戶所屬的組織與角色（依名稱排列）
+// @Tags organizations
+// @Produce json
+// @Success 200 {array} organization.Membership
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	memberships, err := h.service.ListMemberships(*userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memberships)
+}
+
+// GetOrganization 取得組織
+// @Summary 取得組織
+// @Description 取得組織、目前用戶的角色與組織共用配額的使用量。需要組織成員身分
+// @Tags organizations
+// @Produce json
+// @Param orgId path string true "組織 ID"
+// @Success 200 {object} OrganizationResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/organizations/{orgId} [get]
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	organizationID := c.Param("orgId")
+	if !requireOrganizationRole(c, organizationID, organization.RoleViewer) {
+		return
+	}
+
+	org, err := h.service.GetOrganization(organizationID)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	quota, err := h.service.GetQuota(organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, OrganizationResponse{
+		Organization: org,
+		Role:         middleware.GetOrganizationRolesFromContext(c)[organizationID],
+		Quota:        quota,
+	})
+}
+
+// UpdateOrganization 修改組織名稱
+// @Summary 修改組織
+// @Description 修改組織名稱。需要 admin 角色
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param orgId path string true "組織 ID"
+// @Param request body OrganizationRequest true "組織名稱"
+// @Success 200 {object} organization.Organization
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/organizations/{orgId} [put]
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizationID := c.Param("orgId")
+	if !requireOrganizationRole(c, organizationID, organization.RoleAdmin) {
+		return
+	}
+
+	org, err := h.service.RenameOrganization(organizationID, req.Name)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// DeleteOrganization 刪除組織
+// @Summary 刪除組織
+// @Description 刪除組織與成員資料；組織仍擁有拓樸時回應 409。需要 admin 角色
+// @Tags organizations
+// @Param orgId path string true "組織 ID"
+// @Success 204
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/organizations/{orgId} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	organizationID := c.Param("orgId")
+	if !requireOrganizationRole(c, organizationID, organization.RoleAdmin) {
+		return
+	}
+
+	if err := h.service.DeleteOrganization(organizationID); err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListMembers 列出組織成員
+// @Summary 列出組織成員
+// @Description 列出組織成員與角色（依角色高到低）。需要組織成員身分
+// @Tags organizations
+// @Produce json
+// @Param orgId path string true "組織 ID"
+// @Success 200 {array} organization.Member
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/organizations/{orgId}/members [get]
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	organizationID := c.Param("orgId")
+	if !requireOrganizationRole(c, organizationID, organization.RoleViewer) {
+		return
+	}
+
+	members, err := h.service.ListMembers(organizationID)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	for _, member := range members {
+		if u, err := h.userService.GetUserByID(member.UserID); err == nil {
+			member.Email = u.Email
+		}
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// SetMember 以 email 新增成員或更新角色
+// @Summary 新增或更新成員
+// @Description 將指定 email 的用戶加入組織或更新其角色（admin、engineer、viewer）；組織至少需保留一位 admin。需要 admin 角色
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param orgId path string true "組織 ID"
+// @Param request body OrganizationMemberRequest true "用戶 email 與角色"
+// @Success 200 {object} organization.Member
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/organizations/{orgId}/members [put]
+func (h *OrganizationHandler) SetMember(c *gin.Context) {
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizationID := c.Param("orgId")
+	if !requireOrganizationRole(c, organizationID, organization.RoleAdmin) {
+		return
+	}
+
+	target, err := h.userService.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.service.SetMember(organizationID, target.ID, organization.Role(req.Role))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.membershipChanged()
+	member.Email = target.Email
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember 移除組織成員
+// @Summary 移除成員
+// @Description admin 可移除任何成員，成員可移除自己（離開組織）；不能移除最後一位 admin。
+// @Description 成員建立的組織拓樸仍屬於組織
+// @Tags organizations
+// @Param orgId path string true "組織 ID"
+// @Param userId path string true "用戶 ID"
+// @Success 204
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/organizations/{orgId}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	organizationID := c.Param("orgId")
+	targetID := c.Param("userId")
+	userID := auth.GetUserID(c)
+
+	required := organization.RoleAdmin
+	if userID != nil && *userID == targetID {
+		required = organization.RoleViewer
+	}
+	if !requireOrganizationRole(c, organizationID, required) {
+		return
+	}
+
+	if err := h.service.RemoveMember(organizationID, targetID); err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.membershipChanged()
+
+	c.Status(http.StatusNoContent)
+}
+
+// requireOrganizationRole 確認目前用戶在組織中具備 required 以上角色（由 OrganizationMiddleware 載入）。
+// 非成員時回應 404（不透露組織是否存在），角色不足時回應 403，回傳 false 表示已回應
+func requireOrganizationRole(c *gin.Context, organizationID string, required organization.Role) bool {
+	role := middleware.GetOrganizationRolesFromContext(c)[organizationID]
+	if !role.Valid() {
+		c.JSON(http.StatusNotFound, gin.H{"error": organization.ErrOrganizationNotFound.Error()})
+		return false
+	}
+	if !role.Allows(required) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":         organization.ErrInsufficientRole.Error(),
+			"role":          role,
+			"required_role": required,
+		})
+		return false
+	}
+	return true
+}
+
+// organizationErrorStatus 將組織錯誤對應到 HTTP 狀態碼
+func organizationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, organization.ErrOrganizationNotFound),
+		errors.Is(err, organization.ErrMemberNotFound),
+		errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, organization.ErrInvalidName),
+		errors.Is(err, organization.ErrInvalidRole),
+		errors.Is(err, organization.ErrInvalidTier):
+		return http.StatusBadRequest
+	case errors.Is(err, organization.ErrInsufficientRole):
+		return http.StatusForbidden
+	case errors.Is(err, organization.ErrLastAdmin),
+		errors.Is(err, organization.ErrOrganizationNotEmpty):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// PowerflowHandler 處理潮流分析相關的 HTTP 請求
+type PowerflowHandler struct {
+	repo        topology.Repository
+	userService *user.Service
+}
+
+// NewPowerflowHandler 建立新的 PowerflowHandler
+func NewPowerflowHandler(repo topology.Repository, userService *user.Service) *PowerflowHandler {
+	return &PowerflowHandler{
+		repo:        repo,
+		userService: userService,
+	}
+}
+
+// RunPowerflow 執行潮流分析
+// @Summary 執行潮流分析
+// @Description 對拓樸執行 backward/forward sweep（環狀網路使用 Newton-Raphson）潮流分析
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param options body powerflow.Options false "分析參數"
+// @Success 200 {object} powerflow.Result
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/powerflow [post]
+func (h *PowerflowHandler) RunPowerflow(c *gin.Context) {
+	var opts powerflow.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	result, err := powerflow.Solve(topo, opts)
+	if err != nil {
+		c.JSON(powerflowErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if !chargeSimulation(c, h.userService) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// powerflowErrorStatus 將潮流分析錯誤對應到 HTTP 狀態碼
+func powerflowErrorStatus(err error) int {
+	switch err {
+	case powerflow.ErrEmptyTopology, powerflow.ErrNoSource, powerflow.ErrMeshedNetwork,
+		powerflow.ErrUnknownMethod, powerflow.ErrSingularMatrix:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// chargeSimulation 增加已登入用戶的模擬計數（組織擁有的拓樸計入組織共用配額），失敗時直接回應錯誤並回傳 false
+func chargeSimulation(c *gin.Context, userService *user.Service) bool {
+	userID := auth.GetUserID(c)
+	if userService == nil || userID == nil {
+		return true
+	}
+
+	if err := userService.ChargeSimulation(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update simulation quota: " + err.Error()})
+		return false
+	}
+	return true
+}
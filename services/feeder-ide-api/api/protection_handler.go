@@ -0,0 +1,63 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/protection"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// ProtectionHandler 處理保護協調相關的 HTTP 請求
+type ProtectionHandler struct {
+	repo        topology.Repository
+	userService *user.Service
+}
+
+// NewProtectionHandler 建立新的 ProtectionHandler
+func NewProtectionHandler(repo topology.Repository, userService *user.Service) *ProtectionHandler {
+	return &ProtectionHandler{
+		repo:        repo,
+		userService: userService,
+	}
+}
+
+// RunCoordination 執行保護協調檢查
+// @Summary 執行保護協調檢查
+// @Description 依開關節點 properties.protection 的時間電流曲線（IEEE / IEC 反時限、定時限、熔絲）沿輻射路徑檢查上下游保護設備在各下游節點故障電流下的協調時間間隔，並檢查分段開關與復閉器的配合
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param options body protection.Options false "分析參數"
+// @Success 200 {object} protection.Result
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/protection-coordination [post]
+func (h *ProtectionHandler) RunCoordination(c *gin.Context) {
+	var opts protection.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	result, err := protection.Analyze(topo, opts)
+	if err != nil {
+		c.JSON(shortCircuitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if !chargeSimulation(c, h.userService) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/job"
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+	"github.com/feeder-platform/feeder-ide-api/internal/qsts"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/gin-gonic/gin"
+)
+
+// QSTSHandler 處理時間序列潮流相關的 HTTP 請求
+type QSTSHandler struct {
+	repo        topology.Repository
+	profileRepo profiles.Repository
+	jobs        *job.Manager
+}
+
+// NewQSTSHandler 建立新的 QSTSHandler
+func NewQSTSHandler(repo topology.Repository, profileRepo profiles.Repository, jobs *job.Manager) *QSTSHandler {
+	return &QSTSHandler{
+		repo:        repo,
+		profileRepo: profileRepo,
+		jobs:        jobs,
+	}
+}
+
+// RunQSTS 提交 QSTS 時間序列潮流工作
+// @Summary 提交 QSTS 時間序列潮流工作
+// @Description 以逐時負載與 DER 曲線（最多 8760 小時）逐步執行潮流並模擬變電所 LTC，回傳電壓、負載率、損失、分接頭動作與違規的時間序列及降取樣摘要。請求可為 JSON（qsts.Options），或 multipart 上傳 CSV（file，欄位為節點 ID 或 residential / commercial / industrial / ev / pv / wind）與 options（JSON 字串）。以 qsts 工作執行，SSE 事件會在每個摘要區間送出 partial 部分結果
+// @Tags simulations
+// @Accept json,mpfd
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param options body qsts.Options false "分析參數"
+// @Param file formData file false "曲線 CSV"
+// @Success 202 {object} job.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/qsts [post]
+func (h *QSTSHandler) RunQSTS(c *gin.Context) {
+	opts, ok := bindQSTSOptions(c)
+	if !ok {
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	params, err := json.Marshal(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	run, err := qstsJob(h.profileRepo)(topo, params)
+	if err != nil {
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	submitJob(c, h.jobs, jobTypeQSTS, topo, params, run)
+}
+
+// bindQSTSOptions 由 JSON 或 multipart（options + CSV file）解析參數，CSV 曲線覆蓋 options 中的同名曲線
+func bindQSTSOptions(c *gin.Context) (qsts.Options, bool) {
+	var opts qsts.Options
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return opts, false
+		}
+		return opts, true
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	if raw := c.PostForm("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid options: " + err.Error()})
+			return opts, false
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err == http.ErrMissingFile {
+		return opts, true
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "request body too large") {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return opts, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return opts, false
+	}
+	defer file.Close()
+
+	shapes, err := qsts.ParseShapesCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return opts, false
+	}
+	if opts.Shapes == nil {
+		opts.Shapes = map[string][]float64{}
+	}
+	for name, shape := range shapes {
+		opts.Shapes[name] = shape
+	}
+	return opts, true
+}
+
+// qstsJob 以摘要區間回報進度並串流部分結果的 QSTS 工作
+func qstsJob(profileRepo profiles.Repository) jobFactory {
+	return func(topo *topology.Topology, params json.RawMessage) (job.RunFunc, error) {
+		var opts qsts.Options
+		if err := decodeJobParams(params, &opts); err != nil {
+			return nil, err
+		}
+		if err := opts.Validate(); err != nil {
+			return nil, err
+		}
+
+		var composition profiles.LoadComposition
+		if topo.ProfileType != "" {
+			profile, err := profileRepo.GetByType(topo.ProfileType)
+			if err == nil {
+				composition = profile.Characteristics.LoadComposition
+			} else if err != profiles.ErrProfileNotFound {
+				return nil, err
+			}
+		}
+
+		return func(ctx context.Context, report job.Reporter) (interface{}, error) {
+			return qsts.Simulate(ctx, topo, composition, opts, func(interval qsts.Interval, done, total int) {
+				report.Progress(float64(done)/float64(total), fmt.Sprintf("%d of %d intervals simulated", done, total))
+				report.Partial(interval)
+			})
+		}, nil
+	}
+}
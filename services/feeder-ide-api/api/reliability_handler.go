@@ -0,0 +1,86 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+	"github.com/feeder-platform/feeder-ide-api/internal/reliability"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// ReliabilityHandler 處理可靠度分析相關的 HTTP 請求
+type ReliabilityHandler struct {
+	repo        topology.Repository
+	profileRepo profiles.Repository
+	userService *user.Service
+}
+
+// NewReliabilityHandler 建立新的 ReliabilityHandler
+func NewReliabilityHandler(repo topology.Repository, profileRepo profiles.Repository, userService *user.Service) *ReliabilityHandler {
+	return &ReliabilityHandler{
+		repo:        repo,
+		profileRepo: profileRepo,
+		userService: userService,
+	}
+}
+
+// RunReliability 執行可靠度分析
+// @Summary 執行可靠度分析
+// @Description 以線路故障率與修復時間計算 SAIFI、SAIDI、CAIDI、ASAI、MAIFI 與停電電量，考慮保護設備、開關位置（自動化開關快速復電）與各節點用戶數（屬性 customers），並與拓樸 profile_type 的 TargetSAIDI / TargetSAIFI 比較、列出貢獻最大的區段
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param options body reliability.Options false "分析參數"
+// @Success 200 {object} reliability.Result
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/reliability [post]
+func (h *ReliabilityHandler) RunReliability(c *gin.Context) {
+	var opts reliability.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	result, err := reliability.Analyze(topo, opts)
+	if err != nil {
+		c.JSON(reliabilityErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if topo.ProfileType != "" {
+		profile, err := h.profileRepo.GetByType(topo.ProfileType)
+		if err == nil {
+			result.Comparison = reliability.Compare(result.Indices, profile)
+		} else if err != profiles.ErrProfileNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if !chargeSimulation(c, h.userService) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// reliabilityErrorStatus 將可靠度分析錯誤對應到 HTTP 狀態碼
+func reliabilityErrorStatus(err error) int {
+	switch err {
+	case reliability.ErrMeshedNetwork, reliability.ErrNoCustomers:
+		return http.StatusUnprocessableEntity
+	default:
+		return powerflowErrorStatus(err)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/shortcircuit"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// ShortCircuitHandler 處理故障電流分析相關的 HTTP 請求
+type ShortCircuitHandler struct {
+	repo        topology.Repository
+	userService *user.Service
+}
+
+// NewShortCircuitHandler 建立新的 ShortCircuitHandler
+func NewShortCircuitHandler(repo topology.Repository, userService *user.Service) *ShortCircuitHandler {
+	return &ShortCircuitHandler{
+		repo:        repo,
+		userService: userService,
+	}
+}
+
+// RunShortCircuit 執行故障電流分析
+// @Summary 執行故障電流分析
+// @Description 以對稱分量法計算各節點的三相、單相接地與線間故障電流（線路 r/x、r0/x0，變壓器阻抗與接線方式，變電所電源阻抗可設定），並標示故障電流超過啟斷容量（interrupting_rating_ka）的開關
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param options body shortcircuit.Options false "分析參數"
+// @Success 200 {object} shortcircuit.Result
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/short-circuit [post]
+func (h *ShortCircuitHandler) RunShortCircuit(c *gin.Context) {
+	var opts shortcircuit.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	result, err := shortcircuit.Analyze(topo, opts)
+	if err != nil {
+		c.JSON(shortCircuitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if !chargeSimulation(c, h.userService) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// shortCircuitErrorStatus 將故障分析錯誤對應到 HTTP 狀態碼
+func shortCircuitErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, shortcircuit.ErrInvalidOptions):
+		return http.StatusBadRequest
+	case err == shortcircuit.ErrMeshedNetwork:
+		return http.StatusUnprocessableEntity
+	default:
+		return powerflowErrorStatus(err)
+	}
+}
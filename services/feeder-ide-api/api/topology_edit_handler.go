@@ -0,0 +1,333 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/layout"
+	"github.com/gin-gonic/gin"
+)
+
+// maxEditAttempts 新增元素在未指定 If-Match 時遇到版本衝突的重試次數
+const maxEditAttempts = 3
+
+// RemoveNodeResponse 刪除節點的回應
+type RemoveNodeResponse struct {
+	NodeID         string   `json:"node_id"`
+	RemovedLineIDs []string `json:"removed_line_ids"`
+	Version        int      `json:"version"`
+}
+
+// PatchTopology 以 JSON Patch 修改拓樸
+// @Summary 以 JSON Patch 修改拓樸
+// @Description 套用 RFC 6902 JSON Patch（可修改 name、description、profile_type、nodes、lines），驗證後原子性地儲存
+// @Tags topologies
+// @Accept json-patch+json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param If-Match header string true "目前版本的 ETag"
+// @Param message query string false "版本說明"
+// @Param patch body []topology.PatchOperation true "JSON Patch 操作"
+// @Success 200 {object} topology.Topology
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/topologies/{id} [patch]
+func (h *TopologyHandler) PatchTopology(c *gin.Context) {
+	var ops []topology.PatchOperation
+	if err := c.ShouldBindJSON(&ops); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ops) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "patch must contain at least one operation"})
+		return
+	}
+
+	// JSON Patch 以陣列索引定位元素，必須對應到用戶看到的版本
+	topo, ok := h.editTopology(c, true, "", func(t *topology.Topology) error {
+		patched, err := topology.ApplyPatch(t, ops)
+		if err != nil {
+			return err
+		}
+		*t = *patched
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, topo)
+}
+
+// AddNode 新增節點
+// @Summary 新增節點
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param If-Match header string false "目前版本的 ETag"
+// @Param node body topology.Node true "節點"
+// @Success 201 {object} topology.Node
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/v1/topologies/{id}/nodes [post]
+func (h *TopologyHandler) AddNode(c *gin.Context) {
+	var node topology.Node
+	if err := c.ShouldBindJSON(&node); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var added topology.Node
+	_, ok := h.editTopology(c, false, "Add node", func(t *topology.Topology) error {
+		var err error
+		added, err = t.AddNode(node)
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, added)
+}
+
+// UpdateNode 取代節點內容
+// @Summary 更新節點
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param nodeId path string true "節點 ID"
+// @Param If-Match header string true "目前版本的 ETag"
+// @Param node body topology.Node true "節點"
+// @Success 200 {object} topology.Node
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/topologies/{id}/nodes/{nodeId} [put]
+func (h *TopologyHandler) UpdateNode(c *gin.Context) {
+	var node topology.Node
+	if err := c.ShouldBindJSON(&node); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updated topology.Node
+	_, ok := h.editTopology(c, true, "Update node", func(t *topology.Topology) error {
+		var err error
+		updated, err = t.UpdateNode(c.Param("nodeId"), node)
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveNode 刪除節點（連接的線路一併刪除）
+// @Summary 刪除節點
+// @Tags topologies
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param nodeId path string true "節點 ID"
+// @Param If-Match header string true "目前版本的 ETag"
+// @Success 200 {object} RemoveNodeResponse
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/topologies/{id}/nodes/{nodeId} [delete]
+func (h *TopologyHandler) RemoveNode(c *gin.Context) {
+	nodeID := c.Param("nodeId")
+
+	var removedLines []string
+	topo, ok := h.editTopology(c, true, "Remove node", func(t *topology.Topology) error {
+		var err error
+		removedLines, err = t.RemoveNode(nodeID)
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, RemoveNodeResponse{
+		NodeID:         nodeID,
+		RemovedLineIDs: removedLines,
+		Version:        topo.Version,
+	})
+}
+
+// AddLine 新增線路
+// @Summary 新增線路
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param If-Match header string false "目前版本的 ETag"
+// @Param line body topology.Line true "線路"
+// @Success 201 {object} topology.Line
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/v1/topologies/{id}/lines [post]
+func (h *TopologyHandler) AddLine(c *gin.Context) {
+	var line topology.Line
+	if err := c.ShouldBindJSON(&line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var added topology.Line
+	_, ok := h.editTopology(c, false, "Add line", func(t *topology.Topology) error {
+		var err error
+		added, err = t.AddLine(line)
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, added)
+}
+
+// UpdateLine 取代線路內容
+// @Summary 更新線路
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param lineId path string true "線路 ID"
+// @Param If-Match header string true "目前版本的 ETag"
+// @Param line body topology.Line true "線路"
+// @Success 200 {object} topology.Line
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/topologies/{id}/lines/{lineId} [put]
+func (h *TopologyHandler) UpdateLine(c *gin.Context) {
+	var line topology.Line
+	if err := c.ShouldBindJSON(&line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updated topology.Line
+	_, ok := h.editTopology(c, true, "Update line", func(t *topology.Topology) error {
+		var err error
+		updated, err = t.UpdateLine(c.Param("lineId"), line)
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveLine 刪除線路
+// @Summary 刪除線路
+// @Tags topologies
+// @Param id path string true "拓樸 ID"
+// @Param lineId path string true "線路 ID"
+// @Param If-Match header string true "目前版本的 ETag"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/topologies/{id}/lines/{lineId} [delete]
+func (h *TopologyHandler) RemoveLine(c *gin.Context) {
+	_, ok := h.editTopology(c, true, "Remove line", func(t *topology.Topology) error {
+		return t.RemoveLine(c.Param("lineId"))
+	})
+	if !ok {
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// editTopology 讀取拓樸（需要 editor 以上權限）、套用 edit、重新驗證並以 compare-and-swap 儲存
+// requireMatch 時必須提供 If-Match（修改、刪除既有元素時使用，避免覆蓋他人的編輯）；
+// 其餘情況 If-Match 為可選，未提供時遇到版本衝突會以最新版本重試。
+// 成功時設置 ETag；回傳 false 表示已回應錯誤，handler 應停止
+func (h *TopologyHandler) editTopology(c *gin.Context, requireMatch bool, defaultMessage string, edit func(t *topology.Topology) error) (*topology.Topology, bool) {
+	var expectedVersion int
+	var present bool
+	if requireMatch {
+		var ok bool
+		if expectedVersion, ok = requireIfMatch(c); !ok {
+			return nil, false
+		}
+		present = true
+	} else {
+		var err error
+		if expectedVersion, present, err = parseIfMatch(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+	}
+	pinned := present && expectedVersion != anyVersion
+
+	message := c.Query("message")
+	if message == "" {
+		message = defaultMessage
+	}
+	info := topology.RevisionInfo{AuthorID: auth.GetUserID(c), Message: message}
+
+	for attempt := 1; ; attempt++ {
+		existing, _, ok := authorizeTopology(c, h.repo, topology.RoleEditor)
+		if !ok {
+			return nil, false
+		}
+		if pinned && existing.Version != expectedVersion {
+			respondVersionConflict(c, existing)
+			return nil, false
+		}
+
+		if err := edit(existing); err != nil {
+			c.JSON(editErrorStatus(err), gin.H{"error": err.Error()})
+			return nil, false
+		}
+		existing.UpdatedAt = time.Now()
+
+		if !h.validateTopology(c, existing) {
+			return nil, false
+		}
+
+		err := h.repo.UpdateIfVersion(existing.ID, existing.Version, existing, info)
+		if err == topology.ErrVersionConflict && !pinned && attempt < maxEditAttempts {
+			continue
+		}
+		if err != nil {
+			h.respondWriteError(c, existing.ID, err)
+			return nil, false
+		}
+
+		setETag(c, existing)
+		return existing, true
+	}
+}
+
+// editErrorStatus 將編輯錯誤對應到 HTTP 狀態碼
+func editErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, topology.ErrNodeNotFound), errors.Is(err, topology.ErrLineNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, topology.ErrDuplicateElement), errors.Is(err, topology.ErrPatchTestFailed):
+		return http.StatusConflict
+	case errors.Is(err, topology.ErrElementIDMismatch), errors.Is(err, topology.ErrInvalidPatch):
+		return http.StatusBadRequest
+	case errors.Is(err, layout.ErrEmptyTopology), errors.Is(err, layout.ErrNoGeoNodes):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
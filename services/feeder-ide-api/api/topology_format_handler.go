@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats/cim"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats/geojson"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats/opendss"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize 匯入檔案大小上限
+const maxImportSize = 10 << 20
+
+// topologyCodecs 支援的匯入/匯出格式
+var topologyCodecs = []formats.Codec{
+	opendss.Codec{},
+	cim.Codec{},
+	geojson.Codec{},
+}
+
+// lookupCodec 依名稱取得格式
+func lookupCodec(name string) (formats.Codec, error) {
+	for _, codec := range topologyCodecs {
+		if strings.EqualFold(codec.Name(), name) {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", formats.ErrUnknownFormat, name)
+}
+
+// codecForFile 依副檔名推斷格式
+func codecForFile(filename string) (formats.Codec, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, codec := range topologyCodecs {
+		if codec.Extension() == ext {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: cannot infer format from %q, specify format", formats.ErrUnknownFormat, filename)
+}
+
+// ImportTopologyRequest 匯入拓樸的表單欄位（檔案放在 file 欄位）
+type ImportTopologyRequest struct {
+	Format      string `form:"format"`
+	Name        string `form:"name"`
+	Description string `form:"description"`
+	ProfileType string `form:"profile_type" binding:"required,oneof=rural suburban urban"`
+}
+
+// ImportTopologyResponse 匯入結果
+type ImportTopologyResponse struct {
+	Topology *topology.Topology `json:"topology"`
+	Warnings []string           `json:"warnings"`
+}
+
+// ImportValidationResponse validate_only 匯入的驗證結果，附上格式轉換警告
+type ImportValidationResponse struct {
+	*topology.ValidationResult
+	Warnings []string `json:"warnings"`
+}
+
+// ExportTopology 匯出拓樸
+// @Summary 匯出拓樸
+// @Description 將拓樸匯出為外部模擬工具格式（opendss、cim、geojson），設備型錄參照會展開為元素屬性。
+// @Description OpenDSS 的 Circuit 必須接在電源上，沒有電源節點的拓樸匯出 opendss 會回應 422（cim、geojson 不受限制）
+// @Tags topologies
+// @Produce plain
+// @Param id path string true "拓樸 ID"
+// @Param format query string false "格式" default(opendss)
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/export [get]
+func (h *TopologyHandler) ExportTopology(c *gin.Context) {
+	codec, err := lookupCodec(c.DefaultQuery("format", "opendss"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok || !h.resolveCatalog(c, topo) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, topo); err != nil {
+		c.JSON(formatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, exportFilename(topo), codec.Extension()))
+	c.Data(http.StatusOK, codec.ContentType(), buf.Bytes())
+}
+
+// ImportTopology 匯入拓樸
+// @Summary 匯入拓樸
+// @Description 上傳外部格式檔案（OpenDSS .dss、CIM RDF/XML .xml、GeoJSON .geojson）並建立新拓樸，無法轉換的內容列在 warnings。
+// @Description 以 /organizations/{orgId}/topologies/import 匯入時由組織擁有（需要 engineer 以上角色）
+// @Tags topologies
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "拓樸檔案"
+// @Param format formData string false "格式（預設依副檔名判斷）"
+// @Param name formData string false "拓樸名稱（預設使用檔案中的名稱）"
+// @Param description formData string false "描述"
+// @Param profile_type formData string true "場景類型（rural, suburban, urban）"
+// @Param validate_only query bool false "僅驗證，不儲存（回傳驗證結果與 warnings）"
+// @Success 200 {object} ImportValidationResponse
+// @Success 201 {object} ImportTopologyResponse
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/v1/topologies/import [post]
+// @Router /api/v1/organizations/{orgId}/topologies/import [post]
+func (h *TopologyHandler) ImportTopology(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var req ImportTopologyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "request body too large") {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": "file is required: " + err.Error()})
+		return
+	}
+
+	var codec formats.Codec
+	if req.Format != "" {
+		codec, err = lookupCodec(req.Format)
+	} else {
+		codec, err = codecForFile(fileHeader.Filename)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, organizationID, ok := topologyOwner(c)
+	if !ok {
+		return
+	}
+	if !checkTopologyQuota(c, h.userService, userID, organizationID) {
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	result, err := codec.Decode(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse file: " + err.Error()})
+		return
+	}
+
+	topo := result.Topology
+	topo.UserID = userID
+	topo.OrganizationID = organizationID
+	topo.ProfileType = req.ProfileType
+	// 表單未提供 name、description 時保留檔案中的內容
+	if req.Name != "" {
+		topo.Name = req.Name
+	}
+	if req.Description != "" {
+		topo.Description = req.Description
+	}
+	if topo.Name == "" {
+		topo.Name = strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+	}
+	topo.CreatedAt = time.Now()
+	topo.UpdatedAt = time.Now()
+
+	if c.Query("validate_only") == "true" {
+		if validation, ok := h.checkTopology(c, topo); ok {
+			c.JSON(http.StatusOK, ImportValidationResponse{
+				ValidationResult: validation,
+				Warnings:         result.Warnings,
+			})
+		}
+		return
+	}
+
+	if !h.validateTopology(c, topo) {
+		return
+	}
+
+	if err := h.repo.Create(topo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, topo)
+	c.JSON(http.StatusCreated, ImportTopologyResponse{
+		Topology: topo,
+		Warnings: result.Warnings,
+	})
+}
+
+// formatErrorStatus 將格式轉換錯誤對應為 HTTP 狀態碼
+func formatErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, formats.ErrUnknownFormat):
+		return http.StatusBadRequest
+	case errors.Is(err, formats.ErrUnsupportedTopology):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// exportFilename 以拓樸名稱產生安全的檔名
+func exportFilename(topo *topology.Topology) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, topo.Name)
+	if strings.Trim(name, "_") == "" {
+		return topo.ID
+	}
+	return name
+}
@@ -1,10 +1,18 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/catalog"
+	"github.com/feeder-platform/feeder-ide-api/internal/middleware"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/feeder-platform/feeder-ide-api/internal/templates"
 	"github.com/feeder-platform/feeder-ide-api/internal/topology"
 	"github.com/feeder-platform/feeder-ide-api/internal/user"
 	"github.com/gin-gonic/gin"
@@ -12,15 +20,20 @@ import (
 
 // TopologyHandler 處理拓樸相關的 HTTP 請求
 type TopologyHandler struct {
-	repo        topology.Repository
-	userService *user.Service
+	repo           topology.Repository
+	userService    *user.Service
+	catalog        catalog.Repository
+	templates      templates.Repository
+	onAccessChange func(topologyID string)
 }
 
 // NewTopologyHandler 建立新的 TopologyHandler
-func NewTopologyHandler(repo topology.Repository, userService *user.Service) *TopologyHandler {
+func NewTopologyHandler(repo topology.Repository, userService *user.Service, catalogRepo catalog.Repository, templateRepo templates.Repository) *TopologyHandler {
 	return &TopologyHandler{
 		repo:        repo,
 		userService: userService,
+		catalog:     catalogRepo,
+		templates:   templateRepo,
 	}
 }
 
@@ -35,14 +48,19 @@ type CreateTopologyRequest struct {
 
 // CreateTopology 建立新拓樸
 // @Summary 建立新拓樸
-// @Description 建立一個新的配電 feeder 拓樸
+// @Description 建立一個新的配電 feeder 拓樸。以 /organizations/{orgId}/topologies 建立時由組織擁有（需要 engineer 以上角色），計入組織共用配額
 // @Tags topologies
 // @Accept json
 // @Produce json
 // @Param topology body CreateTopologyRequest true "拓樸資料"
+// @Param validate_only query bool false "僅驗證，不儲存"
 // @Success 201 {object} topology.Topology
+// @Success 200 {object} topology.ValidationResult
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Router /api/v1/topologies [post]
+// @Router /api/v1/organizations/{orgId}/topologies [post]
 func (h *TopologyHandler) CreateTopology(c *gin.Context) {
 	var req CreateTopologyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -51,32 +69,28 @@ func (h *TopologyHandler) CreateTopology(c *gin.Context) {
 	}
 
 	// 檢查配額（如果 userService 可用）
-	userID := auth.GetUserID(c)
-	if h.userService != nil {
-		canCreate, used, max, err := h.userService.CheckTopologyQuota(userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check quota: " + err.Error()})
-			return
-		}
-		if !canCreate {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Topology quota exceeded",
-				"used": used,
-				"max":  max,
-			})
-			return
-		}
+	userID, organizationID, ok := topologyOwner(c)
+	if !ok {
+		return
+	}
+	if !checkTopologyQuota(c, h.userService, userID, organizationID) {
+		return
 	}
 
 	topo := &topology.Topology{
-		UserID:      userID, // 設置用戶ID（如果已登入）
-		Name:        req.Name,
-		Description: req.Description,
-		ProfileType: req.ProfileType,
-		Nodes:       req.Nodes,
-		Lines:       req.Lines,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		UserID:         userID, // 設置用戶ID（如果已登入）
+		OrganizationID: organizationID,
+		Name:           req.Name,
+		Description:    req.Description,
+		ProfileType:    req.ProfileType,
+		Nodes:          req.Nodes,
+		Lines:          req.Lines,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if !h.validateTopology(c, topo) {
+		return
 	}
 
 	if err := h.repo.Create(topo); err != nil {
@@ -84,6 +98,7 @@ func (h *TopologyHandler) CreateTopology(c *gin.Context) {
 		return
 	}
 
+	setETag(c, topo)
 	c.JSON(http.StatusCreated, topo)
 }
 
@@ -93,24 +108,23 @@ func (h *TopologyHandler) CreateTopology(c *gin.Context) {
 // @Tags topologies
 // @Produce json
 // @Param id path string true "拓樸 ID"
+// @Param resolve_catalog query bool false "以設備型錄補齊元素屬性"
 // @Success 200 {object} topology.Topology
+// @Header 200 {string} ETag "拓樸版本"
 // @Failure 404 {object} map[string]string
 // @Router /api/v1/topologies/{id} [get]
 func (h *TopologyHandler) GetTopology(c *gin.Context) {
-	id := c.Param("id")
-	userID := auth.GetUserID(c)
+	// 擁有者、共用對象或持有效共用連結者才能讀取
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
 
-	// 使用 GetByIDAndUserID 確保用戶只能訪問自己的拓樸（或 demo 拓樸）
-	topo, err := h.repo.GetByIDAndUserID(id, userID)
-	if err != nil {
-		if err == topology.ErrTopologyNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if c.Query("resolve_catalog") == "true" && !h.resolveCatalog(c, topo) {
 		return
 	}
 
+	setETag(c, topo)
 	c.JSON(http.StatusOK, topo)
 }
 
@@ -121,38 +135,50 @@ type UpdateTopologyRequest struct {
 	ProfileType string                 `json:"profile_type,omitempty" binding:"omitempty,oneof=rural suburban urban"`
 	Nodes       []topology.Node        `json:"nodes,omitempty"`
 	Lines       []topology.Line        `json:"lines,omitempty"`
+	Message     string                 `json:"message,omitempty"` // 版本說明
 }
 
 // UpdateTopology 更新拓樸
 // @Summary 更新拓樸
-// @Description 更新現有拓樸
+// @Description 更新現有拓樸，並產生一個新版本
 // @Tags topologies
 // @Accept json
 // @Produce json
 // @Param id path string true "拓樸 ID"
+// @Param If-Match header string true "目前版本的 ETag"
 // @Param topology body UpdateTopologyRequest true "拓樸資料"
+// @Param validate_only query bool false "僅驗證，不儲存"
 // @Success 200 {object} topology.Topology
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
 // @Router /api/v1/topologies/{id} [put]
 func (h *TopologyHandler) UpdateTopology(c *gin.Context) {
 	id := c.Param("id")
 	userID := auth.GetUserID(c)
 
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
 	var req UpdateTopologyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 取得現有拓樸（檢查權限）
-	existing, err := h.repo.GetByIDAndUserID(id, userID)
-	if err != nil {
-		if err == topology.ErrTopologyNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 取得現有拓樸（需要 editor 以上權限）
+	existing, _, ok := authorizeTopology(c, h.repo, topology.RoleEditor)
+	if !ok {
+		return
+	}
+
+	if expectedVersion != anyVersion && existing.Version != expectedVersion {
+		respondVersionConflict(c, existing)
 		return
 	}
 
@@ -174,11 +200,17 @@ func (h *TopologyHandler) UpdateTopology(c *gin.Context) {
 	}
 	existing.UpdatedAt = time.Now()
 
-	if err := h.repo.Update(id, existing); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !h.validateTopology(c, existing) {
+		return
+	}
+
+	info := topology.RevisionInfo{AuthorID: userID, Message: req.Message}
+	if err := h.repo.UpdateIfVersion(id, existing.Version, existing, info); err != nil {
+		h.respondWriteError(c, id, err)
 		return
 	}
 
+	setETag(c, existing)
 	c.JSON(http.StatusOK, existing)
 }
 
@@ -187,50 +219,116 @@ func (h *TopologyHandler) UpdateTopology(c *gin.Context) {
 // @Description 根據 ID 刪除拓樸
 // @Tags topologies
 // @Param id path string true "拓樸 ID"
+// @Param If-Match header string true "目前版本的 ETag"
 // @Success 204
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Failure 428 {object} map[string]string
 // @Router /api/v1/topologies/{id} [delete]
 func (h *TopologyHandler) DeleteTopology(c *gin.Context) {
 	id := c.Param("id")
-	userID := auth.GetUserID(c)
 
-	// 檢查權限：確保用戶只能刪除自己的拓樸
-	existing, err := h.repo.GetByIDAndUserID(id, userID)
-	if err != nil {
-		if err == topology.ErrTopologyNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
 		return
 	}
 
-	// 確保是該用戶的拓樸（demo 模式允許刪除無 userID 的拓樸）
-	if userID != nil && existing.UserID != nil && *existing.UserID != *userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this topology"})
+	// 檢查權限：只有 owner 能刪除（demo 模式允許刪除無 userID 的拓樸）
+	existing, _, ok := authorizeTopology(c, h.repo, topology.RoleOwner)
+	if !ok {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if expectedVersion != anyVersion && existing.Version != expectedVersion {
+		respondVersionConflict(c, existing)
+		return
+	}
+
+	if err := h.repo.DeleteIfVersion(id, existing.Version); err != nil {
+		h.respondWriteError(c, id, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// TopologyListResponse 分頁列表回應
+type TopologyListResponse struct {
+	Items      interface{} `json:"items"` // view=summary 為 []topology.Summary，view=full 為 []topology.Topology
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// listQueryParams 出現任一參數時改用分頁列表回應
+var listQueryParams = []string{
+	"limit", "cursor", "q", "profile_type", "sort", "order", "view",
+	"created_after", "created_before", "updated_after", "updated_before",
+}
+
 // ListTopologies 列出所有拓樸
 // @Summary 列出所有拓樸
-// @Description 取得所有拓樸列表
+// @Description 取得所有拓樸列表，可用 bbox 篩選經緯度範圍相交的拓樸。
+// @Description 登入時列出自己的拓樸；未登入時只列出沒有擁有者的 demo 拓樸，帶 X-Share-Token 時只列出該共用連結的拓樸。
+// @Description 帶任一分頁、篩選或排序參數時回傳游標分頁結果（預設為不含 nodes/lines 的摘要），否則回傳完整拓樸陣列
 // @Tags topologies
 // @Produce json
-// @Success 200 {array} topology.Topology
+// @Param bbox query string false "經緯度範圍 min_lon,min_lat,max_lon,max_lat（WGS84）"
+// @Param q query string false "搜尋名稱與描述（每個詞皆須符合，前綴比對）"
+// @Param profile_type query string false "profile 類型（rural, suburban, urban）"
+// @Param created_after query string false "建立時間下限（含，RFC3339 或 YYYY-MM-DD）"
+// @Param created_before query string false "建立時間上限（不含）"
+// @Param updated_after query string false "更新時間下限（含）"
+// @Param updated_before query string false "更新時間上限（不含）"
+// @Param sort query string false "排序欄位（created_at, updated_at, name，預設 created_at）"
+// @Param order query string false "排序方向（asc, desc，預設 desc）"
+// @Param limit query int false "每頁數量（預設 50，最多 200）"
+// @Param cursor query string false "上一頁回傳的 next_cursor"
+// @Param view query string false "summary（預設）或 full"
+// @Success 200 {object} TopologyListResponse
+// @Failure 400 {object} map[string]string
 // @Router /api/v1/topologies [get]
 func (h *TopologyHandler) ListTopologies(c *gin.Context) {
-	userID := auth.GetUserID(c)
+	principal := topologyPrincipal(c)
+	query := topology.ListQuery{UserID: principal.UserID}
+	if principal.UserID == nil && principal.ShareToken != "" {
+		query.ShareTokenHash = topology.HashShareToken(principal.ShareToken)
+	}
 
-	// 根據用戶ID列出拓樸
-	topologies, err := h.repo.ListByUserID(userID)
+	for _, param := range listQueryParams {
+		if _, ok := c.GetQuery(param); ok {
+			h.queryTopologies(c, query)
+			return
+		}
+	}
+
+	var bbox *topology.BBox
+	if value := c.Query("bbox"); value != "" {
+		parsed, err := topology.ParseBBox(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		bbox = &parsed
+	}
+
+	var topologies []*topology.Topology
+	var err error
+	switch {
+	case query.ShareTokenHash != "":
+		// 共用連結只對應一個拓樸，以完整投影查詢一次即可
+		query.BBox = bbox
+		query.Projection = topology.ProjectionFull
+		var page *topology.ListPage
+		if page, err = h.repo.Query(query); err == nil {
+			topologies = page.Topologies
+		}
+	case bbox != nil:
+		topologies, err = h.repo.ListByBBox(principal.UserID, *bbox)
+	default:
+		// 根據用戶ID列出拓樸
+		topologies, err = h.repo.ListByUserID(principal.UserID)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -239,3 +337,319 @@ func (h *TopologyHandler) ListTopologies(c *gin.Context) {
 	c.JSON(http.StatusOK, topologies)
 }
 
+// ListOrganizationTopologies 列出組織擁有的拓樸
+// @Summary 列出組織拓樸
+// @Description 以游標分頁列出組織擁有的拓樸，查詢參數與 GET /topologies 的分頁列表相同。需要組織成員身分
+// @Tags organizations
+// @Produce json
+// @Param orgId path string true "組織 ID"
+// @Param q query string false "搜尋名稱與描述（每個詞皆須符合，前綴比對）"
+// @Param profile_type query string false "profile 類型（rural, suburban, urban）"
+// @Param bbox query string false "經緯度範圍 min_lon,min_lat,max_lon,max_lat（WGS84）"
+// @Param sort query string false "排序欄位（created_at, updated_at, name，預設 created_at）"
+// @Param order query string false "排序方向（asc, desc，預設 desc）"
+// @Param limit query int false "每頁數量（預設 50，最多 200）"
+// @Param cursor query string false "上一頁回傳的 next_cursor"
+// @Param view query string false "summary（預設）或 full"
+// @Success 200 {object} TopologyListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/organizations/{orgId}/topologies [get]
+func (h *TopologyHandler) ListOrganizationTopologies(c *gin.Context) {
+	organizationID := c.Param("orgId")
+	if !requireOrganizationRole(c, organizationID, organization.RoleViewer) {
+		return
+	}
+
+	h.queryTopologies(c, topology.ListQuery{OrganizationID: &organizationID})
+}
+
+// queryTopologies 以查詢參數補齊 query 的篩選條件（擁有者範圍由呼叫端指定）並回應一頁列表
+func (h *TopologyHandler) queryTopologies(c *gin.Context, query topology.ListQuery) {
+	query.ProfileType = c.Query("profile_type")
+	query.Search = c.Query("q")
+	query.Sort = c.Query("sort")
+	query.Order = c.Query("order")
+	query.Cursor = c.Query("cursor")
+	query.Projection = c.Query("view")
+
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		if parsed < topology.MaxListLimit {
+			query.Limit = parsed
+		} else {
+			query.Limit = topology.MaxListLimit
+		}
+	}
+
+	if value := c.Query("bbox"); value != "" {
+		bbox, err := topology.ParseBBox(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query.BBox = &bbox
+	}
+
+	for param, target := range map[string]**time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+		"updated_after":  &query.UpdatedAfter,
+		"updated_before": &query.UpdatedBefore,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s, expected RFC3339 or YYYY-MM-DD", param)})
+			return
+		}
+		*target = &parsed
+	}
+
+	page, err := h.repo.Query(query)
+	if err != nil {
+		if errors.Is(err, topology.ErrInvalidQuery) || errors.Is(err, topology.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := TopologyListResponse{NextCursor: page.NextCursor, HasMore: page.HasMore()}
+	if page.Topologies != nil {
+		response.Items = page.Topologies
+	} else {
+		response.Items = page.Summaries
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseQueryTime 解析 RFC3339 時間或 YYYY-MM-DD 日期（UTC 零時）
+func parseQueryTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// topologyOwner 新拓樸的擁有者：組織路由（:orgId）建立的拓樸由組織擁有並需要 engineer 以上角色，
+// 其他由目前用戶擁有（未登入為 nil）。失敗時直接回應錯誤並回傳 false
+func topologyOwner(c *gin.Context) (*string, *string, bool) {
+	organizationID := c.Param("orgId")
+	if organizationID == "" {
+		return auth.GetUserID(c), nil, true
+	}
+	if !requireOrganizationRole(c, organizationID, organization.RoleEngineer) {
+		return nil, nil, false
+	}
+	return nil, &organizationID, true
+}
+
+// checkTopologyQuota 檢查拓樸數量配額（如果 userService 可用），organizationID 不為 nil 時檢查組織共用配額；
+// 超出時回應 403 並回傳 false
+func checkTopologyQuota(c *gin.Context, userService *user.Service, userID *string, organizationID *string) bool {
+	if userService == nil {
+		return true
+	}
+
+	canCreate, used, max, err := userService.CheckTopologyQuotaFor(userID, organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check quota: " + err.Error()})
+		return false
+	}
+	if !canCreate {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Topology quota exceeded",
+			"used": used,
+			"max":  max,
+		})
+		return false
+	}
+	return true
+}
+
+// validateTopology 驗證拓樸結構
+// validate_only=true 時直接回傳驗證結果；驗證失敗時回應 422。回傳 false 表示已回應，handler 應停止
+func (h *TopologyHandler) validateTopology(c *gin.Context, topo *topology.Topology) bool {
+	result, ok := h.checkTopology(c, topo)
+	if !ok {
+		return false
+	}
+
+	if c.Query("validate_only") == "true" {
+		c.JSON(http.StatusOK, result)
+		return false
+	}
+
+	if !result.Valid {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      (&topology.ValidationError{Result: result}).Error(),
+			"violations": result.Violations,
+		})
+		return false
+	}
+
+	return true
+}
+
+// checkTopology 執行結構驗證與設備型錄參照檢查，失敗時直接回應錯誤並回傳 false
+func (h *TopologyHandler) checkTopology(c *gin.Context, topo *topology.Topology) (*topology.ValidationResult, bool) {
+	result := topology.Validate(topo)
+	if h.catalog != nil {
+		violations, err := catalog.CheckReferences(topo, h.catalog, catalog.OwnerVisibility(topo))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		result.Add(violations...)
+	}
+	return result, true
+}
+
+// resolveCatalog 以用戶可見的設備型錄補齊拓樸元素屬性，失敗時直接回應錯誤並回傳 false
+func (h *TopologyHandler) resolveCatalog(c *gin.Context, topo *topology.Topology) bool {
+	if h.catalog == nil {
+		return true
+	}
+	if err := catalog.Resolve(topo, h.catalog, catalog.OwnerVisibility(topo)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// SetAccessChangeHook 設置共用對象、共用連結或擁有權變更後的 hook（例如重新判斷協作連線的角色）
+func (h *TopologyHandler) SetAccessChangeHook(hook func(topologyID string)) {
+	h.onAccessChange = hook
+}
+
+// accessChanged 通知拓樸的存取權已變更
+func (h *TopologyHandler) accessChanged(topologyID string) {
+	if h.onAccessChange != nil {
+		h.onAccessChange(topologyID)
+	}
+}
+
+// loadTopology 取得目前用戶可檢視（viewer 以上）的拓樸，失敗時直接回應錯誤並回傳 false
+func loadTopology(c *gin.Context, repo topology.Repository) (*topology.Topology, bool) {
+	topo, _, ok := authorizeTopology(c, repo, topology.RoleViewer)
+	return topo, ok
+}
+
+// authorizeTopology 取得拓樸並確認目前用戶（或共用連結）具備 required 角色。
+// 沒有任何權限時回應 404（不透露拓樸是否存在），權限不足時回應 403；成功時設置 X-Topology-Role 標頭
+func authorizeTopology(c *gin.Context, repo topology.Repository, required topology.Role) (*topology.Topology, topology.Role, bool) {
+	return authorizeTopologyAs(c, repo, topologyPrincipal(c), required)
+}
+
+// authorizeTopologyAs 與 authorizeTopology 相同，但以指定的身分判斷（例如協作連線票券記錄的身分）
+func authorizeTopologyAs(c *gin.Context, repo topology.Repository, principal topology.Principal, required topology.Role) (*topology.Topology, topology.Role, bool) {
+	topo, role, err := repo.Authorize(c.Param("id"), principal)
+	if err != nil {
+		if err == topology.ErrTopologyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, topology.RoleNone, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, topology.RoleNone, false
+	}
+	if !role.Allows(required) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":         topology.ErrInsufficientRole.Error(),
+			"role":          role,
+			"required_role": required,
+		})
+		return nil, topology.RoleNone, false
+	}
+	c.Header("X-Topology-Role", string(role))
+	return topo, role, true
+}
+
+// topologyPrincipal 目前請求的身分：登入用戶（含所屬組織的角色）與 X-Share-Token 標頭的共用連結
+// 共用連結 token 只接受標頭，避免出現在存取記錄與 Referer 中
+func topologyPrincipal(c *gin.Context) topology.Principal {
+	return topology.Principal{
+		UserID:            auth.GetUserID(c),
+		ShareToken:        c.GetHeader("X-Share-Token"),
+		OrganizationRoles: organization.TopologyRoles(middleware.GetOrganizationRolesFromContext(c)),
+	}
+}
+
+// anyVersion 代表 If-Match: *，不檢查版本
+const anyVersion = -1
+
+// setETag 以拓樸版本設置 ETag 標頭
+func setETag(c *gin.Context, topo *topology.Topology) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(topo.Version)))
+}
+
+// parseIfMatch 解析 If-Match 標頭，回傳期望版本與標頭是否存在
+func parseIfMatch(c *gin.Context) (int, bool, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, false, nil
+	}
+	if header == "*" {
+		return anyVersion, true, nil
+	}
+
+	// 只取第一個 ETag，並接受 W/"3"、"3" 或 3
+	tag := strings.TrimSpace(strings.Split(header, ",")[0])
+	tag = strings.TrimPrefix(tag, "W/")
+	tag = strings.Trim(tag, `"`)
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, true, fmt.Errorf("invalid If-Match header: %s", header)
+	}
+	return version, true, nil
+}
+
+// requireIfMatch 要求 If-Match 標頭，缺少時回應 428，格式錯誤時回應 400
+func requireIfMatch(c *gin.Context) (int, bool) {
+	version, present, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if !present {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+		return 0, false
+	}
+	return version, true
+}
+
+// respondVersionConflict 回應 412 並附上伺服器目前的拓樸
+func respondVersionConflict(c *gin.Context, current *topology.Topology) {
+	setETag(c, current)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   topology.ErrVersionConflict.Error(),
+		"current": current,
+	})
+}
+
+// respondWriteError 處理寫入錯誤；版本衝突時重新讀取並回應 412
+func (h *TopologyHandler) respondWriteError(c *gin.Context, id string, err error) {
+	switch err {
+	case topology.ErrTopologyNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case topology.ErrVersionConflict:
+		current, getErr := h.repo.GetByID(id)
+		if getErr != nil {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
+		respondVersionConflict(c, current)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/layout"
+	"github.com/gin-gonic/gin"
+)
+
+// LayoutRequest 自動排版請求
+type LayoutRequest struct {
+	layout.Options
+	Save bool `json:"save,omitempty"` // 將座標儲存為新版本（只修改節點 position）
+}
+
+// LayoutResponse 自動排版結果
+type LayoutResponse struct {
+	*layout.Result
+	Saved   bool `json:"saved"`
+	Version int  `json:"version"`
+}
+
+// LayoutTopology 自動排版
+// @Summary 自動排版
+// @Description 計算節點的畫布座標：tree（整齊樹）、layered（分層，重心排序減少交錯）、force（弱環狀網路，受層級約束的力導向）、geo（依經緯度定位，其餘節點以力導向補齊），auto 依拓樸自動選擇。只回傳座標，不修改電氣資料；save 時將座標儲存為新版本
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param If-Match header string false "目前版本的 ETag（save 時使用）"
+// @Param request body LayoutRequest false "排版選項"
+// @Success 200 {object} LayoutResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/topologies/{id}/layout [post]
+func (h *TopologyHandler) LayoutTopology(c *gin.Context) {
+	var req LayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Save {
+		topo, ok := loadTopology(c, h.repo)
+		if !ok {
+			return
+		}
+		result, err := layout.Compute(topo, req.Options)
+		if err != nil {
+			c.JSON(editErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		setETag(c, topo)
+		c.JSON(http.StatusOK, LayoutResponse{Result: result, Version: topo.Version})
+		return
+	}
+
+	var result *layout.Result
+	topo, ok := h.editTopology(c, false, "Auto layout", func(t *topology.Topology) error {
+		var err error
+		if result, err = layout.Compute(t, req.Options); err != nil {
+			return err
+		}
+		result.Apply(t)
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, LayoutResponse{Result: result, Saved: true, Version: topo.Version})
+}
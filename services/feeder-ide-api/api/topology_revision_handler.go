@@ -0,0 +1,207 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRevisions 列出拓樸版本歷史
+// @Summary 列出拓樸版本
+// @Description 取得拓樸的版本歷史（由新到舊）
+// @Tags topologies
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Success 200 {array} topology.RevisionSummary
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/revisions [get]
+func (h *TopologyHandler) ListRevisions(c *gin.Context) {
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.repo.ListRevisions(topo.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summaries := make([]topology.RevisionSummary, 0, len(revisions))
+	for _, revision := range revisions {
+		summaries = append(summaries, revision.Summary())
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetRevision 取得特定版本
+// @Summary 取得拓樸版本
+// @Description 取得拓樸特定版本的完整內容
+// @Tags topologies
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param revision path int true "版本號"
+// @Success 200 {object} topology.Revision
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/revisions/{revision} [get]
+func (h *TopologyHandler) GetRevision(c *gin.Context) {
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	revision, ok := h.loadRevision(c, topo.ID, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions 比較兩個版本
+// @Summary 比較拓樸版本
+// @Description 計算兩個版本之間新增、刪除與修改的節點、線路與屬性
+// @Tags topologies
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param from query int true "起始版本"
+// @Param to query int false "目標版本（預設為最新版本）"
+// @Success 200 {object} topology.Diff
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/revisions/diff [get]
+func (h *TopologyHandler) DiffRevisions(c *gin.Context) {
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	if c.Query("from") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from revision is required"})
+		return
+	}
+	from, ok := h.loadRevision(c, topo.ID, c.Query("from"))
+	if !ok {
+		return
+	}
+
+	var to *topology.Revision
+	if c.Query("to") != "" {
+		to, ok = h.loadRevision(c, topo.ID, c.Query("to"))
+		if !ok {
+			return
+		}
+	} else {
+		revisions, err := h.repo.ListRevisions(topo.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(revisions) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": topology.ErrRevisionNotFound.Error()})
+			return
+		}
+		to = revisions[0]
+	}
+
+	diff := topology.DiffTopologies(from.Snapshot(), to.Snapshot())
+	diff.FromRevision = from.Revision
+	diff.ToRevision = to.Revision
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreRevisionRequest 還原版本的請求
+type RestoreRevisionRequest struct {
+	Message string `json:"message,omitempty"`
+}
+
+// RestoreRevision 還原至先前版本
+// @Summary 還原拓樸版本
+// @Description 以指定版本的內容覆寫拓樸，並產生一個新版本（歷史不會被刪除）
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param revision path int true "版本號"
+// @Param If-Match header string false "目前版本的 ETag"
+// @Success 200 {object} topology.Topology
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{}
+// @Router /api/v1/topologies/{id}/revisions/{revision}/restore [post]
+func (h *TopologyHandler) RestoreRevision(c *gin.Context) {
+	var req RestoreRevisionRequest
+	// body 為可選
+	_ = c.ShouldBindJSON(&req)
+
+	// If-Match 為可選，提供時需與目前版本一致
+	expectedVersion, present, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, _, ok := authorizeTopology(c, h.repo, topology.RoleEditor)
+	if !ok {
+		return
+	}
+
+	if present && expectedVersion != anyVersion && existing.Version != expectedVersion {
+		respondVersionConflict(c, existing)
+		return
+	}
+
+	revision, ok := h.loadRevision(c, existing.ID, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	snapshot := revision.Snapshot()
+	existing.Name = snapshot.Name
+	existing.Description = snapshot.Description
+	existing.ProfileType = snapshot.ProfileType
+	existing.Nodes = snapshot.Nodes
+	existing.Lines = snapshot.Lines
+	existing.UpdatedAt = time.Now()
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("Restore revision %d", revision.Revision)
+	}
+
+	info := topology.RevisionInfo{AuthorID: auth.GetUserID(c), Message: message}
+	if err := h.repo.UpdateIfVersion(existing.ID, existing.Version, existing, info); err != nil {
+		h.respondWriteError(c, existing.ID, err)
+		return
+	}
+
+	setETag(c, existing)
+	c.JSON(http.StatusOK, existing)
+}
+
+// loadRevision 解析版本號並取得版本，失敗時直接回應錯誤並回傳 false
+func (h *TopologyHandler) loadRevision(c *gin.Context, topologyID, value string) (*topology.Revision, bool) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision number"})
+		return nil, false
+	}
+
+	revision, err := h.repo.GetRevision(topologyID, number)
+	if err != nil {
+		if err == topology.ErrRevisionNotFound || err == topology.ErrTopologyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return revision, true
+}
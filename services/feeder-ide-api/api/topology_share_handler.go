@@ -0,0 +1,442 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// errSharingUnavailable 沒有用戶帳號（非資料庫模式）時無法以 email 共用
+var errSharingUnavailable = errors.New("sharing with users requires user accounts")
+
+// ShareTopologyRequest 共用拓樸給用戶的請求
+type ShareTopologyRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+// CreateShareLinkRequest 建立共用連結的請求（皆為可選，未指定時連結不會過期）
+type CreateShareLinkRequest struct {
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	ExpiresInHours int        `json:"expires_in_hours,omitempty"`
+}
+
+// TransferOwnershipRequest 轉移擁有權的請求，email 與 organization_id 擇一
+type TransferOwnershipRequest struct {
+	Email string `json:"email,omitempty" binding:"required_without=OrganizationID"`
+	// OrganizationID 轉移給組織（需要在該組織具備 engineer 以上角色）
+	OrganizationID string `json:"organization_id,omitempty"`
+	// PreviousOwnerRole 原擁有者保留的角色（viewer、editor、owner 或 none）；
+	// 轉移給用戶時預設 editor，轉移給組織時預設 none（以組織角色存取）
+	PreviousOwnerRole string `json:"previous_owner_role,omitempty" binding:"omitempty,oneof=viewer editor owner none"`
+}
+
+// TransferOwnershipResponse 轉移擁有權的回應
+type TransferOwnershipResponse struct {
+	TopologyID        string        `json:"topology_id"`
+	OwnerID           string        `json:"owner_id,omitempty"`
+	OrganizationID    string        `json:"organization_id,omitempty"`
+	PreviousOwnerID   string        `json:"previous_owner_id"`
+	PreviousOwnerRole topology.Role `json:"previous_owner_role,omitempty"`
+}
+
+// ListCollaborators 列出拓樸的共用對象
+// @Summary 列出共用對象
+// @Description 列出拓樸共用的用戶與角色（依角色高到低），需要 viewer 以上權限
+// @Tags sharing
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Success 200 {array} topology.Collaborator
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/collaborators [get]
+func (h *TopologyHandler) ListCollaborators(c *gin.Context) {
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	collaborators, err := h.repo.ListCollaborators(topo.ID)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.fillCollaboratorEmails(collaborators)
+
+	c.JSON(http.StatusOK, collaborators)
+}
+
+// ShareTopology 以 email 共用拓樸給用戶
+// @Summary 共用拓樸
+// @Description 授予指定 email 的用戶 viewer、editor 或 owner 角色；已共用時更新角色。需要 owner 權限
+// @Tags sharing
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param request body ShareTopologyRequest true "用戶 email 與角色"
+// @Success 200 {object} topology.Collaborator
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/collaborators [put]
+func (h *TopologyHandler) ShareTopology(c *gin.Context) {
+	var req ShareTopologyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if h.userService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSharingUnavailable.Error()})
+		return
+	}
+
+	topo, _, ok := authorizeTopology(c, h.repo, topology.RoleOwner)
+	if !ok {
+		return
+	}
+
+	target, err := h.userService.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if topo.UserID != nil && *topo.UserID == target.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user already owns this topology"})
+		return
+	}
+
+	collaborator := &topology.Collaborator{
+		TopologyID: topo.ID,
+		UserID:     target.ID,
+		Role:       topology.Role(req.Role),
+		GrantedBy:  auth.GetUserID(c),
+	}
+	if err := h.repo.SetCollaborator(collaborator); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.accessChanged(topo.ID)
+	collaborator.Email = target.Email
+
+	c.JSON(http.StatusOK, collaborator)
+}
+
+// RemoveCollaborator 取消共用
+// @Summary 取消共用
+// @Description 移除共用對象；owner 可移除任何人，共用對象可移除自己（離開共用）
+// @Tags sharing
+// @Param id path string true "拓樸 ID"
+// @Param userId path string true "用戶 ID"
+// @Success 204
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/collaborators/{userId} [delete]
+func (h *TopologyHandler) RemoveCollaborator(c *gin.Context) {
+	targetID := c.Param("userId")
+	userID := auth.GetUserID(c)
+
+	required := topology.RoleOwner
+	if userID != nil && *userID == targetID {
+		required = topology.RoleViewer
+	}
+	topo, _, ok := authorizeTopology(c, h.repo, required)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RemoveCollaborator(topo.ID, targetID); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.accessChanged(topo.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateShareLink 建立唯讀共用連結
+// @Summary 建立共用連結
+// @Description 建立唯讀（viewer）共用連結，可設定到期時間。token 只在此回應出現一次，之後以 X-Share-Token 標頭存取拓樸（不接受查詢參數，避免 token 留在存取記錄與 Referer 中）。需要 owner 權限
+// @Tags sharing
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param request body CreateShareLinkRequest false "到期設定"
+// @Success 201 {object} topology.ShareLink
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/topologies/{id}/share-links [post]
+func (h *TopologyHandler) CreateShareLink(c *gin.Context) {
+	var req CreateShareLinkRequest
+	// body 為可選
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.ExpiresInHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_hours must not be negative"})
+		return
+	}
+	if req.ExpiresInHours > 0 {
+		if expiresAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "specify either expires_at or expires_in_hours"})
+			return
+		}
+		at := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &at
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	topo, _, ok := authorizeTopology(c, h.repo, topology.RoleOwner)
+	if !ok {
+		return
+	}
+
+	link, err := topology.NewShareLink(topo.ID, auth.GetUserID(c), expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.repo.CreateShareLink(link); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// ListShareLinks 列出共用連結
+// @Summary 列出共用連結
+// @Description 列出拓樸的共用連結（含已撤銷與過期者，不含 token）。需要 owner 權限
+// @Tags sharing
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Success 200 {array} topology.ShareLink
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/share-links [get]
+func (h *TopologyHandler) ListShareLinks(c *gin.Context) {
+	topo, _, ok := authorizeTopology(c, h.repo, topology.RoleOwner)
+	if !ok {
+		return
+	}
+
+	links, err := h.repo.ListShareLinks(topo.ID)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink 撤銷共用連結
+// @Summary 撤銷共用連結
+// @Description 撤銷後連結立即失效。需要 owner 權限
+// @Tags sharing
+// @Param id path string true "拓樸 ID"
+// @Param linkId path string true "連結 ID"
+// @Success 204
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/share-links/{linkId} [delete]
+func (h *TopologyHandler) RevokeShareLink(c *gin.Context) {
+	topo, _, ok := authorizeTopology(c, h.repo, topology.RoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RevokeShareLink(topo.ID, c.Param("linkId")); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.accessChanged(topo.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// TransferOwnership 轉移拓樸擁有權
+// @Summary 轉移擁有權
+// @Description 將拓樸擁有權轉移給指定 email 的用戶（需在其拓樸配額內），原擁有者預設保留 editor 角色。
+// @Description 也可轉移給自己具備 engineer 以上角色的組織（需在組織共用配額內）。只有目前的擁有者可以轉移，組織擁有的拓樸不能再轉移
+// @Tags sharing
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param request body TransferOwnershipRequest true "新擁有者 email 或組織 ID"
+// @Success 200 {object} TransferOwnershipResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/topologies/{id}/transfer [post]
+func (h *TopologyHandler) TransferOwnership(c *gin.Context) {
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if h.userService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSharingUnavailable.Error()})
+		return
+	}
+
+	topo, _, ok := authorizeTopology(c, h.repo, topology.RoleOwner)
+	if !ok {
+		return
+	}
+	// 共用的 owner 角色可管理共用，但擁有權只能由擁有者本人轉移
+	userID := auth.GetUserID(c)
+	if topo.OrganizationID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": topology.ErrOrganizationOwned.Error()})
+		return
+	}
+	if topo.UserID == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": topology.ErrNotShareable.Error()})
+		return
+	}
+	if userID == nil || *userID != *topo.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the topology owner can transfer ownership"})
+		return
+	}
+
+	if req.OrganizationID != "" {
+		if req.Email != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "specify either email or organization_id"})
+			return
+		}
+		h.transferToOrganization(c, topo, req)
+		return
+	}
+
+	target, err := h.userService.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if target.ID == *topo.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user already owns this topology"})
+		return
+	}
+	if !checkTopologyQuota(c, h.userService, &target.ID, nil) {
+		return
+	}
+
+	previousOwnerRole := parsePreviousOwnerRole(req.PreviousOwnerRole, topology.RoleEditor)
+	if err := h.repo.TransferOwnership(topo.ID, target.ID, previousOwnerRole); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.accessChanged(topo.ID)
+
+	c.JSON(http.StatusOK, TransferOwnershipResponse{
+		TopologyID:        topo.ID,
+		OwnerID:           target.ID,
+		PreviousOwnerID:   *topo.UserID,
+		PreviousOwnerRole: previousOwnerRole,
+	})
+}
+
+// transferToOrganization 將個人拓樸轉移給組織（呼叫端已確認目前用戶是擁有者）
+func (h *TopologyHandler) transferToOrganization(c *gin.Context, topo *topology.Topology, req TransferOwnershipRequest) {
+	if !requireOrganizationRole(c, req.OrganizationID, organization.RoleEngineer) {
+		return
+	}
+	if !checkTopologyQuota(c, h.userService, nil, &req.OrganizationID) {
+		return
+	}
+
+	previousOwnerRole := parsePreviousOwnerRole(req.PreviousOwnerRole, topology.RoleNone)
+	if err := h.repo.TransferToOrganization(topo.ID, req.OrganizationID, previousOwnerRole); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.accessChanged(topo.ID)
+
+	c.JSON(http.StatusOK, TransferOwnershipResponse{
+		TopologyID:        topo.ID,
+		OrganizationID:    req.OrganizationID,
+		PreviousOwnerID:   *topo.UserID,
+		PreviousOwnerRole: previousOwnerRole,
+	})
+}
+
+// parsePreviousOwnerRole 解析原擁有者保留的角色，未指定時使用 defaultRole
+func parsePreviousOwnerRole(value string, defaultRole topology.Role) topology.Role {
+	switch value {
+	case "":
+		return defaultRole
+	case "none":
+		return topology.RoleNone
+	default:
+		return topology.Role(value)
+	}
+}
+
+// ListSharedWithMe 列出共用給目前用戶的拓樸
+// @Summary 共用給我的拓樸
+// @Description 列出其他用戶共用給目前用戶的拓樸摘要與角色（由新到舊），需要登入
+// @Tags sharing
+// @Produce json
+// @Success 200 {array} topology.SharedTopology
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/topologies/shared-with-me [get]
+func (h *TopologyHandler) ListSharedWithMe(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	shared, err := h.repo.ListSharedWith(*userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shared)
+}
+
+// fillCollaboratorEmails 填入共用對象的 email（沒有用戶服務或查無用戶時略過）
+func (h *TopologyHandler) fillCollaboratorEmails(collaborators []*topology.Collaborator) {
+	if h.userService == nil {
+		return
+	}
+	for _, collaborator := range collaborators {
+		if u, err := h.userService.GetUserByID(collaborator.UserID); err == nil {
+			collaborator.Email = u.Email
+		}
+	}
+}
+
+// shareErrorStatus 將共用錯誤對應到 HTTP 狀態碼
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, topology.ErrTopologyNotFound),
+		errors.Is(err, topology.ErrCollaboratorNotFound),
+		errors.Is(err, topology.ErrShareLinkNotFound),
+		errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, topology.ErrNotShareable), errors.Is(err, topology.ErrOrganizationOwned):
+		return http.StatusConflict
+	case errors.Is(err, topology.ErrInvalidRole):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
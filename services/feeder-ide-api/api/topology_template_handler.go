@@ -0,0 +1,405 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/feeder-platform/feeder-ide-api/internal/templates"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/gin-gonic/gin"
+)
+
+// CloneTopologyRequest 複製拓樸的請求，未指定的欄位沿用來源拓樸
+type CloneTopologyRequest struct {
+	Name        string  `json:"name,omitempty"` // 預設為「來源名稱 (copy)」
+	Description *string `json:"description,omitempty"`
+	ProfileType string  `json:"profile_type,omitempty" binding:"omitempty,oneof=rural suburban urban"` // 複製到其他場景類型
+}
+
+// SaveTemplateRequest 將拓樸發佈為範本的請求
+type SaveTemplateRequest struct {
+	Name        string `json:"name,omitempty"` // 預設使用拓樸名稱
+	Description string `json:"description,omitempty"`
+	ProfileType string `json:"profile_type,omitempty" binding:"omitempty,oneof=rural suburban urban"` // 預設使用拓樸的場景類型
+	// Parameters 範本參數；未指定時由拓樸推導饋線電壓參數，指定空陣列則不建立參數
+	Parameters []templates.Parameter `json:"parameters"`
+	// OrganizationID 發佈為組織共用的範本（需要組織的 engineer 以上角色）
+	OrganizationID *string `json:"organization_id,omitempty"`
+}
+
+// UpdateTemplateRequest 更新範本的請求（節點與線路不可變更）
+type UpdateTemplateRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description,omitempty"`
+	ProfileType string                `json:"profile_type" binding:"required,oneof=rural suburban urban"`
+	Parameters  []templates.Parameter `json:"parameters"`
+}
+
+// InstantiateTemplateRequest 以範本建立拓樸的請求
+type InstantiateTemplateRequest struct {
+	Name        string             `json:"name,omitempty"` // 預設使用範本名稱
+	Description *string            `json:"description,omitempty"`
+	ProfileType string             `json:"profile_type,omitempty" binding:"omitempty,oneof=rural suburban urban"` // 預設使用範本的場景類型
+	Parameters  map[string]float64 `json:"parameters,omitempty"`                                                  // 參數值，未指定的參數使用預設值
+}
+
+// CloneTopology 複製拓樸
+// @Summary 複製拓樸
+// @Description 深拷貝拓樸為目前用戶擁有的新拓樸：節點與線路使用新的 ID（線路端點一併對應），CIM mRID 移除，
+// @Description 可指定 profile_type 複製到其他場景類型。需要來源拓樸的 viewer 以上權限，計入拓樸配額；
+// @Description 以 /organizations/{orgId}/topologies/{id}/clone 複製時由組織擁有（需要 engineer 以上角色）
+// @Tags topologies
+// @Accept json
+// @Produce json
+// @Param id path string true "來源拓樸 ID"
+// @Param request body CloneTopologyRequest false "複製選項"
+// @Success 201 {object} topology.Topology
+// @Header 201 {string} ETag "拓樸版本"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/v1/topologies/{id}/clone [post]
+// @Router /api/v1/organizations/{orgId}/topologies/{id}/clone [post]
+func (h *TopologyHandler) CloneTopology(c *gin.Context) {
+	var req CloneTopologyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	source, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	topo := source.Duplicate()
+	topo.Name = source.Name + " (copy)"
+	if req.Name != "" {
+		topo.Name = req.Name
+	}
+	if req.Description != nil {
+		topo.Description = *req.Description
+	}
+	if req.ProfileType != "" {
+		topo.ProfileType = req.ProfileType
+	}
+
+	h.createOwnedTopology(c, topo)
+}
+
+// SaveAsTemplate 將拓樸發佈為範本
+// @Summary 發佈為範本
+// @Description 以拓樸目前的節點與線路建立範本。未指定參數時由電源節點推導 feeder_voltage_kv 參數；
+// @Description 自訂參數以 targets 指定代入的元素屬性（element、node_type、element_ids、property）。
+// @Description 範本僅發佈者可見，指定 organization_id 時由組織成員共用；未啟用認證時為全域範本。需要拓樸的 viewer 以上權限
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param id path string true "拓樸 ID"
+// @Param request body SaveTemplateRequest false "範本資料"
+// @Success 201 {object} templates.Template
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/topologies/{id}/template [post]
+func (h *TopologyHandler) SaveAsTemplate(c *gin.Context) {
+	var req SaveTemplateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	topo, ok := loadTopology(c, h.repo)
+	if !ok {
+		return
+	}
+
+	content := topo.Clone()
+	tmpl := &templates.Template{
+		Name:             topo.Name,
+		Description:      req.Description,
+		ProfileType:      topo.ProfileType,
+		Parameters:       req.Parameters,
+		Scope:            templates.ScopeGlobal,
+		SourceTopologyID: &topo.ID,
+		Nodes:            content.Nodes,
+		Lines:            content.Lines,
+	}
+	if req.Name != "" {
+		tmpl.Name = req.Name
+	}
+	if req.ProfileType != "" {
+		tmpl.ProfileType = req.ProfileType
+	}
+	if req.Parameters == nil {
+		tmpl.Parameters = templates.InferParameters(topo)
+	}
+	if userID := auth.GetUserID(c); userID != nil {
+		tmpl.Scope = templates.ScopeUser
+		tmpl.OwnerID = userID
+	}
+	if req.OrganizationID != nil {
+		if !requireOrganizationRole(c, *req.OrganizationID, organization.RoleEngineer) {
+			return
+		}
+		tmpl.Scope = templates.ScopeOrganization
+		tmpl.OwnerID = nil
+		tmpl.OrganizationID = req.OrganizationID
+	}
+	if err := tmpl.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.templates.Create(tmpl); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// ListTemplates 列出範本
+// @Summary 列出範本
+// @Description 列出目前用戶可見的範本（系統內建範本、自己發佈的範本、所屬組織的範本），不含節點與線路
+// @Tags templates
+// @Produce json
+// @Param profile_type query string false "profile 類型（rural, suburban, urban）"
+// @Param q query string false "搜尋名稱或說明"
+// @Success 200 {array} templates.Template
+// @Router /api/v1/templates [get]
+func (h *TopologyHandler) ListTemplates(c *gin.Context) {
+	filter := templates.Filter{
+		ProfileType: c.Query("profile_type"),
+		Query:       c.Query("q"),
+		Visibility:  templateVisibility(c),
+	}
+	list, err := h.templates.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetTemplate 取得範本
+// @Summary 取得範本
+// @Description 取得範本與其節點、線路（內建範本為預設參數產生的結果）
+// @Tags templates
+// @Produce json
+// @Param templateId path string true "範本 ID"
+// @Success 200 {object} templates.Template
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/templates/{templateId} [get]
+func (h *TopologyHandler) GetTemplate(c *gin.Context) {
+	tmpl, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// UpdateTemplate 更新範本
+// @Summary 更新範本
+// @Description 更新名稱、說明、場景類型與參數；節點與線路不可變更（請重新發佈），內建範本不可修改
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param templateId path string true "範本 ID"
+// @Param request body UpdateTemplateRequest true "範本資料"
+// @Success 200 {object} templates.Template
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/templates/{templateId} [put]
+func (h *TopologyHandler) UpdateTemplate(c *gin.Context) {
+	var req UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tmpl, ok := h.loadEditableTemplate(c)
+	if !ok {
+		return
+	}
+
+	tmpl.Name = req.Name
+	tmpl.Description = req.Description
+	tmpl.ProfileType = req.ProfileType
+	tmpl.Parameters = req.Parameters
+	if err := tmpl.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.templates.Update(tmpl); err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// DeleteTemplate 刪除範本
+// @Summary 刪除範本
+// @Description 刪除範本；已由範本建立的拓樸不受影響
+// @Tags templates
+// @Param templateId path string true "範本 ID"
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/templates/{templateId} [delete]
+func (h *TopologyHandler) DeleteTemplate(c *gin.Context) {
+	tmpl, ok := h.loadEditableTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templates.Delete(tmpl.ID); err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// InstantiateTemplate 以範本建立拓樸
+// @Summary 以範本建立拓樸
+// @Description 代入參數值（未指定的使用預設值）產生新拓樸（用戶範本的節點與線路使用新的 ID）。內建範本可調整饋線電壓、
+// @Description 分歧線數、每條分歧線的配電變壓器數、區段長度與負載；用戶範本代入參數的 targets。
+// @Description 可指定 profile_type 建立其他場景類型的拓樸，計入拓樸配額；
+// @Description 以 /organizations/{orgId}/templates/{templateId}/instantiate 建立時由組織擁有（需要 engineer 以上角色）
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param templateId path string true "範本 ID"
+// @Param request body InstantiateTemplateRequest false "參數值與拓樸資料"
+// @Param validate_only query bool false "僅驗證，不儲存"
+// @Success 201 {object} topology.Topology
+// @Header 201 {string} ETag "拓樸版本"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/v1/templates/{templateId}/instantiate [post]
+// @Router /api/v1/organizations/{orgId}/templates/{templateId}/instantiate [post]
+func (h *TopologyHandler) InstantiateTemplate(c *gin.Context) {
+	var req InstantiateTemplateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tmpl, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	topo, err := tmpl.Instantiate(req.Parameters)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != "" {
+		topo.Name = req.Name
+	}
+	if req.Description != nil {
+		topo.Description = *req.Description
+	}
+	if req.ProfileType != "" {
+		topo.ProfileType = req.ProfileType
+	}
+
+	h.createOwnedTopology(c, topo)
+}
+
+// createOwnedTopology 以目前用戶（或組織路由的組織）為擁有者建立複製或由範本產生的拓樸：
+// 檢查配額與結構後儲存，回應 201 與 ETag
+func (h *TopologyHandler) createOwnedTopology(c *gin.Context, topo *topology.Topology) {
+	userID, organizationID, ok := topologyOwner(c)
+	if !ok {
+		return
+	}
+	if !checkTopologyQuota(c, h.userService, userID, organizationID) {
+		return
+	}
+
+	topo.UserID = userID
+	topo.OrganizationID = organizationID
+	topo.CreatedAt = time.Now()
+	topo.UpdatedAt = time.Now()
+
+	if !h.validateTopology(c, topo) {
+		return
+	}
+
+	if err := h.repo.Create(topo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, topo)
+	c.JSON(http.StatusCreated, topo)
+}
+
+// loadTemplate 取得目前用戶可見的範本，失敗時直接回應錯誤並回傳 false
+func (h *TopologyHandler) loadTemplate(c *gin.Context) (*templates.Template, bool) {
+	tmpl, err := h.templates.GetByID(c.Param("templateId"))
+	if err == nil && !tmpl.VisibleTo(templateVisibility(c)) {
+		err = templates.ErrTemplateNotFound
+	}
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return tmpl, true
+}
+
+// loadEditableTemplate 取得目前用戶可修改的範本，失敗時直接回應錯誤並回傳 false
+func (h *TopologyHandler) loadEditableTemplate(c *gin.Context) (*templates.Template, bool) {
+	tmpl, ok := h.loadTemplate(c)
+	if !ok {
+		return nil, false
+	}
+	if !tmpl.EditableBy(templateVisibility(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": templates.ErrReadOnly.Error()})
+		return nil, false
+	}
+	if tmpl.OrganizationID != nil && !requireOrganizationRole(c, *tmpl.OrganizationID, organization.RoleEngineer) {
+		return nil, false
+	}
+	return tmpl, true
+}
+
+// templateVisibility 目前用戶的範本可見範圍（與設備型錄相同，含所屬組織的範本）
+func templateVisibility(c *gin.Context) templates.Visibility {
+	visibility := catalogVisibility(c)
+	return templates.Visibility{UserID: visibility.UserID, OrganizationIDs: visibility.OrganizationIDs}
+}
+
+// templateErrorStatus 將範本錯誤對應到 HTTP 狀態碼
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, templates.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, templates.ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, templates.ErrInvalidTemplate), errors.Is(err, templates.ErrInvalidParameters):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,12 +3,20 @@ package main
 import (
 	"log"
 	"os"
+	"runtime"
+	"strconv"
 
 	"github.com/feeder-platform/feeder-ide-api/api"
 	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/catalog"
+	"github.com/feeder-platform/feeder-ide-api/internal/collab"
 	"github.com/feeder-platform/feeder-ide-api/internal/database"
+	"github.com/feeder-platform/feeder-ide-api/internal/flisr"
+	"github.com/feeder-platform/feeder-ide-api/internal/job"
 	"github.com/feeder-platform/feeder-ide-api/internal/middleware"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
 	"github.com/feeder-platform/feeder-ide-api/internal/payment"
+	"github.com/feeder-platform/feeder-ide-api/internal/templates"
 	"github.com/feeder-platform/feeder-ide-api/internal/topology"
 	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
 	"github.com/feeder-platform/feeder-ide-api/internal/user"
@@ -18,6 +26,10 @@ import (
 func main() {
 	// 初始化資料庫連接
 	var topologyRepo topology.Repository
+	var jobRepo job.Repository
+	var catalogRepo catalog.Repository
+	var operationRepo collab.Repository
+	var templateRepo templates.Repository
 	var err error
 
 	// 檢查是否有 DATABASE_URL，如果有則使用 PostgreSQL，否則使用記憶體模式
@@ -33,10 +45,30 @@ func main() {
 		if err != nil {
 			log.Fatalf("Failed to create postgres repository: %v", err)
 		}
+		jobRepo, err = job.NewPostgresRepository()
+		if err != nil {
+			log.Fatalf("Failed to create job repository: %v", err)
+		}
+		catalogRepo, err = catalog.NewPostgresRepository()
+		if err != nil {
+			log.Fatalf("Failed to create catalog repository: %v", err)
+		}
+		operationRepo, err = collab.NewPostgresRepository()
+		if err != nil {
+			log.Fatalf("Failed to create collaboration operation repository: %v", err)
+		}
+		templateRepo, err = templates.NewPostgresRepository()
+		if err != nil {
+			log.Fatalf("Failed to create template repository: %v", err)
+		}
 		log.Println("Using PostgreSQL database")
 	} else {
 		// 使用記憶體模式（開發/測試用）
 		topologyRepo = topology.NewInMemoryRepository()
+		jobRepo = job.NewInMemoryRepository()
+		catalogRepo = catalog.NewInMemoryRepository()
+		operationRepo = collab.NewInMemoryRepository()
+		templateRepo = templates.NewInMemoryRepository()
 		log.Println("Using in-memory database (development mode)")
 	}
 
@@ -47,6 +79,8 @@ func main() {
 	var userService *user.Service
 	var authHandler *api.AuthHandler
 	var paymentHandler *api.PaymentHandler
+	var orgService *organization.Service
+	var organizationHandler *api.OrganizationHandler
 	var oauthConfig *auth.OAuthConfig
 
 	if databaseURL != "" {
@@ -61,6 +95,15 @@ func main() {
 		// 設置拓樸計數器（用於檢查配額）
 		userService.SetTopologyCounter(topologyRepo)
 
+		// 初始化組織服務，組織擁有的拓樸與模擬使用組織共用配額
+		orgRepo, err := organization.NewPostgresRepository()
+		if err != nil {
+			log.Fatalf("Failed to create organization repository: %v", err)
+		}
+		orgService = organization.NewService(orgRepo, topologyRepo)
+		userService.SetOrganizationQuota(orgService)
+		organizationHandler = api.NewOrganizationHandler(orgService, userService)
+
 		// 初始化 OAuth 配置
 		oauthConfig = auth.NewOAuthConfig()
 
@@ -74,14 +117,48 @@ func main() {
 		paymentHandler = api.NewPaymentHandler(stripeService, paypalService, webhookHandler, userRepo, userService)
 	}
 
+	// 初始化非同步工作佇列，模擬配額於工作開始執行時扣除
+	jobManager := job.NewManager(jobRepo, envInt("JOB_WORKERS", runtime.NumCPU()), envInt("JOB_QUEUE_SIZE", 100))
+	if userService != nil {
+		jobManager.SetStartHook(func(j *job.Job) error {
+			if j.UserID == nil {
+				return nil
+			}
+			return userService.ChargeSimulation(j.UserID, j.TopologyID)
+		})
+	}
+	if err := jobManager.Start(); err != nil {
+		log.Fatalf("Failed to start job manager: %v", err)
+	}
+
+	// 分析與模擬讀取拓樸時以設備型錄補齊元素屬性
+	analysisRepo := catalog.NewResolvingRepository(topologyRepo, catalogRepo)
+
 	// 初始化 handlers
 	var topologyHandler *api.TopologyHandler
 	if userService != nil {
-		topologyHandler = api.NewTopologyHandler(topologyRepo, userService)
+		topologyHandler = api.NewTopologyHandler(topologyRepo, userService, catalogRepo, templateRepo)
 	} else {
-		topologyHandler = api.NewTopologyHandler(topologyRepo, nil)
+		topologyHandler = api.NewTopologyHandler(topologyRepo, nil, catalogRepo, templateRepo)
 	}
 	profileHandler := api.NewProfileHandler(profileRepo)
+	powerflowHandler := api.NewPowerflowHandler(analysisRepo, userService)
+	generatorHandler := api.NewGeneratorHandler(profileRepo, topologyRepo, userService)
+	reliabilityHandler := api.NewReliabilityHandler(analysisRepo, profileRepo, userService)
+	shortCircuitHandler := api.NewShortCircuitHandler(analysisRepo, userService)
+	protectionHandler := api.NewProtectionHandler(analysisRepo, userService)
+	hostingCapacityHandler := api.NewHostingCapacityHandler(analysisRepo, userService, jobManager)
+	qstsHandler := api.NewQSTSHandler(analysisRepo, profileRepo, jobManager)
+	contingencyHandler := api.NewContingencyHandler(analysisRepo, userService, jobManager)
+	flisrHandler := api.NewFLISRHandler(analysisRepo, userService, flisr.NewCommandPublisher())
+	jobHandler := api.NewJobHandler(analysisRepo, profileRepo, jobManager)
+	graphHandler := api.NewGraphHandler(topologyRepo)
+	collaborationHandler := api.NewCollaborationHandler(topologyRepo, operationRepo, catalogRepo, orgService)
+	topologyHandler.SetAccessChangeHook(collaborationHandler.Revalidate)
+	if organizationHandler != nil {
+		organizationHandler.SetMembershipChangeHook(collaborationHandler.RevalidateAll)
+	}
+	catalogHandler := api.NewCatalogHandler(catalogRepo)
 
 	// 設定 Gin router
 	router := gin.Default()
@@ -95,8 +172,9 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, If-Match, X-Share-Token")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, X-Topology-Role")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -123,19 +201,113 @@ func main() {
 		// Topology endpoints (使用可選認證中間件和配額檢查)
 		if authHandler != nil && userService != nil {
 			v1.Use(auth.OptionalAuthMiddleware())
+			v1.Use(middleware.OrganizationMiddleware(orgService))
 			// 為創建拓樸添加配額檢查
 			v1.POST("/topologies", middleware.QuotaMiddleware("topology", userService), topologyHandler.CreateTopology)
+			v1.POST("/topologies/import", middleware.QuotaMiddleware("topology", userService), topologyHandler.ImportTopology)
+			v1.POST("/topologies/:id/clone", middleware.QuotaMiddleware("topology", userService), topologyHandler.CloneTopology)
+			v1.POST("/templates/:templateId/instantiate", middleware.QuotaMiddleware("topology", userService), topologyHandler.InstantiateTemplate)
+			// 分析端點需檢查每日模擬配額
+			v1.POST("/topologies/:id/powerflow", middleware.QuotaMiddleware("simulation", userService), powerflowHandler.RunPowerflow)
+			v1.POST("/topologies/:id/reliability", middleware.QuotaMiddleware("simulation", userService), reliabilityHandler.RunReliability)
+			v1.POST("/topologies/:id/short-circuit", middleware.QuotaMiddleware("simulation", userService), shortCircuitHandler.RunShortCircuit)
+			v1.POST("/topologies/:id/protection-coordination", middleware.QuotaMiddleware("simulation", userService), protectionHandler.RunCoordination)
+			v1.POST("/topologies/:id/hosting-capacity", middleware.QuotaMiddleware("simulation", userService), hostingCapacityHandler.RunHostingCapacity)
+			v1.POST("/topologies/:id/qsts", middleware.QuotaMiddleware("simulation", userService), qstsHandler.RunQSTS)
+			v1.POST("/topologies/:id/contingency", middleware.QuotaMiddleware("simulation", userService), contingencyHandler.RunContingency)
+			v1.POST("/topologies/:id/reconfiguration", middleware.QuotaMiddleware("simulation", userService), contingencyHandler.RunReconfiguration)
+			v1.POST("/topologies/:id/flisr", middleware.QuotaMiddleware("simulation", userService), flisrHandler.RunFLISR)
+			v1.POST("/topologies/:id/jobs", middleware.QuotaMiddleware("simulation", userService), jobHandler.SubmitJob)
+			// 建立或修改型錄項目需登入
+			v1.POST("/catalog", auth.AuthMiddleware(), catalogHandler.CreateEntry)
+			v1.PUT("/catalog/:entryId", auth.AuthMiddleware(), catalogHandler.UpdateEntry)
+			v1.DELETE("/catalog/:entryId", auth.AuthMiddleware(), catalogHandler.DeleteEntry)
+			// 發佈或修改範本需登入
+			v1.POST("/topologies/:id/template", auth.AuthMiddleware(), topologyHandler.SaveAsTemplate)
+			v1.PUT("/templates/:templateId", auth.AuthMiddleware(), topologyHandler.UpdateTemplate)
+			v1.DELETE("/templates/:templateId", auth.AuthMiddleware(), topologyHandler.DeleteTemplate)
 		} else {
 			v1.POST("/topologies", topologyHandler.CreateTopology)
+			v1.POST("/topologies/import", topologyHandler.ImportTopology)
+			v1.POST("/topologies/:id/clone", topologyHandler.CloneTopology)
+			v1.POST("/templates/:templateId/instantiate", topologyHandler.InstantiateTemplate)
+			v1.POST("/topologies/:id/powerflow", powerflowHandler.RunPowerflow)
+			v1.POST("/topologies/:id/reliability", reliabilityHandler.RunReliability)
+			v1.POST("/topologies/:id/short-circuit", shortCircuitHandler.RunShortCircuit)
+			v1.POST("/topologies/:id/protection-coordination", protectionHandler.RunCoordination)
+			v1.POST("/topologies/:id/hosting-capacity", hostingCapacityHandler.RunHostingCapacity)
+			v1.POST("/topologies/:id/qsts", qstsHandler.RunQSTS)
+			v1.POST("/topologies/:id/contingency", contingencyHandler.RunContingency)
+			v1.POST("/topologies/:id/reconfiguration", contingencyHandler.RunReconfiguration)
+			v1.POST("/topologies/:id/flisr", flisrHandler.RunFLISR)
+			v1.POST("/topologies/:id/jobs", jobHandler.SubmitJob)
+			v1.POST("/catalog", catalogHandler.CreateEntry)
+			v1.PUT("/catalog/:entryId", catalogHandler.UpdateEntry)
+			v1.DELETE("/catalog/:entryId", catalogHandler.DeleteEntry)
+			v1.POST("/topologies/:id/template", topologyHandler.SaveAsTemplate)
+			v1.PUT("/templates/:templateId", topologyHandler.UpdateTemplate)
+			v1.DELETE("/templates/:templateId", topologyHandler.DeleteTemplate)
 		}
 		v1.GET("/topologies/:id", topologyHandler.GetTopology)
 		v1.PUT("/topologies/:id", topologyHandler.UpdateTopology)
 		v1.DELETE("/topologies/:id", topologyHandler.DeleteTopology)
 		v1.GET("/topologies", topologyHandler.ListTopologies)
+		v1.GET("/topologies/:id/export", topologyHandler.ExportTopology)
+
+		// 共用與權限
+		v1.GET("/topologies/shared-with-me", topologyHandler.ListSharedWithMe)
+		v1.GET("/topologies/:id/collaborators", topologyHandler.ListCollaborators)
+		v1.PUT("/topologies/:id/collaborators", topologyHandler.ShareTopology)
+		v1.DELETE("/topologies/:id/collaborators/:userId", topologyHandler.RemoveCollaborator)
+		v1.GET("/topologies/:id/share-links", topologyHandler.ListShareLinks)
+		v1.POST("/topologies/:id/share-links", topologyHandler.CreateShareLink)
+		v1.DELETE("/topologies/:id/share-links/:linkId", topologyHandler.RevokeShareLink)
+		v1.POST("/topologies/:id/transfer", topologyHandler.TransferOwnership)
+
+		// 增量編輯（JSON Patch 與元素層級）
+		v1.PATCH("/topologies/:id", topologyHandler.PatchTopology)
+		v1.POST("/topologies/:id/nodes", topologyHandler.AddNode)
+		v1.PUT("/topologies/:id/nodes/:nodeId", topologyHandler.UpdateNode)
+		v1.DELETE("/topologies/:id/nodes/:nodeId", topologyHandler.RemoveNode)
+		v1.POST("/topologies/:id/lines", topologyHandler.AddLine)
+		v1.PUT("/topologies/:id/lines/:lineId", topologyHandler.UpdateLine)
+		v1.DELETE("/topologies/:id/lines/:lineId", topologyHandler.RemoveLine)
+		v1.POST("/topologies/:id/layout", topologyHandler.LayoutTopology)
+
+		// 圖形查詢
+		v1.GET("/topologies/:id/trace", graphHandler.Trace)
+		v1.GET("/topologies/:id/path", graphHandler.ShortestPath)
+		v1.GET("/topologies/:id/islands", graphHandler.Islands)
+		v1.GET("/topologies/:id/loops", graphHandler.Loops)
+		v1.GET("/topologies/:id/levels", graphHandler.Levels)
+		// 即時協作編輯（WebSocket）
+		v1.POST("/topologies/:id/collaborate/ticket", collaborationHandler.IssueCollaborationTicket)
+		v1.GET("/topologies/:id/collaborate", collaborationHandler.Collaborate)
+
+		// 版本歷史
+		v1.GET("/topologies/:id/revisions", topologyHandler.ListRevisions)
+		v1.GET("/topologies/:id/revisions/diff", topologyHandler.DiffRevisions)
+		v1.GET("/topologies/:id/revisions/:revision", topologyHandler.GetRevision)
+		v1.POST("/topologies/:id/revisions/:revision/restore", topologyHandler.RestoreRevision)
+
+		// 非同步模擬工作
+		v1.GET("/jobs", jobHandler.ListJobs)
+		v1.GET("/jobs/:jobId", jobHandler.GetJob)
+		v1.POST("/jobs/:jobId/cancel", jobHandler.CancelJob)
+		v1.GET("/jobs/:jobId/events", jobHandler.StreamJobEvents)
+
+		// 設備型錄
+		v1.GET("/catalog", catalogHandler.ListEntries)
+		v1.GET("/catalog/:entryId", catalogHandler.GetEntry)
+
+		// 拓樸範本
+		v1.GET("/templates", topologyHandler.ListTemplates)
+		v1.GET("/templates/:templateId", topologyHandler.GetTemplate)
 
 		// Profile endpoints
 		v1.GET("/profiles", profileHandler.ListProfiles)
 		v1.GET("/profiles/:type", profileHandler.GetProfile)
+		v1.POST("/profiles/:type/generate", generatorHandler.GenerateFeeder)
 
 		// Payment endpoints (僅在資料庫模式下可用)
 		if paymentHandler != nil {
@@ -150,6 +322,28 @@ func main() {
 			v1.POST("/payments/webhook/stripe", paymentHandler.HandleStripeWebhook)
 			v1.POST("/payments/webhook/paypal", paymentHandler.HandlePayPalWebhook)
 		}
+
+		// Organization endpoints (僅在資料庫模式下可用)
+		if organizationHandler != nil {
+			organizations := v1.Group("/organizations")
+			organizations.Use(auth.AuthMiddleware())
+			{
+				organizations.POST("", organizationHandler.CreateOrganization)
+				organizations.GET("", organizationHandler.ListOrganizations)
+				organizations.GET("/:orgId", organizationHandler.GetOrganization)
+				organizations.PUT("/:orgId", organizationHandler.UpdateOrganization)
+				organizations.DELETE("/:orgId", organizationHandler.DeleteOrganization)
+				organizations.GET("/:orgId/members", organizationHandler.ListMembers)
+				organizations.PUT("/:orgId/members", organizationHandler.SetMember)
+				organizations.DELETE("/:orgId/members/:userId", organizationHandler.RemoveMember)
+				// 組織擁有的拓樸，配額由 handler 依組織共用配額檢查
+				organizations.GET("/:orgId/topologies", topologyHandler.ListOrganizationTopologies)
+				organizations.POST("/:orgId/topologies", topologyHandler.CreateTopology)
+				organizations.POST("/:orgId/topologies/import", topologyHandler.ImportTopology)
+				organizations.POST("/:orgId/topologies/:id/clone", topologyHandler.CloneTopology)
+				organizations.POST("/:orgId/templates/:templateId/instantiate", topologyHandler.InstantiateTemplate)
+			}
+		}
 	}
 
 	// 啟動 server
@@ -166,3 +360,10 @@ func main() {
 	}
 }
 
+// envInt 讀取整數環境變數，未設置或格式錯誤時使用預設值
+func envInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
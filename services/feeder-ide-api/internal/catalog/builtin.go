@@ -0,0 +1,164 @@
+package catalog
+
+import (
+	"fmt"
+	"time"
+)
+
+// builtinPrefix 內建項目 ID 的前綴
+const builtinPrefix = "std-"
+
+// builtinCreatedAt 內建項目的建立時間（固定值，讓回應內容穩定）
+var builtinCreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// builtinEntries 常見的配電設備標準規格，數值為典型值
+var builtinEntries = []*Entry{
+	// 架空線（12.47 kV 三相，正序阻抗）
+	lineCode("std-oh-acsr-1-0", "ACSR 1/0 Raven", "ASTM B232", 0.557, 0.440, 242),
+	lineCode("std-oh-acsr-4-0", "ACSR 4/0 Penguin", "ASTM B232", 0.278, 0.415, 357),
+	lineCode("std-oh-acsr-336", "ACSR 336.4 kcmil Linnet", "ASTM B232", 0.306, 0.627, 400),
+	lineCode("std-oh-acsr-556", "ACSR 556.5 kcmil Dove", "ASTM B232", 0.105, 0.380, 730),
+	lineCode("std-oh-aac-795", "AAC 795 kcmil Arbutus", "ASTM B231", 0.074, 0.370, 900),
+	// 地下電纜（15 kV XLPE）
+	lineCode("std-ug-al-1-0", "15 kV XLPE 1/0 AWG Al", "ICEA S-94-649", 0.641, 0.135, 200),
+	lineCode("std-ug-al-500", "15 kV XLPE 500 kcmil Al", "ICEA S-94-649", 0.134, 0.110, 430),
+	lineCode("std-ug-cu-1000", "15 kV XLPE 1000 kcmil Cu", "ICEA S-94-649", 0.046, 0.105, 700),
+
+	// 配電變壓器（12.47 kV 一次側）
+	transformer("std-xfmr-1ph-25", "25 kVA pole-mount 1φ", 25, 12.47, 0.24, 2.0, 1.5, ""),
+	transformer("std-xfmr-1ph-50", "50 kVA pole-mount 1φ", 50, 12.47, 0.24, 2.2, 2.0, ""),
+	transformer("std-xfmr-1ph-75", "75 kVA pad-mount 1φ", 75, 12.47, 0.24, 2.4, 2.2, ""),
+	transformer("std-xfmr-3ph-150", "150 kVA pad-mount 3φ", 150, 12.47, 0.48, 3.0, 2.5, "dyn"),
+	transformer("std-xfmr-3ph-300", "300 kVA pad-mount 3φ", 300, 12.47, 0.48, 4.5, 3.5, "dyn"),
+	transformer("std-xfmr-3ph-500", "500 kVA pad-mount 3φ", 500, 12.47, 0.48, 5.0, 4.5, "dyn"),
+	transformer("std-xfmr-3ph-1000", "1000 kVA pad-mount 3φ", 1000, 12.47, 0.48, 5.75, 5.5, "dyn"),
+	// 變電所主變壓器
+	transformer("std-sub-10mva", "10 MVA substation 69/12.47 kV", 10000, 69, 12.47, 7.5, 15, "dyn"),
+	transformer("std-sub-20mva", "20 MVA substation 69/12.47 kV", 20000, 69, 12.47, 8.0, 20, "dyn"),
+
+	// 開關設備
+	switchGear("std-sw-breaker-15kv", "15 kV vacuum circuit breaker", "IEEE C37.04", map[string]interface{}{
+		"type": "breaker", "interrupting_rating_ka": 25.0, "is_automated": true,
+	}),
+	switchGear("std-sw-recloser-15kv", "15 kV three-phase recloser", "IEEE C37.60", map[string]interface{}{
+		"type": "recloser", "interrupting_rating_ka": 12.5, "is_automated": true, "operations_to_lockout": 4.0,
+	}),
+	switchGear("std-sw-sectionalizer-15kv", "15 kV sectionalizer", "IEEE C37.63", map[string]interface{}{
+		"type": "sectionalizer", "counts_to_open": 3.0, "is_automated": false,
+	}),
+	switchGear("std-sw-fuse-65k", "65K expulsion fuse link", "IEEE C37.42", map[string]interface{}{
+		"type": "fuse", "interrupting_rating_ka": 10.0, "is_automated": false,
+		"protection": map[string]interface{}{"curve": "fuse", "fuse_rating_a": 65.0, "fuse_speed": "k"},
+	}),
+	switchGear("std-sw-fuse-100t", "100T expulsion fuse link", "IEEE C37.42", map[string]interface{}{
+		"type": "fuse", "interrupting_rating_ka": 10.0, "is_automated": false,
+		"protection": map[string]interface{}{"curve": "fuse", "fuse_rating_a": 100.0, "fuse_speed": "t"},
+	}),
+
+	// PV 變流器
+	generic("std-pv-7.6", TypePVInverter, "7.6 kW residential PV inverter", "IEEE 1547-2018", map[string]interface{}{
+		"rated_power_kw": 7.6,
+	}),
+	generic("std-pv-60", TypePVInverter, "60 kW commercial string inverter", "IEEE 1547-2018", map[string]interface{}{
+		"rated_power_kw": 60.0,
+	}),
+	generic("std-pv-250", TypePVInverter, "250 kW central inverter", "IEEE 1547-2018", map[string]interface{}{
+		"rated_power_kw": 250.0,
+	}),
+
+	// 電池儲能
+	generic("std-bess-5", TypeBattery, "5 kW / 13.5 kWh residential battery", "UL 9540", map[string]interface{}{
+		"rated_power_kw": 5.0, "capacity_kwh": 13.5,
+	}),
+	generic("std-bess-250", TypeBattery, "250 kW / 1000 kWh commercial battery", "UL 9540", map[string]interface{}{
+		"rated_power_kw": 250.0, "capacity_kwh": 1000.0,
+	}),
+
+	// EV 充電樁
+	generic("std-ev-l2-7.2", TypeEVCharger, "Level 2 7.2 kW (30 A)", "SAE J1772", map[string]interface{}{
+		"rated_power_kw": 7.2, "max_charging_rate": 7.2,
+	}),
+	generic("std-ev-l2-19.2", TypeEVCharger, "Level 2 19.2 kW (80 A)", "SAE J1772", map[string]interface{}{
+		"rated_power_kw": 19.2, "max_charging_rate": 19.2,
+	}),
+	generic("std-ev-dcfc-50", TypeEVCharger, "DC fast charger 50 kW", "IEC 61851-23", map[string]interface{}{
+		"rated_power_kw": 50.0, "max_charging_rate": 50.0,
+	}),
+	generic("std-ev-dcfc-150", TypeEVCharger, "DC fast charger 150 kW", "IEC 61851-23", map[string]interface{}{
+		"rated_power_kw": 150.0, "max_charging_rate": 150.0,
+	}),
+	generic("std-ev-dcfc-350", TypeEVCharger, "DC fast charger 350 kW", "IEC 61851-23", map[string]interface{}{
+		"rated_power_kw": 350.0, "max_charging_rate": 350.0,
+	}),
+}
+
+// builtinIndex 依 ID 索引內建項目
+var builtinIndex = func() map[string]*Entry {
+	index := make(map[string]*Entry, len(builtinEntries))
+	for _, entry := range builtinEntries {
+		if err := entry.Normalize(); err != nil {
+			panic(fmt.Sprintf("invalid builtin catalog entry %s: %v", entry.ID, err))
+		}
+		index[entry.ID] = entry
+	}
+	return index
+}()
+
+// Builtin 回傳內建項目的副本
+func Builtin() []*Entry {
+	entries := make([]*Entry, 0, len(builtinEntries))
+	for _, entry := range builtinEntries {
+		entries = append(entries, entry.Clone())
+	}
+	return entries
+}
+
+// builtinEntry 依 ID 取得內建項目的副本
+func builtinEntry(id string) (*Entry, bool) {
+	entry, ok := builtinIndex[id]
+	if !ok {
+		return nil, false
+	}
+	return entry.Clone(), true
+}
+
+func generic(id, entryType, name, standard string, props map[string]interface{}) *Entry {
+	return &Entry{
+		ID:         id,
+		Type:       entryType,
+		Name:       name,
+		Standard:   standard,
+		Properties: props,
+		Scope:      ScopeGlobal,
+		Builtin:    true,
+		CreatedAt:  builtinCreatedAt,
+		UpdatedAt:  builtinCreatedAt,
+	}
+}
+
+func lineCode(id, name, standard string, r, x, ampacity float64) *Entry {
+	return generic(id, TypeLineCode, name, standard, map[string]interface{}{
+		"r_ohm_per_km": r,
+		"x_ohm_per_km": x,
+		"ampacity_a":   ampacity,
+	})
+}
+
+func transformer(id, name string, kva, primaryKV, secondaryKV, zPercent, xr float64, connection string) *Entry {
+	props := map[string]interface{}{
+		"rated_capacity_kva": kva,
+		"rated_voltage_kv":   secondaryKV,
+		"primary_voltage":    primaryKV,
+		"secondary_voltage":  secondaryKV,
+		"impedance_percent":  zPercent,
+		"x_r_ratio":          xr,
+	}
+	if connection != "" {
+		props["connection"] = connection
+	}
+	return generic(id, TypeTransformer, name, "IEEE C57.12.00", props)
+}
+
+func switchGear(id, name, standard string, props map[string]interface{}) *Entry {
+	return generic(id, TypeSwitch, name, standard, props)
+}
@@ -0,0 +1,9 @@
+package catalog
+
+import "errors"
+
+var (
+	ErrEntryNotFound = errors.New("catalog entry not found")
+	ErrInvalidEntry  = errors.New("invalid catalog entry")
+	ErrReadOnly      = errors.New("catalog entry is read-only")
+)
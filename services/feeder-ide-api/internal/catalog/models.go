@@ -0,0 +1,217 @@
+// Package catalog 設備型錄：線路代碼、變壓器、開關、PV 變流器、電池與 EV 充電樁型號
+// 節點或線路以 properties.catalog_id 參照型錄項目，分析與匯出時以項目的屬性補齊元素未指定的屬性
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// PropertyKey 節點或線路參照型錄項目的屬性名稱
+const PropertyKey = "catalog_id"
+
+// 型錄項目類型
+const (
+	TypeLineCode    = "line_code"
+	TypeTransformer = "transformer"
+	TypeSwitch      = "switch"
+	TypePVInverter  = "pv_inverter"
+	TypeBattery     = "battery"
+	TypeEVCharger   = "ev_charger"
+)
+
+// 型錄項目範圍
+const (
+	ScopeGlobal       = "global"       // 所有用戶可見
+	ScopeUser         = "user"         // 僅建立者可見
+	ScopeOrganization = "organization" // 組織成員可見
+)
+
+// Entry 型錄項目
+type Entry struct {
+	ID             string                 `json:"id"`
+	Type           string                 `json:"type"`
+	Name           string                 `json:"name"`
+	Manufacturer   string                 `json:"manufacturer,omitempty"`
+	Standard       string                 `json:"standard,omitempty"` // 參考標準或規格（例如 IEEE C57.12.00）
+	Description    string                 `json:"description,omitempty"`
+	Properties     map[string]interface{} `json:"properties"` // 套用到元素的屬性，鍵名與節點 / 線路 properties 相同
+	Scope          string                 `json:"scope"`
+	OwnerID        *string                `json:"owner_id,omitempty"`
+	OrganizationID *string                `json:"organization_id,omitempty"`
+	Builtin        bool                   `json:"builtin"` // 內建標準項目，不可修改
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// Visibility 查詢者身分，用於判斷可見的項目
+type Visibility struct {
+	UserID          *string
+	OrganizationIDs []string
+}
+
+// Filter 列表條件
+type Filter struct {
+	Type       string
+	Query      string // 名稱、製造商或標準包含的文字（不分大小寫）
+	Visibility Visibility
+}
+
+// VisibleTo 判斷項目是否對查詢者可見
+func (e *Entry) VisibleTo(v Visibility) bool {
+	switch e.Scope {
+	case ScopeGlobal:
+		return true
+	case ScopeUser:
+		return e.OwnerID != nil && v.UserID != nil && *e.OwnerID == *v.UserID
+	case ScopeOrganization:
+		if e.OrganizationID == nil {
+			return false
+		}
+		for _, id := range v.OrganizationIDs {
+			if id == *e.OrganizationID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EditableBy 判斷查詢者是否可修改或刪除項目：內建項目不可修改；
+// 全域項目只能在未啟用認證時（匿名）修改，用戶項目限建立者，組織項目限組織成員
+func (e *Entry) EditableBy(v Visibility) bool {
+	if e.Builtin {
+		return false
+	}
+	if e.Scope == ScopeGlobal {
+		return v.UserID == nil
+	}
+	return e.VisibleTo(v)
+}
+
+// Clone 深拷貝項目
+func (e *Entry) Clone() *Entry {
+	copied := *e
+	copied.Properties = make(map[string]interface{}, len(e.Properties))
+	for key, value := range e.Properties {
+		copied.Properties[key] = value
+	}
+	return &copied
+}
+
+// typeSpec 各類型項目適用的元素與屬性規則
+type typeSpec struct {
+	element  string                 // 適用的節點類型，線路為 topology.ElementLine
+	required []string               // 必填的數值屬性
+	numeric  []string               // 其他非負數值屬性
+	fixed    map[string]interface{} // 自動設定的屬性
+}
+
+var specs = map[string]typeSpec{
+	TypeLineCode: {
+		element:  topology.ElementLine,
+		required: []string{"r_ohm_per_km", "x_ohm_per_km", "ampacity_a"},
+		numeric:  []string{"r0_ohm_per_km", "x0_ohm_per_km", "failure_rate_per_km", "repair_time_minutes"},
+	},
+	TypeTransformer: {
+		element:  topology.NodeTypeTransformer,
+		required: []string{"rated_capacity_kva"},
+		numeric:  []string{"rated_voltage_kv", "primary_voltage", "secondary_voltage", "impedance_percent", "x_r_ratio"},
+	},
+	TypeSwitch: {
+		element: topology.NodeTypeSwitch,
+		numeric: []string{"interrupting_rating_ka", "counts_to_open", "operations_to_lockout"},
+	},
+	TypePVInverter: {
+		element:  topology.NodeTypeDER,
+		required: []string{"rated_power_kw"},
+		fixed:    map[string]interface{}{"type": "pv"},
+	},
+	TypeBattery: {
+		element:  topology.NodeTypeDER,
+		required: []string{"rated_power_kw"},
+		numeric:  []string{"capacity_kwh"},
+		fixed:    map[string]interface{}{"type": "battery"},
+	},
+	TypeEVCharger: {
+		element:  topology.NodeTypeEVCharger,
+		required: []string{"rated_power_kw"},
+		numeric:  []string{"max_charging_rate"},
+	},
+}
+
+// 元素狀態或位置相關的屬性，不屬於設備型號
+var forbiddenKeys = []string{PropertyKey, "is_closed", "is_source", "length_km", "load_kw", "load_kvar", "customers", "output_kw"}
+
+var validSwitchTypes = map[string]bool{
+	"sectionalizer": true,
+	"recloser":      true,
+	"breaker":       true,
+	"fuse":          true,
+}
+
+// Types 回傳所有項目類型
+func Types() []string {
+	types := make([]string, 0, len(specs))
+	for t := range specs {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ElementType 取得項目類型適用的節點類型（線路為 topology.ElementLine）
+func ElementType(entryType string) string {
+	return specs[entryType].element
+}
+
+// Normalize 檢查項目內容並套用類型固定的屬性
+func (e *Entry) Normalize() error {
+	spec, ok := specs[e.Type]
+	if !ok {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidEntry, e.Type)
+	}
+	if e.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidEntry)
+	}
+	if e.Properties == nil {
+		e.Properties = map[string]interface{}{}
+	}
+
+	for _, key := range forbiddenKeys {
+		if _, exists := e.Properties[key]; exists {
+			return fmt.Errorf("%w: %s is an element property and cannot be set in the catalog", ErrInvalidEntry, key)
+		}
+	}
+	for _, key := range spec.required {
+		if _, exists := e.Properties[key]; !exists {
+			return fmt.Errorf("%w: %s is required for %s", ErrInvalidEntry, key, e.Type)
+		}
+	}
+	for _, key := range append(append([]string{}, spec.required...), spec.numeric...) {
+		value, exists := e.Properties[key]
+		if !exists {
+			continue
+		}
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%w: %s must be a number", ErrInvalidEntry, key)
+		}
+		if topology.FloatProperty(e.Properties, key, 0) < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidEntry, key)
+		}
+	}
+	if e.Type == TypeSwitch {
+		switchType := topology.StringProperty(e.Properties, "type", "")
+		if !validSwitchTypes[switchType] {
+			return fmt.Errorf("%w: switch type must be one of breaker, recloser, fuse, sectionalizer", ErrInvalidEntry)
+		}
+	}
+
+	for key, value := range spec.fixed {
+		e.Properties[key] = value
+	}
+	return nil
+}
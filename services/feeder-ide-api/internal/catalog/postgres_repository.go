@@ -0,0 +1,224 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresRepository PostgreSQL 實作
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository 建立新的 PostgreSQL repository
+func NewPostgresRepository() (*PostgresRepository, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return &PostgresRepository{
+		db: database.DB,
+	}, nil
+}
+
+const entryColumns = `id, type, name, manufacturer, standard, description, properties, scope, owner_id, organization_id, created_at, updated_at`
+
+func (r *PostgresRepository) Create(entry *Entry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	now := time.Now()
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+
+	props, err := json.Marshal(entry.Properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal properties: %w", err)
+	}
+
+	query := `INSERT INTO equipment_catalog (` + entryColumns + `)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err = r.db.Exec(query,
+		entry.ID,
+		entry.Type,
+		entry.Name,
+		entry.Manufacturer,
+		entry.Standard,
+		entry.Description,
+		props,
+		entry.Scope,
+		entry.OwnerID,
+		entry.OrganizationID,
+		entry.CreatedAt,
+		entry.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create catalog entry: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetByID(id string) (*Entry, error) {
+	if entry, ok := builtinEntry(id); ok {
+		return entry, nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrEntryNotFound
+	}
+
+	query := `SELECT ` + entryColumns + ` FROM equipment_catalog WHERE id = $1`
+	entry, err := scanEntry(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *PostgresRepository) Update(entry *Entry) error {
+	if _, ok := builtinIndex[entry.ID]; ok {
+		return ErrReadOnly
+	}
+	entry.UpdatedAt = time.Now()
+
+	props, err := json.Marshal(entry.Properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal properties: %w", err)
+	}
+
+	query := `
+		UPDATE equipment_catalog
+		SET name = $2, manufacturer = $3, standard = $4, description = $5, properties = $6, updated_at = $7
+		WHERE id = $1
+		RETURNING created_at
+	`
+	err = r.db.QueryRow(query,
+		entry.ID,
+		entry.Name,
+		entry.Manufacturer,
+		entry.Standard,
+		entry.Description,
+		props,
+		entry.UpdatedAt,
+	).Scan(&entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrEntryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update catalog entry: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Delete(id string) error {
+	if _, ok := builtinIndex[id]; ok {
+		return ErrReadOnly
+	}
+
+	res, err := r.db.Exec(`DELETE FROM equipment_catalog WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete catalog entry: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) List(filter Filter) ([]*Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM equipment_catalog WHERE (scope = 'global'`
+	args := []interface{}{}
+	if filter.Visibility.UserID != nil {
+		args = append(args, *filter.Visibility.UserID)
+		query += ` OR (scope = 'user' AND owner_id = $` + strconv.Itoa(len(args)) + `)`
+	}
+	if len(filter.Visibility.OrganizationIDs) > 0 {
+		args = append(args, pq.Array(filter.Visibility.OrganizationIDs))
+		query += ` OR (scope = 'organization' AND organization_id = ANY($` + strconv.Itoa(len(args)) + `::uuid[]))`
+	}
+	query += `)`
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		query += ` AND type = $` + strconv.Itoa(len(args))
+	}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		n := strconv.Itoa(len(args))
+		query += ` AND (name ILIKE $` + n + ` OR manufacturer ILIKE $` + n + ` OR standard ILIKE $` + n + `)`
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for _, entry := range Builtin() {
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan catalog entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate catalog entries: %w", err)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// rowScanner 同時支援 *sql.Row 與 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEntry(row rowScanner) (*Entry, error) {
+	var entry Entry
+	var props []byte
+	var ownerID, organizationID sql.NullString
+	err := row.Scan(
+		&entry.ID,
+		&entry.Type,
+		&entry.Name,
+		&entry.Manufacturer,
+		&entry.Standard,
+		&entry.Description,
+		&props,
+		&entry.Scope,
+		&ownerID,
+		&organizationID,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(props, &entry.Properties); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal properties: %w", err)
+	}
+	if ownerID.Valid {
+		entry.OwnerID = &ownerID.String
+	}
+	if organizationID.Valid {
+		entry.OrganizationID = &organizationID.String
+	}
+	return &entry, nil
+}
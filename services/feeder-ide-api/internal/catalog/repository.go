@@ -0,0 +1,152 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository 定義型錄儲存介面；內建項目不經過儲存層，由實作合併到查詢結果
+type Repository interface {
+	Create(entry *Entry) error
+	GetByID(id string) (*Entry, error)
+	Update(entry *Entry) error
+	Delete(id string) error
+	List(filter Filter) ([]*Entry, error)
+}
+
+// InMemoryRepository 記憶體實作（開發用）
+type InMemoryRepository struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+}
+
+// NewInMemoryRepository 建立新的記憶體 repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		entries: make(map[string]*Entry),
+	}
+}
+
+func (r *InMemoryRepository) Create(entry *Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	now := time.Now()
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+	r.entries[entry.ID] = entry.Clone()
+	return nil
+}
+
+func (r *InMemoryRepository) GetByID(id string) (*Entry, error) {
+	if entry, ok := builtinEntry(id); ok {
+		return entry, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.entries[id]
+	if !exists {
+		return nil, ErrEntryNotFound
+	}
+	return entry.Clone(), nil
+}
+
+func (r *InMemoryRepository) Update(entry *Entry) error {
+	if _, ok := builtinIndex[entry.ID]; ok {
+		return ErrReadOnly
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.entries[entry.ID]
+	if !exists {
+		return ErrEntryNotFound
+	}
+	entry.CreatedAt = existing.CreatedAt
+	entry.UpdatedAt = time.Now()
+	r.entries[entry.ID] = entry.Clone()
+	return nil
+}
+
+func (r *InMemoryRepository) Delete(id string) error {
+	if _, ok := builtinIndex[id]; ok {
+		return ErrReadOnly
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.entries[id]; !exists {
+		return ErrEntryNotFound
+	}
+	delete(r.entries, id)
+	return nil
+}
+
+func (r *InMemoryRepository) List(filter Filter) ([]*Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []*Entry{}
+	for _, entry := range append(Builtin(), r.values()...) {
+		if filter.matches(entry) {
+			entries = append(entries, entry.Clone())
+		}
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+func (r *InMemoryRepository) values() []*Entry {
+	values := make([]*Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		values = append(values, entry)
+	}
+	return values
+}
+
+// matches 判斷項目是否符合列表條件
+func (f Filter) matches(entry *Entry) bool {
+	if f.Type != "" && entry.Type != f.Type {
+		return false
+	}
+	if !entry.VisibleTo(f.Visibility) {
+		return false
+	}
+	if f.Query != "" {
+		query := strings.ToLower(f.Query)
+		text := strings.ToLower(entry.Name + " " + entry.Manufacturer + " " + entry.Standard)
+		if !strings.Contains(text, query) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortEntries 依類型、內建優先、名稱排序
+func sortEntries(entries []*Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Builtin != b.Builtin {
+			return a.Builtin
+		}
+		if a.Builtin {
+			// 內建項目維持定義順序（由小到大）
+			return false
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,192 @@
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// resolver 在一次解析中快取查到的項目
+type resolver struct {
+	repo       Repository
+	visibility Visibility
+	entries    map[string]*Entry
+}
+
+func newResolver(repo Repository, v Visibility) *resolver {
+	return &resolver{repo: repo, visibility: v, entries: make(map[string]*Entry)}
+}
+
+// lookup 取得可見的項目；不存在或不可見時回傳 ErrEntryNotFound
+func (r *resolver) lookup(id string) (*Entry, error) {
+	if entry, ok := r.entries[id]; ok {
+		if entry == nil {
+			return nil, ErrEntryNotFound
+		}
+		return entry, nil
+	}
+	entry, err := r.repo.GetByID(id)
+	if err == ErrEntryNotFound || (err == nil && !entry.VisibleTo(r.visibility)) {
+		r.entries[id] = nil
+		return nil, ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.entries[id] = entry
+	return entry, nil
+}
+
+// reference 取得元素的型錄參照
+func reference(props map[string]interface{}) (string, bool) {
+	id := topology.StringProperty(props, PropertyKey, "")
+	return id, id != ""
+}
+
+// merge 回傳以項目屬性補齊的元素屬性，元素已指定的屬性優先
+func merge(props map[string]interface{}, entry *Entry) map[string]interface{} {
+	merged := make(map[string]interface{}, len(props)+len(entry.Properties))
+	for key, value := range entry.Properties {
+		merged[key] = value
+	}
+	for key, value := range props {
+		merged[key] = value
+	}
+	return merged
+}
+
+// Resolve 將節點與線路參照的型錄屬性合併到元素 properties（直接修改 t）
+// 找不到、不可見或類型不符的參照會被略過，寫入時由 CheckReferences 回報
+func Resolve(t *topology.Topology, repo Repository, v Visibility) error {
+	r := newResolver(repo, v)
+	for i, node := range t.Nodes {
+		id, ok := reference(node.Properties)
+		if !ok {
+			continue
+		}
+		entry, err := r.lookup(id)
+		if err == ErrEntryNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ElementType(entry.Type) == node.Type {
+			t.Nodes[i].Properties = merge(node.Properties, entry)
+		}
+	}
+	for i, line := range t.Lines {
+		id, ok := reference(line.Properties)
+		if !ok {
+			continue
+		}
+		entry, err := r.lookup(id)
+		if err == ErrEntryNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if entry.Type == TypeLineCode {
+			t.Lines[i].Properties = merge(line.Properties, entry)
+		}
+	}
+	return nil
+}
+
+// CheckReferences 檢查拓樸中的型錄參照：項目必須存在、對用戶可見且適用於該元素類型
+func CheckReferences(t *topology.Topology, repo Repository, v Visibility) ([]topology.Violation, error) {
+	r := newResolver(repo, v)
+	violations := []topology.Violation{}
+	check := func(elementType, elementID, kind string, props map[string]interface{}) error {
+		value, exists := props[PropertyKey]
+		if !exists {
+			return nil
+		}
+		id, ok := value.(string)
+		if !ok || id == "" {
+			violations = append(violations, catalogViolation(elementType, elementID, "catalog_id must be a non-empty string"))
+			return nil
+		}
+		entry, err := r.lookup(id)
+		if err == ErrEntryNotFound {
+			violations = append(violations, catalogViolation(elementType, elementID,
+				fmt.Sprintf("catalog entry %q not found", id)))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ElementType(entry.Type) != kind {
+			violations = append(violations, catalogViolation(elementType, elementID,
+				fmt.Sprintf("catalog entry %q is a %s and cannot be used for a %s", id, entry.Type, kind)))
+		}
+		return nil
+	}
+
+	for _, node := range t.Nodes {
+		if err := check(topology.ElementNode, node.ID, node.Type, node.Properties); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range t.Lines {
+		if err := check(topology.ElementLine, line.ID, topology.ElementLine, line.Properties); err != nil {
+			return nil, err
+		}
+	}
+	return violations, nil
+}
+
+func catalogViolation(elementType, elementID, message string) topology.Violation {
+	return topology.Violation{
+		Severity:    topology.SeverityError,
+		ElementID:   elementID,
+		ElementType: elementType,
+		Rule:        topology.RuleCatalogReference,
+		Message:     message,
+	}
+}
+
+// ResolvingRepository 包裝拓樸 repository，讀取拓樸時解析型錄參照，供分析與模擬使用
+type ResolvingRepository struct {
+	topology.Repository
+	catalog Repository
+}
+
+// NewResolvingRepository 建立解析型錄參照的拓樸 repository
+func NewResolvingRepository(topologies topology.Repository, catalog Repository) *ResolvingRepository {
+	return &ResolvingRepository{Repository: topologies, catalog: catalog}
+}
+
+// GetByIDAndUserID 取得拓樸並以擁有者可見的型錄項目解析參照
+func (r *ResolvingRepository) GetByIDAndUserID(id string, userID *string) (*topology.Topology, error) {
+	t, err := r.Repository.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := Resolve(t, r.catalog, OwnerVisibility(t)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Authorize 檢查權限並以擁有者可見的型錄項目解析參照
+func (r *ResolvingRepository) Authorize(id string, principal topology.Principal) (*topology.Topology, topology.Role, error) {
+	t, role, err := r.Repository.Authorize(id, principal)
+	if err != nil {
+		return nil, topology.RoleNone, err
+	}
+	if err := Resolve(t, r.catalog, OwnerVisibility(t)); err != nil {
+		return nil, topology.RoleNone, err
+	}
+	return t, role, nil
+}
+
+// OwnerVisibility 拓樸擁有者的型錄可見範圍；拓樸的型錄參照一律以擁有者的範圍檢查與解析，
+// 共用對象看到的結果與擁有者相同。組織擁有的拓樸使用組織的型錄項目
+func OwnerVisibility(t *topology.Topology) Visibility {
+	if t.OrganizationID != nil {
+		return Visibility{OrganizationIDs: []string{*t.OrganizationID}}
+	}
+	return Visibility{UserID: t.UserID}
+}
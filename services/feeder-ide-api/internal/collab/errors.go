@@ -0,0 +1,13 @@
+package collab
+
+import "errors"
+
+var (
+	ErrUnknownOperation = errors.New("unknown operation type")
+	ErrInvalidOperation = errors.New("invalid operation")
+	ErrUnknownMessage   = errors.New("unknown message type")
+	ErrElementLocked    = errors.New("element is locked by another collaborator")
+	ErrReadOnly         = errors.New("editor role required to edit this topology")
+	ErrAccessRevoked    = errors.New("access to this topology has been revoked")
+	ErrInvalidTicket    = errors.New("invalid or expired collaboration ticket")
+)
@@ -0,0 +1,557 @@
+package collab
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/google/uuid"
+)
+
+const (
+	// LockTTL 軟鎖定的有效時間，持有者需在到期前重新送出 lock 延長
+	LockTTL = 30 * time.Second
+	// MaxCatchUpOperations 重新連線時最多重播的操作數，超過時改送完整拓樸
+	MaxCatchUpOperations = 200
+	// clientBuffer 每個連線待送出的訊息數，滿時中斷連線（用戶端重新連線後補齊）；
+	// 需大於 MaxCatchUpOperations，加入時的 welcome 與補齊訊息會一次放入緩衝
+	clientBuffer = 256
+	// maxApplyAttempts 套用操作時遇到版本衝突（協作以外的修改）的重試次數
+	maxApplyAttempts = 3
+)
+
+// Validator 檢查套用操作後的拓樸（結構驗證與設備型錄參照）
+type Validator func(t *topology.Topology) (*topology.ValidationResult, error)
+
+// RoleResolver 重新判斷連線者對拓樸的角色；沒有存取權時回傳 RoleNone 或 topology.ErrTopologyNotFound
+type RoleResolver func(topologyID string, principal topology.Principal) (topology.Role, error)
+
+// Identity 連線者的身分與對拓樸的角色（由 handler 授權後傳入）；Principal 用於之後重新判斷角色
+type Identity struct {
+	Principal topology.Principal
+	Email     string
+	Role      topology.Role
+}
+
+// Hub 管理各拓樸的協作工作階段。同一拓樸的操作依序套用並以套用後的版本排序，
+// 經由拓樸 repository 儲存（每個操作產生一個版本）並寫入操作紀錄
+type Hub struct {
+	topologies topology.Repository
+	operations Repository
+	validate   Validator
+	resolve    RoleResolver
+
+	mu    sync.Mutex
+	rooms map[string]*room
+}
+
+// NewHub 建立新的 Hub，validate 為 nil 時只做結構驗證；resolve 為 nil 時以拓樸 repository 依連線時的身分判斷角色
+func NewHub(topologies topology.Repository, operations Repository, validate Validator, resolve RoleResolver) *Hub {
+	if validate == nil {
+		validate = func(t *topology.Topology) (*topology.ValidationResult, error) {
+			return topology.Validate(t), nil
+		}
+	}
+	if resolve == nil {
+		resolve = func(topologyID string, principal topology.Principal) (topology.Role, error) {
+			_, role, err := topologies.Authorize(topologyID, principal)
+			return role, err
+		}
+	}
+	return &Hub{
+		topologies: topologies,
+		operations: operations,
+		validate:   validate,
+		resolve:    resolve,
+		rooms:      make(map[string]*room),
+	}
+}
+
+// room 單一拓樸的協作工作階段；所有訊息在 mu 內處理，確保每個連線收到相同順序的操作
+type room struct {
+	topologyID string
+
+	mu      sync.Mutex
+	closed  bool // 最後一個連線離開後關閉，之後加入的連線會建立新的 room
+	version int  // 最後廣播給連線的拓樸版本
+	clients map[string]*Client
+	locks   map[string]*Lock
+}
+
+// Client 一個協作連線
+type Client struct {
+	room      *room
+	peer      *Peer
+	principal topology.Principal
+	send      chan []byte
+	closed    bool // 由 room.mu 保護
+	revoked   bool // 因失去存取權而中斷，於關閉 send 前設定
+}
+
+// ID 連線 ID
+func (c *Client) ID() string {
+	return c.peer.ClientID
+}
+
+// Revoked 連線是否因失去存取權而中斷（Messages 關閉後讀取）
+func (c *Client) Revoked() bool {
+	return c.revoked
+}
+
+// Messages 待送出的訊息（已序列化為 JSON）；連線被中斷（離開或緩衝已滿）時關閉
+func (c *Client) Messages() <-chan []byte {
+	return c.send
+}
+
+// Join 加入拓樸的協作工作階段。since 為用戶端已有的拓樸版本：
+// 操作紀錄足以補齊時依序重播之後的操作，否則（或 since < 0）送出完整拓樸
+func (h *Hub) Join(topologyID string, identity Identity, since int) (*Client, error) {
+	for {
+		r := h.room(topologyID)
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			continue
+		}
+		client, err := h.joinLocked(r, identity, since)
+		r.mu.Unlock()
+		if err != nil {
+			h.releaseIfEmpty(r)
+		}
+		return client, err
+	}
+}
+
+// room 取得或建立拓樸的 room
+func (h *Hub) room(topologyID string) *room {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, exists := h.rooms[topologyID]
+	if !exists {
+		r = &room{
+			topologyID: topologyID,
+			clients:    make(map[string]*Client),
+			locks:      make(map[string]*Lock),
+		}
+		h.rooms[topologyID] = r
+	}
+	return r
+}
+
+func (h *Hub) joinLocked(r *room, identity Identity, since int) (*Client, error) {
+	current, err := h.topologies.GetByID(r.topologyID)
+	if err != nil {
+		return nil, err
+	}
+	r.syncLocked(current)
+
+	client := &Client{
+		room: r,
+		peer: &Peer{
+			ClientID: uuid.New().String(),
+			UserID:   identity.Principal.UserID,
+			Email:    identity.Email,
+			Role:     identity.Role,
+			JoinedAt: time.Now(),
+		},
+		principal: identity.Principal,
+		send:      make(chan []byte, clientBuffer),
+	}
+
+	r.expireLocksLocked(time.Now())
+	peers := []*Peer{client.peer.clone()}
+	for _, other := range r.clients {
+		peers = append(peers, other.peer.clone())
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].JoinedAt.Before(peers[j].JoinedAt) })
+	client.send <- encodeMessage(&ServerMessage{
+		Type:     MessageWelcome,
+		ClientID: client.ID(),
+		Role:     identity.Role,
+		Version:  current.Version,
+		Peers:    peers,
+		Locks:    r.lockListLocked(),
+	})
+
+	catchUp, err := h.catchUp(current, since)
+	if err != nil {
+		return nil, err
+	}
+	if catchUp == nil {
+		client.send <- encodeMessage(&ServerMessage{Type: MessageSnapshot, Version: current.Version, Topology: current})
+	} else {
+		for _, op := range catchUp {
+			client.send <- encodeMessage(&ServerMessage{Type: MessageOp, Version: op.Version, Operation: op})
+		}
+	}
+
+	r.broadcastLocked(&ServerMessage{Type: MessageJoin, Peer: client.peer.clone()}, "")
+	r.clients[client.ID()] = client
+	return client, nil
+}
+
+// catchUp 用戶端從 since 版本補齊到 current 所需的操作；回傳 nil 表示需要送出完整拓樸
+// （沒有 since、操作紀錄不足或中間有協作以外的修改）
+func (h *Hub) catchUp(current *topology.Topology, since int) ([]*LoggedOperation, error) {
+	missing := current.Version - since
+	if since < 0 || missing < 0 || missing > MaxCatchUpOperations {
+		return nil, nil
+	}
+	if missing == 0 {
+		return []*LoggedOperation{}, nil
+	}
+
+	operations, err := h.operations.ListSince(current.ID, since, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(operations) != missing {
+		return nil, nil
+	}
+	for i, op := range operations {
+		if op.Version != since+1+i {
+			return nil, nil
+		}
+	}
+	return operations, nil
+}
+
+// Leave 離開協作工作階段：釋放持有的鎖定並通知其他連線
+func (h *Hub) Leave(client *Client) {
+	r := client.room
+	r.mu.Lock()
+	r.dropLocked(client)
+	r.mu.Unlock()
+
+	h.releaseIfEmpty(r)
+}
+
+// releaseIfEmpty 沒有連線時移除 room
+func (h *Hub) releaseIfEmpty(r *room) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.clients) == 0 && !r.closed {
+		r.closed = true
+		if h.rooms[r.topologyID] == r {
+			delete(h.rooms, r.topologyID)
+		}
+	}
+}
+
+// Revalidate 重新判斷拓樸所有連線的角色（共用、共用連結或擁有權變更後呼叫），失去存取權的連線會被中斷
+func (h *Hub) Revalidate(topologyID string) {
+	h.mu.Lock()
+	r, exists := h.rooms[topologyID]
+	h.mu.Unlock()
+	if exists {
+		h.revalidateRoom(r)
+	}
+}
+
+// RevalidateAll 重新判斷所有連線的角色（組織成員資格變更後呼叫）
+func (h *Hub) RevalidateAll() {
+	h.mu.Lock()
+	rooms := make([]*room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		rooms = append(rooms, r)
+	}
+	h.mu.Unlock()
+
+	for _, r := range rooms {
+		h.revalidateRoom(r)
+	}
+}
+
+func (h *Hub) revalidateRoom(r *room) {
+	r.mu.Lock()
+	clients := make([]*Client, 0, len(r.clients))
+	for _, client := range r.clients {
+		clients = append(clients, client)
+	}
+	for _, client := range clients {
+		h.refreshRoleLocked(client)
+	}
+	r.mu.Unlock()
+
+	h.releaseIfEmpty(r)
+}
+
+// refreshRoleLocked 以目前的共用設定重新判斷連線者的角色：失去存取權時中斷連線並回傳 false，
+// 角色變更時通知所有連線；無法判斷（例如資料庫錯誤）時沿用原角色
+func (h *Hub) refreshRoleLocked(client *Client) bool {
+	r := client.room
+	if client.closed {
+		return false
+	}
+	role, err := h.resolve(r.topologyID, client.principal)
+	if err != nil && err != topology.ErrTopologyNotFound {
+		log.Printf("Failed to re-check collaborator role on topology %s: %v", r.topologyID, err)
+		return true
+	}
+	if !role.Allows(topology.RoleViewer) {
+		r.sendLocked(client, &ServerMessage{Type: MessageError, Error: ErrAccessRevoked.Error()})
+		client.revoked = true
+		r.dropLocked(client)
+		return false
+	}
+	if role != client.peer.Role {
+		client.peer.Role = role
+		r.broadcastLocked(&ServerMessage{Type: MessageRole, Role: role, Peer: client.peer.clone()}, "")
+	}
+	return true
+}
+
+// Handle 處理用戶端訊息；op 與 lock 會先重新判斷角色，共用變更在下一個編輯動作即生效
+func (h *Hub) Handle(client *Client, msg *ClientMessage) {
+	r := client.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client.closed {
+		return
+	}
+	if (msg.Type == MessageOp || msg.Type == MessageLock) && !h.refreshRoleLocked(client) {
+		return
+	}
+	switch msg.Type {
+	case MessageOp:
+		h.applyLocked(client, msg)
+	case MessagePresence:
+		client.peer.Cursor = msg.Cursor
+		client.peer.Selection = msg.Selection
+		r.broadcastLocked(&ServerMessage{Type: MessagePresence, Peer: client.peer.clone()}, client.ID())
+	case MessageLock:
+		r.lockLocked(client, msg)
+	case MessageUnlock:
+		key := lockKey(msg.ElementType, msg.ElementID)
+		if lock := r.locks[key]; lock != nil && lock.ClientID == client.ID() {
+			delete(r.locks, key)
+			r.broadcastLocked(&ServerMessage{Type: MessageUnlock, Lock: lock}, "")
+		}
+	case MessagePing:
+		r.sendLocked(client, &ServerMessage{Type: MessagePong, Version: r.version})
+	default:
+		r.sendLocked(client, &ServerMessage{Type: MessageError, Error: ErrUnknownMessage.Error() + ": " + msg.Type})
+	}
+}
+
+// SendError 送出錯誤訊息給連線（例如無法解析的訊息）
+func (h *Hub) SendError(client *Client, err error) {
+	r := client.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sendLocked(client, &ServerMessage{Type: MessageError, Error: err.Error()})
+}
+
+// applyLocked 套用操作：以最新版本的拓樸檢查鎖定、套用、驗證並以 compare-and-swap 儲存，
+// 成功時寫入操作紀錄並廣播給所有連線（包含送出者）
+func (h *Hub) applyLocked(client *Client, msg *ClientMessage) {
+	r := client.room
+	reject := func(err error, extra *ServerMessage) {
+		if extra == nil {
+			extra = &ServerMessage{}
+		}
+		extra.Type = MessageReject
+		extra.ClientOpID = msg.ClientOpID
+		extra.Version = r.version
+		extra.Error = err.Error()
+		r.sendLocked(client, extra)
+	}
+
+	if !client.peer.Role.Allows(topology.RoleEditor) {
+		reject(ErrReadOnly, nil)
+		return
+	}
+	if msg.Op == nil {
+		reject(ErrInvalidOperation, nil)
+		return
+	}
+	if err := msg.Op.Check(); err != nil {
+		reject(err, nil)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, err := h.topologies.GetByID(r.topologyID)
+		if err != nil {
+			reject(err, nil)
+			return
+		}
+		r.syncLocked(current)
+
+		r.expireLocksLocked(time.Now())
+		for _, key := range msg.Op.LockKeys(current) {
+			if lock := r.locks[key]; lock != nil && lock.ClientID != client.ID() {
+				reject(ErrElementLocked, &ServerMessage{Lock: lock})
+				return
+			}
+		}
+
+		op := msg.Op.clone()
+		if err := op.Apply(current); err != nil {
+			reject(err, nil)
+			return
+		}
+		result, err := h.validate(current)
+		if err != nil {
+			reject(err, nil)
+			return
+		}
+		if !result.Valid {
+			reject(&topology.ValidationError{Result: result}, &ServerMessage{Violations: result.Errors()})
+			return
+		}
+
+		info := topology.RevisionInfo{AuthorID: client.peer.UserID, Message: op.Describe()}
+		err = h.topologies.UpdateIfVersion(current.ID, current.Version, current, info)
+		if errors.Is(err, topology.ErrVersionConflict) && attempt < maxApplyAttempts {
+			continue
+		}
+		if err != nil {
+			reject(err, nil)
+			return
+		}
+
+		logged := &LoggedOperation{
+			TopologyID: current.ID,
+			Version:    current.Version,
+			UserID:     client.peer.UserID,
+			ClientID:   client.ID(),
+			ClientOpID: msg.ClientOpID,
+			Op:         op,
+			CreatedAt:  time.Now(),
+		}
+		// 拓樸已儲存；操作紀錄寫入失敗時，重新連線的用戶端會因紀錄不連續而改收完整拓樸
+		if err := h.operations.Append(logged); err != nil {
+			log.Printf("Failed to log collaborative operation on topology %s: %v", current.ID, err)
+		}
+		r.version = current.Version
+		r.broadcastLocked(&ServerMessage{Type: MessageOp, Version: current.Version, Operation: logged}, "")
+		return
+	}
+}
+
+// lockLocked 取得或延長元素的軟鎖定
+func (r *room) lockLocked(client *Client, msg *ClientMessage) {
+	reject := func(err error, lock *Lock) {
+		r.sendLocked(client, &ServerMessage{Type: MessageReject, Version: r.version, Lock: lock, Error: err.Error()})
+	}
+	if !client.peer.Role.Allows(topology.RoleEditor) {
+		reject(ErrReadOnly, nil)
+		return
+	}
+	if msg.ElementID == "" || (msg.ElementType != topology.ElementNode && msg.ElementType != topology.ElementLine) {
+		reject(errors.New("lock requires element_type (node or line) and element_id"), nil)
+		return
+	}
+
+	now := time.Now()
+	r.expireLocksLocked(now)
+	key := lockKey(msg.ElementType, msg.ElementID)
+	if lock := r.locks[key]; lock != nil && lock.ClientID != client.ID() {
+		reject(ErrElementLocked, lock)
+		return
+	}
+
+	lock := &Lock{
+		ElementType: msg.ElementType,
+		ElementID:   msg.ElementID,
+		ClientID:    client.ID(),
+		UserID:      client.peer.UserID,
+		ExpiresAt:   now.Add(LockTTL),
+	}
+	r.locks[key] = lock
+	r.broadcastLocked(&ServerMessage{Type: MessageLock, Lock: lock}, "")
+}
+
+// expireLocksLocked 釋放已逾時的鎖定並通知連線
+func (r *room) expireLocksLocked(now time.Time) {
+	for key, lock := range r.locks {
+		if !now.Before(lock.ExpiresAt) {
+			delete(r.locks, key)
+			r.broadcastLocked(&ServerMessage{Type: MessageUnlock, Lock: lock}, "")
+		}
+	}
+}
+
+// lockListLocked 目前的鎖定（依元素排列）
+func (r *room) lockListLocked() []*Lock {
+	locks := make([]*Lock, 0, len(r.locks))
+	for _, lock := range r.locks {
+		copied := *lock
+		locks = append(locks, &copied)
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return lockKey(locks[i].ElementType, locks[i].ElementID) < lockKey(locks[j].ElementType, locks[j].ElementID)
+	})
+	return locks
+}
+
+// syncLocked 拓樸在協作以外（REST API）被修改時，送出完整拓樸讓連線重新同步
+func (r *room) syncLocked(current *topology.Topology) {
+	if r.version != current.Version && len(r.clients) > 0 {
+		r.broadcastLocked(&ServerMessage{Type: MessageSnapshot, Version: current.Version, Topology: current}, "")
+	}
+	r.version = current.Version
+}
+
+// dropLocked 中斷連線：釋放持有的鎖定、關閉訊息通道並通知其他連線
+func (r *room) dropLocked(client *Client) {
+	if client.closed {
+		return
+	}
+	client.closed = true
+	close(client.send)
+	if _, joined := r.clients[client.ID()]; !joined {
+		return
+	}
+	delete(r.clients, client.ID())
+
+	for key, lock := range r.locks {
+		if lock.ClientID == client.ID() {
+			delete(r.locks, key)
+			r.broadcastLocked(&ServerMessage{Type: MessageUnlock, Lock: lock}, "")
+		}
+	}
+	r.broadcastLocked(&ServerMessage{Type: MessageLeave, Peer: client.peer.clone()}, "")
+}
+
+// sendLocked 送出訊息給單一連線，緩衝已滿時中斷該連線
+func (r *room) sendLocked(client *Client, msg *ServerMessage) {
+	if client.closed {
+		return
+	}
+	select {
+	case client.send <- encodeMessage(msg):
+	default:
+		r.dropLocked(client)
+	}
+}
+
+// broadcastLocked 送出訊息給所有連線（except 以外），緩衝已滿的連線會被中斷
+func (r *room) broadcastLocked(msg *ServerMessage, except string) {
+	data := encodeMessage(msg)
+	var slow []*Client
+	for id, client := range r.clients {
+		if id == except {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+			slow = append(slow, client)
+		}
+	}
+	for _, client := range slow {
+		r.dropLocked(client)
+	}
+}
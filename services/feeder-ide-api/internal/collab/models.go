@@ -0,0 +1,126 @@
+package collab
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 用戶端送出的訊息類型
+const (
+	MessageOp       = "op"       // 元素層級操作
+	MessagePresence = "presence" // 游標與選取範圍
+	MessageLock     = "lock"     // 取得或延長元素的軟鎖定
+	MessageUnlock   = "unlock"   // 釋放軟鎖定
+	MessagePing     = "ping"
+)
+
+// 伺服器送出的訊息類型（另含 op、presence、lock、unlock）
+const (
+	MessageWelcome  = "welcome"  // 連線成功：自己的 client_id、角色、目前版本、在線成員與鎖定
+	MessageSnapshot = "snapshot" // 完整拓樸：初次連線、操作紀錄不足以補齊或拓樸在協作以外被修改時
+	MessageReject   = "reject"   // 自己的操作或鎖定被拒絕（自己的操作套用後與其他人一樣收到 op）
+	MessageJoin     = "join"
+	MessageLeave    = "leave"
+	MessagePong     = "pong"
+	MessageRole     = "role" // 連線者的角色變更（共用設定或組織成員資格變更後）
+	MessageError    = "error"
+)
+
+// ClientMessage 用戶端送出的訊息
+type ClientMessage struct {
+	Type        string             `json:"type"`
+	ClientOpID  string             `json:"client_op_id,omitempty"` // 用戶端自訂的操作 ID，會在 op/reject 中回傳
+	Op          *Operation         `json:"op,omitempty"`
+	Cursor      *topology.Position `json:"cursor,omitempty"`
+	Selection   []string           `json:"selection,omitempty"`
+	ElementType string             `json:"element_type,omitempty"` // lock/unlock：node 或 line
+	ElementID   string             `json:"element_id,omitempty"`
+}
+
+// ServerMessage 伺服器送出的訊息，依 Type 填入對應欄位
+type ServerMessage struct {
+	Type       string               `json:"type"`
+	ClientID   string               `json:"client_id,omitempty"`
+	Role       topology.Role        `json:"role,omitempty"`
+	Version    int                  `json:"version,omitempty"`
+	ClientOpID string               `json:"client_op_id,omitempty"`
+	Operation  *LoggedOperation     `json:"operation,omitempty"`
+	Topology   *topology.Topology   `json:"topology,omitempty"`
+	Peer       *Peer                `json:"peer,omitempty"`
+	Peers      []*Peer              `json:"peers,omitempty"`
+	Lock       *Lock                `json:"lock,omitempty"`
+	Locks      []*Lock              `json:"locks,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	Violations []topology.Violation `json:"violations,omitempty"`
+	Time       time.Time            `json:"time"`
+}
+
+// LoggedOperation 已套用的操作；Version 為套用後的拓樸版本，也是操作的順序
+type LoggedOperation struct {
+	TopologyID string  `json:"topology_id"`
+	Version    int     `json:"version"`
+	UserID     *string `json:"user_id,omitempty"`
+	ClientID   string  `json:"client_id,omitempty"`
+	// ClientOpID 送出操作時的 client_op_id，重新連線的用戶端可藉此辨識斷線前送出且已套用的操作
+	ClientOpID string    `json:"client_op_id,omitempty"`
+	Op         Operation `json:"op"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Clone 深拷貝已套用的操作
+func (o *LoggedOperation) Clone() *LoggedOperation {
+	clone := *o
+	if o.UserID != nil {
+		userID := *o.UserID
+		clone.UserID = &userID
+	}
+	clone.Op = o.Op.clone()
+	return &clone
+}
+
+// Peer 在線的協作者
+type Peer struct {
+	ClientID  string             `json:"client_id"`
+	UserID    *string            `json:"user_id,omitempty"` // 共用連結或 demo 模式的訪客為 nil
+	Email     string             `json:"email,omitempty"`
+	Role      topology.Role      `json:"role"`
+	Cursor    *topology.Position `json:"cursor,omitempty"`
+	Selection []string           `json:"selection,omitempty"`
+	JoinedAt  time.Time          `json:"joined_at"`
+}
+
+// clone 複製在線成員資訊（廣播用）
+func (p *Peer) clone() *Peer {
+	clone := *p
+	if p.Cursor != nil {
+		cursor := *p.Cursor
+		clone.Cursor = &cursor
+	}
+	clone.Selection = append([]string(nil), p.Selection...)
+	return &clone
+}
+
+// Lock 元素的軟鎖定：持有者以外的協作者不能修改該元素，逾時未延長即自動釋放
+type Lock struct {
+	ElementType string    `json:"element_type"`
+	ElementID   string    `json:"element_id"`
+	ClientID    string    `json:"client_id"`
+	UserID      *string   `json:"user_id,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// lockKey 鎖定表的 key
+func lockKey(elementType, elementID string) string {
+	return elementType + ":" + elementID
+}
+
+// encodeMessage 序列化伺服器訊息
+func encodeMessage(msg *ServerMessage) []byte {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
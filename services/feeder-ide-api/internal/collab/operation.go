@@ -0,0 +1,194 @@
+package collab
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 操作類型
+const (
+	OpAddNode     = "add_node"
+	OpUpdateNode  = "update_node"
+	OpMoveNode    = "move_node"
+	OpRemoveNode  = "remove_node"
+	OpAddLine     = "add_line"
+	OpUpdateLine  = "update_line"
+	OpRemoveLine  = "remove_line"
+	OpSetProperty = "set_property"
+)
+
+// Operation 元素層級操作。伺服器套用時會補齊結果（新增元素的 ID、刪除節點時一併刪除的線路），
+// 記錄與廣播的是補齊後的操作，依序重播即可得到與伺服器相同的拓樸
+type Operation struct {
+	Type        string             `json:"type"`
+	Node        *topology.Node     `json:"node,omitempty"`         // add_node、update_node
+	Line        *topology.Line     `json:"line,omitempty"`         // add_line、update_line
+	ElementType string             `json:"element_type,omitempty"` // set_property：node 或 line
+	ElementID   string             `json:"element_id,omitempty"`   // move_node、remove_node、remove_line、set_property
+	Position    *topology.Position `json:"position,omitempty"`     // move_node
+	Geo         *topology.GeoPoint `json:"geo,omitempty"`          // move_node，可選
+	Key         string             `json:"key,omitempty"`          // set_property
+	Value       interface{}        `json:"value,omitempty"`        // set_property，省略或 null 時刪除屬性
+	// RemovedLineIDs remove_node 時一併刪除的線路（伺服器填入）
+	RemovedLineIDs []string `json:"removed_line_ids,omitempty"`
+}
+
+// Check 檢查操作欄位是否完整
+func (o *Operation) Check() error {
+	switch o.Type {
+	case OpAddNode, OpUpdateNode:
+		if o.Node == nil {
+			return fmt.Errorf("%w: %s requires node", ErrInvalidOperation, o.Type)
+		}
+		if o.Type == OpUpdateNode && o.Node.ID == "" {
+			return fmt.Errorf("%w: update_node requires node.id", ErrInvalidOperation)
+		}
+	case OpAddLine, OpUpdateLine:
+		if o.Line == nil {
+			return fmt.Errorf("%w: %s requires line", ErrInvalidOperation, o.Type)
+		}
+		if o.Type == OpUpdateLine && o.Line.ID == "" {
+			return fmt.Errorf("%w: update_line requires line.id", ErrInvalidOperation)
+		}
+	case OpMoveNode:
+		if o.ElementID == "" || o.Position == nil {
+			return fmt.Errorf("%w: move_node requires element_id and position", ErrInvalidOperation)
+		}
+	case OpRemoveNode, OpRemoveLine:
+		if o.ElementID == "" {
+			return fmt.Errorf("%w: %s requires element_id", ErrInvalidOperation, o.Type)
+		}
+	case OpSetProperty:
+		if o.ElementID == "" || o.Key == "" {
+			return fmt.Errorf("%w: set_property requires element_id and key", ErrInvalidOperation)
+		}
+		if o.ElementType != topology.ElementNode && o.ElementType != topology.ElementLine {
+			return fmt.Errorf("%w: element_type must be node or line", ErrInvalidOperation)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOperation, o.Type)
+	}
+	return nil
+}
+
+// Apply 將操作套用到拓樸，並補齊操作結果
+func (o *Operation) Apply(t *topology.Topology) error {
+	switch o.Type {
+	case OpAddNode:
+		node, err := t.AddNode(*o.Node)
+		if err != nil {
+			return err
+		}
+		o.Node = &node
+	case OpUpdateNode:
+		_, err := t.UpdateNode(o.Node.ID, *o.Node)
+		return err
+	case OpMoveNode:
+		index := t.NodeIndex(o.ElementID)
+		if index < 0 {
+			return topology.ErrNodeNotFound
+		}
+		t.Nodes[index].Position = *o.Position
+		if o.Geo != nil {
+			geo := *o.Geo
+			t.Nodes[index].Geo = &geo
+		}
+	case OpRemoveNode:
+		removed, err := t.RemoveNode(o.ElementID)
+		if err != nil {
+			return err
+		}
+		o.RemovedLineIDs = removed
+	case OpAddLine:
+		line, err := t.AddLine(*o.Line)
+		if err != nil {
+			return err
+		}
+		o.Line = &line
+	case OpUpdateLine:
+		_, err := t.UpdateLine(o.Line.ID, *o.Line)
+		return err
+	case OpRemoveLine:
+		return t.RemoveLine(o.ElementID)
+	case OpSetProperty:
+		props, err := o.properties(t)
+		if err != nil {
+			return err
+		}
+		if o.Value == nil {
+			delete(*props, o.Key)
+		} else {
+			if *props == nil {
+				*props = make(map[string]interface{})
+			}
+			(*props)[o.Key] = o.Value
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOperation, o.Type)
+	}
+	return nil
+}
+
+// properties set_property 目標元素的 properties
+func (o *Operation) properties(t *topology.Topology) (*map[string]interface{}, error) {
+	if o.ElementType == topology.ElementLine {
+		index := t.LineIndex(o.ElementID)
+		if index < 0 {
+			return nil, topology.ErrLineNotFound
+		}
+		return &t.Lines[index].Properties, nil
+	}
+	index := t.NodeIndex(o.ElementID)
+	if index < 0 {
+		return nil, topology.ErrNodeNotFound
+	}
+	return &t.Nodes[index].Properties, nil
+}
+
+// LockKeys 操作會修改的既有元素（鎖定檢查用）；刪除節點時包含連接的線路，新增元素不需檢查
+func (o *Operation) LockKeys(t *topology.Topology) []string {
+	switch o.Type {
+	case OpUpdateNode:
+		return []string{lockKey(topology.ElementNode, o.Node.ID)}
+	case OpMoveNode:
+		return []string{lockKey(topology.ElementNode, o.ElementID)}
+	case OpRemoveNode:
+		keys := []string{lockKey(topology.ElementNode, o.ElementID)}
+		for _, line := range t.Lines {
+			if line.FromNodeID == o.ElementID || line.ToNodeID == o.ElementID {
+				keys = append(keys, lockKey(topology.ElementLine, line.ID))
+			}
+		}
+		return keys
+	case OpUpdateLine:
+		return []string{lockKey(topology.ElementLine, o.Line.ID)}
+	case OpRemoveLine:
+		return []string{lockKey(topology.ElementLine, o.ElementID)}
+	case OpSetProperty:
+		return []string{lockKey(o.ElementType, o.ElementID)}
+	default:
+		return nil
+	}
+}
+
+// Describe 操作說明（作為版本說明）
+func (o *Operation) Describe() string {
+	target := o.ElementID
+	switch {
+	case o.Node != nil:
+		target = o.Node.ID
+	case o.Line != nil:
+		target = o.Line.ID
+	}
+	return fmt.Sprintf("Collaborative edit: %s %s", o.Type, target)
+}
+
+// clone 深拷貝操作（經由 JSON，與用戶端送出的內容一致）
+func (o Operation) clone() Operation {
+	var clone Operation
+	data, _ := json.Marshal(o)
+	_ = json.Unmarshal(data, &clone)
+	return clone
+}
@@ -0,0 +1,85 @@
+package collab
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/database"
+)
+
+// PostgresRepository PostgreSQL 實作
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository 建立新的 PostgreSQL repository
+func NewPostgresRepository() (*PostgresRepository, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return &PostgresRepository{
+		db: database.DB,
+	}, nil
+}
+
+func (r *PostgresRepository) Append(op *LoggedOperation) error {
+	data, err := json.Marshal(op.Op)
+	if err != nil {
+		return fmt.Errorf("failed to marshal operation: %w", err)
+	}
+
+	query := `
+		INSERT INTO topology_operations (topology_id, version, user_id, client_id, client_op_id, operation, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = r.db.Exec(query,
+		op.TopologyID,
+		op.Version,
+		op.UserID,
+		op.ClientID,
+		op.ClientOpID,
+		data,
+		op.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append operation: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListSince(topologyID string, version int, limit int) ([]*LoggedOperation, error) {
+	query := `
+		SELECT topology_id, version, user_id, client_id, client_op_id, operation, created_at
+		FROM topology_operations
+		WHERE topology_id = $1 AND version > $2
+		ORDER BY version
+		LIMIT $3
+	`
+	rows, err := r.db.Query(query, topologyID, version, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []*LoggedOperation{}
+	for rows.Next() {
+		var op LoggedOperation
+		var userID sql.NullString
+		var data []byte
+		if err := rows.Scan(&op.TopologyID, &op.Version, &userID, &op.ClientID, &op.ClientOpID, &data, &op.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+		if userID.Valid {
+			op.UserID = &userID.String
+		}
+		if err := json.Unmarshal(data, &op.Op); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal operation: %w", err)
+		}
+		operations = append(operations, &op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate operations: %w", err)
+	}
+	return operations, nil
+}
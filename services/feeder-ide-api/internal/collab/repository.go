@@ -0,0 +1,56 @@
+package collab
+
+import "sync"
+
+// maxInMemoryOperations 記憶體實作每個拓樸保留的操作數，超過時捨棄最舊的操作
+// （重新連線的用戶端無法補齊時會改收完整拓樸）
+const maxInMemoryOperations = 1000
+
+// Repository 定義協作操作紀錄的儲存介面
+type Repository interface {
+	Append(op *LoggedOperation) error
+	// ListSince 依版本由舊到新列出 version 之後的操作，最多 limit 筆
+	ListSince(topologyID string, version int, limit int) ([]*LoggedOperation, error)
+}
+
+// InMemoryRepository 記憶體實作（開發用）
+type InMemoryRepository struct {
+	mu         sync.RWMutex
+	operations map[string][]*LoggedOperation
+}
+
+// NewInMemoryRepository 建立新的記憶體 repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		operations: make(map[string][]*LoggedOperation),
+	}
+}
+
+func (r *InMemoryRepository) Append(op *LoggedOperation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	operations := append(r.operations[op.TopologyID], op.Clone())
+	if len(operations) > maxInMemoryOperations {
+		operations = append([]*LoggedOperation(nil), operations[len(operations)-maxInMemoryOperations:]...)
+	}
+	r.operations[op.TopologyID] = operations
+	return nil
+}
+
+func (r *InMemoryRepository) ListSince(topologyID string, version int, limit int) ([]*LoggedOperation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []*LoggedOperation{}
+	for _, op := range r.operations[topologyID] {
+		if op.Version <= version {
+			continue
+		}
+		if len(result) == limit {
+			break
+		}
+		result = append(result, op.Clone())
+	}
+	return result, nil
+}
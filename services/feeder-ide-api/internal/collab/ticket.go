@@ -0,0 +1,74 @@
+package collab
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// TicketTTL 連線票券的有效時間
+const TicketTTL = 30 * time.Second
+
+// Tickets 一次性的 WebSocket 連線票券。瀏覽器的 WebSocket 無法設定標頭，先以一般請求（Authorization 或
+// X-Share-Token 標頭）取得票券再以 ?ticket= 連線，JWT 與共用連結 token 就不會出現在 URL 與存取記錄中
+type Tickets struct {
+	mu      sync.Mutex
+	tickets map[string]*ticket
+}
+
+type ticket struct {
+	topologyID string
+	identity   Identity
+	expiresAt  time.Time
+}
+
+// NewTickets 建立新的票券儲存
+func NewTickets() *Tickets {
+	return &Tickets{
+		tickets: make(map[string]*ticket),
+	}
+}
+
+// Issue 為拓樸建立連線票券，identity 的角色在連線時重新判斷
+func (t *Tickets) Issue(topologyID string, identity Identity) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, existing := range t.tickets {
+		if !now.Before(existing.expiresAt) {
+			delete(t.tickets, key)
+		}
+	}
+	expiresAt := now.Add(TicketTTL)
+	identity.Role = ""
+	t.tickets[token] = &ticket{
+		topologyID: topologyID,
+		identity:   identity,
+		expiresAt:  expiresAt,
+	}
+	return token, expiresAt, nil
+}
+
+// Redeem 使用票券（只能使用一次）；票券不存在、已過期或屬於其他拓樸時回傳 ErrInvalidTicket
+func (t *Tickets) Redeem(token, topologyID string) (Identity, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issued, exists := t.tickets[token]
+	if !exists {
+		return Identity{}, ErrInvalidTicket
+	}
+	delete(t.tickets, token)
+	if issued.topologyID != topologyID || !time.Now().Before(issued.expiresAt) {
+		return Identity{}, ErrInvalidTicket
+	}
+	return issued.identity, nil
+}
@@ -0,0 +1,305 @@
+package contingency
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// element 事故元件
+type element struct {
+	id          string
+	elementType string
+}
+
+// Elements 取得會被分析的事故元件 ID，可用於估計工作量
+func Elements(t *topology.Topology, opts Options) ([]string, error) {
+	n, err := powerflow.BuildNetwork(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if n.Meshed {
+		return nil, ErrMeshedNetwork
+	}
+	elements, err := selectElements(n, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(elements))
+	for i, e := range elements {
+		ids[i] = e.id
+	}
+	return ids, nil
+}
+
+// Analyze 逐一停用元件、以聯絡開關轉供並依嚴重程度排序
+// ctx 取消時中止並回傳 ctx.Err()；progress 可為 nil
+func Analyze(ctx context.Context, t *topology.Topology, opts Options, progress ProgressFunc) (*Result, error) {
+	base, err := solveCase(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if !base.result.Converged {
+		return nil, ErrBaseCase
+	}
+	elements, err := selectElements(base.network, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &study{
+		topology:  t,
+		opts:      opts,
+		base:      base,
+		customers: make(map[string]int, len(t.Nodes)),
+	}
+	for _, node := range t.Nodes {
+		demand := powerflow.NodeDemand(node)
+		defaultCustomers := 0.0
+		if demand.LoadKW > 0 {
+			defaultCustomers = 1
+		}
+		s.customers[node.ID] = int(math.Round(topology.FloatProperty(node.Properties, "customers", defaultCustomers)))
+	}
+
+	results := make([]ContingencyResult, 0, len(elements))
+	for i, e := range elements {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := s.contingency(e)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		if progress != nil {
+			progress(i+1, len(elements))
+		}
+	}
+
+	rank(results)
+	return &Result{
+		Base:          base.summary(),
+		Contingencies: results,
+		Summary:       s.summarize(results),
+	}, nil
+}
+
+// selectElements 決定事故元件：供電中的線路與變壓器（不含電源）
+func selectElements(n *powerflow.Network, opts Options) ([]element, error) {
+	available := make(map[string]element)
+	var elements []element
+	for _, branch := range n.Branches {
+		if branch.InService {
+			e := element{id: branch.LineID, elementType: ElementLine}
+			available[e.id] = e
+			elements = append(elements, e)
+		}
+	}
+	if !opts.SkipTransformers {
+		for _, bus := range n.Buses {
+			if bus.Type == topology.NodeTypeTransformer && bus.Energized && bus.Index != n.Source {
+				e := element{id: bus.NodeID, elementType: ElementTransformer}
+				available[e.id] = e
+				elements = append(elements, e)
+			}
+		}
+	}
+
+	if len(opts.ElementIDs) > 0 {
+		elements = elements[:0]
+		seen := make(map[string]bool, len(opts.ElementIDs))
+		for _, id := range opts.ElementIDs {
+			e, exists := available[id]
+			if !exists {
+				return nil, fmt.Errorf("%w: %s is not an energized line or transformer", ErrInvalidOptions, id)
+			}
+			if !seen[id] {
+				seen[id] = true
+				elements = append(elements, e)
+			}
+		}
+	}
+
+	if len(elements) == 0 {
+		return nil, ErrNoElements
+	}
+	return elements, nil
+}
+
+// study 分析過程中的狀態
+type study struct {
+	topology  *topology.Topology
+	opts      Options
+	base      *operatingCase
+	customers map[string]int
+}
+
+// contingency 停用單一元件並轉供
+func (s *study) contingency(e element) (ContingencyResult, error) {
+	outage := withoutElement(s.topology, e.elementType, e.id)
+	current, err := solveCase(outage, s.opts.PowerFlow)
+	if err != nil {
+		return ContingencyResult{}, err
+	}
+
+	result := ContingencyResult{
+		ElementID:       e.id,
+		ElementType:     e.elementType,
+		ClosedSwitchIDs: []string{},
+	}
+	lost := s.lostNodes(current)
+	result.InterruptedLoadKW = round(s.base.servedLoadKW()-current.servedLoadKW(), 2)
+	result.InterruptedCustomers = s.countCustomers(lost)
+
+	closed := map[string]bool{}
+	for len(lost) > 0 {
+		next, switchID, err := s.restore(outage, closed, current)
+		if err != nil {
+			return ContingencyResult{}, err
+		}
+		if next == nil {
+			break
+		}
+		closed[switchID] = true
+		result.ClosedSwitchIDs = append(result.ClosedSwitchIDs, switchID)
+		current = next
+		lost = s.lostNodes(current)
+	}
+
+	unserved := math.Max(s.base.servedLoadKW()-current.servedLoadKW(), 0)
+	result.UnservedLoadKW = round(unserved, 2)
+	result.RestoredLoadKW = round(math.Max(result.InterruptedLoadKW-result.UnservedLoadKW, 0), 2)
+	result.UnservedCustomers = s.countCustomers(lost)
+	result.DeenergizedNodeIDs = lost
+	result.PostRestoration = current.summary()
+	result.Secure = current.result.Converged && unserved <= loadTolerance && current.violations() <= s.base.violations()
+	return result, nil
+}
+
+// restore 嘗試所有可轉供的聯絡開關，回傳恢復最多負載的狀態；沒有可行的開關時回傳 nil
+func (s *study) restore(outage *topology.Topology, closed map[string]bool, current *operatingCase) (*operatingCase, string, error) {
+	n := current.network
+	var best *operatingCase
+	var bestID string
+	for _, bus := range n.Buses {
+		if !bus.OpenSwitch || !bus.Energized || bus.Index == n.Source {
+			continue
+		}
+		live, dead := liveNeighbors(n, bus.Index)
+		if len(live) != 1 || len(dead) == 0 {
+			continue
+		}
+
+		states := make(map[string]bool, len(closed)+1)
+		for id := range closed {
+			states[id] = true
+		}
+		states[bus.NodeID] = true
+		candidate, err := solveCase(outage.WithSwitchStates(states), s.opts.PowerFlow)
+		if err == ErrMeshedNetwork {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if !candidate.result.Converged || candidate.servedLoadKW() <= current.servedLoadKW()+loadTolerance {
+			continue
+		}
+		if !s.opts.AllowViolations && candidate.violations() > current.violations() {
+			continue
+		}
+		if best == nil || better(candidate, best) {
+			best, bestID = candidate, bus.NodeID
+		}
+	}
+	return best, bestID, nil
+}
+
+// better 恢復負載較多者優先，其次為違規較少、損失較低
+func better(a, b *operatingCase) bool {
+	if math.Abs(a.servedLoadKW()-b.servedLoadKW()) > loadTolerance {
+		return a.servedLoadKW() > b.servedLoadKW()
+	}
+	if a.violations() != b.violations() {
+		return a.violations() < b.violations()
+	}
+	return a.result.Summary.TotalLossesKW < b.result.Summary.TotalLossesKW
+}
+
+// lostNodes 基準情境帶電但目前停電的節點
+func (s *study) lostNodes(current *operatingCase) []string {
+	lost := []string{}
+	for i, node := range s.base.result.Nodes {
+		if node.Energized && !current.result.Nodes[i].Energized {
+			lost = append(lost, node.NodeID)
+		}
+	}
+	return lost
+}
+
+func (s *study) countCustomers(nodeIDs []string) int {
+	total := 0
+	for _, id := range nodeIDs {
+		total += s.customers[id]
+	}
+	return total
+}
+
+// rank 依未供電負載、違規數與停電負載排序
+func rank(results []ContingencyResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.PostRestoration.Converged != b.PostRestoration.Converged {
+			return !a.PostRestoration.Converged
+		}
+		if a.UnservedLoadKW != b.UnservedLoadKW {
+			return a.UnservedLoadKW > b.UnservedLoadKW
+		}
+		va := a.PostRestoration.VoltageViolations + a.PostRestoration.ThermalViolations
+		vb := b.PostRestoration.VoltageViolations + b.PostRestoration.ThermalViolations
+		if va != vb {
+			return va > vb
+		}
+		if a.InterruptedLoadKW != b.InterruptedLoadKW {
+			return a.InterruptedLoadKW > b.InterruptedLoadKW
+		}
+		return a.ElementID < b.ElementID
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+	}
+}
+
+func (s *study) summarize(results []ContingencyResult) Summary {
+	summary := Summary{Contingencies: len(results)}
+	for _, bus := range s.base.network.Buses {
+		if bus.OpenSwitch {
+			summary.TieSwitches++
+		}
+	}
+	for _, r := range results {
+		if r.Secure {
+			summary.Secure++
+		}
+		if r.UnservedLoadKW > 0 {
+			summary.WithUnservedLoad++
+		}
+		if r.PostRestoration.VoltageViolations+r.PostRestoration.ThermalViolations > s.base.violations() {
+			summary.WithViolations++
+		}
+		if r.UnservedLoadKW > summary.MaxUnservedLoadKW {
+			summary.MaxUnservedLoadKW = r.UnservedLoadKW
+		}
+	}
+	if len(results) > 0 {
+		summary.WorstElementID = results[0].ElementID
+	}
+	summary.N1Secure = summary.Secure == summary.Contingencies
+	return summary
+}
@@ -0,0 +1,10 @@
+package contingency
+
+import "errors"
+
+var (
+	ErrInvalidOptions = errors.New("invalid contingency options")
+	ErrNoElements     = errors.New("no energized lines or transformers to analyze")
+	ErrMeshedNetwork  = errors.New("contingency analysis requires a radial network")
+	ErrBaseCase       = errors.New("base case power flow did not converge")
+)
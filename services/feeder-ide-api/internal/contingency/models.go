@@ -0,0 +1,116 @@
+// Package contingency 分析輻射狀 feeder 的 N-1 事故與開關重新配置
+//
+// N-1 事故分析逐一移除供電中的線路或變壓器，依序閉合常開聯絡開關（properties.is_closed 為 false 的 switch 節點）
+// 轉供失去供電的區域並重新執行潮流，依未供電負載與違規數排序事故。每次只閉合一端帶電、
+// 另一端停電的聯絡開關，因此轉供後網路仍為輻射狀。
+//
+// 重新配置以分支交換法尋找損失最低的開關狀態：閉合一個聯絡開關形成迴路後，
+// 開啟迴路上的另一個開關恢復輻射狀，重複套用最能降低損失的交換直到無法再改善。
+package contingency
+
+import "github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+
+// 事故元件類型
+const (
+	ElementLine        = "line"
+	ElementTransformer = "transformer"
+)
+
+// 未指定參數時使用的預設值
+const (
+	defaultMaxSwaps = 50
+	minImprovement  = 1e-3 // 交換至少需降低的損失（kW）
+	loadTolerance   = 1e-6
+)
+
+// Options N-1 事故分析參數
+type Options struct {
+	ElementIDs       []string          `json:"element_ids,omitempty"`       // 要分析的線路或變壓器節點，預設為所有供電中的線路與變壓器
+	SkipTransformers bool              `json:"skip_transformers,omitempty"` // 只分析線路事故
+	AllowViolations  bool              `json:"allow_violations,omitempty"`  // 允許轉供後增加電壓 / 熱容量違規
+	PowerFlow        powerflow.Options `json:"power_flow"`                  // 潮流參數（電壓上下限等）
+}
+
+// Result N-1 事故分析結果
+type Result struct {
+	Base          CaseSummary         `json:"base"`
+	Contingencies []ContingencyResult `json:"contingencies"` // 依嚴重程度排序
+	Summary       Summary             `json:"summary"`
+}
+
+// CaseSummary 單一運轉狀態的潮流摘要
+type CaseSummary struct {
+	Converged             bool    `json:"converged"`
+	ServedLoadKW          float64 `json:"served_load_kw"`
+	LossesKW              float64 `json:"losses_kw"`
+	MinVoltagePU          float64 `json:"min_voltage_pu"`
+	MaxVoltagePU          float64 `json:"max_voltage_pu"`
+	MaxLineLoadingPercent float64 `json:"max_line_loading_percent"`
+	VoltageViolations     int     `json:"voltage_violations"`
+	ThermalViolations     int     `json:"thermal_violations"`
+}
+
+// ContingencyResult 單一元件停用後的結果
+type ContingencyResult struct {
+	Rank                 int         `json:"rank"`
+	ElementID            string      `json:"element_id"`
+	ElementType          string      `json:"element_type"`
+	InterruptedLoadKW    float64     `json:"interrupted_load_kw"` // 事故後、轉供前失去供電的負載
+	InterruptedCustomers int         `json:"interrupted_customers"`
+	RestoredLoadKW       float64     `json:"restored_load_kw"`
+	UnservedLoadKW       float64     `json:"unserved_load_kw"`
+	UnservedCustomers    int         `json:"unserved_customers"`
+	ClosedSwitchIDs      []string    `json:"closed_switch_ids"`    // 轉供時依序閉合的聯絡開關
+	DeenergizedNodeIDs   []string    `json:"deenergized_node_ids"` // 轉供後仍停電的節點
+	Secure               bool        `json:"secure"`               // 無未供電負載且違規未增加
+	PostRestoration      CaseSummary `json:"post_restoration"`
+}
+
+// Summary N-1 整體統計
+type Summary struct {
+	Contingencies     int     `json:"contingencies"`
+	Secure            int     `json:"secure"`
+	WithUnservedLoad  int     `json:"with_unserved_load"`
+	WithViolations    int     `json:"with_violations"` // 轉供後違規多於基準情境
+	TieSwitches       int     `json:"tie_switches"`
+	MaxUnservedLoadKW float64 `json:"max_unserved_load_kw"`
+	WorstElementID    string  `json:"worst_element_id,omitempty"`
+	N1Secure          bool    `json:"n1_secure"` // 所有事故皆可完全轉供且無新增違規
+}
+
+// ProgressFunc 每完成一個事故時呼叫
+type ProgressFunc func(done, total int)
+
+// ReconfigurationOptions 重新配置參數
+type ReconfigurationOptions struct {
+	LockedSwitchIDs []string          `json:"locked_switch_ids,omitempty"` // 不可改變狀態的開關
+	MaxSwaps        int               `json:"max_swaps,omitempty"`         // 最多套用的交換次數（預設 50）
+	AllowViolations bool              `json:"allow_violations,omitempty"`  // 允許增加電壓 / 熱容量違規
+	PowerFlow       powerflow.Options `json:"power_flow"`                  // 潮流參數
+}
+
+// ReconfigurationResult 重新配置結果
+type ReconfigurationResult struct {
+	Initial              CaseSummary   `json:"initial"`
+	Final                CaseSummary   `json:"final"`
+	LossReductionKW      float64       `json:"loss_reduction_kw"`
+	LossReductionPercent float64       `json:"loss_reduction_percent"`
+	Swaps                []Swap        `json:"swaps"`    // 依序套用的開關交換
+	Switches             []SwitchState `json:"switches"` // 所有開關的最終狀態
+	PowerFlowsExecuted   int           `json:"power_flows_executed"`
+}
+
+// Swap 一次分支交換
+type Swap struct {
+	ClosedSwitchID string  `json:"closed_switch_id"`
+	OpenedSwitchID string  `json:"opened_switch_id"`
+	LossesKW       float64 `json:"losses_kw"` // 交換後的總損失
+}
+
+// SwitchState 開關狀態
+type SwitchState struct {
+	NodeID          string `json:"node_id"`
+	InitiallyClosed bool   `json:"initially_closed"`
+	Closed          bool   `json:"closed"`
+	Locked          bool   `json:"locked,omitempty"`
+}
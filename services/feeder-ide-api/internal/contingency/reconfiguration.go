@@ -0,0 +1,156 @@
+package contingency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Reconfigure 以分支交換法尋找損失最低且維持輻射狀的開關狀態
+// 交換不可使任何負載停電；未允許違規時也不可增加電壓 / 熱容量違規數
+func Reconfigure(ctx context.Context, t *topology.Topology, opts ReconfigurationOptions) (*ReconfigurationResult, error) {
+	if opts.MaxSwaps < 0 {
+		return nil, fmt.Errorf("%w: max_swaps must not be negative", ErrInvalidOptions)
+	}
+	if opts.MaxSwaps == 0 {
+		opts.MaxSwaps = defaultMaxSwaps
+	}
+
+	initialStates := t.SwitchStates()
+	locked := make(map[string]bool, len(opts.LockedSwitchIDs))
+	for _, id := range opts.LockedSwitchIDs {
+		if _, exists := initialStates[id]; !exists {
+			return nil, fmt.Errorf("%w: %s is not a switch", ErrInvalidOptions, id)
+		}
+		locked[id] = true
+	}
+
+	current, err := solveCase(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if !current.result.Converged {
+		return nil, ErrBaseCase
+	}
+	initial := current
+	executed := 1
+
+	states := make(map[string]bool, len(initialStates))
+	for id, closed := range initialStates {
+		states[id] = closed
+	}
+
+	swaps := []Swap{}
+	for len(swaps) < opts.MaxSwaps {
+		var best *operatingCase
+		var bestSwap Swap
+		n := current.network
+		for _, tie := range n.Buses {
+			if !tie.OpenSwitch || !tie.Energized || locked[tie.NodeID] || tie.Index == n.Source {
+				continue
+			}
+			live, _ := liveNeighbors(n, tie.Index)
+			if len(live) != 2 {
+				continue
+			}
+
+			for _, open := range loopSwitches(n, live[0], live[1]) {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				openID := n.Buses[open].NodeID
+				if locked[openID] {
+					continue
+				}
+
+				states[tie.NodeID], states[openID] = true, false
+				candidate, err := solveCase(t.WithSwitchStates(states), opts.PowerFlow)
+				states[tie.NodeID], states[openID] = false, true
+				executed++
+				if err == ErrMeshedNetwork {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				if !candidate.result.Converged || candidate.servedLoadKW() < current.servedLoadKW()-loadTolerance {
+					continue
+				}
+				if !opts.AllowViolations && candidate.violations() > current.violations() {
+					continue
+				}
+
+				losses := candidate.result.Summary.TotalLossesKW
+				if losses > current.result.Summary.TotalLossesKW-minImprovement {
+					continue
+				}
+				if best == nil || losses < best.result.Summary.TotalLossesKW {
+					best = candidate
+					bestSwap = Swap{ClosedSwitchID: tie.NodeID, OpenedSwitchID: openID, LossesKW: round(losses, 3)}
+				}
+			}
+		}
+
+		if best == nil {
+			break
+		}
+		states[bestSwap.ClosedSwitchID], states[bestSwap.OpenedSwitchID] = true, false
+		swaps = append(swaps, bestSwap)
+		current = best
+	}
+
+	result := &ReconfigurationResult{
+		Initial:            initial.summary(),
+		Final:              current.summary(),
+		Swaps:              swaps,
+		Switches:           make([]SwitchState, 0, len(states)),
+		PowerFlowsExecuted: executed,
+	}
+	initialLosses := initial.result.Summary.TotalLossesKW
+	reduction := initialLosses - current.result.Summary.TotalLossesKW
+	result.LossReductionKW = round(reduction, 3)
+	if initialLosses > 0 {
+		result.LossReductionPercent = round(reduction/initialLosses*100, 2)
+	}
+	for id, closed := range states {
+		result.Switches = append(result.Switches, SwitchState{
+			NodeID:          id,
+			InitiallyClosed: initialStates[id],
+			Closed:          closed,
+			Locked:          locked[id],
+		})
+	}
+	sort.Slice(result.Switches, func(i, j int) bool {
+		return result.Switches[i].NodeID < result.Switches[j].NodeID
+	})
+	return result, nil
+}
+
+// loopSwitches 取得輻射樹中 a 到 b 路徑上閉合的開關匯流排（閉合聯絡開關後形成的迴路）
+func loopSwitches(n *powerflow.Network, a, b int) []int {
+	var path []int
+	for a != b {
+		if n.Buses[a].Depth >= n.Buses[b].Depth {
+			path = append(path, a)
+			a = n.Buses[a].Parent
+		} else {
+			path = append(path, b)
+			b = n.Buses[b].Parent
+		}
+		if a < 0 || b < 0 {
+			return nil
+		}
+	}
+	path = append(path, a)
+
+	switches := []int{}
+	for _, bus := range path {
+		if n.Buses[bus].Type == topology.NodeTypeSwitch && !n.Buses[bus].OpenSwitch && bus != n.Source {
+			switches = append(switches, bus)
+		}
+	}
+	return switches
+}
@@ -0,0 +1,87 @@
+package contingency
+
+import (
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// withoutElement 回傳移除線路（或變壓器所有連接線路）後的拓樸副本
+func withoutElement(t *topology.Topology, elementType, id string) *topology.Topology {
+	copied := *t
+	copied.Lines = make([]topology.Line, 0, len(t.Lines))
+	for _, line := range t.Lines {
+		switch {
+		case elementType == ElementLine && line.ID == id:
+			continue
+		case elementType == ElementTransformer && (line.FromNodeID == id || line.ToNodeID == id):
+			continue
+		}
+		copied.Lines = append(copied.Lines, line)
+	}
+	return &copied
+}
+
+// operatingCase 一個運轉狀態的網路與潮流結果
+type operatingCase struct {
+	network *powerflow.Network
+	result  *powerflow.Result
+}
+
+// solveCase 建立網路並執行潮流
+func solveCase(t *topology.Topology, opts powerflow.Options) (*operatingCase, error) {
+	n, err := powerflow.BuildNetwork(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	if n.Meshed {
+		return nil, ErrMeshedNetwork
+	}
+	result, err := powerflow.SolveNetwork(n, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &operatingCase{network: n, result: result}, nil
+}
+
+func (c *operatingCase) servedLoadKW() float64 {
+	return c.result.Summary.TotalLoadKW
+}
+
+func (c *operatingCase) violations() int {
+	return c.result.Summary.VoltageViolations + c.result.Summary.ThermalViolations
+}
+
+func (c *operatingCase) summary() CaseSummary {
+	s := c.result.Summary
+	return CaseSummary{
+		Converged:             c.result.Converged,
+		ServedLoadKW:          round(s.TotalLoadKW, 2),
+		LossesKW:              round(s.TotalLossesKW, 3),
+		MinVoltagePU:          round(s.MinVoltagePU, 4),
+		MaxVoltagePU:          round(s.MaxVoltagePU, 4),
+		MaxLineLoadingPercent: round(s.MaxLineLoadingPercent, 2),
+		VoltageViolations:     s.VoltageViolations,
+		ThermalViolations:     s.ThermalViolations,
+	}
+}
+
+// liveNeighbors 計算開關節點相鄰的帶電（且非開路）匯流排與停電匯流排
+func liveNeighbors(n *powerflow.Network, bus int) (live []int, dead []int) {
+	for _, bi := range n.BranchesAt(bus) {
+		other := n.Buses[n.Branches[bi].Other(bus)]
+		switch {
+		case !other.Energized:
+			dead = append(dead, other.Index)
+		case !other.OpenSwitch:
+			live = append(live, other.Index)
+		}
+	}
+	return live, dead
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,674 @@
+package flisr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/google/uuid"
+)
+
+// Simulate 產生單一線路故障的 FLISR 開關操作計畫，不會修改拓樸
+func Simulate(t *topology.Topology, opts Options) (*Plan, error) {
+	opts = opts.withDefaults()
+	if err := validate(opts); err != nil {
+		return nil, err
+	}
+
+	n, err := powerflow.BuildNetwork(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if n.Meshed {
+		return nil, ErrMeshedNetwork
+	}
+	base, err := powerflow.SolveNetwork(n, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if !base.Converged {
+		return nil, ErrBaseCase
+	}
+
+	var fault *powerflow.Branch
+	for _, branch := range n.Branches {
+		if branch.LineID == opts.FaultLineID {
+			fault = branch
+			break
+		}
+	}
+	if fault == nil || !fault.InTree {
+		return nil, fmt.Errorf("%w: fault line %s is not an energized line", ErrInvalidOptions, opts.FaultLineID)
+	}
+
+	e := newEngine(t, n, base, opts)
+	return e.run(fault)
+}
+
+func validate(opts Options) error {
+	if opts.FaultLineID == "" {
+		return fmt.Errorf("%w: fault_line_id is required", ErrInvalidOptions)
+	}
+	if opts.MaxLoadingPercent > 1000 {
+		return fmt.Errorf("%w: max_loading_percent must not exceed 1000", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// state 一組開關狀態下的網路與潮流結果
+type state struct {
+	network   *powerflow.Network
+	result    *powerflow.Result
+	energized []bool
+}
+
+// engine 計畫產生過程中的狀態，陣列皆以故障前網路的匯流排索引對應
+type engine struct {
+	topology      *topology.Topology
+	network       *powerflow.Network
+	opts          Options
+	nodes         []topology.Node
+	customers     []int
+	base          []bool // 故障前帶電
+	switches      map[string]bool
+	sourceOpen    bool
+	manual        bool // 人工階段：非自動化開關也可操作
+	protective    int
+	faultUpstream int          // 故障線路的上游端
+	boundary      map[int]bool // 目前的隔離邊界
+	locked        map[int]bool // 復電過程中已操作的開關
+	faulted       map[int]bool
+	current       *state
+	plan          *Plan
+	elapsed       float64
+}
+
+func newEngine(t *topology.Topology, n *powerflow.Network, base *powerflow.Result, opts Options) *engine {
+	e := &engine{
+		topology:  t,
+		network:   n,
+		opts:      opts,
+		nodes:     make([]topology.Node, len(n.Buses)),
+		customers: make([]int, len(n.Buses)),
+		base:      make([]bool, len(n.Buses)),
+		switches:  t.SwitchStates(),
+		locked:    make(map[int]bool),
+		faulted:   make(map[int]bool),
+		current:   &state{network: n, result: base},
+		plan: &Plan{
+			ID:                     uuid.New().String(),
+			TopologyID:             t.ID,
+			FaultLineID:            opts.FaultLineID,
+			DownstreamIsolationIDs: []string{},
+			FaultedSectionNodeIDs:  []string{},
+			Steps:                  []Step{},
+			CreatedAt:              time.Now(),
+		},
+	}
+
+	for _, node := range t.Nodes {
+		index, exists := n.BusIndex(node.ID)
+		if !exists || e.nodes[index].ID != "" {
+			continue
+		}
+		e.nodes[index] = node
+		bus := n.Buses[index]
+		e.base[index] = bus.Energized
+		defaultCustomers := 0.0
+		if bus.Demand.LoadKW > 0 {
+			defaultCustomers = 1
+		}
+		e.customers[index] = int(math.Round(topology.FloatProperty(node.Properties, "customers", defaultCustomers)))
+	}
+	e.current.energized = e.base
+	return e
+}
+
+func (e *engine) run(fault *powerflow.Branch) (*Plan, error) {
+	n := e.network
+	plan := e.plan
+	e.faultUpstream = n.Buses[fault.Downstream].Parent
+
+	// 保護設備跳脫，其下游全部停電
+	e.protective = e.protectiveDevice(n.Buses[fault.Downstream].Parent)
+	plan.ProtectiveDeviceID = n.Buses[e.protective].NodeID
+	energized := append([]bool(nil), e.base...)
+	for _, bus := range e.subtree(e.protective) {
+		energized[bus] = false
+	}
+	if e.protective == n.Source {
+		e.sourceOpen = true
+	} else {
+		e.switches[plan.ProtectiveDeviceID] = false
+	}
+	e.current = &state{energized: energized}
+	e.addStep(PhaseProtection, ActionTrip, e.protective, true, 0,
+		fmt.Sprintf("%s %s operates on fault at line %s", e.deviceKind(e.protective), plan.ProtectiveDeviceID, fault.LineID))
+	plan.Summary.CustomersInterrupted, plan.Summary.LoadInterruptedKW = e.outage(energized)
+
+	// 先以自動化開關完成隔離與復電，允許人工時再由現場人員縮小故障區段
+	e.elapsed = e.opts.FaultLocationSeconds
+	if err := e.stage(fault); err != nil {
+		return nil, err
+	}
+	if e.opts.AllowManual {
+		// 人工階段沒有恢復任何用戶時不派遣人員操作
+		checkpoint := e.checkpoint()
+		e.manual = true
+		if err := e.stage(fault); err != nil {
+			return nil, err
+		}
+		if out, _ := e.outage(e.current.energized); out >= checkpoint.customersOut {
+			e.rollback(checkpoint)
+		}
+	}
+
+	return e.finish(), nil
+}
+
+// stage 隔離故障區段、重新投入保護設備並轉供其餘停電區域
+func (e *engine) stage(fault *powerflow.Branch) error {
+	n := e.network
+	plan := e.plan
+
+	upstream, downstream := e.isolate(fault)
+	plan.UpstreamIsolationID = n.Buses[upstream].NodeID
+	plan.DownstreamIsolationIDs = []string{}
+	if upstream != e.protective && e.switches[plan.UpstreamIsolationID] {
+		e.switches[plan.UpstreamIsolationID] = false
+		e.addStep(PhaseIsolation, ActionOpen, upstream, e.automated(upstream), 0,
+			fmt.Sprintf("Open %s upstream of the faulted section", plan.UpstreamIsolationID))
+	}
+	for _, bus := range downstream {
+		id := n.Buses[bus].NodeID
+		plan.DownstreamIsolationIDs = append(plan.DownstreamIsolationIDs, id)
+		if e.switches[id] {
+			e.switches[id] = false
+			e.addStep(PhaseIsolation, ActionOpen, bus, e.automated(bus), 0,
+				fmt.Sprintf("Open %s downstream of the faulted section", id))
+		}
+	}
+
+	// 重新投入保護設備恢復上游供電（熔絲需人員更換）
+	protectiveOpen := e.sourceOpen || (e.protective != n.Source && !e.switches[plan.ProtectiveDeviceID])
+	if upstream != e.protective && protectiveOpen {
+		automated := e.protective == n.Source || (e.deviceType(e.protective) != "fuse" && e.automated(e.protective))
+		if automated || e.manual {
+			if e.protective == n.Source {
+				e.sourceOpen = false
+			} else {
+				e.switches[plan.ProtectiveDeviceID] = true
+			}
+			next, err := e.evaluate(e.switches)
+			if err != nil {
+				return err
+			}
+			action := "Close"
+			if e.deviceType(e.protective) == "fuse" {
+				action = "Replace fuse and close"
+			}
+			e.apply(next, PhaseUpstreamRestoration, ActionClose, e.protective, automated,
+				fmt.Sprintf("%s %s to restore the upstream section", action, plan.ProtectiveDeviceID))
+		}
+	}
+
+	if e.sourceOpen {
+		return nil
+	}
+	return e.restore()
+}
+
+// checkpoint 回復人工階段前的狀態所需的資料
+type checkpoint struct {
+	plan         Plan
+	switches     map[string]bool
+	current      *state
+	elapsed      float64
+	faulted      map[int]bool
+	boundary     map[int]bool
+	locked       map[int]bool
+	customersOut int
+}
+
+func (e *engine) checkpoint() checkpoint {
+	c := checkpoint{
+		plan:     *e.plan,
+		switches: make(map[string]bool, len(e.switches)),
+		current:  e.current,
+		elapsed:  e.elapsed,
+		faulted:  e.faulted,
+		boundary: e.boundary,
+		locked:   make(map[int]bool, len(e.locked)),
+	}
+	for id, closed := range e.switches {
+		c.switches[id] = closed
+	}
+	for bus := range e.locked {
+		c.locked[bus] = true
+	}
+	c.customersOut, _ = e.outage(e.current.energized)
+	return c
+}
+
+func (e *engine) rollback(c checkpoint) {
+	*e.plan = c.plan
+	e.switches = c.switches
+	e.current = c.current
+	e.elapsed = c.elapsed
+	e.faulted = c.faulted
+	e.boundary = c.boundary
+	e.locked = c.locked
+	e.manual = false
+}
+
+// protectiveDevice 由 bus 往電源方向找第一個保護設備，沒有時回傳電源
+func (e *engine) protectiveDevice(bus int) int {
+	for ; bus >= 0; bus = e.network.Buses[bus].Parent {
+		switch e.deviceType(bus) {
+		case "breaker", "recloser", "fuse":
+			return bus
+		}
+	}
+	return e.network.Source
+}
+
+// isolate 以故障線路為起點擴展，遇到可操作的閉合開關或保護設備即為邊界
+// 回傳上游邊界與下游邊界，並重新記錄故障區段
+func (e *engine) isolate(fault *powerflow.Branch) (int, []int) {
+	n := e.network
+	upstream := e.protective
+	var downstream []int
+	e.faulted = make(map[int]bool)
+	e.boundary = make(map[int]bool)
+	e.plan.FaultedSectionNodeIDs = []string{}
+
+	visited := map[int]bool{}
+	queue := []int{n.Buses[fault.Downstream].Parent, fault.Downstream}
+	for len(queue) > 0 {
+		bus := queue[0]
+		queue = queue[1:]
+		if bus < 0 || visited[bus] {
+			continue
+		}
+		visited[bus] = true
+
+		b := n.Buses[bus]
+		if b.OpenSwitch {
+			continue
+		}
+		if bus == e.protective || (e.eligible(bus) && bus != n.Source) {
+			e.boundary[bus] = true
+			if e.isAncestor(bus, e.faultUpstream) {
+				upstream = bus
+			} else {
+				downstream = append(downstream, bus)
+			}
+			continue
+		}
+
+		e.faulted[bus] = true
+		e.plan.FaultedSectionNodeIDs = append(e.plan.FaultedSectionNodeIDs, b.NodeID)
+		queue = append(queue, b.Parent)
+		queue = append(queue, b.Children...)
+	}
+
+	sort.Slice(downstream, func(i, j int) bool {
+		return n.Buses[downstream[i]].NodeID < n.Buses[downstream[j]].NodeID
+	})
+	sort.Strings(e.plan.FaultedSectionNodeIDs)
+	return upstream, downstream
+}
+
+// candidate 一組轉供操作
+type candidate struct {
+	open      int // 為降低轉供負載而開啟的開關，-1 表示不需要
+	tie       int
+	next      *state
+	customers int
+	loadKW    float64
+}
+
+// restore 重複選擇恢復最多用戶的可行操作，直到沒有一側帶電、另一側停電的可操作開關
+// 包含常開聯絡開關，以及人工階段縮小故障區段後不再是邊界的隔離開關
+func (e *engine) restore() error {
+	if e.current.network == nil {
+		next, err := e.evaluate(e.switches)
+		if err != nil {
+			return err
+		}
+		e.current = next
+	}
+	for {
+		var best *candidate
+		n := e.current.network
+		for _, tie := range n.Buses {
+			if !tie.OpenSwitch || !tie.Energized || !e.operable(tie.Index) {
+				continue
+			}
+			live, dead, touchesFault := e.neighbors(n, tie.Index)
+			if live != 1 || dead == 0 || touchesFault {
+				continue
+			}
+
+			options, err := e.transfers(tie.Index)
+			if err != nil {
+				return err
+			}
+			for _, option := range options {
+				if best == nil || option.better(best) {
+					best = option
+				}
+			}
+		}
+		if best == nil {
+			return nil
+		}
+
+		tieID := e.network.Buses[best.tie].NodeID
+		if best.open >= 0 {
+			openID := e.network.Buses[best.open].NodeID
+			e.switches[openID] = false
+			e.locked[best.open] = true
+			e.addStep(PhaseDownstreamRestoration, ActionOpen, best.open, e.automated(best.open), 0,
+				fmt.Sprintf("Open %s to limit the load transferred through %s", openID, tieID))
+		}
+		e.switches[tieID] = true
+		e.locked[best.tie] = true
+
+		phase, description := PhaseDownstreamRestoration, fmt.Sprintf("Close tie switch %s to restore the downstream section", tieID)
+		if e.base[best.tie] && !e.network.Buses[best.tie].OpenSwitch {
+			description = fmt.Sprintf("Close %s to restore the section released from the faulted area", tieID)
+			if e.isAncestor(best.tie, e.faultUpstream) {
+				phase = PhaseUpstreamRestoration
+			}
+		} else {
+			e.plan.Summary.TieSwitchesClosed++
+		}
+		e.apply(best.next, phase, ActionClose, best.tie, e.automated(best.tie), description)
+	}
+}
+
+// transfers 評估閉合聯絡開關的轉供方案；超出限制時嘗試在轉供區段內再開啟一個開關
+func (e *engine) transfers(tie int) ([]*candidate, error) {
+	tieID := e.network.Buses[tie].NodeID
+	full, err := e.evaluate(e.with(map[string]bool{tieID: true}))
+	if err != nil {
+		return nil, err
+	}
+	option := e.candidate(-1, tie, full)
+	if option == nil {
+		return nil, nil
+	}
+	if e.feasible(full) {
+		return []*candidate{option}, nil
+	}
+
+	var options []*candidate
+	for bus, energized := range full.energized {
+		if !energized || e.current.energized[bus] || bus == tie || !e.operable(bus) || !e.switches[e.network.Buses[bus].NodeID] {
+			continue
+		}
+		partial, err := e.evaluate(e.with(map[string]bool{tieID: true, e.network.Buses[bus].NodeID: false}))
+		if err != nil {
+			return nil, err
+		}
+		if option := e.candidate(bus, tie, partial); option != nil && e.feasible(partial) {
+			options = append(options, option)
+		}
+	}
+	return options, nil
+}
+
+func (e *engine) candidate(open, tie int, next *state) *candidate {
+	if next.result == nil || !next.result.Converged {
+		return nil
+	}
+	c := &candidate{open: open, tie: tie, next: next}
+	for bus, energized := range next.energized {
+		if energized && e.base[bus] && !e.current.energized[bus] {
+			c.customers += e.customers[bus]
+			c.loadKW += e.network.Buses[bus].Demand.LoadKW
+		}
+	}
+	if c.customers == 0 && c.loadKW == 0 {
+		return nil
+	}
+	return c
+}
+
+// better 恢復用戶較多者優先，其次為負載較大、操作較少
+func (c *candidate) better(other *candidate) bool {
+	if c.customers != other.customers {
+		return c.customers > other.customers
+	}
+	if c.loadKW != other.loadKW {
+		return c.loadKW > other.loadKW
+	}
+	return c.open < 0 && other.open >= 0
+}
+
+// feasible 轉供後的違規數不得多於轉供前
+func (e *engine) feasible(next *state) bool {
+	return e.violations(next.result) <= e.violations(e.current.result)
+}
+
+// violations 計算超出電壓上下限或負載率上限的節點與線路
+func (e *engine) violations(result *powerflow.Result) int {
+	if result == nil {
+		return 0
+	}
+	count := 0
+	for _, node := range result.Nodes {
+		if !node.Energized {
+			continue
+		}
+		if node.VoltagePU < e.opts.PowerFlow.VoltageMinPU || node.VoltagePU > e.opts.PowerFlow.VoltageMaxPU || node.LoadingPercent > e.opts.MaxLoadingPercent {
+			count++
+		}
+	}
+	for _, line := range result.Lines {
+		if line.Energized && line.LoadingPercent > e.opts.MaxLoadingPercent {
+			count++
+		}
+	}
+	return count
+}
+
+// with 回傳目前開關狀態加上指定變更
+func (e *engine) with(changes map[string]bool) map[string]bool {
+	states := make(map[string]bool, len(e.switches))
+	for id, closed := range e.switches {
+		states[id] = closed
+	}
+	for id, closed := range changes {
+		states[id] = closed
+	}
+	return states
+}
+
+// evaluate 以指定開關狀態執行潮流
+func (e *engine) evaluate(switches map[string]bool) (*state, error) {
+	n, err := powerflow.BuildNetwork(e.topology.WithSwitchStates(switches), e.opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if n.Meshed {
+		return nil, ErrMeshedNetwork
+	}
+	result, err := powerflow.SolveNetwork(n, e.opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	s := &state{network: n, result: result, energized: make([]bool, len(n.Buses))}
+	for i, bus := range n.Buses {
+		s.energized[i] = bus.Energized && !e.faulted[i]
+	}
+	return s, nil
+}
+
+// apply 套用新狀態並記錄操作與恢復的用戶
+func (e *engine) apply(next *state, phase, action string, bus int, automated bool, description string) {
+	restored := 0
+	var restoredKW float64
+	for i, energized := range next.energized {
+		if energized && e.base[i] && !e.current.energized[i] {
+			restored += e.customers[i]
+			restoredKW += e.network.Buses[i].Demand.LoadKW
+		}
+	}
+	e.current = next
+	e.addStep(phase, action, bus, automated, restored, description)
+	e.plan.Steps[len(e.plan.Steps)-1].LoadRestoredKW = round(restoredKW, 2)
+}
+
+func (e *engine) addStep(phase, action string, bus int, automated bool, restored int, description string) {
+	duration := 0.0
+	if action != ActionTrip {
+		duration = e.opts.AutomatedSwitchSeconds
+		if !automated {
+			duration = e.opts.ManualSwitchMinutes * 60
+		}
+		if automated {
+			e.plan.Summary.AutomatedOperations++
+		} else {
+			e.plan.Summary.ManualOperations++
+		}
+	}
+	e.elapsed += duration
+	out, _ := e.outage(e.current.energized)
+	e.plan.Steps = append(e.plan.Steps, Step{
+		Sequence:          len(e.plan.Steps) + 1,
+		Phase:             phase,
+		Action:            action,
+		SwitchID:          e.network.Buses[bus].NodeID,
+		Automated:         automated,
+		DurationSeconds:   duration,
+		ElapsedSeconds:    e.elapsed,
+		CustomersRestored: restored,
+		CustomersOut:      out,
+		Description:       description,
+	})
+}
+
+func (e *engine) finish() *Plan {
+	plan := e.plan
+	summary := &plan.Summary
+	out, outKW := e.outage(e.current.energized)
+	summary.CustomersAwaitingRepair = out
+	summary.LoadAwaitingRepairKW = round(outKW, 2)
+	summary.CustomersRestored = summary.CustomersInterrupted - out
+	summary.LoadRestoredKW = round(summary.LoadInterruptedKW-outKW, 2)
+	summary.LoadInterruptedKW = round(summary.LoadInterruptedKW, 2)
+	for _, step := range plan.Steps {
+		if step.CustomersRestored > 0 || step.LoadRestoredKW > 0 {
+			summary.RestorationSeconds = step.ElapsedSeconds
+		}
+	}
+
+	if result := e.current.result; result != nil && !e.sourceOpen {
+		plan.PostRestoration = Network{
+			Converged:             result.Converged,
+			MinVoltagePU:          round(result.Summary.MinVoltagePU, 4),
+			MaxVoltagePU:          round(result.Summary.MaxVoltagePU, 4),
+			MaxLineLoadingPercent: round(result.Summary.MaxLineLoadingPercent, 2),
+			LossesKW:              round(result.Summary.TotalLossesKW, 3),
+			Violations:            e.violations(result),
+		}
+	}
+	return plan
+}
+
+// outage 故障前帶電但目前停電的用戶數與負載
+func (e *engine) outage(energized []bool) (int, float64) {
+	customers := 0
+	var loadKW float64
+	for bus, wasEnergized := range e.base {
+		if wasEnergized && !energized[bus] {
+			customers += e.customers[bus]
+			loadKW += e.network.Buses[bus].Demand.LoadKW
+		}
+	}
+	return customers, loadKW
+}
+
+// neighbors 統計開關兩側的帶電與停電匯流排，以及是否與故障區段相鄰
+func (e *engine) neighbors(n *powerflow.Network, bus int) (live, dead int, touchesFault bool) {
+	for _, bi := range n.BranchesAt(bus) {
+		other := n.Buses[n.Branches[bi].Other(bus)]
+		if e.faulted[other.Index] {
+			touchesFault = true
+		}
+		switch {
+		case !other.Energized:
+			dead++
+		case !other.OpenSwitch:
+			live++
+		}
+	}
+	return live, dead, touchesFault
+}
+
+// subtree 故障前輻射樹中以 bus 為根的子樹
+func (e *engine) subtree(root int) []int {
+	buses := []int{root}
+	for i := 0; i < len(buses); i++ {
+		buses = append(buses, e.network.Buses[buses[i]].Children...)
+	}
+	return buses
+}
+
+// isAncestor ancestor 是否位於 bus 往電源的路徑上
+func (e *engine) isAncestor(ancestor, bus int) bool {
+	for ; bus >= 0; bus = e.network.Buses[bus].Parent {
+		if bus == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *engine) deviceType(bus int) string {
+	node := e.nodes[bus]
+	if node.Type != topology.NodeTypeSwitch {
+		return ""
+	}
+	return topology.StringProperty(node.Properties, "type", "")
+}
+
+func (e *engine) deviceKind(bus int) string {
+	if bus == e.network.Source {
+		return "Substation breaker at"
+	}
+	switch kind := e.deviceType(bus); kind {
+	case "breaker":
+		return "Breaker"
+	case "recloser":
+		return "Recloser"
+	case "fuse":
+		return "Fuse"
+	default:
+		return "Switch"
+	}
+}
+
+func (e *engine) automated(bus int) bool {
+	return topology.BoolProperty(e.nodes[bus].Properties, "is_automated", false)
+}
+
+// eligible 開關是否可用於隔離與轉供
+func (e *engine) eligible(bus int) bool {
+	return e.nodes[bus].Type == topology.NodeTypeSwitch && (e.automated(bus) || e.manual)
+}
+
+// operable 開關可用於轉供：不是隔離邊界，也未在復電過程中操作過
+func (e *engine) operable(bus int) bool {
+	return e.eligible(bus) && !e.boundary[bus] && !e.locked[bus]
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
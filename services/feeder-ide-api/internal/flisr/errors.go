@@ -0,0 +1,11 @@
+package flisr
+
+import "errors"
+
+var (
+	ErrInvalidOptions    = errors.New("invalid flisr options")
+	ErrMeshedNetwork     = errors.New("flisr requires a radial network")
+	ErrBaseCase          = errors.New("base case power flow did not converge")
+	ErrPublishFailed     = errors.New("failed to publish switching commands")
+	ErrPublisherDisabled = errors.New("feeder os controller is not configured")
+)
@@ -0,0 +1,151 @@
+// Package flisr 模擬輻射狀 feeder 的故障定位、隔離與復電（FLISR）
+//
+// 線路故障時，上游最近的保護設備（斷路器、復閉器、熔絲，沒有時為變電所斷路器）先跳脫；
+// 接著開啟故障區段兩端最近的可操作開關（properties.is_automated 為 true，允許人工時也包含一般開關）隔離故障，
+// 重新投入保護設備恢復上游供電，再經由常開聯絡開關轉供下游健全區段。
+// 轉供以潮流檢查電壓與負載率限制，容量不足時嘗試在下游區段內再開啟一個開關只轉供部分負載。
+package flisr
+
+import (
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+)
+
+// 開關動作
+const (
+	ActionTrip  = "trip" // 保護設備自動跳脫 / 熔斷
+	ActionOpen  = "open"
+	ActionClose = "close"
+)
+
+// 階段
+const (
+	PhaseProtection            = "protection"
+	PhaseIsolation             = "isolation"
+	PhaseUpstreamRestoration   = "upstream_restoration"
+	PhaseDownstreamRestoration = "downstream_restoration"
+)
+
+// 未指定參數時使用的預設值
+const (
+	defaultFaultLocationSeconds   = 30.0 // 故障指示器回報與故障定位
+	defaultAutomatedSwitchSeconds = 10.0 // SCADA 遙控開關操作
+	defaultManualSwitchMinutes    = 45.0 // 人員到場並操作開關
+	defaultMaxLoadingPercent      = 100.0
+)
+
+// Options FLISR 參數
+type Options struct {
+	FaultLineID            string            `json:"fault_line_id"`
+	AllowManual            bool              `json:"allow_manual,omitempty"`             // 允許使用非自動化開關（以人工操作時間計算）
+	FaultLocationSeconds   float64           `json:"fault_location_seconds,omitempty"`   // 保護跳脫後到開始隔離的時間
+	AutomatedSwitchSeconds float64           `json:"automated_switch_seconds,omitempty"` // 每次自動化開關操作時間
+	ManualSwitchMinutes    float64           `json:"manual_switch_minutes,omitempty"`    // 每次人工開關操作時間
+	MaxLoadingPercent      float64           `json:"max_loading_percent,omitempty"`      // 轉供後線路與變壓器負載率上限
+	PowerFlow              powerflow.Options `json:"power_flow"`                         // 潮流參數（電壓上下限等）
+}
+
+// withDefaults 補上預設值
+func (o Options) withDefaults() Options {
+	if o.FaultLocationSeconds <= 0 {
+		o.FaultLocationSeconds = defaultFaultLocationSeconds
+	}
+	if o.AutomatedSwitchSeconds <= 0 {
+		o.AutomatedSwitchSeconds = defaultAutomatedSwitchSeconds
+	}
+	if o.ManualSwitchMinutes <= 0 {
+		o.ManualSwitchMinutes = defaultManualSwitchMinutes
+	}
+	if o.MaxLoadingPercent <= 0 {
+		o.MaxLoadingPercent = defaultMaxLoadingPercent
+	}
+	if o.PowerFlow.VoltageMinPU <= 0 {
+		o.PowerFlow.VoltageMinPU = 0.95
+	}
+	if o.PowerFlow.VoltageMaxPU <= 0 {
+		o.PowerFlow.VoltageMaxPU = 1.05
+	}
+	return o
+}
+
+// Plan 開關操作計畫
+type Plan struct {
+	ID                     string    `json:"id"`
+	TopologyID             string    `json:"topology_id"`
+	FaultLineID            string    `json:"fault_line_id"`
+	ProtectiveDeviceID     string    `json:"protective_device_id"`
+	UpstreamIsolationID    string    `json:"upstream_isolation_id"`    // 故障區段上游邊界（未另行隔離時為保護設備）
+	DownstreamIsolationIDs []string  `json:"downstream_isolation_ids"` // 故障區段下游邊界開關
+	FaultedSectionNodeIDs  []string  `json:"faulted_section_node_ids"` // 需等待修復的區段
+	Steps                  []Step    `json:"steps"`
+	PostRestoration        Network   `json:"post_restoration"`
+	Summary                Summary   `json:"summary"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// Step 單一開關操作
+type Step struct {
+	Sequence          int     `json:"sequence"`
+	Phase             string  `json:"phase"`
+	Action            string  `json:"action"`
+	SwitchID          string  `json:"switch_id"`
+	Automated         bool    `json:"automated"`
+	DurationSeconds   float64 `json:"duration_seconds"`
+	ElapsedSeconds    float64 `json:"elapsed_seconds"` // 故障發生至此操作完成的時間
+	CustomersRestored int     `json:"customers_restored"`
+	LoadRestoredKW    float64 `json:"load_restored_kw"`
+	CustomersOut      int     `json:"customers_out"` // 此操作完成後仍停電的用戶數
+	Description       string  `json:"description"`
+}
+
+// Network 復電後的網路狀態
+type Network struct {
+	Converged             bool    `json:"converged"`
+	MinVoltagePU          float64 `json:"min_voltage_pu"`
+	MaxVoltagePU          float64 `json:"max_voltage_pu"`
+	MaxLineLoadingPercent float64 `json:"max_line_loading_percent"`
+	LossesKW              float64 `json:"losses_kw"`
+	Violations            int     `json:"violations"` // 超出電壓上下限或負載率上限的節點與線路數
+}
+
+// Summary 計畫統計
+type Summary struct {
+	CustomersInterrupted    int     `json:"customers_interrupted"`
+	CustomersRestored       int     `json:"customers_restored"`
+	CustomersAwaitingRepair int     `json:"customers_awaiting_repair"`
+	LoadInterruptedKW       float64 `json:"load_interrupted_kw"`
+	LoadRestoredKW          float64 `json:"load_restored_kw"`
+	LoadAwaitingRepairKW    float64 `json:"load_awaiting_repair_kw"`
+	RestorationSeconds      float64 `json:"restoration_seconds"` // 最後一個操作完成的時間
+	AutomatedOperations     int     `json:"automated_operations"`
+	ManualOperations        int     `json:"manual_operations"`
+	TieSwitchesClosed       int     `json:"tie_switches_closed"`
+}
+
+// Command 發布至 feeder-os-controller 的開關命令
+type Command struct {
+	Sequence  int    `json:"sequence"`
+	AssetID   string `json:"asset_id"`
+	Action    string `json:"action"`
+	Phase     string `json:"phase"`
+	Automated bool   `json:"automated"` // false 表示需由現場人員執行
+}
+
+// Commands 將計畫轉換為開關命令（保護設備自動跳脫不需下令）
+func (p *Plan) Commands() []Command {
+	commands := []Command{}
+	for _, step := range p.Steps {
+		if step.Action == ActionTrip {
+			continue
+		}
+		commands = append(commands, Command{
+			Sequence:  len(commands) + 1,
+			AssetID:   step.SwitchID,
+			Action:    step.Action,
+			Phase:     step.Phase,
+			Automated: step.Automated,
+		})
+	}
+	return commands
+}
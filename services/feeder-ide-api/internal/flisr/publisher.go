@@ -0,0 +1,101 @@
+package flisr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const publishTimeout = 10 * time.Second
+
+// CommandPublisher 經由 feeder-os-controller 將開關命令發布到 feeder/<feeder_id>/commands/<asset_id>
+type CommandPublisher struct {
+	baseURL string
+	token   string // feeder-os-controller 的命令端點服務 token
+	client  *http.Client
+}
+
+// PublishRequest 送往 feeder-os-controller 的命令批次
+type PublishRequest struct {
+	Source   string    `json:"source"`
+	PlanID   string    `json:"plan_id"`
+	Commands []Command `json:"commands"`
+}
+
+// PublishResult 發布結果
+type PublishResult struct {
+	FeederID string   `json:"feeder_id"`
+	Commands int      `json:"commands"`
+	Topics   []string `json:"topics"`
+}
+
+// NewCommandPublisher 依 FEEDER_OS_URL 與 FEEDER_OS_COMMAND_TOKEN 建立發布器，任一未設定時回傳 nil
+func NewCommandPublisher() *CommandPublisher {
+	baseURL := strings.TrimRight(os.Getenv("FEEDER_OS_URL"), "/")
+	if baseURL == "" {
+		return nil
+	}
+	token := os.Getenv("FEEDER_OS_COMMAND_TOKEN")
+	if token == "" {
+		log.Printf("Warning: FEEDER_OS_URL is set but FEEDER_OS_COMMAND_TOKEN is not, FLISR publishing is disabled")
+		return nil
+	}
+	return &CommandPublisher{
+		baseURL: baseURL,
+		token:   token,
+		client:  &http.Client{Timeout: publishTimeout},
+	}
+}
+
+// Publish 以拓樸 ID 作為 feeder ID 發布計畫中的開關命令，沒有開關操作時不送出請求
+func (p *CommandPublisher) Publish(ctx context.Context, plan *Plan) (*PublishResult, error) {
+	commands := plan.Commands()
+	if len(commands) == 0 {
+		return &PublishResult{FeederID: plan.TopologyID, Topics: []string{}}, nil
+	}
+
+	payload, err := json.Marshal(PublishRequest{
+		Source:   "flisr",
+		PlanID:   plan.ID,
+		Commands: commands,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/feeders/%s/commands", p.baseURL, url.PathEscape(plan.TopologyID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPublishFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPublishFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("%w: feeder os controller returned %d: %s", ErrPublishFailed, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result PublishResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPublishFailed, err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,604 @@
+// Package generator 依 profile 特徵產生合成的輻射狀 feeder 拓樸
+//
+// 產生的 feeder 由變電所主變壓器、饋線斷路器、主幹線（中段設有復閉器）、
+// 由主幹分出的分歧線（起點設有分段開關）與配電變壓器組成；負載放在配電變壓器節點上，
+// DER 與 EV 充電樁以低壓線路接在配電變壓器下。相同的 profile、參數與 seed 會產生相同的拓樸。
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// 節點數上下限
+const (
+	MinNodeCount = 10
+	MaxNodeCount = 2000
+)
+
+// maxFeederLengthKM 饋線長度上限
+const maxFeederLengthKM = 500.0
+
+// ErrInvalidOptions 產生參數不合法
+var ErrInvalidOptions = errors.New("invalid generator options")
+
+// Options 產生參數，未指定的欄位使用 profile 的典型值
+type Options struct {
+	Seed           *int64   `json:"seed,omitempty"`             // 未指定時隨機產生，並回傳於結果中
+	Name           string   `json:"name,omitempty"`             // 拓樸名稱
+	NodeCount      int      `json:"node_count,omitempty"`       // 目標節點數（預設 TypicalNodeCount）
+	FeederLengthKM float64  `json:"feeder_length_km,omitempty"` // 主幹與分歧線總長（預設 TypicalFeederLength）
+	DERPenetration *float64 `json:"der_penetration,omitempty"`  // DER 容量佔負載比例（預設在 profile 範圍內抽樣）
+	EVPenetration  *float64 `json:"ev_penetration,omitempty"`   // 設有 EV 充電樁的配電變壓器比例（預設在 profile 範圍內抽樣）
+}
+
+// Summary 產生結果統計
+type Summary struct {
+	NodeCount           int                      `json:"node_count"`
+	LineCount           int                      `json:"line_count"`
+	ServiceTransformers int                      `json:"service_transformers"`
+	FeederLengthKM      float64                  `json:"feeder_length_km"`
+	TotalLoadKW         float64                  `json:"total_load_kw"`
+	DERCount            int                      `json:"der_count"`
+	DERCapacityKW       float64                  `json:"der_capacity_kw"`
+	DERPenetration      float64                  `json:"der_penetration"` // DER 容量 / 負載
+	EVChargerCount      int                      `json:"ev_charger_count"`
+	EVPenetration       float64                  `json:"ev_penetration"` // 設有 EV 充電樁的配電變壓器比例
+	LoadComposition     profiles.LoadComposition `json:"load_composition"`
+}
+
+// Result 產生結果
+type Result struct {
+	Topology *topology.Topology `json:"topology"`
+	Seed     int64              `json:"seed"`
+	Summary  Summary            `json:"summary"`
+}
+
+// feederClass 各 profile 的電氣設計參數
+type feederClass struct {
+	VoltageKV     float64
+	SubstationKVA float64
+	Trunk         topology.LineProperties // 主幹線導線（長度另計）
+	Lateral       topology.LineProperties // 分歧線導線
+	TrunkShare    float64                 // 主幹線佔饋線總長比例
+	MaxLoading    float64                 // 總負載上限（主變容量比例）
+	DERMix        []weighted              // DER 類型比例
+}
+
+type weighted struct {
+	Value  string
+	Weight float64
+}
+
+var feederClasses = map[string]feederClass{
+	"rural": {
+		VoltageKV:     24.9,
+		SubstationKVA: 10000,
+		Trunk:         topology.LineProperties{ROhmPerKM: 0.190, XOhmPerKM: 0.400, AmpacityA: 530},
+		Lateral:       topology.LineProperties{ROhmPerKM: 0.550, XOhmPerKM: 0.450, AmpacityA: 230},
+		TrunkShare:    0.6,
+		MaxLoading:    0.5,
+		DERMix:        []weighted{{"pv", 0.7}, {"wind", 0.2}, {"battery", 0.1}},
+	},
+	"suburban": {
+		VoltageKV:     12.47,
+		SubstationKVA: 20000,
+		Trunk:         topology.LineProperties{ROhmPerKM: 0.130, XOhmPerKM: 0.390, AmpacityA: 600},
+		Lateral:       topology.LineProperties{ROhmPerKM: 0.306, XOhmPerKM: 0.450, AmpacityA: 400},
+		TrunkShare:    0.55,
+		MaxLoading:    0.6,
+		DERMix:        []weighted{{"pv", 0.8}, {"battery", 0.2}},
+	},
+	"urban": {
+		VoltageKV:     12.47,
+		SubstationKVA: 30000,
+		Trunk:         topology.LineProperties{ROhmPerKM: 0.090, XOhmPerKM: 0.110, AmpacityA: 450},
+		Lateral:       topology.LineProperties{ROhmPerKM: 0.540, XOhmPerKM: 0.140, AmpacityA: 200},
+		TrunkShare:    0.5,
+		MaxLoading:    0.6,
+		DERMix:        []weighted{{"pv", 0.75}, {"battery", 0.25}},
+	},
+}
+
+// 低壓接戶線（配電變壓器至 DER / EV 充電樁）標準載流量（A），阻抗依載流量反比縮放
+var serviceAmpacities = []float64{100, 200, 400, 600, 800, 1200, 1600, 2000, 3000}
+
+const (
+	serviceROhmPerKMAt250A = 0.320
+	serviceXOhmPerKM       = 0.090
+)
+
+// 負載校正：潮流結果超出電壓下限或線路過載時逐步縮小負載
+const (
+	calibrationSteps  = 10
+	calibrationFactor = 0.85
+)
+
+// 變電所一次側與配電變壓器二次側電壓
+const (
+	substationPrimaryKV = 69.0
+	serviceVoltageKV    = 0.38
+)
+
+// 負載類別參數
+type loadClass struct {
+	Name        string
+	MinKW       float64
+	MaxKW       float64
+	PowerFactor float64
+	Customers   [2]int    // 每個配電變壓器的用戶數範圍
+	EVRatings   []float64 // 可選的充電樁額定功率（kW）
+}
+
+var loadClasses = []loadClass{
+	{Name: "residential", MinKW: 15, MaxKW: 60, PowerFactor: 0.95, Customers: [2]int{4, 12}, EVRatings: []float64{7.2, 11}},
+	{Name: "commercial", MinKW: 60, MaxKW: 300, PowerFactor: 0.90, Customers: [2]int{1, 4}, EVRatings: []float64{22, 50}},
+	{Name: "industrial", MinKW: 250, MaxKW: 900, PowerFactor: 0.85, Customers: [2]int{1, 1}, EVRatings: []float64{50, 150}},
+}
+
+// 標準配電變壓器容量（kVA）
+var transformerSizes = []float64{25, 50, 75, 100, 167, 250, 333, 500, 750, 1000, 1500, 2000, 2500}
+
+// servicePoint 配電變壓器與其負載
+type servicePoint struct {
+	NodeIndex int
+	Class     loadClass
+	LoadKW    float64
+	EVKW      float64 // EV 充電樁額定功率（配電變壓器容量一併考量）
+}
+
+// transformerKVA 依負載與 EV 充電功率選擇配電變壓器容量
+func (p servicePoint) transformerKVA() float64 {
+	return transformerSize((p.LoadKW + p.EVKW) / p.Class.PowerFactor)
+}
+
+// generator 產生過程中的狀態
+type generator struct {
+	rng          *rand.Rand
+	class        feederClass
+	topo         *topology.Topology
+	trunkLines   []int // 主幹線路索引（長度依比例分配）
+	lateralLines []int // 分歧線路索引
+	points       []servicePoint
+	nextLine     int
+}
+
+// Generate 依 profile 特徵產生合成 feeder
+func Generate(profile *profiles.Profile, opts Options) (*Result, error) {
+	class, exists := feederClasses[profile.Type]
+	if !exists {
+		return nil, fmt.Errorf("%w: unsupported profile type %q", ErrInvalidOptions, profile.Type)
+	}
+	chars := profile.Characteristics
+
+	nodeCount := opts.NodeCount
+	if nodeCount == 0 {
+		nodeCount = chars.TypicalNodeCount
+	}
+	if nodeCount < MinNodeCount || nodeCount > MaxNodeCount {
+		return nil, fmt.Errorf("%w: node_count must be between %d and %d", ErrInvalidOptions, MinNodeCount, MaxNodeCount)
+	}
+	lengthKM := opts.FeederLengthKM
+	if lengthKM == 0 {
+		lengthKM = chars.TypicalFeederLength
+	}
+	if lengthKM <= 0 || lengthKM > maxFeederLengthKM {
+		return nil, fmt.Errorf("%w: feeder_length_km must be between 0 and %g", ErrInvalidOptions, maxFeederLengthKM)
+	}
+	for name, value := range map[string]*float64{"der_penetration": opts.DERPenetration, "ev_penetration": opts.EVPenetration} {
+		if value != nil && (*value < 0 || *value > 1) {
+			return nil, fmt.Errorf("%w: %s must be between 0 and 1", ErrInvalidOptions, name)
+		}
+	}
+
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+	g := &generator{
+		rng:   rand.New(rand.NewSource(seed)),
+		class: class,
+		topo: &topology.Topology{
+			Name:        opts.Name,
+			Description: fmt.Sprintf("Synthetic %s feeder generated with seed %d", profile.Type, seed),
+			ProfileType: profile.Type,
+			Nodes:       []topology.Node{},
+			Lines:       []topology.Line{},
+		},
+	}
+	if g.topo.Name == "" {
+		g.topo.Name = fmt.Sprintf("%s (seed %d)", profile.Name, seed)
+	}
+
+	// 先抽樣滲透率，用於估計每個配電變壓器連帶的節點數
+	derPenetration := g.sample(chars.DERPenetrationRange.Min, chars.DERPenetrationRange.Max, opts.DERPenetration)
+	evPenetration := g.sample(chars.EVPenetrationRange.Min, chars.EVPenetrationRange.Max, opts.EVPenetration)
+
+	trunk := g.buildTrunk(nodeCount)
+	g.buildLaterals(trunk, nodeCount, chars.LoadComposition, 1+derPenetration+evPenetration)
+	feederLength := g.assignLengths(lengthKM)
+	evCount := g.addEVChargers(evPenetration)
+	g.calibrateLoads()
+	derCount, derKW := g.addDERs(derPenetration)
+
+	formats.AutoLayout(g.topo, nil)
+
+	return &Result{
+		Topology: g.topo,
+		Seed:     seed,
+		Summary:  g.summary(feederLength, derCount, derKW, evCount),
+	}, nil
+}
+
+// sample 在範圍內均勻抽樣；override 不為 nil 時直接使用
+func (g *generator) sample(min, max float64, override *float64) float64 {
+	if override != nil {
+		return *override
+	}
+	return min + g.rng.Float64()*(max-min)
+}
+
+func (g *generator) uniform(min, max float64) float64 {
+	return min + g.rng.Float64()*(max-min)
+}
+
+func (g *generator) addNode(node topology.Node) int {
+	g.topo.Nodes = append(g.topo.Nodes, node)
+	return len(g.topo.Nodes) - 1
+}
+
+func (g *generator) connect(from, to int, conductor topology.LineProperties, lengthKM float64) int {
+	g.nextLine++
+	g.topo.Lines = append(g.topo.Lines, topology.Line{
+		ID:         fmt.Sprintf("line-%d", g.nextLine),
+		FromNodeID: g.topo.Nodes[from].ID,
+		ToNodeID:   g.topo.Nodes[to].ID,
+		Properties: map[string]interface{}{
+			"length_km":    round(lengthKM, 3),
+			"r_ohm_per_km": conductor.ROhmPerKM,
+			"x_ohm_per_km": conductor.XOhmPerKM,
+			"ampacity_a":   conductor.AmpacityA,
+		},
+	})
+	return len(g.topo.Lines) - 1
+}
+
+// buildTrunk 建立變電所、饋線斷路器與主幹線（中段設復閉器），回傳主幹匯流排索引
+func (g *generator) buildTrunk(nodeCount int) []int {
+	sub := g.addNode(topology.Node{
+		ID:   "sub",
+		Type: topology.NodeTypeTransformer,
+		Name: "Substation",
+		Properties: map[string]interface{}{
+			"is_source":          true,
+			"primary_voltage":    substationPrimaryKV,
+			"secondary_voltage":  g.class.VoltageKV,
+			"rated_voltage_kv":   g.class.VoltageKV,
+			"rated_capacity_kva": g.class.SubstationKVA,
+			"impedance_percent":  8.0,
+			"x_r_ratio":          10.0,
+		},
+	})
+	breaker := g.addNode(switchNode("brk-1", "Feeder breaker", "breaker"))
+	g.connect(sub, breaker, g.class.Trunk, 0.05)
+
+	trunkCount := nodeCount / 8
+	if trunkCount < 3 {
+		trunkCount = 3
+	}
+	if trunkCount > 40 {
+		trunkCount = 40
+	}
+
+	buses := make([]int, 0, trunkCount)
+	previous := breaker
+	for i := 1; i <= trunkCount; i++ {
+		if i == trunkCount/2+1 {
+			recloser := g.addNode(switchNode("rec-1", "Mid-line recloser", "recloser"))
+			g.trunkLines = append(g.trunkLines, g.connect(previous, recloser, g.class.Trunk, 0))
+			previous = recloser
+		}
+		bus := g.addNode(topology.Node{
+			ID:   fmt.Sprintf("trunk-%d", i),
+			Type: topology.NodeTypeBus,
+			Name: fmt.Sprintf("Trunk %d", i),
+		})
+		g.trunkLines = append(g.trunkLines, g.connect(previous, bus, g.class.Trunk, 0))
+		buses = append(buses, bus)
+		previous = bus
+	}
+	return buses
+}
+
+func switchNode(id, name, switchType string) topology.Node {
+	return topology.Node{
+		ID:   id,
+		Type: topology.NodeTypeSwitch,
+		Name: name,
+		Properties: map[string]interface{}{
+			"type":         switchType,
+			"is_closed":    true,
+			"is_automated": switchType != "sectionalizer",
+		},
+	}
+}
+
+// buildLaterals 由主幹分出分歧線並加入配電變壓器，直到估計節點數達到目標
+// pointCost 為每個配電變壓器（含預期的 DER 與 EV 節點）估計佔用的節點數
+func (g *generator) buildLaterals(trunk []int, nodeCount int, composition profiles.LoadComposition, pointCost float64) {
+	estimated := float64(len(g.topo.Nodes))
+	for lateral := 1; estimated+1+pointCost <= float64(nodeCount) || len(g.points) == 0; lateral++ {
+		tap := trunk[g.rng.Intn(len(trunk))]
+		sectionalizer := g.addNode(switchNode(fmt.Sprintf("sec-%d", lateral), fmt.Sprintf("Lateral %d switch", lateral), "sectionalizer"))
+		g.connect(tap, sectionalizer, g.class.Lateral, 0.01)
+		estimated++
+
+		previous := sectionalizer
+		buses := 1 + g.rng.Intn(3)
+		for b := 1; b <= buses && estimated+1+pointCost <= float64(nodeCount) || b == 1; b++ {
+			bus := g.addNode(topology.Node{
+				ID:   fmt.Sprintf("lat-%d-%d", lateral, b),
+				Type: topology.NodeTypeBus,
+				Name: fmt.Sprintf("Lateral %d bus %d", lateral, b),
+			})
+			g.lateralLines = append(g.lateralLines, g.connect(previous, bus, g.class.Lateral, 0))
+			estimated++
+			previous = bus
+
+			points := 1 + g.rng.Intn(2)
+			for p := 0; p < points && (estimated+pointCost <= float64(nodeCount) || p == 0); p++ {
+				g.addServicePoint(bus, composition)
+				estimated += pointCost
+			}
+		}
+	}
+}
+
+// addServicePoint 在匯流排下加入一個配電變壓器（負載類別依 LoadComposition 抽樣）
+func (g *generator) addServicePoint(bus int, composition profiles.LoadComposition) {
+	class := g.pickLoadClass(composition)
+	loadKW := round(g.uniform(class.MinKW, class.MaxKW), 1)
+
+	index := len(g.points) + 1
+	node := g.addNode(topology.Node{
+		ID:   fmt.Sprintf("xfmr-%d", index),
+		Type: topology.NodeTypeTransformer,
+		Name: fmt.Sprintf("Distribution transformer %d", index),
+		Properties: map[string]interface{}{
+			"primary_voltage":    g.class.VoltageKV,
+			"secondary_voltage":  serviceVoltageKV,
+			"rated_voltage_kv":   serviceVoltageKV,
+			"rated_capacity_kva": transformerSize(loadKW / class.PowerFactor),
+			"load_kw":            loadKW,
+			"power_factor":       class.PowerFactor,
+			"load_class":         class.Name,
+			"customers":          class.Customers[0] + g.rng.Intn(class.Customers[1]-class.Customers[0]+1),
+		},
+	})
+	g.connect(bus, node, g.class.Lateral, g.uniform(0.02, 0.1))
+	g.points = append(g.points, servicePoint{NodeIndex: node, Class: class, LoadKW: loadKW})
+}
+
+func (g *generator) pickLoadClass(composition profiles.LoadComposition) loadClass {
+	// LoadComposition 為負載量占比，依各類別平均負載換算為數量占比
+	weights := []float64{composition.Residential, composition.Commercial, composition.Industrial}
+	total := 0.0
+	for i := range weights {
+		weights[i] /= (loadClasses[i].MinKW + loadClasses[i].MaxKW) / 2
+		total += weights[i]
+	}
+	if total <= 0 {
+		return loadClasses[0]
+	}
+	r := g.rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return loadClasses[i]
+		}
+		r -= w
+	}
+	return loadClasses[len(loadClasses)-1]
+}
+
+// transformerSize 取不小於 kVA / 0.8 的標準容量
+func transformerSize(kva float64) float64 {
+	for _, size := range transformerSizes {
+		if size*0.8 >= kva {
+			return size
+		}
+	}
+	return transformerSizes[len(transformerSizes)-1]
+}
+
+// calibrateLoads 將總負載限制在主變容量上限內，並在潮流結果低於電壓下限或線路過載時逐步縮小負載
+func (g *generator) calibrateLoads() {
+	total := 0.0
+	for _, point := range g.points {
+		total += point.LoadKW
+	}
+	if limit := g.class.SubstationKVA * g.class.MaxLoading; total > limit {
+		g.scaleLoads(limit / total)
+	}
+
+	for step := 0; step < calibrationSteps; step++ {
+		result, err := powerflow.Solve(g.topo, powerflow.Options{})
+		if err == nil && result.Converged && result.Summary.VoltageViolations == 0 && result.Summary.MaxLineLoadingPercent <= 100 {
+			return
+		}
+		g.scaleLoads(calibrationFactor)
+	}
+}
+
+func (g *generator) scaleLoads(factor float64) {
+	for i := range g.points {
+		point := &g.points[i]
+		point.LoadKW = round(point.LoadKW*factor, 1)
+		props := g.topo.Nodes[point.NodeIndex].Properties
+		props["load_kw"] = point.LoadKW
+		props["rated_capacity_kva"] = point.transformerKVA()
+	}
+}
+
+// serviceConductor 依接入容量選擇低壓接戶線（載流量至少為額定電流的 1.25 倍）
+func serviceConductor(kw float64) topology.LineProperties {
+	current := kw / (math.Sqrt(3) * serviceVoltageKV)
+	ampacity := serviceAmpacities[len(serviceAmpacities)-1]
+	for _, candidate := range serviceAmpacities {
+		if candidate >= current*1.25 {
+			ampacity = candidate
+			break
+		}
+	}
+	return topology.LineProperties{
+		ROhmPerKM: round(serviceROhmPerKMAt250A*250/ampacity, 3),
+		XOhmPerKM: serviceXOhmPerKM,
+		AmpacityA: ampacity,
+	}
+}
+
+// addDERs 依滲透率在隨機的配電變壓器下加入 DER，容量約為該處負載的 0.5–1.5 倍（不超過配電變壓器容量）
+func (g *generator) addDERs(penetration float64) (int, float64) {
+	count := int(math.Round(penetration * float64(len(g.points))))
+	capacity := 0.0
+	for i, p := range g.rng.Perm(len(g.points))[:count] {
+		point := g.points[p]
+		derType := g.pickWeighted(g.class.DERMix)
+		rated := round(point.LoadKW*g.uniform(0.5, 1.5), 1)
+		if kva := topology.FloatProperty(g.topo.Nodes[point.NodeIndex].Properties, "rated_capacity_kva", 0); rated > kva {
+			rated = kva
+		}
+		capacity += rated
+
+		node := g.addNode(topology.Node{
+			ID:   fmt.Sprintf("der-%d", i+1),
+			Type: topology.NodeTypeDER,
+			Name: fmt.Sprintf("%s %d", derNames[derType], i+1),
+			Properties: map[string]interface{}{
+				"type":            derType,
+				"rated_power_kw":  rated,
+				"is_controllable": derType == "battery" || g.rng.Float64() < 0.3,
+			},
+		})
+		g.connect(point.NodeIndex, node, serviceConductor(rated), g.uniform(0.01, 0.05))
+	}
+	return count, capacity
+}
+
+var derNames = map[string]string{
+	"pv":      "PV",
+	"battery": "Battery",
+	"wind":    "Wind turbine",
+}
+
+func (g *generator) pickWeighted(options []weighted) string {
+	total := 0.0
+	for _, option := range options {
+		total += option.Weight
+	}
+	r := g.rng.Float64() * total
+	for _, option := range options {
+		if r < option.Weight {
+			return option.Value
+		}
+		r -= option.Weight
+	}
+	return options[len(options)-1].Value
+}
+
+// addEVChargers 依滲透率在隨機的配電變壓器下加入 EV 充電樁（額定功率依負載類別）
+func (g *generator) addEVChargers(penetration float64) int {
+	count := int(math.Round(penetration * float64(len(g.points))))
+	for i, p := range g.rng.Perm(len(g.points))[:count] {
+		point := &g.points[p]
+		rated := point.Class.EVRatings[g.rng.Intn(len(point.Class.EVRatings))]
+		point.EVKW = rated
+		g.topo.Nodes[point.NodeIndex].Properties["rated_capacity_kva"] = point.transformerKVA()
+
+		node := g.addNode(topology.Node{
+			ID:   fmt.Sprintf("ev-%d", i+1),
+			Type: topology.NodeTypeEVCharger,
+			Name: fmt.Sprintf("EV charger %d", i+1),
+			Properties: map[string]interface{}{
+				"rated_power_kw":    rated,
+				"max_charging_rate": rated,
+				"is_controllable":   g.rng.Float64() < 0.5,
+			},
+		})
+		g.connect(point.NodeIndex, node, serviceConductor(rated), g.uniform(0.01, 0.05))
+	}
+	return count
+}
+
+// assignLengths 依比例將饋線總長分配到主幹與分歧線（各段長度有 ±30% 變化），回傳實際總長
+func (g *generator) assignLengths(lengthKM float64) float64 {
+	trunkKM := lengthKM * g.class.TrunkShare
+	lateralKM := lengthKM - trunkKM
+	if len(g.lateralLines) == 0 {
+		trunkKM = lengthKM
+	}
+	return round(g.distribute(g.trunkLines, trunkKM)+g.distribute(g.lateralLines, lateralKM), 3)
+}
+
+func (g *generator) distribute(lines []int, totalKM float64) float64 {
+	if len(lines) == 0 {
+		return 0
+	}
+	weights := make([]float64, len(lines))
+	sum := 0.0
+	for i := range weights {
+		weights[i] = g.uniform(0.7, 1.3)
+		sum += weights[i]
+	}
+
+	assigned := 0.0
+	for i, index := range lines {
+		length := round(math.Max(totalKM*weights[i]/sum, 0.01), 3)
+		g.topo.Lines[index].Properties["length_km"] = length
+		assigned += length
+	}
+	return assigned
+}
+
+func (g *generator) summary(feederLength float64, derCount int, derKW float64, evCount int) Summary {
+	summary := Summary{
+		NodeCount:           len(g.topo.Nodes),
+		LineCount:           len(g.topo.Lines),
+		ServiceTransformers: len(g.points),
+		FeederLengthKM:      feederLength,
+		DERCount:            derCount,
+		DERCapacityKW:       round(derKW, 1),
+		EVChargerCount:      evCount,
+	}
+
+	byClass := map[string]float64{}
+	for _, point := range g.points {
+		summary.TotalLoadKW += point.LoadKW
+		byClass[point.Class.Name] += point.LoadKW
+	}
+	if summary.TotalLoadKW > 0 {
+		summary.DERPenetration = round(derKW/summary.TotalLoadKW, 3)
+		summary.LoadComposition = profiles.LoadComposition{
+			Residential: round(byClass["residential"]/summary.TotalLoadKW, 3),
+			Commercial:  round(byClass["commercial"]/summary.TotalLoadKW, 3),
+			Industrial:  round(byClass["industrial"]/summary.TotalLoadKW, 3),
+		}
+	}
+	if len(g.points) > 0 {
+		summary.EVPenetration = round(float64(evCount)/float64(len(g.points)), 3)
+	}
+	summary.TotalLoadKW = round(summary.TotalLoadKW, 1)
+	return summary
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,401 @@
+package hostingcapacity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Candidates 取得會被分析的候選節點，可用於估計工作量
+func Candidates(t *topology.Topology, opts Options) ([]string, error) {
+	opts = opts.withDefaults()
+	if err := validate(opts); err != nil {
+		return nil, err
+	}
+	n, err := powerflow.BuildNetwork(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	buses, err := candidates(n, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(buses))
+	for i, bus := range buses {
+		ids[i] = n.Buses[bus].NodeID
+	}
+	return ids, nil
+}
+
+// Analyze 計算各候選節點的容量
+// ctx 取消時中止並回傳 ctx.Err()；progress 可為 nil
+func Analyze(ctx context.Context, t *topology.Topology, opts Options, progress ProgressFunc) (*Result, error) {
+	opts = opts.withDefaults()
+	if err := validate(opts); err != nil {
+		return nil, err
+	}
+
+	n, err := powerflow.BuildNetwork(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	buses, err := candidates(n, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := powerflow.SolveNetwork(n, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+	if !base.Converged {
+		return nil, ErrBaseCase
+	}
+
+	s := newStudy(t, n, opts, base)
+	result := &Result{
+		Mode:  opts.Mode,
+		Nodes: make([]NodeResult, 0, len(buses)),
+		Summary: Summary{
+			CandidateNodes: len(buses),
+			Constraints:    map[string]int{},
+		},
+	}
+	for i, bus := range buses {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var node NodeResult
+		if opts.Mode == ModeEV {
+			node, err = s.evCapacity(bus)
+		} else {
+			node, err = s.derCapacity(bus)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Nodes = append(result.Nodes, node)
+		result.Summary.Constraints[node.BindingConstraint]++
+		result.Summary.PowerFlowsExecuted += node.PowerFlowsExecuted
+
+		if progress != nil {
+			progress(i+1, len(buses))
+		}
+	}
+
+	summarize(result)
+	return result, nil
+}
+
+func validate(opts Options) error {
+	if opts.Mode != ModeDER && opts.Mode != ModeEV {
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidOptions, opts.Mode)
+	}
+	if opts.StepKW > opts.MaxKW {
+		return fmt.Errorf("%w: step_kw must not exceed max_kw", ErrInvalidOptions)
+	}
+	if opts.ChargerKW < 0 {
+		return fmt.Errorf("%w: charger_kw must not be negative", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// candidates 解析候選節點，未指定時取所有帶電的 bus 與變壓器
+func candidates(n *powerflow.Network, opts Options) ([]int, error) {
+	buses := []int{}
+	if len(opts.NodeIDs) > 0 {
+		seen := make(map[int]bool, len(opts.NodeIDs))
+		for _, id := range opts.NodeIDs {
+			index, exists := n.BusIndex(id)
+			if !exists {
+				return nil, fmt.Errorf("%w: node %s not found", ErrInvalidOptions, id)
+			}
+			if !n.Buses[index].Energized {
+				return nil, fmt.Errorf("%w: node %s is not energized", ErrInvalidOptions, id)
+			}
+			if !seen[index] {
+				seen[index] = true
+				buses = append(buses, index)
+			}
+		}
+		return buses, nil
+	}
+
+	for _, index := range n.Order {
+		bus := n.Buses[index]
+		if index == n.Source || bus.OpenSwitch {
+			continue
+		}
+		if bus.Type == topology.NodeTypeBus || bus.Type == topology.NodeTypeTransformer {
+			buses = append(buses, index)
+		}
+	}
+	if len(buses) == 0 {
+		return nil, ErrNoCandidates
+	}
+	return buses, nil
+}
+
+// study 分析過程中的狀態
+type study struct {
+	network     *powerflow.Network
+	opts        Options
+	nodes       map[string]topology.Node
+	defaultEV   float64   // 拓樸中 EV 充電樁的額定功率
+	lineLimit   []float64 // 各支路負載率上限（基準情境已超過時取基準值）
+	xfmrLimit   []float64 // 各匯流排變壓器負載率上限
+	vMin, vMax  []float64
+	sourceMin   float64 // 變電所送出功率下限（kW）
+	evKVARPerKW float64
+}
+
+func newStudy(t *topology.Topology, n *powerflow.Network, opts Options, base *powerflow.Result) *study {
+	s := &study{
+		network:     n,
+		opts:        opts,
+		nodes:       make(map[string]topology.Node, len(t.Nodes)),
+		defaultEV:   defaultChargerKW,
+		lineLimit:   make([]float64, len(base.Lines)),
+		xfmrLimit:   make([]float64, len(base.Nodes)),
+		vMin:        make([]float64, len(base.Nodes)),
+		vMax:        make([]float64, len(base.Nodes)),
+		evKVARPerKW: math.Tan(math.Acos(opts.EVPowerFactor)),
+	}
+
+	foundEV := false
+	for _, node := range t.Nodes {
+		s.nodes[node.ID] = node
+		if node.Type == topology.NodeTypeEVCharger && !foundEV {
+			if kw := topology.FloatProperty(node.Properties, "rated_power_kw", 0); kw > 0 {
+				s.defaultEV = kw
+				foundEV = true
+			}
+		}
+	}
+
+	for i, line := range base.Lines {
+		s.lineLimit[i] = math.Max(opts.MaxLoadingPercent, line.LoadingPercent)
+	}
+	for i, node := range base.Nodes {
+		s.xfmrLimit[i] = math.Max(opts.MaxLoadingPercent, node.LoadingPercent)
+		s.vMin[i] = math.Min(opts.PowerFlow.VoltageMinPU, node.VoltagePU)
+		s.vMax[i] = math.Max(opts.PowerFlow.VoltageMaxPU, node.VoltagePU)
+	}
+	s.sourceMin = math.Min(0, base.Summary.SourcePowerKW)
+	return s
+}
+
+// violation 觸發的限制
+type violation struct {
+	constraint string
+	elementID  string
+	value      float64
+}
+
+// evaluate 在 bus 增加 kw 的注入（DER）或負載（EV）後執行潮流並檢查限制，無違規時回傳 nil
+func (s *study) evaluate(bus int, kw float64) (*violation, error) {
+	demand := &s.network.Buses[bus].Demand
+	original := *demand
+	defer func() { *demand = original }()
+
+	if s.opts.Mode == ModeEV {
+		demand.LoadKW += kw
+		demand.LoadKVAR += kw * s.evKVARPerKW
+	} else {
+		demand.GenerationKW += kw
+	}
+
+	result, err := powerflow.SolveNetwork(s.network, s.opts.PowerFlow)
+	if err != nil {
+		if err == powerflow.ErrSingularMatrix {
+			return &violation{constraint: ConstraintNonConvergence}, nil
+		}
+		return nil, err
+	}
+	if !result.Converged {
+		return &violation{constraint: ConstraintNonConvergence}, nil
+	}
+	return s.check(result), nil
+}
+
+// check 依電壓、變壓器、線路、逆送電力的順序回傳超出最多的違規
+func (s *study) check(result *powerflow.Result) *violation {
+	var worst *violation
+	excess := 0.0
+	consider := func(constraint, elementID string, value, over float64) {
+		if over > excess {
+			excess = over
+			worst = &violation{constraint: constraint, elementID: elementID, value: value}
+		}
+	}
+
+	for i, node := range result.Nodes {
+		if !node.Energized {
+			continue
+		}
+		consider(ConstraintOvervoltage, node.NodeID, node.VoltagePU, node.VoltagePU-s.vMax[i])
+		consider(ConstraintUndervoltage, node.NodeID, node.VoltagePU, s.vMin[i]-node.VoltagePU)
+	}
+	if worst != nil {
+		return round(worst)
+	}
+
+	for i, node := range result.Nodes {
+		consider(ConstraintTransformer, node.NodeID, node.LoadingPercent, node.LoadingPercent-s.xfmrLimit[i])
+	}
+	if worst != nil {
+		return round(worst)
+	}
+
+	for i, line := range result.Lines {
+		consider(ConstraintLineThermal, line.LineID, line.LoadingPercent, line.LoadingPercent-s.lineLimit[i])
+	}
+	if worst != nil {
+		return round(worst)
+	}
+
+	if s.opts.Mode == ModeDER && !s.opts.AllowReversePower && result.Summary.SourcePowerKW < s.sourceMin {
+		return round(&violation{
+			constraint: ConstraintReversePower,
+			elementID:  result.Summary.SourceNodeID,
+			value:      result.Summary.SourcePowerKW,
+		})
+	}
+	return nil
+}
+
+// derCapacity 以固定步長增加 DER 注入，觸發限制後以二分法細化
+func (s *study) derCapacity(bus int) (NodeResult, error) {
+	node := NodeResult{NodeID: s.network.Buses[bus].NodeID, BindingConstraint: ConstraintNone}
+
+	hostable, failing := 0.0, 0.0
+	var binding *violation
+	for kw := s.opts.StepKW; kw <= s.opts.MaxKW+1e-9; kw += s.opts.StepKW {
+		v, err := s.evaluate(bus, kw)
+		node.PowerFlowsExecuted++
+		if err != nil {
+			return node, err
+		}
+		if v != nil {
+			binding, failing = v, kw
+			break
+		}
+		hostable = kw
+	}
+
+	if binding != nil {
+		for i := 0; i < refineIterations; i++ {
+			mid := (hostable + failing) / 2
+			v, err := s.evaluate(bus, mid)
+			node.PowerFlowsExecuted++
+			if err != nil {
+				return node, err
+			}
+			if v != nil {
+				binding, failing = v, mid
+			} else {
+				hostable = mid
+			}
+		}
+		node.BindingConstraint = binding.constraint
+		node.BindingElementID = binding.elementID
+		node.BindingValue = binding.value
+	}
+	node.CapacityKW = math.Round(hostable*10) / 10
+	return node, nil
+}
+
+// evCapacity 以一支充電樁為單位增加負載：充電樁數量倍增至觸發限制後，再以二分法找出最大數量
+func (s *study) evCapacity(bus int) (NodeResult, error) {
+	busNode := s.network.Buses[bus]
+	chargerKW := s.opts.ChargerKW
+	if chargerKW <= 0 {
+		chargerKW = s.defaultEV
+		if busNode.Type == topology.NodeTypeEVCharger {
+			if kw := topology.FloatProperty(s.nodes[busNode.NodeID].Properties, "rated_power_kw", 0); kw > 0 {
+				chargerKW = kw
+			}
+		}
+	}
+
+	node := NodeResult{NodeID: busNode.NodeID, ChargerKW: chargerKW, BindingConstraint: ConstraintNone}
+	maxCount := int(math.Floor(s.opts.MaxKW/chargerKW + 1e-9))
+	evaluate := func(count int) (*violation, error) {
+		node.PowerFlowsExecuted++
+		return s.evaluate(bus, float64(count)*chargerKW)
+	}
+
+	hostable, failing := 0, 0
+	var binding *violation
+	for count := 1; count <= maxCount; count *= 2 {
+		v, err := evaluate(count)
+		if err != nil {
+			return node, err
+		}
+		if v != nil {
+			binding, failing = v, count
+			break
+		}
+		hostable = count
+		if count < maxCount && count*2 > maxCount {
+			count = maxCount / 2 // 下一步剛好檢查 max_kw
+		}
+	}
+
+	if binding != nil {
+		for failing-hostable > 1 {
+			mid := (hostable + failing) / 2
+			v, err := evaluate(mid)
+			if err != nil {
+				return node, err
+			}
+			if v != nil {
+				binding, failing = v, mid
+			} else {
+				hostable = mid
+			}
+		}
+		node.BindingConstraint = binding.constraint
+		node.BindingElementID = binding.elementID
+		node.BindingValue = binding.value
+	}
+	node.Chargers = hostable
+	node.CapacityKW = math.Round(float64(hostable)*chargerKW*10) / 10
+	return node, nil
+}
+
+func summarize(result *Result) {
+	if len(result.Nodes) == 0 {
+		return
+	}
+	capacities := make([]float64, len(result.Nodes))
+	for i, node := range result.Nodes {
+		capacities[i] = node.CapacityKW
+	}
+	sort.Float64s(capacities)
+
+	summary := &result.Summary
+	summary.MinCapacityKW = capacities[0]
+	summary.MaxCapacityKW = capacities[len(capacities)-1]
+	middle := len(capacities) / 2
+	summary.MedianCapacityKW = capacities[middle]
+	if len(capacities)%2 == 0 {
+		summary.MedianCapacityKW = math.Round((capacities[middle-1]+capacities[middle])/2*10) / 10
+	}
+}
+
+func round(v *violation) *violation {
+	decimals := 1.0
+	if v.constraint == ConstraintOvervoltage || v.constraint == ConstraintUndervoltage {
+		decimals = 4
+	}
+	scale := math.Pow(10, decimals)
+	v.value = math.Round(v.value*scale) / scale
+	return v
+}
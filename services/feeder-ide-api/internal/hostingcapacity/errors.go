@@ -0,0 +1,9 @@
+package hostingcapacity
+
+import "errors"
+
+var (
+	ErrInvalidOptions = errors.New("invalid hosting capacity options")
+	ErrNoCandidates   = errors.New("no energized candidate nodes")
+	ErrBaseCase       = errors.New("base case power flow did not converge")
+)
@@ -0,0 +1,106 @@
+// Package hostingcapacity 計算各節點可再接入的 DER 發電量或 EV 充電負載
+//
+// 對每個候選節點逐步增加 DER 注入（或以 EV 充電樁額定功率為單位增加負載），
+// 每一步重新執行潮流分析，直到電壓、熱容量或逆送電力限制被觸發，
+// 回傳各節點的容量與限制條件。基準情境已存在的違規不會重複判定，只有惡化時才視為觸發。
+package hostingcapacity
+
+import "github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+
+// 分析模式
+const (
+	ModeDER = "der"
+	ModeEV  = "ev"
+)
+
+// 限制條件
+const (
+	ConstraintNone           = "none" // 達到 max_kw 仍未觸發任何限制
+	ConstraintOvervoltage    = "overvoltage"
+	ConstraintUndervoltage   = "undervoltage"
+	ConstraintLineThermal    = "line_thermal"
+	ConstraintTransformer    = "transformer_thermal"
+	ConstraintReversePower   = "reverse_power"
+	ConstraintNonConvergence = "non_convergence"
+)
+
+// 未指定參數時使用的預設值
+const (
+	defaultStepKW            = 50.0
+	defaultMaxKW             = 10000.0
+	defaultMaxLoadingPercent = 100.0
+	defaultChargerKW         = 7.2
+	defaultEVPowerFactor     = 0.98
+	refineIterations         = 5 // DER 模式觸發限制後以二分法細化的次數
+)
+
+// Options 容量分析參數
+type Options struct {
+	Mode              string            `json:"mode,omitempty"`                // der（預設）或 ev
+	NodeIDs           []string          `json:"node_ids,omitempty"`            // 候選節點，預設為所有帶電的 bus 與變壓器（不含電源）
+	StepKW            float64           `json:"step_kw,omitempty"`             // DER 每步增加的注入量
+	MaxKW             float64           `json:"max_kw,omitempty"`              // 單一節點的搜尋上限
+	ChargerKW         float64           `json:"charger_kw,omitempty"`          // EV 每步增加一支充電樁的功率，預設取自 ev_charger 節點的 rated_power_kw
+	EVPowerFactor     float64           `json:"ev_power_factor,omitempty"`     // EV 負載功率因數
+	MaxLoadingPercent float64           `json:"max_loading_percent,omitempty"` // 線路與變壓器負載率上限
+	AllowReversePower bool              `json:"allow_reverse_power,omitempty"` // DER 模式是否允許電力逆送至變電所
+	PowerFlow         powerflow.Options `json:"power_flow"`                    // 潮流參數（電壓上下限等）
+}
+
+// withDefaults 補上預設值
+func (o Options) withDefaults() Options {
+	if o.Mode == "" {
+		o.Mode = ModeDER
+	}
+	if o.StepKW <= 0 {
+		o.StepKW = defaultStepKW
+	}
+	if o.MaxKW <= 0 {
+		o.MaxKW = defaultMaxKW
+	}
+	if o.EVPowerFactor <= 0 || o.EVPowerFactor > 1 {
+		o.EVPowerFactor = defaultEVPowerFactor
+	}
+	if o.MaxLoadingPercent <= 0 {
+		o.MaxLoadingPercent = defaultMaxLoadingPercent
+	}
+	if o.PowerFlow.VoltageMinPU <= 0 {
+		o.PowerFlow.VoltageMinPU = 0.95
+	}
+	if o.PowerFlow.VoltageMaxPU <= 0 {
+		o.PowerFlow.VoltageMaxPU = 1.05
+	}
+	return o
+}
+
+// Result 容量分析結果
+type Result struct {
+	Mode    string       `json:"mode"`
+	Nodes   []NodeResult `json:"nodes"`
+	Summary Summary      `json:"summary"`
+}
+
+// NodeResult 單一節點的容量
+type NodeResult struct {
+	NodeID             string  `json:"node_id"`
+	CapacityKW         float64 `json:"capacity_kw"`
+	Chargers           int     `json:"chargers,omitempty"`   // EV 模式可接入的充電樁數量
+	ChargerKW          float64 `json:"charger_kw,omitempty"` // EV 模式每支充電樁的功率
+	BindingConstraint  string  `json:"binding_constraint"`
+	BindingElementID   string  `json:"binding_element_id,omitempty"` // 觸發限制的節點或線路
+	BindingValue       float64 `json:"binding_value,omitempty"`      // 觸發時的電壓（pu）、負載率（%）或逆送功率（kW）
+	PowerFlowsExecuted int     `json:"power_flows_executed"`
+}
+
+// Summary 整體統計
+type Summary struct {
+	CandidateNodes     int            `json:"candidate_nodes"`
+	MinCapacityKW      float64        `json:"min_capacity_kw"`
+	MaxCapacityKW      float64        `json:"max_capacity_kw"`
+	MedianCapacityKW   float64        `json:"median_capacity_kw"`
+	Constraints        map[string]int `json:"constraints"` // 各限制條件觸發的節點數
+	PowerFlowsExecuted int            `json:"power_flows_executed"`
+}
+
+// ProgressFunc 每完成一個候選節點時回報進度
+type ProgressFunc func(done, total int)
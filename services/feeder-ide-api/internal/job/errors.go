@@ -0,0 +1,9 @@
+package job
+
+import "errors"
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job has already finished")
+	ErrQueueFull   = errors.New("job queue is full")
+)
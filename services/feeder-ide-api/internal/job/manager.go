@@ -0,0 +1,355 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// progressPersistInterval 進度寫入 repository 的最小間隔（事件仍即時發布）
+	progressPersistInterval = time.Second
+	// subscriberBuffer 每個訂閱者的事件緩衝，滿時捨棄進度事件
+	subscriberBuffer = 32
+	// interruptedMessage 服務重啟時未完成工作的錯誤訊息
+	interruptedMessage = "job was interrupted by a server restart"
+)
+
+// Reporter 工作執行中回報進度與部分結果
+type Reporter interface {
+	// Progress 回報進度（0 ~ 1）與說明
+	Progress(progress float64, message string)
+	// Partial 發布部分結果（序列化為 JSON），訂閱者緩衝已滿時略過
+	Partial(data interface{})
+}
+
+// RunFunc 工作內容，ctx 在工作被取消時結束；回傳值會序列化為 JSON 保存
+type RunFunc func(ctx context.Context, report Reporter) (interface{}, error)
+
+// StartFunc 工作開始執行前呼叫（例如扣除模擬配額），回傳錯誤時工作直接失敗
+type StartFunc func(job *Job) error
+
+// task 佇列中的工作與其取消函數
+type task struct {
+	job         *Job
+	run         RunFunc
+	ctx         context.Context
+	cancel      context.CancelFunc
+	lastPersist time.Time
+}
+
+// Manager 以固定數量的 worker 執行工作
+type Manager struct {
+	repo    Repository
+	workers int
+	queue   chan *task
+	onStart StartFunc
+
+	mu          sync.Mutex
+	tasks       map[string]*task // 尚未結束的工作
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewManager 建立新的 Manager，workers 為同時執行的工作數，queueSize 為等待中的工作上限
+func NewManager(repo Repository, workers, queueSize int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Manager{
+		repo:        repo,
+		workers:     workers,
+		queue:       make(chan *task, queueSize),
+		tasks:       make(map[string]*task),
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// SetStartHook 設置工作開始執行前的 hook
+func (m *Manager) SetStartHook(hook StartFunc) {
+	m.onStart = hook
+}
+
+// Start 將上次執行未完成的工作標示為失敗並啟動 worker
+func (m *Manager) Start() error {
+	count, err := m.repo.FailUnfinished(interruptedMessage)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("Marked %d unfinished simulation jobs as failed", count)
+	}
+
+	for i := 0; i < m.workers; i++ {
+		go m.worker()
+	}
+	return nil
+}
+
+// Submit 建立工作並放入佇列，佇列已滿時回傳 ErrQueueFull
+func (m *Manager) Submit(job *Job, run RunFunc) (*Job, error) {
+	job.ID = uuid.New().String()
+	job.Status = StatusQueued
+	job.Progress = 0
+	job.CreatedAt = time.Now()
+	if err := m.repo.Create(job); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &task{job: job, run: run, ctx: ctx, cancel: cancel}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case m.queue <- t:
+		m.tasks[job.ID] = t
+		return job.Clone(), nil
+	default:
+		cancel()
+		m.finishLocked(t, StatusFailed, nil, ErrQueueFull.Error())
+		return nil, ErrQueueFull
+	}
+}
+
+// Get 取得工作目前狀態
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	if t, exists := m.tasks[id]; exists {
+		defer m.mu.Unlock()
+		return t.job.Clone(), nil
+	}
+	m.mu.Unlock()
+	return m.repo.GetByID(id)
+}
+
+// List 列出工作
+func (m *Manager) List(filter Filter) ([]*Job, error) {
+	return m.repo.List(filter)
+}
+
+// Cancel 取消工作：等待中的工作立即取消，執行中的工作透過 context 通知並由 worker 結束
+func (m *Manager) Cancel(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, exists := m.tasks[id]
+	if !exists {
+		if _, err := m.repo.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobFinished
+	}
+
+	t.cancel()
+	if t.job.Status == StatusQueued {
+		m.finishLocked(t, StatusCancelled, nil, "")
+	} else {
+		t.job.Message = "cancellation requested"
+		m.publishLocked(t.job, EventStatus)
+	}
+	return t.job.Clone(), nil
+}
+
+// Subscribe 訂閱工作事件，回傳目前狀態；工作已結束時 events 為 nil
+// 工作結束後 events 會被關閉，呼叫端需在不再接收時呼叫 unsubscribe
+func (m *Manager) Subscribe(id string) (current *Job, events <-chan Event, unsubscribe func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, exists := m.tasks[id]
+	if !exists {
+		job, err := m.repo.GetByID(id)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return job, nil, func() {}, nil
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	if m.subscribers[id] == nil {
+		m.subscribers[id] = make(map[chan Event]struct{})
+	}
+	m.subscribers[id][ch] = struct{}{}
+
+	unsubscribe = func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, subscribed := m.subscribers[id][ch]; subscribed {
+			delete(m.subscribers[id], ch)
+			close(ch)
+		}
+	}
+	return t.job.Clone(), ch, unsubscribe, nil
+}
+
+func (m *Manager) worker() {
+	for t := range m.queue {
+		m.execute(t)
+	}
+}
+
+// execute 執行單一工作並記錄結果
+func (m *Manager) execute(t *task) {
+	m.mu.Lock()
+	if t.job.Finished() {
+		// 在佇列中已被取消
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+
+	if m.onStart != nil {
+		if err := m.onStart(t.job.Clone()); err != nil {
+			m.mu.Lock()
+			m.finishLocked(t, StatusFailed, nil, fmt.Sprintf("failed to start job: %v", err))
+			m.mu.Unlock()
+			return
+		}
+	}
+
+	m.mu.Lock()
+	if t.job.Finished() {
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	t.job.Status = StatusRunning
+	t.job.StartedAt = &now
+	m.persistLocked(t)
+	m.publishLocked(t.job, EventStatus)
+	m.mu.Unlock()
+
+	result, err := m.run(t)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case t.ctx.Err() != nil:
+		m.finishLocked(t, StatusCancelled, nil, "")
+	case err != nil:
+		m.finishLocked(t, StatusFailed, nil, err.Error())
+	default:
+		raw, err := json.Marshal(result)
+		if err != nil {
+			m.finishLocked(t, StatusFailed, nil, fmt.Sprintf("failed to encode result: %v", err))
+			return
+		}
+		m.finishLocked(t, StatusSucceeded, raw, "")
+	}
+}
+
+// run 執行工作內容，panic 時視為失敗
+func (m *Manager) run(t *task) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return t.run(t.ctx, &taskReporter{manager: m, task: t})
+}
+
+// taskReporter 單一工作的 Reporter
+type taskReporter struct {
+	manager *Manager
+	task    *task
+}
+
+func (r *taskReporter) Progress(progress float64, message string) {
+	m, t := r.manager, r.task
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t.job.Finished() || math.IsNaN(progress) {
+		return
+	}
+	t.job.Progress = math.Max(0, math.Min(1, progress))
+	t.job.Message = message
+	if time.Since(t.lastPersist) >= progressPersistInterval {
+		m.persistLocked(t)
+	}
+	m.publishLocked(t.job, EventProgress)
+}
+
+func (r *taskReporter) Partial(data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode partial result of job %s: %v", r.task.job.ID, err)
+		return
+	}
+
+	m, t := r.manager, r.task
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t.job.Finished() {
+		return
+	}
+	m.publishEventLocked(t.job, EventPartial, raw)
+}
+
+// finishLocked 記錄工作結束狀態、通知並關閉訂閱者，呼叫端需持有鎖
+func (m *Manager) finishLocked(t *task, status string, result json.RawMessage, errMessage string) {
+	now := time.Now()
+	t.job.Status = status
+	t.job.Result = result
+	t.job.Error = errMessage
+	t.job.CompletedAt = &now
+	if status == StatusSucceeded {
+		t.job.Progress = 1
+		t.job.Message = ""
+	}
+	if status == StatusCancelled {
+		t.job.Message = "cancelled"
+	}
+	t.cancel()
+	m.persistLocked(t)
+	m.publishLocked(t.job, EventStatus)
+
+	for ch := range m.subscribers[t.job.ID] {
+		close(ch)
+	}
+	delete(m.subscribers, t.job.ID)
+	delete(m.tasks, t.job.ID)
+}
+
+// persistLocked 寫入 repository，失敗時只記錄（工作狀態仍以記憶體為準直到結束）
+func (m *Manager) persistLocked(t *task) {
+	t.lastPersist = time.Now()
+	if err := m.repo.Update(t.job); err != nil && !errors.Is(err, ErrJobNotFound) {
+		log.Printf("Failed to persist job %s: %v", t.job.ID, err)
+	}
+}
+
+// publishLocked 發布狀態或進度事件給訂閱者
+func (m *Manager) publishLocked(job *Job, eventType string) {
+	m.publishEventLocked(job, eventType, nil)
+}
+
+// publishEventLocked 發布事件給訂閱者，緩衝已滿的訂閱者會略過此事件
+func (m *Manager) publishEventLocked(job *Job, eventType string, data json.RawMessage) {
+	event := Event{
+		Type:     eventType,
+		JobID:    job.ID,
+		Status:   job.Status,
+		Progress: job.Progress,
+		Message:  job.Message,
+		Error:    job.Error,
+		Data:     data,
+		Time:     time.Now(),
+	}
+	for ch := range m.subscribers[job.ID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
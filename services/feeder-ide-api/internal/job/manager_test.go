@@ -0,0 +1,266 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitTimeout 等待工作結束的上限
+const waitTimeout = 5 * time.Second
+
+func newJob() *Job {
+	return &Job{Type: "powerflow", TopologyID: "topology-a"}
+}
+
+// waitFinished 等待訂閱的事件通道關閉（工作結束），回傳收到的事件
+func waitFinished(t *testing.T, events <-chan Event) []Event {
+	t.Helper()
+	received := []Event{}
+	timeout := time.After(waitTimeout)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return received
+			}
+			received = append(received, event)
+		case <-timeout:
+			t.Fatal("timed out waiting for job to finish")
+		}
+	}
+}
+
+func TestManagerOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        RunFunc
+		onStart    StartFunc
+		wantStatus string
+		wantError  string
+		wantResult string
+	}{
+		{
+			name: "succeeded",
+			run: func(ctx context.Context, report Reporter) (interface{}, error) {
+				report.Progress(0.5, "half way")
+				return map[string]int{"answer": 42}, nil
+			},
+			wantStatus: StatusSucceeded,
+			wantResult: `{"answer":42}`,
+		},
+		{
+			name: "failed",
+			run: func(ctx context.Context, report Reporter) (interface{}, error) {
+				return nil, errors.New("solver diverged")
+			},
+			wantStatus: StatusFailed,
+			wantError:  "solver diverged",
+		},
+		{
+			name: "panic",
+			run: func(ctx context.Context, report Reporter) (interface{}, error) {
+				panic("boom")
+			},
+			wantStatus: StatusFailed,
+			wantError:  "job panicked: boom",
+		},
+		{
+			name: "start hook rejects",
+			run: func(ctx context.Context, report Reporter) (interface{}, error) {
+				t.Error("run called after the start hook failed")
+				return nil, nil
+			},
+			onStart:    func(job *Job) error { return errors.New("quota exceeded") },
+			wantStatus: StatusFailed,
+			wantError:  "failed to start job: quota exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewInMemoryRepository()
+			m := NewManager(repo, 1, 4)
+			m.SetStartHook(tt.onStart)
+
+			submitted, err := m.Submit(newJob(), tt.run)
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if submitted.Status != StatusQueued {
+				t.Errorf("submitted status = %s, want %s", submitted.Status, StatusQueued)
+			}
+			_, events, unsubscribe, err := m.Subscribe(submitted.ID)
+			if err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+			defer unsubscribe()
+
+			if err := m.Start(); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			received := waitFinished(t, events)
+			if len(received) == 0 || received[len(received)-1].Status != tt.wantStatus {
+				t.Errorf("last event = %+v, want status %s", received, tt.wantStatus)
+			}
+
+			// 結束後以 repository 中的資料為準
+			stored, err := repo.GetByID(submitted.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if stored.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", stored.Error, tt.wantError)
+			}
+			if string(stored.Result) != tt.wantResult {
+				t.Errorf("result = %s, want %s", stored.Result, tt.wantResult)
+			}
+			if stored.CompletedAt == nil {
+				t.Error("completed_at not set")
+			}
+		})
+	}
+}
+
+func TestManagerQueueFull(t *testing.T) {
+	repo := NewInMemoryRepository()
+	// 不啟動 worker，佇列只容納一個工作
+	m := NewManager(repo, 1, 1)
+	never := func(ctx context.Context, report Reporter) (interface{}, error) {
+		t.Error("job ran without a worker")
+		return nil, nil
+	}
+
+	first, err := m.Submit(newJob(), never)
+	if err != nil {
+		t.Fatalf("first Submit: %v", err)
+	}
+	if _, err := m.Submit(newJob(), never); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("second Submit error = %v, want ErrQueueFull", err)
+	}
+
+	// 被拒絕的工作以失敗狀態保存，佇列中的工作不受影響
+	jobs, err := repo.List(Filter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	statuses := map[string]int{}
+	for _, job := range jobs {
+		statuses[job.Status]++
+		if job.Status == StatusFailed && job.Error != ErrQueueFull.Error() {
+			t.Errorf("rejected job error = %q, want %q", job.Error, ErrQueueFull.Error())
+		}
+	}
+	if statuses[StatusQueued] != 1 || statuses[StatusFailed] != 1 {
+		t.Errorf("statuses = %v, want one queued and one failed", statuses)
+	}
+
+	current, err := m.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if current.Status != StatusQueued {
+		t.Errorf("first job status = %s, want %s", current.Status, StatusQueued)
+	}
+}
+
+func TestManagerCancelQueued(t *testing.T) {
+	repo := NewInMemoryRepository()
+	m := NewManager(repo, 1, 4)
+	submitted, err := m.Submit(newJob(), func(ctx context.Context, report Reporter) (interface{}, error) {
+		t.Error("cancelled job ran")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	cancelled, err := m.Cancel(submitted.ID)
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if cancelled.Status != StatusCancelled {
+		t.Errorf("status = %s, want %s", cancelled.Status, StatusCancelled)
+	}
+
+	// worker 取出已取消的工作時直接略過
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	_, events, unsubscribe, err := m.Subscribe(submitted.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer unsubscribe()
+	if events != nil {
+		t.Error("events channel returned for a finished job")
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{"already cancelled", submitted.ID, ErrJobFinished},
+		{"unknown job", "missing", ErrJobNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Cancel(tt.id); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Cancel error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManagerCancelRunning(t *testing.T) {
+	repo := NewInMemoryRepository()
+	m := NewManager(repo, 1, 4)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	started := make(chan struct{})
+	submitted, err := m.Submit(newJob(), func(ctx context.Context, report Reporter) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	_, events, unsubscribe, err := m.Subscribe(submitted.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	select {
+	case <-started:
+	case <-time.After(waitTimeout):
+		t.Fatal("job did not start")
+	}
+
+	requested, err := m.Cancel(submitted.ID)
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if requested.Status != StatusRunning || !strings.Contains(requested.Message, "cancellation requested") {
+		t.Errorf("after Cancel = %s %q, want running with cancellation requested", requested.Status, requested.Message)
+	}
+
+	waitFinished(t, events)
+	stored, err := m.Get(submitted.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	// ctx 被取消時不論 RunFunc 回傳什麼都記為 cancelled
+	if stored.Status != StatusCancelled || stored.Error != "" {
+		t.Errorf("status = %s, error = %q, want cancelled without error", stored.Status, stored.Error)
+	}
+}
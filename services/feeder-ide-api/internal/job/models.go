@@ -0,0 +1,102 @@
+// Package job 以有限的 worker pool 非同步執行耗時的模擬分析
+//
+// 提交後工作進入佇列（queued），由 worker 取出執行（running），結束時為 succeeded、failed 或 cancelled。
+// 執行中的進度、部分結果與狀態變化會發布給訂閱者（SSE），工作狀態與最終結果保存在 Repository。
+package job
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 工作狀態
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// 事件類型
+const (
+	EventStatus   = "status"
+	EventProgress = "progress"
+	EventPartial  = "partial" // 執行中的部分結果，只發布給訂閱者不保存
+)
+
+// Job 非同步模擬工作
+type Job struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"` // powerflow, hosting_capacity, ...
+	TopologyID     string          `json:"topology_id"`
+	UserID         *string         `json:"user_id,omitempty"`
+	ShareTokenHash string          `json:"-"` // 未登入者透過共用連結提交時的連結 token 雜湊
+	Status         string          `json:"status"`
+	Progress       float64         `json:"progress"` // 0 ~ 1
+	Message        string          `json:"message,omitempty"`
+	Params         json.RawMessage `json:"params,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	StartedAt      *time.Time      `json:"started_at,omitempty"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Finished 工作是否已結束
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Visibility 存取者身分：登入用戶，或未登入時使用的共用連結 token 雜湊
+type Visibility struct {
+	UserID         *string
+	ShareTokenHash string
+}
+
+// VisibleTo 是否可以存取此工作：用戶的工作只有本人可見，透過共用連結提交的工作只有持有同一連結者可見；
+// 其餘無用戶的工作屬於無擁有者的 demo 拓樸，對所有未登入者可見
+func (j *Job) VisibleTo(v Visibility) bool {
+	if j.UserID != nil || v.UserID != nil {
+		return j.UserID != nil && v.UserID != nil && *j.UserID == *v.UserID
+	}
+	return j.ShareTokenHash == v.ShareTokenHash
+}
+
+// Clone 複製工作，避免呼叫端修改影響已儲存的資料
+func (j *Job) Clone() *Job {
+	clone := *j
+	if j.UserID != nil {
+		userID := *j.UserID
+		clone.UserID = &userID
+	}
+	if j.StartedAt != nil {
+		startedAt := *j.StartedAt
+		clone.StartedAt = &startedAt
+	}
+	if j.CompletedAt != nil {
+		completedAt := *j.CompletedAt
+		clone.CompletedAt = &completedAt
+	}
+	return &clone
+}
+
+// Event 工作狀態或進度變化
+type Event struct {
+	Type     string          `json:"type"` // status, progress, partial
+	JobID    string          `json:"job_id"`
+	Status   string          `json:"status"`
+	Progress float64         `json:"progress"`
+	Message  string          `json:"message,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"` // partial 事件的內容
+	Time     time.Time       `json:"time"`
+}
+
+// Filter 列出工作的條件
+type Filter struct {
+	Visibility Visibility
+	TopologyID string
+	Status     string
+	Limit      int
+}
@@ -0,0 +1,216 @@
+package job
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/database"
+)
+
+// PostgresRepository PostgreSQL 實作
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository 建立新的 PostgreSQL repository
+func NewPostgresRepository() (*PostgresRepository, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return &PostgresRepository{
+		db: database.DB,
+	}, nil
+}
+
+const jobColumns = `id, type, topology_id, user_id, share_token_hash, status, progress, message, params, result, error, created_at, started_at, completed_at`
+
+func (r *PostgresRepository) Create(job *Job) error {
+	query := `
+		INSERT INTO simulation_jobs (` + jobColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err := r.db.Exec(query,
+		job.ID,
+		job.Type,
+		job.TopologyID,
+		job.UserID,
+		job.ShareTokenHash,
+		job.Status,
+		job.Progress,
+		job.Message,
+		nullJSON(job.Params),
+		nullJSON(job.Result),
+		job.Error,
+		job.CreatedAt,
+		job.StartedAt,
+		job.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Update(job *Job) error {
+	query := `
+		UPDATE simulation_jobs
+		SET status = $2, progress = $3, message = $4, result = $5, error = $6, started_at = $7, completed_at = $8
+		WHERE id = $1
+	`
+	res, err := r.db.Exec(query,
+		job.ID,
+		job.Status,
+		job.Progress,
+		job.Message,
+		nullJSON(job.Result),
+		job.Error,
+		job.StartedAt,
+		job.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetByID(id string) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM simulation_jobs WHERE id = $1`
+	job, err := scanJob(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *PostgresRepository) List(filter Filter) ([]*Job, error) {
+	// 列表不含結果，避免傳回大量資料
+	// 註冊用戶只列出自己的工作，未登入時只列出同一共用連結（或 demo）的工作
+	query := `SELECT id, type, topology_id, user_id, share_token_hash, status, progress, message, params, NULL, error, created_at, started_at, completed_at
+	          FROM simulation_jobs WHERE `
+	args := []interface{}{}
+	if filter.Visibility.UserID == nil {
+		args = append(args, filter.Visibility.ShareTokenHash)
+		query += `user_id IS NULL AND share_token_hash = $1`
+	} else {
+		args = append(args, *filter.Visibility.UserID)
+		query += `user_id = $1`
+	}
+	if filter.TopologyID != "" {
+		args = append(args, filter.TopologyID)
+		query += ` AND topology_id = $` + strconv.Itoa(len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += ` AND status = $` + strconv.Itoa(len(args))
+	}
+	query += ` ORDER BY created_at DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += ` LIMIT $` + strconv.Itoa(len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *PostgresRepository) FailUnfinished(message string) (int, error) {
+	query := `
+		UPDATE simulation_jobs
+		SET status = $1, error = $2, completed_at = $3
+		WHERE status IN ($4, $5)
+	`
+	res, err := r.db.Exec(query, StatusFailed, message, time.Now(), StatusQueued, StatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail unfinished jobs: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// rowScanner 同時支援 *sql.Row 與 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	var job Job
+	var userID sql.NullString
+	var params, result []byte
+	var startedAt, completedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&job.TopologyID,
+		&userID,
+		&job.ShareTokenHash,
+		&job.Status,
+		&job.Progress,
+		&job.Message,
+		&params,
+		&result,
+		&job.Error,
+		&job.CreatedAt,
+		&startedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		job.UserID = &userID.String
+	}
+	if len(params) > 0 {
+		job.Params = params
+	}
+	if len(result) > 0 {
+		job.Result = result
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
+
+// nullJSON 空的 JSON 以 NULL 儲存
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
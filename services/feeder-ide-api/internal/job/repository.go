@@ -0,0 +1,103 @@
+package job
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Repository 定義工作儲存介面
+type Repository interface {
+	Create(job *Job) error
+	Update(job *Job) error
+	GetByID(id string) (*Job, error)
+	List(filter Filter) ([]*Job, error) // 依建立時間由新到舊，不含結果
+	// FailUnfinished 將未結束的工作標示為失敗（服務重啟後無法繼續執行），回傳更新數量
+	FailUnfinished(message string) (int, error)
+}
+
+// InMemoryRepository 記憶體實作（開發用）
+type InMemoryRepository struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewInMemoryRepository 建立新的記憶體 repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		jobs: make(map[string]*Job),
+	}
+}
+
+func (r *InMemoryRepository) Create(job *Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID] = job.Clone()
+	return nil
+}
+
+func (r *InMemoryRepository) Update(job *Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[job.ID]; !exists {
+		return ErrJobNotFound
+	}
+	r.jobs[job.ID] = job.Clone()
+	return nil
+}
+
+func (r *InMemoryRepository) GetByID(id string) (*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	return job.Clone(), nil
+}
+
+func (r *InMemoryRepository) List(filter Filter) ([]*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := []*Job{}
+	for _, job := range r.jobs {
+		if !job.VisibleTo(filter.Visibility) ||
+			(filter.TopologyID != "" && job.TopologyID != filter.TopologyID) ||
+			(filter.Status != "" && job.Status != filter.Status) {
+			continue
+		}
+		clone := job.Clone()
+		clone.Result = nil
+		jobs = append(jobs, clone)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+func (r *InMemoryRepository) FailUnfinished(message string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	now := time.Now()
+	for _, job := range r.jobs {
+		if job.Finished() {
+			continue
+		}
+		job.Status = StatusFailed
+		job.Error = message
+		job.CompletedAt = &now
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/auth"
+	"github.com/feeder-platform/feeder-ide-api/internal/organization"
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationMiddleware 載入登入用戶所屬組織的角色，供組織拓樸權限與型錄可見範圍使用
+func OrganizationMiddleware(orgService *organization.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := auth.GetUserID(c)
+		if userID != nil {
+			roles, err := orgService.GetMemberRoles(*userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load organization memberships"})
+				c.Abort()
+				return
+			}
+			c.Set("organization_roles", roles)
+		}
+
+		c.Next()
+	}
+}
+
+// GetOrganizationRolesFromContext 從 context 取得組織角色（組織 ID -> 角色），未登入或未啟用組織功能時為 nil
+func GetOrganizationRolesFromContext(c *gin.Context) map[string]organization.Role {
+	roles, exists := c.Get("organization_roles")
+	if !exists {
+		return nil
+	}
+
+	organizationRoles, ok := roles.(map[string]organization.Role)
+	if !ok {
+		return nil
+	}
+
+	return organizationRoles
+}
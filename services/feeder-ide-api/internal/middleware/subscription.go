@@ -106,7 +106,8 @@ func QuotaMiddleware(quotaType string, userService *user.Service) gin.HandlerFun
 			c.Set("quota_max", max)
 
 		case "simulation":
-			canSimulate, err := userService.CheckSimulationQuota(userID)
+			// 組織擁有的拓樸（:id）使用組織共用配額
+			canSimulate, err := userService.CheckSimulationQuotaForTopology(userID, c.Param("id"))
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check simulation quota"})
 				c.Abort()
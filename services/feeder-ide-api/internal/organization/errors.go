@@ -0,0 +1,14 @@
+package organization
+
+import "errors"
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrInvalidName          = errors.New("organization name must be 1-255 characters")
+	ErrInvalidRole          = errors.New("invalid organization role")
+	ErrInvalidTier          = errors.New("invalid organization tier")
+	ErrInsufficientRole     = errors.New("insufficient role in this organization")
+	ErrLastAdmin            = errors.New("an organization must keep at least one admin")
+	ErrOrganizationNotEmpty = errors.New("organization still owns topologies")
+)
@@ -0,0 +1,127 @@
+package organization
+
+import (
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 組織訂閱等級
+const (
+	TierFree       = "free"
+	TierTeam       = "team"
+	TierEnterprise = "enterprise"
+)
+
+// Organization 組織（團隊工作區），成員共用組織擁有的拓樸與配額
+type Organization struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	SubscriptionTier   string    `json:"subscription_tier"`   // free, team, enterprise
+	SubscriptionStatus string    `json:"subscription_status"` // active, cancelled, expired
+	CreatedBy          *string   `json:"created_by,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Role 成員在組織中的角色
+type Role string
+
+// 組織角色
+const (
+	RoleAdmin    Role = "admin"    // 管理成員與組織設定，對組織拓樸為 owner
+	RoleEngineer Role = "engineer" // 建立與修改組織拓樸，對組織拓樸為 editor
+	RoleViewer   Role = "viewer"   // 讀取與分析組織拓樸，對組織拓樸為 viewer
+)
+
+// rank 角色等級，用於比較權限
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEngineer:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Valid 是否為可授予的角色
+func (r Role) Valid() bool {
+	return r.rank() > 0
+}
+
+// Allows 是否具備 required 角色以上的權限
+func (r Role) Allows(required Role) bool {
+	return r.rank() > 0 && r.rank() >= required.rank()
+}
+
+// TopologyRole 組織角色對組織擁有的拓樸所對應的拓樸角色
+func (r Role) TopologyRole() topology.Role {
+	switch r {
+	case RoleAdmin:
+		return topology.RoleOwner
+	case RoleEngineer:
+		return topology.RoleEditor
+	case RoleViewer:
+		return topology.RoleViewer
+	default:
+		return topology.RoleNone
+	}
+}
+
+// TopologyRoles 將組織角色對應為 topology.Principal 使用的拓樸角色
+func TopologyRoles(roles map[string]Role) map[string]topology.Role {
+	if len(roles) == 0 {
+		return nil
+	}
+	mapped := make(map[string]topology.Role, len(roles))
+	for organizationID, role := range roles {
+		mapped[organizationID] = role.TopologyRole()
+	}
+	return mapped
+}
+
+// Member 組織成員
+type Member struct {
+	OrganizationID string    `json:"organization_id"`
+	UserID         string    `json:"user_id"`
+	Email          string    `json:"email,omitempty"` // 由 handler 填入
+	Role           Role      `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Membership 用戶所屬的組織與角色
+type Membership struct {
+	*Organization
+	Role Role `json:"role"`
+}
+
+// Quota 組織共用配額，所有成員的拓樸數量與每日模擬次數合併計算
+type Quota struct {
+	OrganizationID          string    `json:"organization_id"`
+	MaxTopologies           int       `json:"max_topologies"`
+	UsedTopologies          int       `json:"used_topologies"`
+	MaxSimulationsPerDay    int       `json:"max_simulations_per_day"`
+	UsedSimulationsToday    int       `json:"used_simulations_today"`
+	LastSimulationResetDate time.Time `json:"last_simulation_reset_date"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// tierLimits 各訂閱等級的配額上限（拓樸數量、每日模擬次數）
+func tierLimits(tier string) (int, int, bool) {
+	switch tier {
+	case TierFree:
+		return 10, 100, true
+	case TierTeam:
+		return 999999, 1000, true // 拓樸數量無限
+	case TierEnterprise:
+		return 999999, 999999, true // 無限
+	default:
+		return 0, 0, false
+	}
+}
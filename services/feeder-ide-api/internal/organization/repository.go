@@ -0,0 +1,534 @@
+package organization
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/database"
+	"github.com/google/uuid"
+)
+
+// Repository 組織資料存取介面
+type Repository interface {
+	// Organization
+	// CreateOrganization 建立組織、配額，並將建立者加入為 admin
+	CreateOrganization(org *Organization, quota *Quota) error
+	GetOrganizationByID(id string) (*Organization, error)
+	UpdateOrganization(org *Organization) error
+	// DeleteOrganization 刪除組織；仍擁有拓樸時回傳 ErrOrganizationNotEmpty
+	DeleteOrganization(id string) error
+
+	// Member
+	ListMembershipsByUserID(userID string) ([]*Membership, error) // 依組織名稱排列
+	GetMemberRoles(userID string) (map[string]Role, error)        // 組織 ID -> 角色
+	GetMember(organizationID, userID string) (*Member, error)
+	ListMembers(organizationID string) ([]*Member, error)
+	// SetMember 新增成員或更新角色；會讓組織沒有 admin 時回傳 ErrLastAdmin
+	SetMember(member *Member) error
+	// RemoveMember 移除成員；移除最後一位 admin 時回傳 ErrLastAdmin
+	RemoveMember(organizationID, userID string) error
+
+	// Quota
+	CreateOrUpdateQuota(quota *Quota) error
+	GetQuotaByOrganizationID(organizationID string) (*Quota, error)
+	// IncrementSimulationCount 以單一敘述增加當日模擬次數（跨日時重新計數），成員同時執行模擬也不會漏算
+	IncrementSimulationCount(organizationID string, today time.Time) error
+}
+
+// PostgresRepository PostgreSQL 實作
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository 建立新的 PostgreSQL organization repository
+func NewPostgresRepository() (*PostgresRepository, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return &PostgresRepository{
+		db: database.DB,
+	}, nil
+}
+
+// organizationColumns 組織查詢欄位（對應 scanOrganization），o 為 organizations 表別名
+const organizationColumns = `o.id, o.name, o.subscription_tier, o.subscription_status, o.created_by, o.created_at, o.updated_at`
+
+// rowScanner 可同時接受 *sql.Row 與 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrganization 掃描 organizationColumns，extra 為其後的額外欄位；查無資料時回傳 sql.ErrNoRows
+func scanOrganization(row rowScanner, extra ...interface{}) (*Organization, error) {
+	var org Organization
+	var createdBy sql.NullString
+
+	dest := []interface{}{
+		&org.ID,
+		&org.Name,
+		&org.SubscriptionTier,
+		&org.SubscriptionStatus,
+		&createdBy,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan organization: %w", err)
+	}
+
+	if createdBy.Valid {
+		createdByStr := createdBy.String
+		org.CreatedBy = &createdByStr
+	}
+	return &org, nil
+}
+
+// Organization
+func (r *PostgresRepository) CreateOrganization(org *Organization, quota *Quota) error {
+	if org.ID == "" {
+		org.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	org.CreatedAt = now
+	org.UpdatedAt = now
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO organizations (id, name, subscription_tier, subscription_status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = tx.Exec(query,
+		org.ID,
+		org.Name,
+		org.SubscriptionTier,
+		org.SubscriptionStatus,
+		org.CreatedBy,
+		org.CreatedAt,
+		org.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	if org.CreatedBy != nil {
+		_, err = tx.Exec(`INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+		                  VALUES ($1, $2, $3, $4, $4)`, org.ID, *org.CreatedBy, RoleAdmin, now)
+		if err != nil {
+			return fmt.Errorf("failed to add organization admin: %w", err)
+		}
+	}
+
+	quota.OrganizationID = org.ID
+	if err := upsertQuota(tx, quota); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetOrganizationByID(id string) (*Organization, error) {
+	org, err := scanOrganization(r.db.QueryRow(`SELECT `+organizationColumns+` FROM organizations o WHERE o.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (r *PostgresRepository) UpdateOrganization(org *Organization) error {
+	org.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE organizations
+		SET name = $1, subscription_tier = $2, subscription_status = $3, updated_at = $4
+		WHERE id = $5
+	`
+
+	result, err := r.db.Exec(query,
+		org.Name,
+		org.SubscriptionTier,
+		org.SubscriptionStatus,
+		org.UpdatedAt,
+		org.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrOrganizationNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) DeleteOrganization(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOrganization(tx, id); err != nil {
+		return err
+	}
+
+	var hasTopologies bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM topologies WHERE organization_id = $1)`, id).Scan(&hasTopologies); err != nil {
+		return fmt.Errorf("failed to check organization topologies: %w", err)
+	}
+	if hasTopologies {
+		return ErrOrganizationNotEmpty
+	}
+
+	// 成員與配額以 ON DELETE CASCADE 一併刪除
+	if _, err := tx.Exec(`DELETE FROM organizations WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockOrganization 鎖定組織列，讓成員異動與刪除依序執行
+func lockOrganization(tx *sql.Tx, id string) error {
+	var locked string
+	err := tx.QueryRow(`SELECT id FROM organizations WHERE id = $1 FOR UPDATE`, id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock organization: %w", err)
+	}
+	return nil
+}
+
+// Member
+func (r *PostgresRepository) ListMembershipsByUserID(userID string) ([]*Membership, error) {
+	query := `SELECT ` + organizationColumns + `, m.role
+	          FROM organization_members m
+	          JOIN organizations o ON o.id = m.organization_id
+	          WHERE m.user_id = $1
+	          ORDER BY o.name, o.id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query organizations: %w", err)
+	}
+	defer rows.Close()
+
+	memberships := []*Membership{}
+	for rows.Next() {
+		var role Role
+		org, err := scanOrganization(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, &Membership{Organization: org, Role: role})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return memberships, nil
+}
+
+func (r *PostgresRepository) GetMemberRoles(userID string) (map[string]Role, error) {
+	rows, err := r.db.Query(`SELECT organization_id, role FROM organization_members WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query organization roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := make(map[string]Role)
+	for rows.Next() {
+		var organizationID string
+		var role Role
+		if err := rows.Scan(&organizationID, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan organization role: %w", err)
+		}
+		roles[organizationID] = role
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// memberColumns 成員查詢欄位（對應 scanMember）
+const memberColumns = `organization_id, user_id, role, created_at, updated_at`
+
+func scanMember(row rowScanner) (*Member, error) {
+	var member Member
+	err := row.Scan(
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan member: %w", err)
+	}
+	return &member, nil
+}
+
+func (r *PostgresRepository) GetMember(organizationID, userID string) (*Member, error) {
+	member, err := scanMember(r.db.QueryRow(`SELECT `+memberColumns+` FROM organization_members
+	                                          WHERE organization_id = $1 AND user_id = $2`, organizationID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (r *PostgresRepository) ListMembers(organizationID string) ([]*Member, error) {
+	// 依角色（高到低）與加入時間排列
+	query := `SELECT ` + memberColumns + ` FROM organization_members WHERE organization_id = $1
+	          ORDER BY CASE role WHEN 'admin' THEN 3 WHEN 'engineer' THEN 2 ELSE 1 END DESC, created_at, user_id`
+
+	rows, err := r.db.Query(query, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*Member{}
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return members, nil
+}
+
+func (r *PostgresRepository) SetMember(member *Member) error {
+	if !member.Role.Valid() {
+		return ErrInvalidRole
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOrganization(tx, member.OrganizationID); err != nil {
+		return err
+	}
+	if member.Role != RoleAdmin {
+		if err := ensureOtherAdmin(tx, member.OrganizationID, member.UserID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (organization_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+	err = tx.QueryRow(query,
+		member.OrganizationID,
+		member.UserID,
+		member.Role,
+		time.Now(),
+	).Scan(&member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set member: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) RemoveMember(organizationID, userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOrganization(tx, organizationID); err != nil {
+		return err
+	}
+	if err := ensureOtherAdmin(tx, organizationID, userID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, organizationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrMemberNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ensureOtherAdmin userID 是 admin 時，確認組織還有其他 admin（呼叫端需已鎖定組織列）
+func ensureOtherAdmin(tx *sql.Tx, organizationID, userID string) error {
+	var isAdmin, hasOtherAdmin bool
+	query := `SELECT
+	              EXISTS (SELECT 1 FROM organization_members WHERE organization_id = $1 AND user_id = $2 AND role = 'admin'),
+	              EXISTS (SELECT 1 FROM organization_members WHERE organization_id = $1 AND user_id <> $2 AND role = 'admin')`
+	if err := tx.QueryRow(query, organizationID, userID).Scan(&isAdmin, &hasOtherAdmin); err != nil {
+		return fmt.Errorf("failed to check organization admins: %w", err)
+	}
+	if isAdmin && !hasOtherAdmin {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// Quota
+func (r *PostgresRepository) CreateOrUpdateQuota(quota *Quota) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertQuota(tx, quota); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// upsertQuota 在交易中新增或更新組織配額
+func upsertQuota(tx *sql.Tx, quota *Quota) error {
+	now := time.Now()
+	if quota.CreatedAt.IsZero() {
+		quota.CreatedAt = now
+	}
+	quota.UpdatedAt = now
+
+	query := `
+		INSERT INTO organization_quotas (organization_id, max_topologies, used_topologies, max_simulations_per_day,
+		                                 used_simulations_today, last_simulation_reset_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (organization_id)
+		DO UPDATE SET
+			max_topologies = EXCLUDED.max_topologies,
+			used_topologies = EXCLUDED.used_topologies,
+			max_simulations_per_day = EXCLUDED.max_simulations_per_day,
+			used_simulations_today = EXCLUDED.used_simulations_today,
+			last_simulation_reset_date = EXCLUDED.last_simulation_reset_date,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := tx.Exec(query,
+		quota.OrganizationID,
+		quota.MaxTopologies,
+		quota.UsedTopologies,
+		quota.MaxSimulationsPerDay,
+		quota.UsedSimulationsToday,
+		quota.LastSimulationResetDate,
+		quota.CreatedAt,
+		quota.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create or update organization quota: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetQuotaByOrganizationID(organizationID string) (*Quota, error) {
+	query := `SELECT organization_id, max_topologies, used_topologies, max_simulations_per_day,
+	                 used_simulations_today, last_simulation_reset_date, created_at, updated_at
+	          FROM organization_quotas WHERE organization_id = $1`
+
+	var quota Quota
+	err := r.db.QueryRow(query, organizationID).Scan(
+		&quota.OrganizationID,
+		&quota.MaxTopologies,
+		&quota.UsedTopologies,
+		&quota.MaxSimulationsPerDay,
+		&quota.UsedSimulationsToday,
+		&quota.LastSimulationResetDate,
+		&quota.CreatedAt,
+		&quota.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // 沒有配額時由 service 依等級建立
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization quota: %w", err)
+	}
+
+	return &quota, nil
+}
+
+func (r *PostgresRepository) IncrementSimulationCount(organizationID string, today time.Time) error {
+	query := `
+		UPDATE organization_quotas
+		SET used_simulations_today = CASE WHEN last_simulation_reset_date < $2 THEN 1 ELSE used_simulations_today + 1 END,
+		    last_simulation_reset_date = GREATEST(last_simulation_reset_date, $2),
+		    updated_at = $3
+		WHERE organization_id = $1
+	`
+
+	result, err := r.db.Exec(query, organizationID, today, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update organization quota: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("organization quota not found")
+	}
+
+	return nil
+}
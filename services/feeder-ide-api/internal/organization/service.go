@@ -0,0 +1,256 @@
+package organization
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// TopologyStore 組織配額使用的拓樸查詢（由 topology.Repository 實作）
+type TopologyStore interface {
+	CountByOrganizationID(organizationID string) (int, error)
+	GetOrganizationID(id string) (*string, error)
+}
+
+// Service 組織服務：組織、成員與組織共用配額
+type Service struct {
+	repo       Repository
+	topologies TopologyStore
+}
+
+// NewService 建立新的組織服務
+func NewService(repo Repository, topologies TopologyStore) *Service {
+	return &Service{
+		repo:       repo,
+		topologies: topologies,
+	}
+}
+
+// normalizeName 去除前後空白並檢查長度
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 255 {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+// CreateOrganization 建立組織（免費等級），建立者成為 admin
+func (s *Service) CreateOrganization(name string, creatorID string) (*Organization, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	org := &Organization{
+		Name:               name,
+		SubscriptionTier:   TierFree,
+		SubscriptionStatus: "active",
+		CreatedBy:          &creatorID,
+	}
+	if err := s.repo.CreateOrganization(org, defaultQuota(TierFree)); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrganization 取得組織，不存在時回傳 ErrOrganizationNotFound
+func (s *Service) GetOrganization(id string) (*Organization, error) {
+	return s.repo.GetOrganizationByID(id)
+}
+
+// RenameOrganization 修改組織名稱
+func (s *Service) RenameOrganization(id string, name string) (*Organization, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.repo.GetOrganizationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	org.Name = name
+	if err := s.repo.UpdateOrganization(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// DeleteOrganization 刪除組織；仍擁有拓樸時回傳 ErrOrganizationNotEmpty
+func (s *Service) DeleteOrganization(id string) error {
+	return s.repo.DeleteOrganization(id)
+}
+
+// UpdateOrganizationTier 更新組織訂閱等級（用於付費後），配額上限隨等級調整，已使用量保留
+func (s *Service) UpdateOrganizationTier(id string, tier string) error {
+	maxTopologies, maxSimulations, ok := tierLimits(tier)
+	if !ok {
+		return ErrInvalidTier
+	}
+
+	org, err := s.repo.GetOrganizationByID(id)
+	if err != nil {
+		return err
+	}
+	quota, err := s.GetQuota(id)
+	if err != nil {
+		return err
+	}
+
+	quota.MaxTopologies = maxTopologies
+	quota.MaxSimulationsPerDay = maxSimulations
+	if err := s.repo.CreateOrUpdateQuota(quota); err != nil {
+		return fmt.Errorf("failed to update quota: %w", err)
+	}
+
+	org.SubscriptionTier = tier
+	org.SubscriptionStatus = "active"
+	return s.repo.UpdateOrganization(org)
+}
+
+// ListMemberships 列出用戶所屬的組織與角色
+func (s *Service) ListMemberships(userID string) ([]*Membership, error) {
+	return s.repo.ListMembershipsByUserID(userID)
+}
+
+// GetMemberRoles 用戶在各組織的角色（組織 ID -> 角色）
+func (s *Service) GetMemberRoles(userID string) (map[string]Role, error) {
+	return s.repo.GetMemberRoles(userID)
+}
+
+// RequireRole 確認用戶是組織成員且具備 required 以上角色。
+// 非成員時回傳 ErrOrganizationNotFound（不透露組織是否存在），角色不足時回傳 ErrInsufficientRole 與目前的成員資料
+func (s *Service) RequireRole(organizationID, userID string, required Role) (*Member, error) {
+	member, err := s.repo.GetMember(organizationID, userID)
+	if errors.Is(err, ErrMemberNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !member.Role.Allows(required) {
+		return member, ErrInsufficientRole
+	}
+	return member, nil
+}
+
+// ListMembers 列出組織成員（依角色高到低）
+func (s *Service) ListMembers(organizationID string) ([]*Member, error) {
+	return s.repo.ListMembers(organizationID)
+}
+
+// SetMember 新增成員或更新角色
+func (s *Service) SetMember(organizationID, userID string, role Role) (*Member, error) {
+	member := &Member{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+	}
+	if err := s.repo.SetMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember 移除成員（或成員自行離開）
+func (s *Service) RemoveMember(organizationID, userID string) error {
+	return s.repo.RemoveMember(organizationID, userID)
+}
+
+// defaultQuota 依等級建立組織配額
+func defaultQuota(tier string) *Quota {
+	maxTopologies, maxSimulations, ok := tierLimits(tier)
+	if !ok {
+		maxTopologies, maxSimulations, _ = tierLimits(TierFree)
+	}
+	return &Quota{
+		MaxTopologies:           maxTopologies,
+		MaxSimulationsPerDay:    maxSimulations,
+		LastSimulationResetDate: time.Now().Truncate(24 * time.Hour),
+	}
+}
+
+// GetQuota 取得組織共用配額（含目前拓樸數量），跨日時重置模擬計數
+func (s *Service) GetQuota(organizationID string) (*Quota, error) {
+	quota, err := s.repo.GetQuotaByOrganizationID(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 如果沒有配額，依組織等級建立
+	if quota == nil {
+		org, err := s.repo.GetOrganizationByID(organizationID)
+		if err != nil {
+			return nil, err
+		}
+		quota = defaultQuota(org.SubscriptionTier)
+		quota.OrganizationID = organizationID
+		if err := s.repo.CreateOrUpdateQuota(quota); err != nil {
+			return nil, fmt.Errorf("failed to create quota: %w", err)
+		}
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	if quota.LastSimulationResetDate.Before(today) {
+		quota.UsedSimulationsToday = 0
+		quota.LastSimulationResetDate = today
+	}
+
+	if s.topologies != nil {
+		count, err := s.topologies.CountByOrganizationID(organizationID)
+		if err != nil {
+			return nil, err
+		}
+		quota.UsedTopologies = count
+	}
+
+	return quota, nil
+}
+
+// CheckTopologyQuota 檢查組織是否還能建立拓樸（所有成員共用）
+func (s *Service) CheckTopologyQuota(organizationID string) (bool, int, int, error) {
+	quota, err := s.GetQuota(organizationID)
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	canCreate := quota.UsedTopologies < quota.MaxTopologies
+	return canCreate, quota.UsedTopologies, quota.MaxTopologies, nil
+}
+
+// CheckSimulationQuota 檢查組織今日是否還能執行模擬（所有成員共用）
+func (s *Service) CheckSimulationQuota(organizationID string) (bool, error) {
+	quota, err := s.GetQuota(organizationID)
+	if err != nil {
+		return false, err
+	}
+
+	canSimulate := quota.UsedSimulationsToday < quota.MaxSimulationsPerDay
+	return canSimulate, nil
+}
+
+// IncrementSimulationCount 增加組織的模擬計數
+func (s *Service) IncrementSimulationCount(organizationID string) error {
+	// 確保配額存在（舊組織可能尚未建立配額）
+	if _, err := s.GetQuota(organizationID); err != nil {
+		return err
+	}
+	return s.repo.IncrementSimulationCount(organizationID, time.Now().Truncate(24*time.Hour))
+}
+
+// TopologyOrganization 擁有拓樸的組織，個人拓樸或拓樸不存在時回傳 nil（由 handler 回應 404）
+func (s *Service) TopologyOrganization(topologyID string) (*string, error) {
+	if s.topologies == nil {
+		return nil, nil
+	}
+	organizationID, err := s.topologies.GetOrganizationID(topologyID)
+	if errors.Is(err, topology.ErrTopologyNotFound) {
+		return nil, nil
+	}
+	return organizationID, err
+}
@@ -0,0 +1,11 @@
+package powerflow
+
+import "errors"
+
+var (
+	ErrEmptyTopology  = errors.New("topology has no nodes")
+	ErrNoSource       = errors.New("topology has no source node")
+	ErrMeshedNetwork  = errors.New("backward/forward sweep requires a radial network")
+	ErrSingularMatrix = errors.New("jacobian matrix is singular")
+	ErrUnknownMethod  = errors.New("unknown power flow method")
+)
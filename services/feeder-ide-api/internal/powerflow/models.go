@@ -0,0 +1,112 @@
+package powerflow
+
+// 求解方法
+const (
+	MethodAuto   = "auto"
+	MethodSweep  = "backward_forward_sweep"
+	MethodNewton = "newton_raphson"
+)
+
+// 節點 / 線路狀態
+const (
+	StatusNormal      = "normal"
+	StatusWarning     = "warning"
+	StatusCritical    = "critical"
+	StatusDeenergized = "deenergized"
+)
+
+// Options 潮流分析參數
+type Options struct {
+	Method          string  `json:"method,omitempty"`            // auto, backward_forward_sweep, newton_raphson
+	BaseVoltageKV   float64 `json:"base_voltage_kv,omitempty"`   // 變電所出口電壓（kV），預設取自電源節點
+	BaseMVA         float64 `json:"base_mva,omitempty"`          // 標么基準容量
+	SourceVoltagePU float64 `json:"source_voltage_pu,omitempty"` // 電源端電壓（pu）
+	Tolerance       float64 `json:"tolerance,omitempty"`
+	MaxIterations   int     `json:"max_iterations,omitempty"`
+	VoltageMinPU    float64 `json:"voltage_min_pu,omitempty"` // 電壓下限（pu）
+	VoltageMaxPU    float64 `json:"voltage_max_pu,omitempty"` // 電壓上限（pu）
+}
+
+// withDefaults 補上預設值
+func (o Options) withDefaults() Options {
+	if o.Method == "" {
+		o.Method = MethodAuto
+	}
+	if o.BaseMVA <= 0 {
+		o.BaseMVA = defaultBaseMVA
+	}
+	if o.SourceVoltagePU <= 0 {
+		o.SourceVoltagePU = 1.0
+	}
+	if o.Tolerance <= 0 {
+		o.Tolerance = defaultTolerance
+	}
+	if o.MaxIterations <= 0 {
+		o.MaxIterations = defaultMaxIter
+	}
+	if o.VoltageMinPU <= 0 {
+		o.VoltageMinPU = 0.95
+	}
+	if o.VoltageMaxPU <= 0 {
+		o.VoltageMaxPU = 1.05
+	}
+	return o
+}
+
+// Result 潮流分析結果
+type Result struct {
+	Method     string       `json:"method"`
+	Converged  bool         `json:"converged"`
+	Iterations int          `json:"iterations"`
+	Nodes      []NodeResult `json:"nodes"`
+	Lines      []LineResult `json:"lines"`
+	Summary    Summary      `json:"summary"`
+}
+
+// NodeResult 節點結果
+type NodeResult struct {
+	NodeID         string  `json:"node_id"`
+	Energized      bool    `json:"energized"`
+	VoltagePU      float64 `json:"voltage_pu"`
+	VoltageKV      float64 `json:"voltage_kv"`
+	AngleDeg       float64 `json:"angle_deg"`
+	LoadKW         float64 `json:"load_kw"`
+	LoadKVAR       float64 `json:"load_kvar"`
+	GenerationKW   float64 `json:"generation_kw"`
+	LoadingPercent float64 `json:"loading_percent,omitempty"` // 變壓器負載率
+	Status         string  `json:"status"`
+}
+
+// LineResult 線路結果
+type LineResult struct {
+	LineID         string  `json:"line_id"`
+	FromNodeID     string  `json:"from_node_id"`
+	ToNodeID       string  `json:"to_node_id"`
+	Energized      bool    `json:"energized"`
+	CurrentA       float64 `json:"current_a"`
+	LoadingPercent float64 `json:"loading_percent"`
+	PowerKW        float64 `json:"power_kw"`   // 由 from 端流向 to 端的實功率
+	PowerKVAR      float64 `json:"power_kvar"` // 由 from 端流向 to 端的虛功率
+	LossKW         float64 `json:"loss_kw"`
+	LossKVAR       float64 `json:"loss_kvar"`
+	Status         string  `json:"status"`
+}
+
+// Summary 整體統計
+type Summary struct {
+	SourceNodeID          string  `json:"source_node_id"`
+	SourcePowerKW         float64 `json:"source_power_kw"`
+	SourcePowerKVAR       float64 `json:"source_power_kvar"`
+	TotalLoadKW           float64 `json:"total_load_kw"`
+	TotalLoadKVAR         float64 `json:"total_load_kvar"`
+	TotalGenerationKW     float64 `json:"total_generation_kw"`
+	TotalLossesKW         float64 `json:"total_losses_kw"`
+	TotalLossesKVAR       float64 `json:"total_losses_kvar"`
+	MinVoltagePU          float64 `json:"min_voltage_pu"`
+	MaxVoltagePU          float64 `json:"max_voltage_pu"`
+	MaxLineLoadingPercent float64 `json:"max_line_loading_percent"`
+	VoltageViolations     int     `json:"voltage_violations"`
+	ThermalViolations     int     `json:"thermal_violations"`
+	DeenergizedNodes      int     `json:"deenergized_nodes"`
+	Meshed                bool    `json:"meshed"`
+}
@@ -0,0 +1,348 @@
+package powerflow
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 未指定屬性時使用的預設電氣參數
+const (
+	defaultBaseKV       = 12.47
+	defaultBaseMVA      = 10.0
+	defaultTolerance    = 1e-6
+	defaultMaxIter      = 100
+	defaultLengthKM     = 1.0
+	defaultROhmPerKM    = 0.306 // 336 kcmil ACSR 架空線
+	defaultXOhmPerKM    = 0.627
+	defaultAmpacityA    = 400.0
+	defaultPowerFactor  = 0.95
+	defaultXfmrZPercent = 5.0
+	defaultXfmrXRRatio  = 5.0
+	minImpedancePU      = 1e-6
+)
+
+// Demand 節點的負載與發電需求
+type Demand struct {
+	LoadKW       float64 `json:"load_kw"`
+	LoadKVAR     float64 `json:"load_kvar"`
+	GenerationKW float64 `json:"generation_kw"`
+}
+
+// NodeDemand 依節點類型與屬性計算負載與發電
+// 一般負載讀取 load_kw / load_kvar（或 power_factor）；EV 充電樁以額定功率計算；
+// DER 以 output_kw 為準，未指定時 PV / 風機以額定功率出力，電池預設不出力
+func NodeDemand(node topology.Node) Demand {
+	props := node.Properties
+	demand := Demand{
+		LoadKW: topology.FloatProperty(props, "load_kw", 0),
+	}
+
+	switch node.Type {
+	case topology.NodeTypeEVCharger:
+		if _, exists := props["load_kw"]; !exists {
+			var ev topology.EVChargerProperties
+			if err := topology.DecodeProperties(props, &ev); err == nil {
+				demand.LoadKW = ev.RatedPowerKW
+			}
+		}
+	case topology.NodeTypeDER:
+		var der topology.DERProperties
+		if err := topology.DecodeProperties(props, &der); err == nil {
+			defaultOutput := der.RatedPowerKW
+			if der.Type == "battery" {
+				defaultOutput = 0
+			}
+			demand.GenerationKW = topology.FloatProperty(props, "output_kw", defaultOutput)
+		}
+	}
+
+	if _, exists := props["load_kvar"]; exists {
+		demand.LoadKVAR = topology.FloatProperty(props, "load_kvar", 0)
+	} else {
+		pf := topology.FloatProperty(props, "power_factor", defaultPowerFactor)
+		if pf > 0 && pf < 1 {
+			demand.LoadKVAR = demand.LoadKW * math.Tan(math.Acos(pf))
+		}
+	}
+
+	return demand
+}
+
+// Bus 網路中的匯流排（對應拓樸節點）
+type Bus struct {
+	Index        int
+	NodeID       string
+	Type         string
+	BaseKV       float64
+	Demand       Demand
+	RatedKVA     float64 // 變壓器額定容量（非變壓器為 0）
+	OpenSwitch   bool    // 開路的開關節點，電力無法穿越
+	Energized    bool
+	Parent       int // 輻射樹中的上游匯流排，電源或未供電時為 -1
+	ParentBranch int
+	Children     []int
+	Depth        int
+}
+
+// NetDemandPU 匯流排淨需求（負載減發電），以標么表示
+func (b *Bus) NetDemandPU(baseMVA float64) complex128 {
+	baseKVA := baseMVA * 1000
+	return complex((b.Demand.LoadKW-b.Demand.GenerationKW)/baseKVA, b.Demand.LoadKVAR/baseKVA)
+}
+
+// Branch 網路中的支路（對應拓樸線路）
+type Branch struct {
+	Index      int
+	LineID     string
+	FromBus    int // 依拓樸 from_node_id
+	ToBus      int // 依拓樸 to_node_id
+	Z          complex128
+	BaseKV     float64
+	AmpacityA  float64
+	InService  bool // 兩端帶電且未被開路開關阻斷
+	InTree     bool // 屬於以電源為根的輻射樹
+	Downstream int  // 輻射樹中的下游匯流排（非樹狀支路為 -1）
+}
+
+// Network 由拓樸建立的電氣網路模型
+type Network struct {
+	Buses    []*Bus
+	Branches []*Branch
+	Source   int
+	BaseMVA  float64
+	Order    []int // 由電源開始的廣度優先順序（僅含帶電匯流排）
+	Meshed   bool
+	busIndex map[string]int
+	adjacent [][]int
+}
+
+// BusIndex 依節點 ID 取得匯流排索引
+func (n *Network) BusIndex(nodeID string) (int, bool) {
+	index, exists := n.busIndex[nodeID]
+	return index, exists
+}
+
+// BranchesAt 取得與匯流排相連的支路索引
+func (n *Network) BranchesAt(bus int) []int {
+	return n.adjacent[bus]
+}
+
+// Other 取得支路另一端的匯流排
+func (b *Branch) Other(bus int) int {
+	if b.FromBus == bus {
+		return b.ToBus
+	}
+	return b.FromBus
+}
+
+// BaseCurrentA 支路電壓等級下的基準電流（A）
+func (n *Network) BaseCurrentA(baseKV float64) float64 {
+	return n.BaseMVA * 1000 / (math.Sqrt(3) * baseKV)
+}
+
+// BuildNetwork 將拓樸轉換為電氣網路並以電源為根建立輻射樹
+func BuildNetwork(t *topology.Topology, opts Options) (*Network, error) {
+	opts = opts.withDefaults()
+	if len(t.Nodes) == 0 {
+		return nil, ErrEmptyTopology
+	}
+
+	sources := topology.SourceNodes(t)
+	if len(sources) == 0 {
+		return nil, ErrNoSource
+	}
+
+	n := &Network{
+		Buses:    make([]*Bus, 0, len(t.Nodes)),
+		Branches: make([]*Branch, 0, len(t.Lines)),
+		BaseMVA:  opts.BaseMVA,
+		busIndex: make(map[string]int, len(t.Nodes)),
+	}
+
+	xfmrProps := make(map[int]topology.TransformerProperties)
+	for _, node := range t.Nodes {
+		if _, exists := n.busIndex[node.ID]; exists {
+			continue
+		}
+		bus := &Bus{
+			Index:        len(n.Buses),
+			NodeID:       node.ID,
+			Type:         node.Type,
+			Demand:       NodeDemand(node),
+			Parent:       -1,
+			ParentBranch: -1,
+		}
+		switch node.Type {
+		case topology.NodeTypeTransformer:
+			var props topology.TransformerProperties
+			if err := topology.DecodeProperties(node.Properties, &props); err == nil {
+				xfmrProps[bus.Index] = props
+				bus.RatedKVA = props.RatedCapacityKVA
+			}
+		case topology.NodeTypeSwitch:
+			bus.OpenSwitch = !topology.BoolProperty(node.Properties, "is_closed", true)
+		}
+		n.busIndex[node.ID] = bus.Index
+		n.Buses = append(n.Buses, bus)
+	}
+	n.adjacent = make([][]int, len(n.Buses))
+
+	for _, line := range t.Lines {
+		from, okFrom := n.busIndex[line.FromNodeID]
+		to, okTo := n.busIndex[line.ToNodeID]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+		params := LineParameters(t, line)
+		branch := &Branch{
+			Index:      len(n.Branches),
+			LineID:     line.ID,
+			FromBus:    from,
+			ToBus:      to,
+			AmpacityA:  params.AmpacityA,
+			Downstream: -1,
+		}
+		// 先存歐姆值，決定電壓等級後再換算標么
+		branch.Z = complex(params.ROhmPerKM*params.LengthKM, params.XOhmPerKM*params.LengthKM)
+
+		n.adjacent[from] = append(n.adjacent[from], branch.Index)
+		n.adjacent[to] = append(n.adjacent[to], branch.Index)
+		n.Branches = append(n.Branches, branch)
+	}
+
+	n.Source = n.busIndex[sources[0].ID]
+	source := n.Buses[n.Source]
+	source.BaseKV = sourceBaseKV(sources[0], opts)
+	source.OpenSwitch = false
+
+	// 由電源廣度優先走訪，決定供電狀態、上下游方向與各區電壓等級
+	processed := make([]bool, len(n.Branches))
+	source.Energized = true
+	n.Order = []int{n.Source}
+	for head := 0; head < len(n.Order); head++ {
+		current := n.Buses[n.Order[head]]
+		if current.OpenSwitch {
+			continue
+		}
+		for _, bi := range n.adjacent[current.Index] {
+			if processed[bi] {
+				continue
+			}
+			branch := n.Branches[bi]
+			next := n.Buses[branch.Other(current.Index)]
+			processed[bi] = true
+
+			if next.Energized {
+				if !next.OpenSwitch {
+					branch.InService = true
+					branch.BaseKV = current.BaseKV
+					n.Meshed = true
+				}
+				continue
+			}
+
+			next.Energized = true
+			next.Parent = current.Index
+			next.ParentBranch = bi
+			next.Depth = current.Depth + 1
+			next.BaseKV = current.BaseKV
+			if props, ok := xfmrProps[next.Index]; ok && props.SecondaryVoltage > 0 {
+				next.BaseKV = props.SecondaryVoltage
+			}
+			current.Children = append(current.Children, next.Index)
+
+			branch.InService = true
+			branch.InTree = true
+			branch.Downstream = next.Index
+			branch.BaseKV = current.BaseKV
+			n.Order = append(n.Order, next.Index)
+		}
+	}
+
+	for _, branch := range n.Branches {
+		if branch.BaseKV <= 0 {
+			branch.BaseKV = source.BaseKV
+		}
+		zBase := branch.BaseKV * branch.BaseKV / n.BaseMVA
+		branch.Z /= complex(zBase, 0)
+
+		// 變壓器串聯阻抗併入供電給它的支路
+		if branch.InTree {
+			if props, ok := xfmrProps[branch.Downstream]; ok && props.RatedCapacityKVA > 0 {
+				branch.Z += transformerImpedancePU(props, n.BaseMVA)
+			}
+		}
+		if cmplx.Abs(branch.Z) < minImpedancePU {
+			branch.Z = complex(0, minImpedancePU)
+		}
+	}
+
+	for _, bus := range n.Buses {
+		if bus.BaseKV <= 0 {
+			bus.BaseKV = source.BaseKV
+		}
+	}
+
+	return n, nil
+}
+
+// sourceBaseKV 決定電源端電壓等級
+func sourceBaseKV(source topology.Node, opts Options) float64 {
+	if opts.BaseVoltageKV > 0 {
+		return opts.BaseVoltageKV
+	}
+	if kv := topology.FloatProperty(source.Properties, "voltage_kv", 0); kv > 0 {
+		return kv
+	}
+	if source.Type == topology.NodeTypeTransformer {
+		var props topology.TransformerProperties
+		if err := topology.DecodeProperties(source.Properties, &props); err == nil {
+			if props.SecondaryVoltage > 0 {
+				return props.SecondaryVoltage
+			}
+			if props.RatedVoltageKV > 0 {
+				return props.RatedVoltageKV
+			}
+		}
+	}
+	return defaultBaseKV
+}
+
+// LineParameters 取得線路電氣參數，未指定的欄位套用預設值
+// 未指定 length_km 時，若兩端節點有經緯度則以折線的大圓距離計算長度
+func LineParameters(t *topology.Topology, line topology.Line) topology.LineProperties {
+	lengthKM := defaultLengthKM
+	if geoLength, ok := t.GeoLengthKM(line); ok {
+		lengthKM = geoLength
+	}
+	return topology.LineProperties{
+		LengthKM:  topology.FloatProperty(line.Properties, "length_km", lengthKM),
+		ROhmPerKM: topology.FloatProperty(line.Properties, "r_ohm_per_km", defaultROhmPerKM),
+		XOhmPerKM: topology.FloatProperty(line.Properties, "x_ohm_per_km", defaultXOhmPerKM),
+		AmpacityA: topology.FloatProperty(line.Properties, "ampacity_a", defaultAmpacityA),
+	}
+}
+
+// TransformerImpedance 取得變壓器短路阻抗（%）與 X/R 比，未指定時套用預設值
+func TransformerImpedance(props topology.TransformerProperties) (zPercent, xr float64) {
+	zPercent = props.ImpedancePercent
+	if zPercent <= 0 {
+		zPercent = defaultXfmrZPercent
+	}
+	xr = props.XRRatio
+	if xr <= 0 {
+		xr = defaultXfmrXRRatio
+	}
+	return zPercent, xr
+}
+
+// transformerImpedancePU 將變壓器阻抗換算為系統基準的標么值
+func transformerImpedancePU(props topology.TransformerProperties, baseMVA float64) complex128 {
+	zPercent, xr := TransformerImpedance(props)
+
+	z := zPercent / 100 * (baseMVA * 1000 / props.RatedCapacityKVA)
+	r := z / math.Sqrt(1+xr*xr)
+	return complex(r, r*xr)
+}
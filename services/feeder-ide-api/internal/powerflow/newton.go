@@ -0,0 +1,174 @@
+package powerflow
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// newton 以極座標 Newton-Raphson 法求解（適用於弱環狀網路）
+// 電源為平衡匯流排，其餘帶電匯流排皆視為 PQ 匯流排
+func newton(n *Network, opts Options) ([]complex128, int, bool, error) {
+	voltages := make([]complex128, len(n.Buses))
+
+	// 只對帶電匯流排建立導納矩陣，local 索引以 n.Order 為準（0 為電源）
+	size := len(n.Order)
+	local := make(map[int]int, size)
+	for i, index := range n.Order {
+		local[index] = i
+	}
+
+	ybus := make([][]complex128, size)
+	for i := range ybus {
+		ybus[i] = make([]complex128, size)
+	}
+	for _, branch := range n.Branches {
+		if !branch.InService {
+			continue
+		}
+		f, okF := local[branch.FromBus]
+		t, okT := local[branch.ToBus]
+		if !okF || !okT {
+			continue
+		}
+		y := 1 / branch.Z
+		ybus[f][f] += y
+		ybus[t][t] += y
+		ybus[f][t] -= y
+		ybus[t][f] -= y
+	}
+
+	magnitude := make([]float64, size)
+	angle := make([]float64, size)
+	pSpec := make([]float64, size)
+	qSpec := make([]float64, size)
+	for i, index := range n.Order {
+		magnitude[i] = opts.SourceVoltagePU
+		injection := -n.Buses[index].NetDemandPU(n.BaseMVA)
+		pSpec[i] = real(injection)
+		qSpec[i] = imag(injection)
+	}
+
+	calculated := func() ([]float64, []float64) {
+		p := make([]float64, size)
+		q := make([]float64, size)
+		for i := 0; i < size; i++ {
+			for k := 0; k < size; k++ {
+				y := ybus[i][k]
+				if y == 0 {
+					continue
+				}
+				g, b := real(y), imag(y)
+				theta := angle[i] - angle[k]
+				p[i] += magnitude[i] * magnitude[k] * (g*math.Cos(theta) + b*math.Sin(theta))
+				q[i] += magnitude[i] * magnitude[k] * (g*math.Sin(theta) - b*math.Cos(theta))
+			}
+		}
+		return p, q
+	}
+
+	finish := func() []complex128 {
+		for i, index := range n.Order {
+			voltages[index] = cmplx.Rect(magnitude[i], angle[i])
+		}
+		return voltages
+	}
+
+	unknowns := size - 1
+	if unknowns == 0 {
+		return finish(), 0, true, nil
+	}
+
+	for iteration := 1; iteration <= opts.MaxIterations; iteration++ {
+		p, q := calculated()
+
+		// 不平衡量：[ΔP(1..n-1), ΔQ(1..n-1)]
+		mismatch := make([]float64, 2*unknowns)
+		maxMismatch := 0.0
+		for i := 1; i < size; i++ {
+			mismatch[i-1] = pSpec[i] - p[i]
+			mismatch[unknowns+i-1] = qSpec[i] - q[i]
+			maxMismatch = math.Max(maxMismatch, math.Max(math.Abs(mismatch[i-1]), math.Abs(mismatch[unknowns+i-1])))
+		}
+		if maxMismatch < opts.Tolerance {
+			return finish(), iteration - 1, true, nil
+		}
+
+		jacobian := make([][]float64, 2*unknowns)
+		for i := range jacobian {
+			jacobian[i] = make([]float64, 2*unknowns)
+		}
+		for i := 1; i < size; i++ {
+			for k := 1; k < size; k++ {
+				y := ybus[i][k]
+				g, b := real(y), imag(y)
+				row, col := i-1, k-1
+				if i == k {
+					jacobian[row][col] = -q[i] - b*magnitude[i]*magnitude[i]
+					jacobian[row][unknowns+col] = p[i]/magnitude[i] + g*magnitude[i]
+					jacobian[unknowns+row][col] = p[i] - g*magnitude[i]*magnitude[i]
+					jacobian[unknowns+row][unknowns+col] = q[i]/magnitude[i] - b*magnitude[i]
+					continue
+				}
+				if y == 0 {
+					continue
+				}
+				theta := angle[i] - angle[k]
+				sin, cos := math.Sin(theta), math.Cos(theta)
+				jacobian[row][col] = magnitude[i] * magnitude[k] * (g*sin - b*cos)
+				jacobian[row][unknowns+col] = magnitude[i] * (g*cos + b*sin)
+				jacobian[unknowns+row][col] = -magnitude[i] * magnitude[k] * (g*cos + b*sin)
+				jacobian[unknowns+row][unknowns+col] = magnitude[i] * (g*sin - b*cos)
+			}
+		}
+
+		correction, err := solveLinear(jacobian, mismatch)
+		if err != nil {
+			return finish(), iteration, false, err
+		}
+		for i := 1; i < size; i++ {
+			angle[i] += correction[i-1]
+			magnitude[i] += correction[unknowns+i-1]
+		}
+	}
+
+	return finish(), opts.MaxIterations, false, nil
+}
+
+// solveLinear 以部分樞軸高斯消去法解 Ax = b（會修改 a 與 b）
+func solveLinear(a [][]float64, b []float64) ([]float64, error) {
+	size := len(b)
+	for col := 0; col < size; col++ {
+		pivot := col
+		for row := col + 1; row < size; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, ErrSingularMatrix
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < size; row++ {
+			factor := a[row][col] / a[col][col]
+			if factor == 0 {
+				continue
+			}
+			for k := col; k < size; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, size)
+	for row := size - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < size; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, nil
+}
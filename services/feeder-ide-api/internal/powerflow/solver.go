@@ -0,0 +1,204 @@
+package powerflow
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Solve 對拓樸執行潮流分析
+// 輻射狀網路使用 backward/forward sweep，弱環狀網路自動改用 Newton-Raphson
+func Solve(t *topology.Topology, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+
+	network, err := BuildNetwork(t, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return SolveNetwork(network, opts)
+}
+
+// SolveNetwork 對已建立的網路執行潮流分析（可在呼叫前調整匯流排需求）
+func SolveNetwork(n *Network, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+
+	method := opts.Method
+	if method == MethodAuto {
+		method = MethodSweep
+		if n.Meshed {
+			method = MethodNewton
+		}
+	}
+
+	var voltages []complex128
+	var iterations int
+	var converged bool
+
+	switch method {
+	case MethodSweep:
+		if n.Meshed {
+			return nil, ErrMeshedNetwork
+		}
+		voltages, iterations, converged = sweep(n, opts)
+	case MethodNewton:
+		var err error
+		voltages, iterations, converged, err = newton(n, opts)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnknownMethod
+	}
+
+	return buildResult(n, opts, method, voltages, iterations, converged), nil
+}
+
+// buildResult 由匯流排電壓計算支路潮流、損失與違規統計
+func buildResult(n *Network, opts Options, method string, voltages []complex128, iterations int, converged bool) *Result {
+	baseKVA := n.BaseMVA * 1000
+	result := &Result{
+		Method:     method,
+		Converged:  converged,
+		Iterations: iterations,
+		Nodes:      make([]NodeResult, len(n.Buses)),
+		Lines:      make([]LineResult, len(n.Branches)),
+	}
+	summary := &result.Summary
+	summary.SourceNodeID = n.Buses[n.Source].NodeID
+	summary.Meshed = n.Meshed
+	summary.MinVoltagePU = math.Inf(1)
+
+	// 流入每個匯流排的視在功率（用於變壓器負載率）
+	throughput := make([]complex128, len(n.Buses))
+
+	for i, branch := range n.Branches {
+		line := LineResult{
+			LineID:     branch.LineID,
+			FromNodeID: n.Buses[branch.FromBus].NodeID,
+			ToNodeID:   n.Buses[branch.ToBus].NodeID,
+			Energized:  branch.InService,
+			Status:     StatusDeenergized,
+		}
+		if branch.InService {
+			vFrom, vTo := voltages[branch.FromBus], voltages[branch.ToBus]
+			current := (vFrom - vTo) / branch.Z
+			sFrom := vFrom * cmplx.Conj(current)
+			sTo := vTo * cmplx.Conj(current)
+			loss := sFrom - sTo
+
+			line.CurrentA = cmplx.Abs(current) * n.BaseCurrentA(branch.BaseKV)
+			if branch.AmpacityA > 0 {
+				line.LoadingPercent = line.CurrentA / branch.AmpacityA * 100
+			}
+			line.PowerKW = real(sFrom) * baseKVA
+			line.PowerKVAR = imag(sFrom) * baseKVA
+			line.LossKW = real(loss) * baseKVA
+			line.LossKVAR = imag(loss) * baseKVA
+			line.Status = loadingStatus(line.LoadingPercent)
+
+			summary.TotalLossesKW += line.LossKW
+			summary.TotalLossesKVAR += line.LossKVAR
+			summary.MaxLineLoadingPercent = math.Max(summary.MaxLineLoadingPercent, line.LoadingPercent)
+			if line.LoadingPercent > 100 {
+				summary.ThermalViolations++
+			}
+
+			if branch.InTree {
+				if branch.Downstream == branch.ToBus {
+					throughput[branch.ToBus] = sTo
+				} else {
+					throughput[branch.FromBus] = -sFrom
+				}
+			}
+			if branch.FromBus == n.Source {
+				summary.SourcePowerKW += real(sFrom) * baseKVA
+				summary.SourcePowerKVAR += imag(sFrom) * baseKVA
+			} else if branch.ToBus == n.Source {
+				summary.SourcePowerKW -= real(sTo) * baseKVA
+				summary.SourcePowerKVAR -= imag(sTo) * baseKVA
+			}
+		}
+		result.Lines[i] = line
+	}
+
+	source := n.Buses[n.Source]
+	summary.SourcePowerKW += source.Demand.LoadKW - source.Demand.GenerationKW
+	summary.SourcePowerKVAR += source.Demand.LoadKVAR
+	throughput[n.Source] = complex(summary.SourcePowerKW, summary.SourcePowerKVAR) / complex(baseKVA, 0)
+
+	for i, bus := range n.Buses {
+		node := NodeResult{
+			NodeID:       bus.NodeID,
+			Energized:    bus.Energized,
+			LoadKW:       bus.Demand.LoadKW,
+			LoadKVAR:     bus.Demand.LoadKVAR,
+			GenerationKW: bus.Demand.GenerationKW,
+			Status:       StatusDeenergized,
+		}
+		if bus.Energized {
+			node.VoltagePU = cmplx.Abs(voltages[i])
+			node.VoltageKV = node.VoltagePU * bus.BaseKV
+			node.AngleDeg = cmplx.Phase(voltages[i]) * 180 / math.Pi
+			node.Status = voltageStatus(node.VoltagePU, opts)
+			if bus.RatedKVA > 0 {
+				node.LoadingPercent = cmplx.Abs(throughput[i]) * baseKVA / bus.RatedKVA * 100
+				node.Status = worstStatus(node.Status, loadingStatus(node.LoadingPercent))
+				if node.LoadingPercent > 100 {
+					summary.ThermalViolations++
+				}
+			}
+
+			summary.TotalLoadKW += bus.Demand.LoadKW
+			summary.TotalLoadKVAR += bus.Demand.LoadKVAR
+			summary.TotalGenerationKW += bus.Demand.GenerationKW
+			summary.MinVoltagePU = math.Min(summary.MinVoltagePU, node.VoltagePU)
+			summary.MaxVoltagePU = math.Max(summary.MaxVoltagePU, node.VoltagePU)
+			if node.VoltagePU < opts.VoltageMinPU || node.VoltagePU > opts.VoltageMaxPU {
+				summary.VoltageViolations++
+			}
+		} else {
+			summary.DeenergizedNodes++
+		}
+		result.Nodes[i] = node
+	}
+
+	if math.IsInf(summary.MinVoltagePU, 1) {
+		summary.MinVoltagePU = 0
+	}
+
+	return result
+}
+
+// voltageStatus 依電壓上下限判斷狀態（超出限制 5% 以上視為嚴重）
+func voltageStatus(voltagePU float64, opts Options) string {
+	switch {
+	case voltagePU < opts.VoltageMinPU-0.05 || voltagePU > opts.VoltageMaxPU+0.05:
+		return StatusCritical
+	case voltagePU < opts.VoltageMinPU || voltagePU > opts.VoltageMaxPU:
+		return StatusWarning
+	default:
+		return StatusNormal
+	}
+}
+
+// loadingStatus 依負載率判斷狀態
+func loadingStatus(loadingPercent float64) string {
+	switch {
+	case loadingPercent > 100:
+		return StatusCritical
+	case loadingPercent > 80:
+		return StatusWarning
+	default:
+		return StatusNormal
+	}
+}
+
+func worstStatus(a, b string) string {
+	rank := map[string]int{StatusNormal: 0, StatusWarning: 1, StatusCritical: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,193 @@
+package powerflow
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+const (
+	testBaseKV  = 12.47
+	testBaseMVA = 10.0
+)
+
+// testZBase 測試電壓等級下的基準阻抗（ohm）
+var testZBase = testBaseKV * testBaseKV / testBaseMVA
+
+func sourceNode(id string) topology.Node {
+	return topology.Node{ID: id, Type: topology.NodeTypeBus, Properties: map[string]interface{}{"is_source": true, "voltage_kv": testBaseKV}}
+}
+
+func loadNode(id string, kw, kvar float64) topology.Node {
+	return topology.Node{ID: id, Type: topology.NodeTypeBus, Properties: map[string]interface{}{"load_kw": kw, "load_kvar": kvar}}
+}
+
+// testLine 以總電阻、電抗（ohm）建立 1 km 線路
+func testLine(id, from, to string, r, x float64) topology.Line {
+	return topology.Line{ID: id, FromNodeID: from, ToNodeID: to, Properties: map[string]interface{}{
+		"length_km": 1.0, "r_ohm_per_km": r, "x_ohm_per_km": x, "ampacity_a": 400.0,
+	}}
+}
+
+// receivingEnd 電源電壓 1 pu、串聯阻抗 z（pu）供應定功率負載 s（pu）時的受電端電壓
+// |V|^4 + (2(RP+XQ) - 1)|V|^2 + |Z|^2|S|^2 = 0 取較高的解，再以 V = 1 - Z·conj(S/V) 求相角
+func receivingEnd(z, s complex128) complex128 {
+	b := 1 - 2*(real(z)*real(s)+imag(z)*imag(s))
+	c := cmplx.Abs(z) * cmplx.Abs(z) * cmplx.Abs(s) * cmplx.Abs(s)
+	magnitude := math.Sqrt((b + math.Sqrt(b*b-4*c)) / 2)
+
+	// V = |V|e^{jθ} 代入 1 = V + Z·conj(S)/conj(V) 得 e^{jθ} = 1 / (|V| + Z·conj(S)/|V|)
+	drop := z * cmplx.Conj(s) / complex(magnitude, 0)
+	return complex(magnitude, 0) / (complex(magnitude, 0) + drop)
+}
+
+func nodeResult(t *testing.T, result *Result, id string) NodeResult {
+	t.Helper()
+	for _, node := range result.Nodes {
+		if node.NodeID == id {
+			return node
+		}
+	}
+	t.Fatalf("node %s not in result", id)
+	return NodeResult{}
+}
+
+func lineResult(t *testing.T, result *Result, id string) LineResult {
+	t.Helper()
+	for _, line := range result.Lines {
+		if line.LineID == id {
+			return line
+		}
+	}
+	t.Fatalf("line %s not in result", id)
+	return LineResult{}
+}
+
+func assertClose(t *testing.T, what string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.6f, want %.6f (±%g)", what, got, want, tolerance)
+	}
+}
+
+func TestSolveKnownCases(t *testing.T) {
+	// 線路 1 + j2 ohm，負載 2 MW + 1 MVAr
+	z := complex(1/testZBase, 2/testZBase)
+	s := complex(0.2, 0.1)
+	zHalf := complex(0.4/testZBase, 0.8/testZBase)
+
+	twoBus := &topology.Topology{
+		Nodes: []topology.Node{sourceNode("s"), loadNode("b", 2000, 1000)},
+		Lines: []topology.Line{testLine("l1", "s", "b", 1, 2)},
+	}
+	v2 := receivingEnd(z, s)
+
+	// 三匯流排輻射：中間匯流排無負載，等同總阻抗 1 + j2 ohm 的兩匯流排，中間電壓為 V2 + Z2·I
+	threeBusRadial := &topology.Topology{
+		Nodes: []topology.Node{sourceNode("s"), loadNode("m", 0, 0), loadNode("b", 2000, 1000)},
+		Lines: []topology.Line{testLine("l1", "s", "m", 0.6, 1.2), testLine("l2", "m", "b", 0.4, 0.8)},
+	}
+	v3 := receivingEnd(z, s)
+	vMid := v3 + zHalf*cmplx.Conj(s/v3)
+
+	// 三匯流排環狀：兩個對稱的分支，b1-b2 之間沒有電流，各分支等同兩匯流排
+	threeBusMesh := &topology.Topology{
+		Nodes: []topology.Node{sourceNode("s"), loadNode("b1", 2000, 1000), loadNode("b2", 2000, 1000)},
+		Lines: []topology.Line{testLine("l1", "s", "b1", 1, 2), testLine("l2", "s", "b2", 1, 2), testLine("l3", "b1", "b2", 0.5, 1)},
+	}
+
+	tests := []struct {
+		name     string
+		topo     *topology.Topology
+		method   string
+		voltages map[string]complex128
+		meshed   bool
+	}{
+		{"2-bus sweep", twoBus, MethodSweep, map[string]complex128{"s": 1, "b": v2}, false},
+		{"2-bus newton", twoBus, MethodNewton, map[string]complex128{"s": 1, "b": v2}, false},
+		{"3-bus radial sweep", threeBusRadial, MethodSweep, map[string]complex128{"s": 1, "m": vMid, "b": v3}, false},
+		{"3-bus radial newton", threeBusRadial, MethodNewton, map[string]complex128{"s": 1, "m": vMid, "b": v3}, false},
+		{"3-bus mesh auto", threeBusMesh, MethodAuto, map[string]complex128{"s": 1, "b1": v2, "b2": v2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.topo, Options{Method: tt.method, BaseMVA: testBaseMVA, Tolerance: 1e-10})
+			if err != nil {
+				t.Fatalf("Solve: %v", err)
+			}
+			if !result.Converged {
+				t.Fatalf("did not converge after %d iterations", result.Iterations)
+			}
+			if result.Summary.Meshed != tt.meshed {
+				t.Errorf("meshed = %v, want %v", result.Summary.Meshed, tt.meshed)
+			}
+
+			for id, want := range tt.voltages {
+				node := nodeResult(t, result, id)
+				assertClose(t, id+" voltage_pu", node.VoltagePU, cmplx.Abs(want), 1e-6)
+				assertClose(t, id+" angle_deg", node.AngleDeg, cmplx.Phase(want)*180/math.Pi, 1e-4)
+				assertClose(t, id+" voltage_kv", node.VoltageKV, cmplx.Abs(want)*testBaseKV, 1e-4)
+			}
+
+			// 電源出力 = 負載 + 線路損失
+			summary := result.Summary
+			assertClose(t, "source_power_kw", summary.SourcePowerKW, summary.TotalLoadKW+summary.TotalLossesKW, 1e-3)
+			assertClose(t, "source_power_kvar", summary.SourcePowerKVAR, summary.TotalLoadKVAR+summary.TotalLossesKVAR, 1e-3)
+		})
+	}
+}
+
+func TestSolveTwoBusLineFlow(t *testing.T) {
+	topo := &topology.Topology{
+		Nodes: []topology.Node{sourceNode("s"), loadNode("b", 2000, 1000)},
+		Lines: []topology.Line{testLine("l1", "s", "b", 1, 2)},
+	}
+	result, err := Solve(topo, Options{BaseMVA: testBaseMVA, Tolerance: 1e-10})
+	if err != nil {
+		t.Fatalf("Solve: %v", err)
+	}
+
+	z := complex(1/testZBase, 2/testZBase)
+	v := receivingEnd(z, complex(0.2, 0.1))
+	current := cmplx.Abs(cmplx.Conj(complex(0.2, 0.1) / v))
+	currentA := current * testBaseMVA * 1000 / (math.Sqrt(3) * testBaseKV)
+	lossKW := current * current * real(z) * testBaseMVA * 1000
+
+	line := lineResult(t, result, "l1")
+	assertClose(t, "current_a", line.CurrentA, currentA, 1e-3)
+	assertClose(t, "loading_percent", line.LoadingPercent, currentA/400*100, 1e-3)
+	assertClose(t, "loss_kw", line.LossKW, lossKW, 1e-3)
+	assertClose(t, "power_kw", line.PowerKW, 2000+lossKW, 1e-3)
+	if result.Method != MethodSweep {
+		t.Errorf("method = %s, want %s for a radial network", result.Method, MethodSweep)
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	mesh := &topology.Topology{
+		Nodes: []topology.Node{sourceNode("s"), loadNode("a", 10, 0), loadNode("b", 10, 0)},
+		Lines: []topology.Line{testLine("l1", "s", "a", 1, 1), testLine("l2", "a", "b", 1, 1), testLine("l3", "b", "s", 1, 1)},
+	}
+
+	tests := []struct {
+		name    string
+		topo    *topology.Topology
+		method  string
+		wantErr error
+	}{
+		{"empty topology", &topology.Topology{}, MethodAuto, ErrEmptyTopology},
+		{"sweep on meshed network", mesh, MethodSweep, ErrMeshedNetwork},
+		{"unknown method", mesh, "gauss_seidel", ErrUnknownMethod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Solve(tt.topo, Options{Method: tt.method}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
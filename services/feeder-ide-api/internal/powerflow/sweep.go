@@ -0,0 +1,46 @@
+package powerflow
+
+import "math/cmplx"
+
+// sweep 以 backward/forward sweep 求解輻射狀網路
+// 回傳各匯流排電壓（pu）、迭代次數與是否收斂
+func sweep(n *Network, opts Options) ([]complex128, int, bool) {
+	voltages := make([]complex128, len(n.Buses))
+	for _, index := range n.Order {
+		voltages[index] = complex(opts.SourceVoltagePU, 0)
+	}
+
+	branchCurrents := make([]complex128, len(n.Branches))
+	accumulated := make([]complex128, len(n.Buses))
+
+	for iteration := 1; iteration <= opts.MaxIterations; iteration++ {
+		// Backward：由末端往電源累加負載電流
+		for i := range accumulated {
+			accumulated[i] = 0
+		}
+		for k := len(n.Order) - 1; k >= 1; k-- {
+			bus := n.Buses[n.Order[k]]
+			accumulated[bus.Index] += cmplx.Conj(bus.NetDemandPU(n.BaseMVA) / voltages[bus.Index])
+			branchCurrents[bus.ParentBranch] = accumulated[bus.Index]
+			accumulated[bus.Parent] += accumulated[bus.Index]
+		}
+
+		// Forward：由電源往末端更新電壓
+		maxDelta := 0.0
+		for k := 1; k < len(n.Order); k++ {
+			bus := n.Buses[n.Order[k]]
+			branch := n.Branches[bus.ParentBranch]
+			updated := voltages[bus.Parent] - branch.Z*branchCurrents[bus.ParentBranch]
+			if delta := cmplx.Abs(updated - voltages[bus.Index]); delta > maxDelta {
+				maxDelta = delta
+			}
+			voltages[bus.Index] = updated
+		}
+
+		if maxDelta < opts.Tolerance {
+			return voltages, iteration, true
+		}
+	}
+
+	return voltages, opts.MaxIterations, false
+}
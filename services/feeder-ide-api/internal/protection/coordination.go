@@ -0,0 +1,384 @@
+package protection
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/shortcircuit"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Analyze 檢查拓樸上保護設備的協調
+func Analyze(t *topology.Topology, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+
+	faults, err := shortcircuit.Analyze(t, opts.ShortCircuit)
+	if err != nil {
+		return nil, err
+	}
+	n, err := powerflow.BuildNetwork(t, powerflow.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &coordinator{
+		network:  n,
+		opts:     opts,
+		nodes:    make([]topology.Node, len(n.Buses)),
+		faults:   make(map[string]shortcircuit.NodeResult, len(faults.Nodes)),
+		settings: map[int]Settings{},
+		result: &Result{
+			Devices:        []Device{},
+			Pairs:          []PairResult{},
+			Sectionalizers: []SectionalizerCheck{},
+			Violations:     []Violation{},
+		},
+	}
+	for _, node := range t.Nodes {
+		if index, exists := n.BusIndex(node.ID); exists && c.nodes[index].ID == "" {
+			c.nodes[index] = node
+		}
+	}
+	for _, fault := range faults.Nodes {
+		c.faults[fault.NodeID] = fault
+	}
+
+	c.collectDevices()
+	for _, device := range c.result.Devices {
+		bus, _ := n.BusIndex(device.NodeID)
+		if _, ok := c.settings[bus]; !ok {
+			continue
+		}
+		c.checkReach(bus)
+		if upstream := c.upstreamDevice(bus); upstream >= 0 {
+			c.checkPair(bus, upstream)
+		}
+	}
+	for index, bus := range n.Buses {
+		node := c.nodes[index]
+		if bus.Energized && node.Type == topology.NodeTypeSwitch && switchType(node) == "sectionalizer" {
+			c.checkSectionalizer(index)
+		}
+	}
+
+	summary := &c.result.Summary
+	summary.Devices = len(c.result.Devices)
+	summary.Pairs = len(c.result.Pairs)
+	for _, pair := range c.result.Pairs {
+		if pair.Coordinated {
+			summary.CoordinatedPairs++
+		}
+	}
+	for _, violation := range c.result.Violations {
+		if violation.Severity == SeverityError {
+			summary.Errors++
+		} else {
+			summary.Warnings++
+		}
+	}
+	summary.Coordinated = summary.Errors == 0
+	return c.result, nil
+}
+
+// coordinator 分析過程中的狀態
+type coordinator struct {
+	network  *powerflow.Network
+	opts     Options
+	nodes    []topology.Node
+	faults   map[string]shortcircuit.NodeResult
+	settings map[int]Settings // 設定有效的保護設備（以匯流排索引對應）
+	result   *Result
+}
+
+// faultCurrent 單一故障點的故障電流
+type faultCurrent struct {
+	nodeID    string
+	faultType string
+	currentKA float64
+	baseKV    float64
+}
+
+// amps 換算為指定電壓等級下的電流（A）
+func (f faultCurrent) amps(baseKV float64) float64 {
+	return f.currentKA * 1000 * f.baseKV / baseKV
+}
+
+func switchType(node topology.Node) string {
+	return topology.StringProperty(node.Properties, "type", "")
+}
+
+// collectDevices 收集帶電的斷路器、復閉器與熔絲並解析保護設定
+func (c *coordinator) collectDevices() {
+	for index, bus := range c.network.Buses {
+		node := c.nodes[index]
+		if !bus.Energized || node.Type != topology.NodeTypeSwitch {
+			continue
+		}
+		kind := switchType(node)
+		if kind == "sectionalizer" {
+			continue
+		}
+
+		device := Device{NodeID: node.ID, SwitchType: kind, BaseKV: bus.BaseKV}
+		raw, exists := node.Properties["protection"].(map[string]interface{})
+		if !exists {
+			if kind == "breaker" || kind == "recloser" || kind == "fuse" {
+				c.violate(Violation{
+					Severity: SeverityWarning,
+					Rule:     RuleMissingSettings,
+					DeviceID: node.ID,
+					Message:  fmt.Sprintf("%s %s has no protection settings and is excluded from coordination", kind, node.ID),
+				})
+				c.result.Devices = append(c.result.Devices, device)
+			}
+			continue
+		}
+
+		var settings Settings
+		err := topology.DecodeProperties(raw, &settings)
+		if err == nil {
+			err = settings.Validate()
+		}
+		if err != nil {
+			c.violate(Violation{
+				Severity: SeverityError,
+				Rule:     RuleInvalidSettings,
+				DeviceID: node.ID,
+				Message:  fmt.Sprintf("invalid protection settings on %s: %v", node.ID, err),
+			})
+			c.result.Devices = append(c.result.Devices, device)
+			continue
+		}
+
+		device.Settings = &settings
+		c.settings[index] = settings
+		c.result.Devices = append(c.result.Devices, device)
+	}
+
+	for i := range c.result.Devices {
+		bus, _ := c.network.BusIndex(c.result.Devices[i].NodeID)
+		if upstream := c.upstreamDevice(bus); upstream >= 0 && c.result.Devices[i].Settings != nil {
+			c.result.Devices[i].UpstreamDeviceID = c.nodes[upstream].ID
+		}
+	}
+}
+
+// upstreamDevice 沿輻射路徑往電源方向找最近且設定有效的保護設備
+func (c *coordinator) upstreamDevice(bus int) int {
+	for parent := c.network.Buses[bus].Parent; parent >= 0; parent = c.network.Buses[parent].Parent {
+		if _, ok := c.settings[parent]; ok {
+			return parent
+		}
+	}
+	return -1
+}
+
+// downstreamFaults 取得 bus 下游各節點的故障電流；zoneOnly 時不含下游其他保護設備之後的節點
+func (c *coordinator) downstreamFaults(bus int, zoneOnly bool) []faultCurrent {
+	currents := []faultCurrent{}
+	stack := []int{bus}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if fault, ok := c.faults[c.nodes[current].ID]; ok && fault.Energized {
+			for _, f := range []faultCurrent{
+				{faultType: FaultThreePhase, currentKA: fault.ThreePhaseKA},
+				{faultType: FaultLineToLine, currentKA: fault.LineToLineKA},
+				{faultType: FaultLineToGround, currentKA: fault.LineToGroundKA},
+			} {
+				if f.currentKA > 0 {
+					f.nodeID, f.baseKV = fault.NodeID, fault.BaseKV
+					currents = append(currents, f)
+				}
+			}
+		}
+
+		for _, child := range c.network.Buses[current].Children {
+			if _, isDevice := c.settings[child]; zoneOnly && isDevice {
+				continue
+			}
+			stack = append(stack, child)
+		}
+	}
+	return currents
+}
+
+// checkReach 檢查保護設備能否偵測其保護區內所有故障
+func (c *coordinator) checkReach(bus int) {
+	settings := c.settings[bus]
+	baseKV := c.network.Buses[bus].BaseKV
+
+	var worst *faultCurrent
+	count := 0
+	for _, f := range c.downstreamFaults(bus, true) {
+		f := f
+		if f.amps(baseKV) > settings.MinimumOperatingCurrentA() {
+			continue
+		}
+		count++
+		if worst == nil || f.amps(baseKV) < worst.amps(baseKV) {
+			worst = &f
+		}
+	}
+	if worst == nil {
+		return
+	}
+	c.violate(Violation{
+		Severity:  SeverityWarning,
+		Rule:      RuleDownstreamNoOperation,
+		DeviceID:  c.nodes[bus].ID,
+		NodeID:    worst.nodeID,
+		FaultType: worst.faultType,
+		CurrentA:  round(worst.amps(baseKV), 1),
+		Message: fmt.Sprintf("%s does not operate for %d fault case(s) in its zone (minimum %.1f A below %.1f A)",
+			c.nodes[bus].ID, count, worst.amps(baseKV), settings.MinimumOperatingCurrentA()),
+	})
+}
+
+// checkPair 以下游設備下游所有故障點檢查上下游協調
+func (c *coordinator) checkPair(down, up int) {
+	downSettings, upSettings := c.settings[down], c.settings[up]
+	downKV, upKV := c.network.Buses[down].BaseKV, c.network.Buses[up].BaseKV
+
+	pair := PairResult{DownstreamID: c.nodes[down].ID, UpstreamID: c.nodes[up].ID}
+	for _, f := range c.downstreamFaults(down, false) {
+		clearing, ok := downSettings.ClearingTime(f.amps(downKV))
+		if !ok {
+			continue
+		}
+		upstream, ok := upSettings.ResponseTime(f.amps(upKV))
+		if !ok {
+			// 上游不動作即不會搶先跳脫
+			pair.ChecksEvaluated++
+			continue
+		}
+
+		check := Check{
+			NodeID:          f.nodeID,
+			FaultType:       f.faultType,
+			CurrentA:        round(f.amps(downKV), 1),
+			DownstreamTimeS: round(clearing, 4),
+			UpstreamTimeS:   round(upstream, 4),
+			MarginS:         round(upstream-clearing, 4),
+			RequiredS:       c.opts.CTISeconds,
+		}
+		if upSettings.IsFuse() {
+			check.RequiredS = round(upstream*(1-c.opts.FuseRatio), 4)
+		}
+
+		pair.ChecksEvaluated++
+		if check.MarginS < check.RequiredS {
+			pair.ChecksFailed++
+		}
+		if pair.Critical == nil || check.MarginS-check.RequiredS < pair.Critical.MarginS-pair.Critical.RequiredS {
+			critical := check
+			pair.Critical = &critical
+		}
+	}
+	pair.Coordinated = pair.ChecksFailed == 0
+	c.result.Pairs = append(c.result.Pairs, pair)
+
+	if pair.Coordinated {
+		return
+	}
+	critical := pair.Critical
+	rule, message := RuleInsufficientMargin, fmt.Sprintf("%s clears a %s fault at %s in %.3f s, leaving %.3f s before %s operates (required %.3f s)",
+		pair.DownstreamID, critical.FaultType, critical.NodeID, critical.DownstreamTimeS, critical.MarginS, pair.UpstreamID, critical.RequiredS)
+	if critical.MarginS < 0 {
+		rule = RuleMiscoordination
+		message = fmt.Sprintf("%s operates in %.3f s before %s clears a %s fault at %s (%.3f s)",
+			pair.UpstreamID, critical.UpstreamTimeS, pair.DownstreamID, critical.FaultType, critical.NodeID, critical.DownstreamTimeS)
+	}
+	c.violate(Violation{
+		Severity:         SeverityError,
+		Rule:             rule,
+		DeviceID:         pair.DownstreamID,
+		UpstreamDeviceID: pair.UpstreamID,
+		NodeID:           critical.NodeID,
+		FaultType:        critical.FaultType,
+		CurrentA:         critical.CurrentA,
+		Message:          fmt.Sprintf("%s (%d of %d fault cases)", message, pair.ChecksFailed, pair.ChecksEvaluated),
+	})
+}
+
+// checkSectionalizer 檢查分段開關與上游復閉器的配合
+func (c *coordinator) checkSectionalizer(bus int) {
+	node := c.nodes[bus]
+	check := SectionalizerCheck{
+		NodeID:       node.ID,
+		CountsToOpen: int(topology.FloatProperty(node.Properties, "counts_to_open", defaultCountsToOpen)),
+	}
+
+	recloser := -1
+	for parent := c.network.Buses[bus].Parent; parent >= 0; parent = c.network.Buses[parent].Parent {
+		if parentNode := c.nodes[parent]; parentNode.Type == topology.NodeTypeSwitch && switchType(parentNode) == "recloser" {
+			recloser = parent
+			break
+		}
+	}
+	if recloser < 0 {
+		c.result.Sectionalizers = append(c.result.Sectionalizers, check)
+		c.violate(Violation{
+			Severity: SeverityError,
+			Rule:     RuleSectionalizerNoRecloser,
+			DeviceID: node.ID,
+			Message:  fmt.Sprintf("sectionalizer %s has no upstream recloser to count", node.ID),
+		})
+		return
+	}
+
+	recloserNode := c.nodes[recloser]
+	check.RecloserID = recloserNode.ID
+	check.OperationsToLockout = int(topology.FloatProperty(recloserNode.Properties, "operations_to_lockout", defaultOperationsToLockout))
+	check.Coordinated = true
+
+	if check.CountsToOpen >= check.OperationsToLockout {
+		check.Coordinated = false
+		c.violate(Violation{
+			Severity:         SeverityError,
+			Rule:             RuleSectionalizerCounts,
+			DeviceID:         node.ID,
+			UpstreamDeviceID: recloserNode.ID,
+			Message: fmt.Sprintf("sectionalizer %s opens after %d counts but %s locks out after %d operations",
+				node.ID, check.CountsToOpen, recloserNode.ID, check.OperationsToLockout),
+		})
+	}
+
+	recloserKV := c.network.Buses[recloser].BaseKV
+	var weakest *faultCurrent
+	for _, f := range c.downstreamFaults(bus, false) {
+		f := f
+		if weakest == nil || f.amps(recloserKV) < weakest.amps(recloserKV) {
+			weakest = &f
+		}
+	}
+	if weakest != nil {
+		check.MinFaultCurrentA = round(weakest.amps(recloserKV), 1)
+	}
+	if settings, ok := c.settings[recloser]; ok && weakest != nil && weakest.amps(recloserKV) <= settings.MinimumOperatingCurrentA() {
+		check.Coordinated = false
+		c.violate(Violation{
+			Severity:         SeverityError,
+			Rule:             RuleRecloserReach,
+			DeviceID:         node.ID,
+			UpstreamDeviceID: recloserNode.ID,
+			NodeID:           weakest.nodeID,
+			FaultType:        weakest.faultType,
+			CurrentA:         check.MinFaultCurrentA,
+			Message: fmt.Sprintf("%s does not detect a %s fault at %s (%.1f A, pickup %.1f A), so sectionalizer %s never counts",
+				recloserNode.ID, weakest.faultType, weakest.nodeID, check.MinFaultCurrentA, settings.MinimumOperatingCurrentA(), node.ID),
+		})
+	}
+	c.result.Sectionalizers = append(c.result.Sectionalizers, check)
+}
+
+func (c *coordinator) violate(violation Violation) {
+	c.result.Violations = append(c.result.Violations, violation)
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,177 @@
+package protection
+
+import (
+	"fmt"
+	"math"
+)
+
+// 時間電流曲線類型
+const (
+	CurveIEEEModeratelyInverse = "ieee_moderately_inverse"
+	CurveIEEEVeryInverse       = "ieee_very_inverse"
+	CurveIEEEExtremelyInverse  = "ieee_extremely_inverse"
+	CurveIECStandardInverse    = "iec_standard_inverse"
+	CurveIECVeryInverse        = "iec_very_inverse"
+	CurveIECExtremelyInverse   = "iec_extremely_inverse"
+	CurveIECLongTimeInverse    = "iec_long_time_inverse"
+	CurveDefiniteTime          = "definite_time"
+	CurveFuse                  = "fuse"
+)
+
+// 熔絲速度
+const (
+	FuseSpeedK = "k"
+	FuseSpeedT = "t"
+)
+
+// 未指定設定時使用的預設值
+const (
+	defaultTimeDial           = 1.0
+	defaultInstantaneousTimeS = 0.02
+	defaultOperatingTimeS     = 0.05 // 斷路器 / 復閉器啟斷時間（3 週波）
+	maxMultiple               = 20.0 // 反時限曲線計算的最大倍數，超過時時間固定
+	fuseMeltPickupMultiple    = 2.0  // 熔絲 300 秒熔斷電流約為額定電流的 2 倍
+	fuseMeltPickupTimeS       = 300.0
+	fuseFastMeltTimeS         = 0.1
+	fuseClearingFactor        = 1.15 // 總清除時間 = 熔斷時間 × 1.15 + 電弧時間
+	fuseArcingTimeS           = 0.01
+)
+
+// inverseCurve 反時限曲線 t = 時間乘數 × (A / (M^P − 1) + B)
+type inverseCurve struct {
+	A, B, P float64
+}
+
+// IEEE C37.112 與 IEC 60255-151 曲線常數
+var inverseCurves = map[string]inverseCurve{
+	CurveIEEEModeratelyInverse: {A: 0.0515, B: 0.114, P: 0.02},
+	CurveIEEEVeryInverse:       {A: 19.61, B: 0.491, P: 2},
+	CurveIEEEExtremelyInverse:  {A: 28.2, B: 0.1217, P: 2},
+	CurveIECStandardInverse:    {A: 0.14, P: 0.02},
+	CurveIECVeryInverse:        {A: 13.5, P: 1},
+	CurveIECExtremelyInverse:   {A: 80, P: 2},
+	CurveIECLongTimeInverse:    {A: 120, P: 1},
+}
+
+// 熔絲速度比（0.1 秒與 300 秒熔斷電流之比，ANSI C37.43）
+var fuseSpeedRatios = map[string]float64{
+	FuseSpeedK: 7,
+	FuseSpeedT: 11,
+}
+
+// Settings 開關節點 properties.protection 的保護設定
+type Settings struct {
+	Curve              string  `json:"curve"`
+	PickupA            float64 `json:"pickup_a,omitempty"`             // 始動電流（A，於開關所在電壓等級）
+	TimeDial           float64 `json:"time_dial,omitempty"`            // IEEE 時間刻度 / IEC 時間乘數（預設 1）
+	DefiniteTimeS      float64 `json:"definite_time_s,omitempty"`      // definite_time 曲線的動作時間
+	InstantaneousA     float64 `json:"instantaneous_a,omitempty"`      // 瞬時元件始動電流（0 表示不使用）
+	InstantaneousTimeS float64 `json:"instantaneous_time_s,omitempty"` // 瞬時元件動作時間（預設 0.02 秒）
+	OperatingTimeS     float64 `json:"operating_time_s,omitempty"`     // 斷路器啟斷時間（預設 0.05 秒，熔絲不適用）
+	FuseRatingA        float64 `json:"fuse_rating_a,omitempty"`        // 熔絲額定電流
+	FuseSpeed          string  `json:"fuse_speed,omitempty"`           // k（預設）或 t
+}
+
+// Validate 檢查設定是否完整
+func (s Settings) Validate() error {
+	switch {
+	case s.Curve == CurveFuse:
+		if s.FuseRatingA <= 0 {
+			return fmt.Errorf("fuse curve requires fuse_rating_a")
+		}
+		if s.FuseSpeed != "" && fuseSpeedRatios[s.FuseSpeed] == 0 {
+			return fmt.Errorf("unknown fuse_speed %q", s.FuseSpeed)
+		}
+		return nil
+	case s.Curve == CurveDefiniteTime:
+		if s.DefiniteTimeS <= 0 {
+			return fmt.Errorf("definite_time curve requires definite_time_s")
+		}
+	default:
+		if _, exists := inverseCurves[s.Curve]; !exists {
+			return fmt.Errorf("unknown curve %q", s.Curve)
+		}
+	}
+	if s.PickupA <= 0 {
+		return fmt.Errorf("%s curve requires pickup_a", s.Curve)
+	}
+	if s.TimeDial < 0 || s.InstantaneousA < 0 || s.InstantaneousTimeS < 0 || s.OperatingTimeS < 0 {
+		return fmt.Errorf("settings must not be negative")
+	}
+	return nil
+}
+
+// IsFuse 是否為熔絲
+func (s Settings) IsFuse() bool {
+	return s.Curve == CurveFuse
+}
+
+// ResponseTime 設備開始動作的時間（電驛跳脫 / 熔絲熔斷），電流低於始動值時回傳 false
+func (s Settings) ResponseTime(currentA float64) (float64, bool) {
+	if s.IsFuse() {
+		return s.meltingTime(currentA)
+	}
+
+	if s.InstantaneousA > 0 && currentA >= s.InstantaneousA {
+		instantaneous := s.InstantaneousTimeS
+		if instantaneous <= 0 {
+			instantaneous = defaultInstantaneousTimeS
+		}
+		return instantaneous, true
+	}
+
+	multiple := currentA / s.PickupA
+	if multiple <= 1 {
+		return 0, false
+	}
+	if s.Curve == CurveDefiniteTime {
+		return s.DefiniteTimeS, true
+	}
+
+	timeDial := s.TimeDial
+	if timeDial <= 0 {
+		timeDial = defaultTimeDial
+	}
+	curve := inverseCurves[s.Curve]
+	multiple = math.Min(multiple, maxMultiple)
+	return timeDial * (curve.A/(math.Pow(multiple, curve.P)-1) + curve.B), true
+}
+
+// ClearingTime 故障清除時間（電驛加上斷路器啟斷時間 / 熔絲總清除時間）
+func (s Settings) ClearingTime(currentA float64) (float64, bool) {
+	response, ok := s.ResponseTime(currentA)
+	if !ok {
+		return 0, false
+	}
+	if s.IsFuse() {
+		return response*fuseClearingFactor + fuseArcingTimeS, true
+	}
+
+	operating := s.OperatingTimeS
+	if operating <= 0 {
+		operating = defaultOperatingTimeS
+	}
+	return response + operating, true
+}
+
+// MinimumOperatingCurrentA 設備會動作的最小電流
+func (s Settings) MinimumOperatingCurrentA() float64 {
+	if s.IsFuse() {
+		return s.FuseRatingA * fuseMeltPickupMultiple
+	}
+	return s.PickupA
+}
+
+// meltingTime 熔絲最小熔斷時間，以通過 (2×額定, 300 秒) 與 (2×額定×速度比, 0.1 秒) 的對數直線近似
+func (s Settings) meltingTime(currentA float64) (float64, bool) {
+	pickup := s.MinimumOperatingCurrentA()
+	if currentA <= pickup {
+		return 0, false
+	}
+	ratio := fuseSpeedRatios[s.FuseSpeed]
+	if ratio == 0 {
+		ratio = fuseSpeedRatios[FuseSpeedK]
+	}
+	slope := math.Log(fuseMeltPickupTimeS/fuseFastMeltTimeS) / math.Log(ratio)
+	return fuseMeltPickupTimeS * math.Pow(currentA/pickup, -slope), true
+}
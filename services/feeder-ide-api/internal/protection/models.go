@@ -0,0 +1,133 @@
+// Package protection 檢查輻射狀 feeder 上斷路器、復閉器、熔絲與分段開關的保護協調
+//
+// 保護設定以開關節點的 properties.protection 描述（見 Settings），支援 IEEE / IEC 反時限曲線、
+// 定時限與熔絲曲線。沿輻射路徑找出每個保護設備最近的上游保護設備組成協調對，
+// 以故障電流分析得到的各下游節點三相、線間與單相接地故障電流檢查協調時間間隔（CTI）；
+// 上游為熔絲時改用下游總清除時間不超過上游熔斷時間 75% 的規則。
+// 分段開關不啟斷故障電流，檢查其上游是否有復閉器、計數次數是否小於復閉器閉鎖前的動作次數，
+// 以及復閉器能否偵測分段開關下游的故障。
+package protection
+
+import "github.com/feeder-platform/feeder-ide-api/internal/shortcircuit"
+
+// 未指定參數時使用的預設值
+const (
+	defaultCTISeconds          = 0.2  // 下游清除後上游仍未動作的裕度（已扣除下游啟斷時間）
+	defaultFuseRatio           = 0.75 // 下游總清除時間 / 上游熔絲熔斷時間上限
+	defaultCountsToOpen        = 3
+	defaultOperationsToLockout = 4
+)
+
+// 故障類型
+const (
+	FaultThreePhase   = "three_phase"
+	FaultLineToLine   = "line_to_line"
+	FaultLineToGround = "line_to_ground"
+)
+
+// 違規規則
+const (
+	RuleMiscoordination         = "miscoordination"
+	RuleInsufficientMargin      = "insufficient_margin"
+	RuleDownstreamNoOperation   = "downstream_no_operation"
+	RuleMissingSettings         = "missing_settings"
+	RuleInvalidSettings         = "invalid_settings"
+	RuleSectionalizerNoRecloser = "sectionalizer_without_recloser"
+	RuleSectionalizerCounts     = "sectionalizer_counts"
+	RuleRecloserReach           = "recloser_reach"
+)
+
+// 嚴重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Options 協調分析參數
+type Options struct {
+	CTISeconds   float64              `json:"cti_seconds,omitempty"` // 最小協調時間間隔
+	FuseRatio    float64              `json:"fuse_ratio,omitempty"`  // 上游為熔絲時，下游清除時間 / 上游熔斷時間上限
+	ShortCircuit shortcircuit.Options `json:"short_circuit"`         // 故障電流分析參數
+}
+
+// withDefaults 補上預設值
+func (o Options) withDefaults() Options {
+	if o.CTISeconds <= 0 {
+		o.CTISeconds = defaultCTISeconds
+	}
+	if o.FuseRatio <= 0 || o.FuseRatio >= 1 {
+		o.FuseRatio = defaultFuseRatio
+	}
+	return o
+}
+
+// Result 協調分析結果
+type Result struct {
+	Devices        []Device             `json:"devices"`
+	Pairs          []PairResult         `json:"pairs"`
+	Sectionalizers []SectionalizerCheck `json:"sectionalizers"`
+	Violations     []Violation          `json:"violations"`
+	Summary        Summary              `json:"summary"`
+}
+
+// Device 保護設備
+type Device struct {
+	NodeID           string    `json:"node_id"`
+	SwitchType       string    `json:"switch_type"`
+	BaseKV           float64   `json:"base_kv"`
+	Settings         *Settings `json:"settings,omitempty"`
+	UpstreamDeviceID string    `json:"upstream_device_id,omitempty"`
+}
+
+// PairResult 上下游保護設備協調結果
+type PairResult struct {
+	DownstreamID    string `json:"downstream_id"`
+	UpstreamID      string `json:"upstream_id"`
+	Coordinated     bool   `json:"coordinated"`
+	ChecksEvaluated int    `json:"checks_evaluated"`
+	ChecksFailed    int    `json:"checks_failed"`
+	Critical        *Check `json:"critical,omitempty"` // 裕度最小的故障點
+}
+
+// Check 單一故障點的協調檢查
+type Check struct {
+	NodeID          string  `json:"node_id"`
+	FaultType       string  `json:"fault_type"`
+	CurrentA        float64 `json:"current_a"`         // 流經下游設備的故障電流
+	DownstreamTimeS float64 `json:"downstream_time_s"` // 下游清除時間
+	UpstreamTimeS   float64 `json:"upstream_time_s"`   // 上游動作（跳脫 / 熔斷）時間
+	MarginS         float64 `json:"margin_s"`
+	RequiredS       float64 `json:"required_s"`
+}
+
+// SectionalizerCheck 分段開關與上游復閉器的配合
+type SectionalizerCheck struct {
+	NodeID              string  `json:"node_id"`
+	RecloserID          string  `json:"recloser_id,omitempty"`
+	CountsToOpen        int     `json:"counts_to_open"`
+	OperationsToLockout int     `json:"operations_to_lockout,omitempty"`
+	MinFaultCurrentA    float64 `json:"min_fault_current_a,omitempty"` // 下游最小故障電流（於復閉器電壓等級）
+	Coordinated         bool    `json:"coordinated"`
+}
+
+// Violation 協調違規
+type Violation struct {
+	Severity         string  `json:"severity"`
+	Rule             string  `json:"rule"`
+	DeviceID         string  `json:"device_id"`
+	UpstreamDeviceID string  `json:"upstream_device_id,omitempty"`
+	NodeID           string  `json:"node_id,omitempty"` // 最嚴重的故障點
+	FaultType        string  `json:"fault_type,omitempty"`
+	CurrentA         float64 `json:"current_a,omitempty"`
+	Message          string  `json:"message"`
+}
+
+// Summary 整體統計
+type Summary struct {
+	Devices          int  `json:"devices"`
+	Pairs            int  `json:"pairs"`
+	CoordinatedPairs int  `json:"coordinated_pairs"`
+	Errors           int  `json:"errors"`
+	Warnings         int  `json:"warnings"`
+	Coordinated      bool `json:"coordinated"`
+}
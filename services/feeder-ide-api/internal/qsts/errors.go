@@ -0,0 +1,8 @@
+package qsts
+
+import "errors"
+
+var (
+	ErrInvalidOptions = errors.New("invalid qsts options")
+	ErrInvalidShape   = errors.New("invalid shape")
+)
@@ -0,0 +1,185 @@
+// Package qsts 以逐時負載與 DER 出力曲線執行準穩態時間序列（QSTS）潮流分析
+//
+// 每個時間步（1 小時）依節點的曲線調整負載與發電後執行潮流，變電所有載分接頭（LTC）
+// 依調節點電壓與不感帶調整分接頭。曲線長度可為 24（每日重複）、168（每週重複）或 8760 小時；
+// 未提供時，負載依節點 load_class 或 profile 負載組成混合住宅 / 商業 / 工業樣板，
+// PV 以晴空日照模型乘上隨機雲量，風機與 EV 充電亦有預設樣板。
+// 年份以週一開始、不含閏日簡化。
+package qsts
+
+import (
+	"fmt"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+)
+
+// HoursPerYear 一年的小時數
+const HoursPerYear = 8760
+
+// 曲線樣板名稱，亦可作為上傳曲線的欄位名稱以覆蓋樣板
+const (
+	ShapeResidential = "residential"
+	ShapeCommercial  = "commercial"
+	ShapeIndustrial  = "industrial"
+	ShapeEV          = "ev"
+	ShapePV          = "pv"
+	ShapeWind        = "wind"
+)
+
+// 未指定參數時使用的預設值
+const (
+	defaultSummaryHours = 24
+	defaultLatitude     = 35.0
+	defaultSeed         = 1
+	defaultTargetPU     = 1.0
+	defaultBandwidthPU  = 0.0167 // 120 V 基準下 2 V
+	defaultTapStepPU    = 0.00625
+	defaultMaxTap       = 16
+	maxTapIterations    = 3
+)
+
+// Options QSTS 參數
+type Options struct {
+	StartHour         int                  `json:"start_hour,omitempty"`          // 起始小時（0 ~ 8759，0 為 1 月 1 日 0 時）
+	Hours             int                  `json:"hours,omitempty"`               // 模擬時數（預設 8760）
+	Shapes            map[string][]float64 `json:"shapes,omitempty"`              // 以節點 ID 或樣板名稱為鍵的曲線（負載為尖峰負載倍數，DER 為額定出力比例）
+	Seed              int64                `json:"seed,omitempty"`                // 產生雲量與風速的亂數種子
+	Latitude          float64              `json:"latitude,omitempty"`            // PV 日照模型的緯度（度）
+	SummaryHours      int                  `json:"summary_hours,omitempty"`       // 降取樣摘要的區間長度（預設 24）
+	IncludeTimeSeries *bool                `json:"include_time_series,omitempty"` // 是否回傳逐時序列（預設 true）
+	MonitorNodeIDs    []string             `json:"monitor_node_ids,omitempty"`    // 回傳逐時電壓的節點，預設為調節點與基準情境最低電壓節點
+	MonitorLineIDs    []string             `json:"monitor_line_ids,omitempty"`    // 回傳逐時負載率的線路，預設為變電所出口線路
+	Regulation        Regulation           `json:"regulation"`
+	PowerFlow         powerflow.Options    `json:"power_flow"`
+}
+
+// Regulation 變電所有載分接頭控制
+type Regulation struct {
+	Disabled        bool    `json:"disabled,omitempty"`
+	RegulatedNodeID string  `json:"regulated_node_id,omitempty"` // 調節點，預設為電源到最低電壓節點路徑上的電壓中點
+	TargetPU        float64 `json:"target_pu,omitempty"`
+	BandwidthPU     float64 `json:"bandwidth_pu,omitempty"` // 不感帶寬度（上下各一半）
+	TapStepPU       float64 `json:"tap_step_pu,omitempty"`
+	MaxTap          int     `json:"max_tap,omitempty"` // 分接頭位置範圍 ±MaxTap
+}
+
+// withDefaults 補上預設值
+func (o Options) withDefaults() Options {
+	if o.Hours <= 0 {
+		o.Hours = HoursPerYear
+	}
+	if o.SummaryHours <= 0 {
+		o.SummaryHours = defaultSummaryHours
+	}
+	if o.Latitude == 0 {
+		o.Latitude = defaultLatitude
+	}
+	if o.Seed == 0 {
+		o.Seed = defaultSeed
+	}
+	if o.IncludeTimeSeries == nil {
+		include := true
+		o.IncludeTimeSeries = &include
+	}
+	if o.Regulation.TargetPU <= 0 {
+		o.Regulation.TargetPU = defaultTargetPU
+	}
+	if o.Regulation.BandwidthPU <= 0 {
+		o.Regulation.BandwidthPU = defaultBandwidthPU
+	}
+	if o.Regulation.TapStepPU <= 0 {
+		o.Regulation.TapStepPU = defaultTapStepPU
+	}
+	if o.Regulation.MaxTap <= 0 {
+		o.Regulation.MaxTap = defaultMaxTap
+	}
+	return o
+}
+
+// Result QSTS 結果
+type Result struct {
+	StartHour       int          `json:"start_hour"`
+	Hours           int          `json:"hours"`
+	RegulatedNodeID string       `json:"regulated_node_id,omitempty"`
+	TimeSeries      *TimeSeries  `json:"time_series,omitempty"`
+	Nodes           []NodeSeries `json:"nodes,omitempty"`
+	Lines           []LineSeries `json:"lines,omitempty"`
+	Intervals       []Interval   `json:"intervals"`
+	Summary         Summary      `json:"summary"`
+}
+
+// TimeSeries 逐時的饋線層級序列（以欄位陣列表示，索引對應模擬的第幾小時）
+type TimeSeries struct {
+	Hour                  []int     `json:"hour"` // 一年中的小時
+	MinVoltagePU          []float64 `json:"min_voltage_pu"`
+	MaxVoltagePU          []float64 `json:"max_voltage_pu"`
+	MaxLineLoadingPercent []float64 `json:"max_line_loading_percent"`
+	LoadKW                []float64 `json:"load_kw"`
+	GenerationKW          []float64 `json:"generation_kw"`
+	SourceKW              []float64 `json:"source_kw"`
+	LossesKW              []float64 `json:"losses_kw"`
+	TapPosition           []int     `json:"tap_position"`
+	VoltageViolations     []int     `json:"voltage_violations"` // 違規節點數
+	ThermalViolations     []int     `json:"thermal_violations"` // 過載線路與變壓器數
+}
+
+// NodeSeries 監測節點的逐時電壓
+type NodeSeries struct {
+	NodeID    string    `json:"node_id"`
+	VoltagePU []float64 `json:"voltage_pu"`
+}
+
+// LineSeries 監測線路的逐時負載率
+type LineSeries struct {
+	LineID         string    `json:"line_id"`
+	LoadingPercent []float64 `json:"loading_percent"`
+}
+
+// Interval 降取樣摘要（預設每日）
+type Interval struct {
+	StartHour             int     `json:"start_hour"`
+	Hours                 int     `json:"hours"`
+	MinVoltagePU          float64 `json:"min_voltage_pu"`
+	MaxVoltagePU          float64 `json:"max_voltage_pu"`
+	MaxLineLoadingPercent float64 `json:"max_line_loading_percent"`
+	PeakLoadKW            float64 `json:"peak_load_kw"`
+	LoadKWh               float64 `json:"load_kwh"`
+	GenerationKWh         float64 `json:"generation_kwh"`
+	LossesKWh             float64 `json:"losses_kwh"`
+	TapOperations         int     `json:"tap_operations"`
+	VoltageViolationHours int     `json:"voltage_violation_hours"` // 至少一個節點違規的小時數
+	ThermalViolationHours int     `json:"thermal_violation_hours"`
+	NonConvergedHours     int     `json:"non_converged_hours,omitempty"`
+}
+
+// Summary 整個模擬期間的統計
+type Summary struct {
+	Interval
+	MinVoltageHour     int     `json:"min_voltage_hour"`
+	MaxVoltageHour     int     `json:"max_voltage_hour"`
+	PeakLoadHour       int     `json:"peak_load_hour"`
+	ReverseFlowHours   int     `json:"reverse_flow_hours"` // 變電所逆送的小時數
+	LossesPercent      float64 `json:"losses_percent"`     // 損失佔（負載 + 損失）的比例
+	MinTapPosition     int     `json:"min_tap_position"`
+	MaxTapPosition     int     `json:"max_tap_position"`
+	PowerFlowsExecuted int     `json:"power_flows_executed"`
+}
+
+// IntervalFunc 每完成一個摘要區間時呼叫，可用於串流部分結果
+type IntervalFunc func(interval Interval, done, total int)
+
+// Validate 檢查分析區間與使用者提供的曲線
+func (o Options) Validate() error {
+	if o.StartHour < 0 || o.StartHour >= HoursPerYear {
+		return fmt.Errorf("%w: start_hour must be between 0 and %d", ErrInvalidOptions, HoursPerYear-1)
+	}
+	if o.Hours < 0 || o.Hours > HoursPerYear {
+		return fmt.Errorf("%w: hours must be between 0 and %d", ErrInvalidOptions, HoursPerYear)
+	}
+	for name, shape := range o.Shapes {
+		if err := validateShape(name, shape); err != nil {
+			return err
+		}
+	}
+	return nil
+}
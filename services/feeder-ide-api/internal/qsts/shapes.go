@@ -0,0 +1,249 @@
+package qsts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+)
+
+// dailyTemplate 平日與週末的 24 小時曲線（以尖峰為 1）及各月份的季節係數
+type dailyTemplate struct {
+	Weekday  [24]float64
+	Weekend  [24]float64
+	Seasonal [12]float64
+}
+
+var templates = map[string]dailyTemplate{
+	ShapeResidential: {
+		Weekday:  [24]float64{0.45, 0.40, 0.38, 0.37, 0.38, 0.45, 0.60, 0.72, 0.65, 0.55, 0.50, 0.50, 0.50, 0.50, 0.52, 0.58, 0.68, 0.82, 0.95, 1.00, 0.96, 0.85, 0.70, 0.55},
+		Weekend:  [24]float64{0.50, 0.45, 0.42, 0.40, 0.40, 0.42, 0.48, 0.58, 0.68, 0.72, 0.70, 0.68, 0.66, 0.64, 0.64, 0.66, 0.72, 0.84, 0.95, 1.00, 0.95, 0.85, 0.72, 0.58},
+		Seasonal: [12]float64{0.95, 0.90, 0.80, 0.75, 0.80, 0.92, 1.00, 1.00, 0.88, 0.78, 0.85, 0.95},
+	},
+	ShapeCommercial: {
+		Weekday:  [24]float64{0.35, 0.33, 0.32, 0.32, 0.33, 0.38, 0.50, 0.70, 0.88, 0.96, 1.00, 1.00, 0.98, 1.00, 1.00, 0.98, 0.92, 0.82, 0.65, 0.55, 0.48, 0.42, 0.38, 0.36},
+		Weekend:  [24]float64{0.32, 0.30, 0.30, 0.30, 0.30, 0.32, 0.36, 0.42, 0.50, 0.56, 0.60, 0.62, 0.62, 0.62, 0.60, 0.58, 0.55, 0.50, 0.45, 0.42, 0.38, 0.36, 0.34, 0.33},
+		Seasonal: [12]float64{0.85, 0.85, 0.85, 0.85, 0.90, 0.97, 1.00, 1.00, 0.95, 0.88, 0.85, 0.85},
+	},
+	ShapeIndustrial: {
+		Weekday:  [24]float64{0.70, 0.68, 0.68, 0.68, 0.70, 0.75, 0.85, 0.95, 1.00, 1.00, 1.00, 0.98, 0.95, 1.00, 1.00, 0.98, 0.95, 0.90, 0.85, 0.80, 0.78, 0.75, 0.72, 0.70},
+		Weekend:  [24]float64{0.55, 0.55, 0.54, 0.54, 0.55, 0.56, 0.58, 0.60, 0.62, 0.62, 0.62, 0.60, 0.60, 0.60, 0.60, 0.60, 0.60, 0.58, 0.58, 0.57, 0.56, 0.56, 0.55, 0.55},
+		Seasonal: [12]float64{0.96, 0.96, 0.97, 0.97, 0.98, 1.00, 1.00, 1.00, 0.99, 0.98, 0.97, 0.96},
+	},
+	// EV 以住家晚間充電為主，週末分散至白天
+	ShapeEV: {
+		Weekday:  [24]float64{0.55, 0.40, 0.25, 0.15, 0.10, 0.08, 0.08, 0.10, 0.12, 0.10, 0.08, 0.08, 0.10, 0.10, 0.12, 0.15, 0.22, 0.40, 0.65, 0.85, 1.00, 0.95, 0.85, 0.70},
+		Weekend:  [24]float64{0.45, 0.35, 0.25, 0.15, 0.10, 0.08, 0.08, 0.10, 0.15, 0.22, 0.30, 0.35, 0.38, 0.38, 0.36, 0.35, 0.35, 0.40, 0.50, 0.60, 0.70, 0.72, 0.65, 0.55},
+		Seasonal: [12]float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+	},
+}
+
+// 各月份天數（不含閏日）
+var daysInMonth = [12]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// monthOfDay 一年中第幾天所在的月份（0 ~ 11）
+func monthOfDay(day int) int {
+	for month, days := range daysInMonth {
+		if day < days {
+			return month
+		}
+		day -= days
+	}
+	return 11
+}
+
+// templateShape 將每日樣板展開為 8760 小時曲線
+func templateShape(template dailyTemplate) []float64 {
+	shape := make([]float64, HoursPerYear)
+	for hour := range shape {
+		day := hour / 24
+		daily := template.Weekday
+		if day%7 >= 5 {
+			daily = template.Weekend
+		}
+		shape[hour] = daily[hour%24] * template.Seasonal[monthOfDay(day)]
+	}
+	return shape
+}
+
+// blendShape 依 profile 負載組成混合住宅 / 商業 / 工業曲線
+func blendShape(composition profiles.LoadComposition, shapes map[string][]float64) []float64 {
+	weights := map[string]float64{
+		ShapeResidential: composition.Residential,
+		ShapeCommercial:  composition.Commercial,
+		ShapeIndustrial:  composition.Industrial,
+	}
+	total := composition.Residential + composition.Commercial + composition.Industrial
+	if total <= 0 {
+		weights[ShapeResidential], total = 1, 1
+	}
+
+	blend := make([]float64, HoursPerYear)
+	for name, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		shape := shapes[name]
+		for hour := range blend {
+			blend[hour] += weight / total * at(shape, hour)
+		}
+	}
+	return blend
+}
+
+// pvShape 以晴空日照模型（太陽仰角）乘上每日隨機雲量產生 PV 出力比例
+func pvShape(latitude float64, rng *rand.Rand) []float64 {
+	phi := latitude * math.Pi / 180
+	// 夏至正午的仰角正弦作為額定出力
+	peak := math.Sin(math.Pi/2 - math.Abs(phi-23.44*math.Pi/180))
+
+	shape := make([]float64, HoursPerYear)
+	for day := 0; day < HoursPerYear/24; day++ {
+		declination := 23.44 * math.Pi / 180 * math.Sin(2*math.Pi*float64(284+day+1)/365)
+
+		// 晴天 60%、多雲 25%、陰天 15%
+		clearness, variability := 0.0, 0.0
+		switch draw := rng.Float64(); {
+		case draw < 0.60:
+			clearness, variability = 0.85+0.15*rng.Float64(), 0.03
+		case draw < 0.85:
+			clearness, variability = 0.50+0.35*rng.Float64(), 0.25
+		default:
+			clearness, variability = 0.15+0.25*rng.Float64(), 0.10
+		}
+
+		for h := 0; h < 24; h++ {
+			hourAngle := 15 * (float64(h) + 0.5 - 12) * math.Pi / 180
+			sinElevation := math.Sin(phi)*math.Sin(declination) + math.Cos(phi)*math.Cos(declination)*math.Cos(hourAngle)
+			if sinElevation <= 0 {
+				continue
+			}
+			factor := clearness * (1 + variability*(2*rng.Float64()-1))
+			shape[day*24+h] = math.Max(0, math.Min(1, sinElevation/peak*factor))
+		}
+	}
+	return shape
+}
+
+// windShape 以一階自我迴歸風速與簡化功率曲線產生風機出力比例（平均容量因數約 0.3）
+func windShape(rng *rand.Rand) []float64 {
+	shape := make([]float64, HoursPerYear)
+	speed := 7.0
+	for hour := range shape {
+		speed = 7 + 0.95*(speed-7) + 1.2*rng.NormFloat64()
+		switch {
+		case speed < 3 || speed > 25:
+			shape[hour] = 0
+		case speed >= 12:
+			shape[hour] = 1
+		default:
+			shape[hour] = math.Pow((speed-3)/9, 3)
+		}
+	}
+	return shape
+}
+
+// defaultShapes 建立所有樣板曲線，並以使用者提供的同名曲線覆蓋（曲線已由 Validate 檢查）
+func defaultShapes(opts Options) map[string][]float64 {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	shapes := map[string][]float64{
+		ShapePV:   pvShape(opts.Latitude, rng),
+		ShapeWind: windShape(rng),
+	}
+	for name, template := range templates {
+		shapes[name] = templateShape(template)
+	}
+
+	for name, shape := range opts.Shapes {
+		if _, isTemplate := shapes[name]; isTemplate {
+			shapes[name] = shape
+		}
+	}
+	return shapes
+}
+
+// validateShape 檢查曲線長度與數值
+func validateShape(name string, shape []float64) error {
+	switch len(shape) {
+	case 24, 168, HoursPerYear:
+	default:
+		return fmt.Errorf("%w: %s has %d values, expected 24, 168 or %d", ErrInvalidShape, name, len(shape), HoursPerYear)
+	}
+	for i, value := range shape {
+		if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("%w: %s value %d must be a non-negative number", ErrInvalidShape, name, i)
+		}
+	}
+	return nil
+}
+
+// at 取得曲線在一年中某小時的值，較短的曲線重複使用
+func at(shape []float64, hour int) float64 {
+	if shape == nil {
+		return 1
+	}
+	return shape[hour%len(shape)]
+}
+
+// ParseShapesCSV 解析曲線 CSV：第一列為欄位名稱（節點 ID 或樣板名稱），每列一小時
+// 名為 hour、time 或 timestamp 的欄位會被忽略
+func ParseShapesCSV(r io.Reader) (map[string][]float64, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidShape, err)
+	}
+
+	names := make([]string, len(header))
+	shapes := map[string][]float64{}
+	for i, column := range header {
+		name := strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		switch strings.ToLower(name) {
+		case "", "hour", "time", "timestamp":
+			continue
+		}
+		if _, duplicate := shapes[name]; duplicate {
+			return nil, fmt.Errorf("%w: duplicate column %s", ErrInvalidShape, name)
+		}
+		names[i] = name
+		shapes[name] = []float64{}
+	}
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("%w: no shape columns", ErrInvalidShape)
+	}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidShape, err)
+		}
+		for i, field := range record {
+			if names[i] == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: row %d column %s: %v", ErrInvalidShape, row, names[i], err)
+			}
+			shapes[names[i]] = append(shapes[names[i]], value)
+		}
+	}
+
+	for name, shape := range shapes {
+		if err := validateShape(name, shape); err != nil {
+			return nil, err
+		}
+	}
+	return shapes, nil
+}
@@ -0,0 +1,432 @@
+package qsts
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/profiles"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Simulate 執行 QSTS 分析
+// composition 用於沒有 load_class 的負載節點；ctx 取消時中止並回傳 ctx.Err()；onInterval 可為 nil
+func Simulate(ctx context.Context, t *topology.Topology, composition profiles.LoadComposition, opts Options, onInterval IntervalFunc) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+
+	shapes := defaultShapes(opts)
+	n, err := powerflow.BuildNetwork(t, opts.PowerFlow)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &simulation{
+		network:    n,
+		opts:       opts,
+		sourcePU:   opts.PowerFlow.SourceVoltagePU,
+		base:       make([]powerflow.Demand, len(n.Buses)),
+		loadShapes: make([][]float64, len(n.Buses)),
+		genShapes:  make([][]float64, len(n.Buses)),
+	}
+	if s.sourcePU <= 0 {
+		s.sourcePU = 1
+	}
+	if err := s.assignShapes(t, shapes, composition); err != nil {
+		return nil, err
+	}
+	if err := s.selectMonitors(); err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, onInterval)
+}
+
+// simulation 分析過程中的狀態，陣列皆以匯流排索引對應
+type simulation struct {
+	network    *powerflow.Network
+	opts       Options
+	sourcePU   float64
+	base       []powerflow.Demand
+	loadShapes [][]float64 // nil 表示固定不變
+	genShapes  [][]float64
+	regulated  int // 調節點，-1 表示不調節
+	monitorBus []int
+	monitorBr  []int
+	tap        int
+}
+
+// assignShapes 決定每個匯流排的負載與發電曲線
+func (s *simulation) assignShapes(t *topology.Topology, shapes map[string][]float64, composition profiles.LoadComposition) error {
+	nodes := make(map[string]topology.Node, len(t.Nodes))
+	for _, node := range t.Nodes {
+		nodes[node.ID] = node
+	}
+	for id := range s.opts.Shapes {
+		if _, isTemplate := shapes[id]; isTemplate {
+			continue
+		}
+		if _, exists := s.network.BusIndex(id); !exists {
+			return fmt.Errorf("%w: shape %s matches neither a node nor a template", ErrInvalidShape, id)
+		}
+	}
+
+	var blended []float64
+	for i, bus := range s.network.Buses {
+		s.base[i] = bus.Demand
+		node := nodes[bus.NodeID]
+		custom := s.opts.Shapes[bus.NodeID]
+
+		switch bus.Type {
+		case topology.NodeTypeEVCharger:
+			s.loadShapes[i] = pick(custom, shapes[ShapeEV])
+		case topology.NodeTypeDER:
+			switch topology.StringProperty(node.Properties, "type", "") {
+			case "pv":
+				s.genShapes[i] = pick(custom, shapes[ShapePV])
+			case "wind":
+				s.genShapes[i] = pick(custom, shapes[ShapeWind])
+			default:
+				s.genShapes[i] = custom
+			}
+		default:
+			if bus.Demand.LoadKW == 0 && bus.Demand.LoadKVAR == 0 {
+				continue
+			}
+			if class := shapes[topology.StringProperty(node.Properties, "load_class", "")]; class != nil && custom == nil {
+				s.loadShapes[i] = class
+				continue
+			}
+			if custom == nil && blended == nil {
+				blended = blendShape(composition, shapes)
+			}
+			s.loadShapes[i] = pick(custom, blended)
+		}
+	}
+	return nil
+}
+
+func pick(custom, fallback []float64) []float64 {
+	if custom != nil {
+		return custom
+	}
+	return fallback
+}
+
+// selectMonitors 以尖峰（曲線倍數為 1）情境決定調節點與預設監測點
+func (s *simulation) selectMonitors() error {
+	n := s.network
+	base, err := powerflow.SolveNetwork(n, s.powerflowOptions())
+	if err != nil {
+		return err
+	}
+
+	worst := n.Source
+	for _, index := range n.Order {
+		if base.Nodes[index].VoltagePU < base.Nodes[worst].VoltagePU {
+			worst = index
+		}
+	}
+
+	s.regulated = -1
+	if !s.opts.Regulation.Disabled {
+		if id := s.opts.Regulation.RegulatedNodeID; id != "" {
+			index, exists := n.BusIndex(id)
+			if !exists || !n.Buses[index].Energized {
+				return fmt.Errorf("%w: regulated node %s not found or not energized", ErrInvalidOptions, id)
+			}
+			s.regulated = index
+		} else {
+			// 電源到最低電壓節點路徑上，電壓最接近兩端中點的匯流排
+			middle := (base.Nodes[n.Source].VoltagePU + base.Nodes[worst].VoltagePU) / 2
+			s.regulated = worst
+			for bus := worst; bus >= 0; bus = n.Buses[bus].Parent {
+				if math.Abs(base.Nodes[bus].VoltagePU-middle) < math.Abs(base.Nodes[s.regulated].VoltagePU-middle) {
+					s.regulated = bus
+				}
+			}
+		}
+	}
+
+	if len(s.opts.MonitorNodeIDs) > 0 {
+		for _, id := range s.opts.MonitorNodeIDs {
+			index, exists := n.BusIndex(id)
+			if !exists {
+				return fmt.Errorf("%w: monitored node %s not found", ErrInvalidOptions, id)
+			}
+			s.monitorBus = append(s.monitorBus, index)
+		}
+	} else {
+		if s.regulated >= 0 && s.regulated != worst {
+			s.monitorBus = append(s.monitorBus, s.regulated)
+		}
+		s.monitorBus = append(s.monitorBus, worst)
+	}
+
+	if len(s.opts.MonitorLineIDs) > 0 {
+		branches := make(map[string]int, len(n.Branches))
+		for i, branch := range n.Branches {
+			branches[branch.LineID] = i
+		}
+		for _, id := range s.opts.MonitorLineIDs {
+			index, exists := branches[id]
+			if !exists {
+				return fmt.Errorf("%w: monitored line %s not found", ErrInvalidOptions, id)
+			}
+			s.monitorBr = append(s.monitorBr, index)
+		}
+	} else {
+		for i, branch := range n.Branches {
+			if branch.InTree && (branch.FromBus == n.Source || branch.ToBus == n.Source) {
+				s.monitorBr = append(s.monitorBr, i)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *simulation) powerflowOptions() powerflow.Options {
+	opts := s.opts.PowerFlow
+	opts.SourceVoltagePU = s.sourcePU + float64(s.tap)*s.opts.Regulation.TapStepPU
+	return opts
+}
+
+// applyHour 依曲線設定某小時的匯流排需求
+func (s *simulation) applyHour(hour int) {
+	for i, bus := range s.network.Buses {
+		base := s.base[i]
+		load, gen := at(s.loadShapes[i], hour), at(s.genShapes[i], hour)
+		bus.Demand = powerflow.Demand{
+			LoadKW:       base.LoadKW * load,
+			LoadKVAR:     base.LoadKVAR * load,
+			GenerationKW: base.GenerationKW * gen,
+		}
+	}
+}
+
+// solveHour 執行潮流，調節點電壓超出不感帶時調整分接頭後重新計算，回傳結果、分接頭動作次數與潮流次數
+func (s *simulation) solveHour() (*powerflow.Result, int, int, error) {
+	reg := s.opts.Regulation
+	operations, flows := 0, 0
+	for iteration := 0; ; iteration++ {
+		result, err := powerflow.SolveNetwork(s.network, s.powerflowOptions())
+		flows++
+		if err != nil {
+			return nil, operations, flows, err
+		}
+		if s.regulated < 0 || !result.Converged || iteration == maxTapIterations {
+			return result, operations, flows, nil
+		}
+
+		deviation := reg.TargetPU - result.Nodes[s.regulated].VoltagePU
+		if math.Abs(deviation) <= reg.BandwidthPU/2 {
+			return result, operations, flows, nil
+		}
+		next := s.tap + int(math.Round(deviation/reg.TapStepPU))
+		next = max(-reg.MaxTap, min(reg.MaxTap, next))
+		if next == s.tap {
+			return result, operations, flows, nil
+		}
+		operations += abs(next - s.tap)
+		s.tap = next
+	}
+}
+
+func (s *simulation) run(ctx context.Context, onInterval IntervalFunc) (*Result, error) {
+	opts := s.opts
+	result := &Result{
+		StartHour: opts.StartHour,
+		Hours:     opts.Hours,
+		Intervals: []Interval{},
+	}
+	if s.regulated >= 0 {
+		result.RegulatedNodeID = s.network.Buses[s.regulated].NodeID
+	}
+
+	includeSeries := *opts.IncludeTimeSeries
+	series := &TimeSeries{}
+	if includeSeries {
+		result.TimeSeries = series
+		for _, bus := range s.monitorBus {
+			result.Nodes = append(result.Nodes, NodeSeries{NodeID: s.network.Buses[bus].NodeID, VoltagePU: make([]float64, 0, opts.Hours)})
+		}
+		for _, branch := range s.monitorBr {
+			result.Lines = append(result.Lines, LineSeries{LineID: s.network.Branches[branch].LineID, LoadingPercent: make([]float64, 0, opts.Hours)})
+		}
+	}
+
+	total := newAccumulator(opts.StartHour)
+	interval := newAccumulator(opts.StartHour)
+	intervals := (opts.Hours + opts.SummaryHours - 1) / opts.SummaryHours
+	summary := &result.Summary
+	summary.MinTapPosition, summary.MaxTapPosition = s.tap, s.tap
+
+	for step := 0; step < opts.Hours; step++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hour := (opts.StartHour + step) % HoursPerYear
+		s.applyHour(hour)
+
+		pf, operations, flows, err := s.solveHour()
+		if err != nil {
+			return nil, fmt.Errorf("power flow failed at hour %d: %w", hour, err)
+		}
+		summary.PowerFlowsExecuted += flows
+		summary.MinTapPosition = min(summary.MinTapPosition, s.tap)
+		summary.MaxTapPosition = max(summary.MaxTapPosition, s.tap)
+
+		sample := newSample(hour, pf, operations)
+		if step == 0 || sample.minVoltage < total.interval.MinVoltagePU {
+			summary.MinVoltageHour = hour
+		}
+		if step == 0 || sample.maxVoltage > total.interval.MaxVoltagePU {
+			summary.MaxVoltageHour = hour
+		}
+		if step == 0 || sample.load > total.interval.PeakLoadKW {
+			summary.PeakLoadHour = hour
+		}
+		total.add(sample)
+		interval.add(sample)
+		if pf.Summary.SourcePowerKW < 0 {
+			summary.ReverseFlowHours++
+		}
+
+		if includeSeries {
+			series.append(sample, s.tap)
+			for i, bus := range s.monitorBus {
+				result.Nodes[i].VoltagePU = append(result.Nodes[i].VoltagePU, round(pf.Nodes[bus].VoltagePU, 4))
+			}
+			for i, branch := range s.monitorBr {
+				result.Lines[i].LoadingPercent = append(result.Lines[i].LoadingPercent, round(pf.Lines[branch].LoadingPercent, 2))
+			}
+		}
+
+		if interval.interval.Hours == opts.SummaryHours || step == opts.Hours-1 {
+			done := interval.finish()
+			result.Intervals = append(result.Intervals, done)
+			if onInterval != nil {
+				onInterval(done, len(result.Intervals), intervals)
+			}
+			interval = newAccumulator((hour + 1) % HoursPerYear)
+		}
+	}
+
+	summary.Interval = total.finish()
+	if consumed := summary.LoadKWh + summary.LossesKWh; consumed > 0 {
+		summary.LossesPercent = round(summary.LossesKWh/consumed*100, 3)
+	}
+	return result, nil
+}
+
+// sample 單一小時的饋線層級結果
+type sample struct {
+	hour              int
+	minVoltage        float64
+	maxVoltage        float64
+	maxLoading        float64
+	load, generation  float64
+	source, losses    float64
+	tapOperations     int
+	voltageViolations int
+	thermalViolations int
+	converged         bool
+}
+
+func newSample(hour int, pf *powerflow.Result, operations int) sample {
+	maxLoading := pf.Summary.MaxLineLoadingPercent
+	for _, node := range pf.Nodes {
+		maxLoading = math.Max(maxLoading, node.LoadingPercent)
+	}
+	return sample{
+		hour:              hour,
+		minVoltage:        pf.Summary.MinVoltagePU,
+		maxVoltage:        pf.Summary.MaxVoltagePU,
+		maxLoading:        maxLoading,
+		load:              pf.Summary.TotalLoadKW,
+		generation:        pf.Summary.TotalGenerationKW,
+		source:            pf.Summary.SourcePowerKW,
+		losses:            pf.Summary.TotalLossesKW,
+		tapOperations:     operations,
+		voltageViolations: pf.Summary.VoltageViolations,
+		thermalViolations: pf.Summary.ThermalViolations,
+		converged:         pf.Converged,
+	}
+}
+
+func (ts *TimeSeries) append(sample sample, tap int) {
+	ts.Hour = append(ts.Hour, sample.hour)
+	ts.MinVoltagePU = append(ts.MinVoltagePU, round(sample.minVoltage, 4))
+	ts.MaxVoltagePU = append(ts.MaxVoltagePU, round(sample.maxVoltage, 4))
+	ts.MaxLineLoadingPercent = append(ts.MaxLineLoadingPercent, round(sample.maxLoading, 2))
+	ts.LoadKW = append(ts.LoadKW, round(sample.load, 2))
+	ts.GenerationKW = append(ts.GenerationKW, round(sample.generation, 2))
+	ts.SourceKW = append(ts.SourceKW, round(sample.source, 2))
+	ts.LossesKW = append(ts.LossesKW, round(sample.losses, 2))
+	ts.TapPosition = append(ts.TapPosition, tap)
+	ts.VoltageViolations = append(ts.VoltageViolations, sample.voltageViolations)
+	ts.ThermalViolations = append(ts.ThermalViolations, sample.thermalViolations)
+}
+
+// accumulator 累計區間統計
+type accumulator struct {
+	interval Interval
+}
+
+func newAccumulator(startHour int) *accumulator {
+	return &accumulator{interval: Interval{StartHour: startHour, MinVoltagePU: math.Inf(1)}}
+}
+
+// add 加入一小時結果
+func (a *accumulator) add(sample sample) {
+	iv := &a.interval
+	iv.Hours++
+	iv.MinVoltagePU = math.Min(iv.MinVoltagePU, sample.minVoltage)
+	iv.MaxVoltagePU = math.Max(iv.MaxVoltagePU, sample.maxVoltage)
+	iv.MaxLineLoadingPercent = math.Max(iv.MaxLineLoadingPercent, sample.maxLoading)
+	iv.PeakLoadKW = math.Max(iv.PeakLoadKW, sample.load)
+	iv.LoadKWh += sample.load
+	iv.GenerationKWh += sample.generation
+	iv.LossesKWh += sample.losses
+	iv.TapOperations += sample.tapOperations
+	if sample.voltageViolations > 0 {
+		iv.VoltageViolationHours++
+	}
+	if sample.thermalViolations > 0 {
+		iv.ThermalViolationHours++
+	}
+	if !sample.converged {
+		iv.NonConvergedHours++
+	}
+}
+
+// finish 四捨五入並回傳區間統計
+func (a *accumulator) finish() Interval {
+	iv := a.interval
+	if math.IsInf(iv.MinVoltagePU, 1) {
+		iv.MinVoltagePU = 0
+	}
+	iv.MinVoltagePU = round(iv.MinVoltagePU, 4)
+	iv.MaxVoltagePU = round(iv.MaxVoltagePU, 4)
+	iv.MaxLineLoadingPercent = round(iv.MaxLineLoadingPercent, 2)
+	iv.PeakLoadKW = round(iv.PeakLoadKW, 2)
+	iv.LoadKWh = round(iv.LoadKWh, 1)
+	iv.GenerationKWh = round(iv.GenerationKWh, 1)
+	iv.LossesKWh = round(iv.LossesKWh, 1)
+	return iv
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,274 @@
+package reliability
+
+import (
+	"math"
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Analyze 計算拓樸的可靠度指標
+// 只計入由電源供電的負載點；用戶數取自節點屬性 customers，未指定時有負載的節點視為 1 戶
+func Analyze(t *topology.Topology, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+
+	n, err := powerflow.BuildNetwork(t, powerflow.Options{})
+	if err != nil {
+		return nil, err
+	}
+	if n.Meshed {
+		return nil, ErrMeshedNetwork
+	}
+
+	a := newAnalysis(t, n, opts)
+	if a.totalCustomers == 0 {
+		return nil, ErrNoCustomers
+	}
+
+	lines := make(map[string]topology.Line, len(t.Lines))
+	for _, line := range t.Lines {
+		lines[line.ID] = line
+	}
+
+	sections := []SectionContribution{}
+	for _, branch := range n.Branches {
+		if !branch.InTree {
+			continue
+		}
+		sections = append(sections, a.fault(t, lines[branch.LineID], branch))
+	}
+
+	return a.result(t, sections), nil
+}
+
+// analysis 分析過程中的狀態，陣列皆以匯流排索引對應
+type analysis struct {
+	network        *powerflow.Network
+	opts           Options
+	nodes          []topology.Node
+	customers      []int
+	loads          []float64
+	tin, tout      []int // 輻射樹的 DFS 進出順序，子樹為 euler[tin:tout]
+	euler          []int
+	failureRate    []float64
+	unavailability []float64
+	momentary      []float64
+	totalCustomers int
+	totalLoadKW    float64
+	ci, cmi, mci   float64 // 用戶停電次數、用戶停電分鐘數、用戶瞬時停電次數
+	ens            float64
+}
+
+func newAnalysis(t *topology.Topology, n *powerflow.Network, opts Options) *analysis {
+	count := len(n.Buses)
+	a := &analysis{
+		network:        n,
+		opts:           opts,
+		nodes:          make([]topology.Node, count),
+		customers:      make([]int, count),
+		loads:          make([]float64, count),
+		tin:            make([]int, count),
+		tout:           make([]int, count),
+		failureRate:    make([]float64, count),
+		unavailability: make([]float64, count),
+		momentary:      make([]float64, count),
+	}
+
+	for _, node := range t.Nodes {
+		index, exists := n.BusIndex(node.ID)
+		if !exists || a.nodes[index].ID != "" {
+			continue
+		}
+		a.nodes[index] = node
+		bus := n.Buses[index]
+		if !bus.Energized {
+			continue
+		}
+
+		a.loads[index] = bus.Demand.LoadKW
+		defaultCustomers := 0.0
+		if bus.Demand.LoadKW > 0 {
+			defaultCustomers = 1
+		}
+		a.customers[index] = int(math.Round(topology.FloatProperty(node.Properties, "customers", defaultCustomers)))
+		a.totalCustomers += a.customers[index]
+		a.totalLoadKW += a.loads[index]
+	}
+
+	// 以迭代 DFS 建立子樹區間
+	type frame struct {
+		bus   int
+		child int
+	}
+	stack := []frame{{bus: n.Source}}
+	a.tin[n.Source] = 0
+	a.euler = append(a.euler, n.Source)
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		children := n.Buses[top.bus].Children
+		if top.child < len(children) {
+			child := children[top.child]
+			top.child++
+			a.tin[child] = len(a.euler)
+			a.euler = append(a.euler, child)
+			stack = append(stack, frame{bus: child})
+			continue
+		}
+		a.tout[top.bus] = len(a.euler)
+		stack = stack[:len(stack)-1]
+	}
+	return a
+}
+
+// subtree 取得以 bus 為根的子樹
+func (a *analysis) subtree(bus int) []int {
+	return a.euler[a.tin[bus]:a.tout[bus]]
+}
+
+func (a *analysis) inSubtree(root, bus int) bool {
+	return a.tin[root] <= a.tin[bus] && a.tin[bus] < a.tout[root]
+}
+
+// fault 計算單一線路故障對各負載點的影響並回傳區段貢獻
+func (a *analysis) fault(t *topology.Topology, line topology.Line, branch *powerflow.Branch) SectionContribution {
+	n := a.network
+	lengthKM := powerflow.LineParameters(t, line).LengthKM
+	failures := topology.FloatProperty(line.Properties, "failure_rate_per_km", a.opts.FailureRatePerKM) * lengthKM
+	repair := topology.FloatProperty(line.Properties, "repair_time_minutes", a.opts.RepairTimeMinutes)
+
+	// 由故障點往電源方向找隔離開關與保護設備
+	protective, isolating := n.Source, -1
+	for bus := n.Buses[branch.Downstream].Parent; bus >= 0; bus = n.Buses[bus].Parent {
+		node := a.nodes[bus]
+		if node.Type != topology.NodeTypeSwitch {
+			continue
+		}
+		if isolating < 0 {
+			isolating = bus
+		}
+		switchType := topology.StringProperty(node.Properties, "type", "")
+		if switchType == "breaker" || switchType == "recloser" || switchType == "fuse" {
+			protective = bus
+			break
+		}
+	}
+
+	switching := a.opts.ManualSwitchingMinutes
+	if isolating >= 0 && topology.BoolProperty(a.nodes[isolating].Properties, "is_automated", false) {
+		switching = a.opts.AutomatedSwitchingMinutes
+	}
+
+	section := SectionContribution{
+		LineID:             line.ID,
+		FromNodeID:         line.FromNodeID,
+		ToNodeID:           line.ToNodeID,
+		LengthKM:           round(lengthKM, 4),
+		FailuresPerYear:    round(failures, 6),
+		RepairTimeMinutes:  repair,
+		ProtectiveDeviceID: a.nodes[protective].ID,
+	}
+	if isolating >= 0 {
+		section.IsolatingSwitchID = a.nodes[isolating].ID
+	}
+
+	var ci, cmi, ens float64
+	for _, bus := range a.subtree(protective) {
+		customers := a.customers[bus]
+		if customers == 0 && a.loads[bus] == 0 {
+			continue
+		}
+		section.CustomersInterrupted += customers
+
+		duration := repair
+		if isolating >= 0 && !a.inSubtree(isolating, bus) {
+			duration = switching
+		} else {
+			section.CustomersAwaitRepair += customers
+		}
+
+		if duration <= a.opts.MomentaryThresholdMinutes {
+			a.momentary[bus] += failures
+			a.mci += failures * float64(customers)
+			continue
+		}
+		a.failureRate[bus] += failures
+		a.unavailability[bus] += failures * duration
+		ci += failures * float64(customers)
+		cmi += failures * float64(customers) * duration
+		ens += failures * a.loads[bus] * duration / 60
+	}
+
+	a.ci += ci
+	a.cmi += cmi
+	a.ens += ens
+	total := float64(a.totalCustomers)
+	section.SAIFIContribution = ci / total
+	section.SAIDIContribution = cmi / total
+	section.ENSKWh = ens
+	return section
+}
+
+func (a *analysis) result(t *topology.Topology, sections []SectionContribution) *Result {
+	total := float64(a.totalCustomers)
+	indices := Indices{
+		SAIFI:  round(a.ci/total, 4),
+		SAIDI:  round(a.cmi/total, 4),
+		ASAI:   round(1-a.cmi/total/minutesPerYear, 6),
+		MAIFI:  round(a.mci/total, 4),
+		ENSKWh: round(a.ens, 2),
+		AENS:   round(a.ens/total, 4),
+	}
+	if a.ci > 0 {
+		indices.CAIDI = round(a.cmi/a.ci, 2)
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].SAIDIContribution > sections[j].SAIDIContribution
+	})
+	if len(sections) > a.opts.TopSections {
+		sections = sections[:a.opts.TopSections]
+	}
+	for i := range sections {
+		section := &sections[i]
+		if a.cmi > 0 {
+			section.SAIDISharePercent = round(section.SAIDIContribution*total/a.cmi*100, 2)
+		}
+		section.SAIFIContribution = round(section.SAIFIContribution, 6)
+		section.SAIDIContribution = round(section.SAIDIContribution, 4)
+		section.ENSKWh = round(section.ENSKWh, 2)
+	}
+
+	nodes := []NodeReliability{}
+	for _, node := range t.Nodes {
+		bus, exists := a.network.BusIndex(node.ID)
+		if !exists || a.nodes[bus].ID != node.ID || (a.customers[bus] == 0 && a.loads[bus] == 0) {
+			continue
+		}
+		result := NodeReliability{
+			NodeID:                node.ID,
+			Customers:             a.customers[bus],
+			LoadKW:                a.loads[bus],
+			FailureRate:           round(a.failureRate[bus], 6),
+			UnavailabilityMinutes: round(a.unavailability[bus], 4),
+			MomentaryRate:         round(a.momentary[bus], 6),
+		}
+		if a.failureRate[bus] > 0 {
+			result.AverageDuration = round(a.unavailability[bus]/a.failureRate[bus], 2)
+		}
+		nodes = append(nodes, result)
+	}
+
+	return &Result{
+		Indices:        indices,
+		TotalCustomers: a.totalCustomers,
+		TotalLoadKW:    round(a.totalLoadKW, 2),
+		WorstSections:  sections,
+		Nodes:          nodes,
+	}
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
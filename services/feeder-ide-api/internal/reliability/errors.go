@@ -0,0 +1,8 @@
+package reliability
+
+import "errors"
+
+var (
+	ErrMeshedNetwork = errors.New("reliability analysis requires a radial network")
+	ErrNoCustomers   = errors.New("topology has no energized customers")
+)
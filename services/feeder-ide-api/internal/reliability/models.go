@@ -0,0 +1,132 @@
+// Package reliability 以解析法計算輻射狀 feeder 的可靠度指標（IEEE 1366）
+//
+// 每條線路以故障率（次/年/km）與修復時間建模。線路故障時，上游最近的保護設備（斷路器、復閉器或熔絲，
+// 沒有時為電源）跳脫，其下游用戶全部停電；上游最近的開關隔離故障後，開關上游的用戶於切換時間後復電
+// （自動化開關使用自動切換時間），開關下游的用戶需等待修復。復電時間不超過瞬時停電門檻者計為瞬時停電。
+package reliability
+
+import "github.com/feeder-platform/feeder-ide-api/internal/profiles"
+
+// 未指定參數時使用的預設值
+const (
+	defaultFailureRatePerKM   = 0.1   // 次/年/km
+	defaultRepairMinutes      = 240.0 // 平均修復時間
+	defaultManualSwitching    = 60.0  // 人工開關切換時間
+	defaultAutomatedSwitching = 1.0   // 自動化開關切換時間
+	defaultMomentaryMinutes   = 5.0   // IEEE 1366 瞬時停電門檻
+	defaultTopSections        = 10
+	minutesPerYear            = 525600.0
+)
+
+// Options 可靠度分析參數，個別線路可以屬性 failure_rate_per_km / repair_time_minutes 覆寫
+type Options struct {
+	FailureRatePerKM          float64 `json:"failure_rate_per_km,omitempty"`         // 線路故障率（次/年/km）
+	RepairTimeMinutes         float64 `json:"repair_time_minutes,omitempty"`         // 平均修復時間（分鐘）
+	ManualSwitchingMinutes    float64 `json:"manual_switching_minutes,omitempty"`    // 人工開關隔離與復電時間（分鐘）
+	AutomatedSwitchingMinutes float64 `json:"automated_switching_minutes,omitempty"` // 自動化開關隔離與復電時間（分鐘）
+	MomentaryThresholdMinutes float64 `json:"momentary_threshold_minutes,omitempty"` // 不超過此時間的停電計為瞬時停電
+	TopSections               int     `json:"top_sections,omitempty"`                // 回傳貢獻最大的區段數
+}
+
+// withDefaults 補上預設值
+func (o Options) withDefaults() Options {
+	if o.FailureRatePerKM <= 0 {
+		o.FailureRatePerKM = defaultFailureRatePerKM
+	}
+	if o.RepairTimeMinutes <= 0 {
+		o.RepairTimeMinutes = defaultRepairMinutes
+	}
+	if o.ManualSwitchingMinutes <= 0 {
+		o.ManualSwitchingMinutes = defaultManualSwitching
+	}
+	if o.AutomatedSwitchingMinutes <= 0 {
+		o.AutomatedSwitchingMinutes = defaultAutomatedSwitching
+	}
+	if o.MomentaryThresholdMinutes <= 0 {
+		o.MomentaryThresholdMinutes = defaultMomentaryMinutes
+	}
+	if o.TopSections <= 0 {
+		o.TopSections = defaultTopSections
+	}
+	return o
+}
+
+// Result 可靠度分析結果
+type Result struct {
+	Indices        Indices               `json:"indices"`
+	TotalCustomers int                   `json:"total_customers"`
+	TotalLoadKW    float64               `json:"total_load_kw"`
+	Comparison     *Comparison           `json:"comparison,omitempty"` // 拓樸有 profile_type 時與 profile 目標比較
+	WorstSections  []SectionContribution `json:"worst_sections"`       // 依 SAIDI 貢獻排序
+	Nodes          []NodeReliability     `json:"nodes"`
+}
+
+// Indices IEEE 1366 可靠度指標
+type Indices struct {
+	SAIFI  float64 `json:"saifi"`                       // 次/年
+	SAIDI  float64 `json:"saidi"`                       // 分鐘/年
+	CAIDI  float64 `json:"caidi"`                       // 分鐘/次
+	ASAI   float64 `json:"asai"`                        // 供電可用率
+	MAIFI  float64 `json:"maifi"`                       // 瞬時停電次/年
+	ENSKWh float64 `json:"energy_not_supplied_kwh"`     // kWh/年
+	AENS   float64 `json:"average_energy_not_supplied"` // kWh/用戶/年
+}
+
+// SectionContribution 單一線路區段故障對指標的貢獻
+type SectionContribution struct {
+	LineID               string  `json:"line_id"`
+	FromNodeID           string  `json:"from_node_id"`
+	ToNodeID             string  `json:"to_node_id"`
+	LengthKM             float64 `json:"length_km"`
+	FailuresPerYear      float64 `json:"failures_per_year"`
+	RepairTimeMinutes    float64 `json:"repair_time_minutes"`
+	ProtectiveDeviceID   string  `json:"protective_device_id"`          // 跳脫的保護設備（或電源）
+	IsolatingSwitchID    string  `json:"isolating_switch_id,omitempty"` // 隔離故障的開關
+	CustomersInterrupted int     `json:"customers_interrupted"`         // 每次故障停電用戶數（含瞬時）
+	CustomersAwaitRepair int     `json:"customers_awaiting_repair"`     // 需等待修復的用戶數
+	SAIFIContribution    float64 `json:"saifi_contribution"`
+	SAIDIContribution    float64 `json:"saidi_contribution"`
+	ENSKWh               float64 `json:"energy_not_supplied_kwh"`
+	SAIDISharePercent    float64 `json:"saidi_share_percent"`
+}
+
+// NodeReliability 負載點可靠度
+type NodeReliability struct {
+	NodeID                string  `json:"node_id"`
+	Customers             int     `json:"customers"`
+	LoadKW                float64 `json:"load_kw"`
+	FailureRate           float64 `json:"failure_rate"`             // 持續停電次/年
+	UnavailabilityMinutes float64 `json:"unavailability_minutes"`   // 分鐘/年
+	AverageDuration       float64 `json:"average_duration_minutes"` // 分鐘/次
+	MomentaryRate         float64 `json:"momentary_rate"`           // 瞬時停電次/年
+}
+
+// Comparison 與 profile 可靠度目標比較
+type Comparison struct {
+	ProfileType string  `json:"profile_type"`
+	TargetSAIDI float64 `json:"target_saidi"`
+	TargetSAIFI float64 `json:"target_saifi"`
+	SAIDIRatio  float64 `json:"saidi_ratio"` // 實際 / 目標
+	SAIFIRatio  float64 `json:"saifi_ratio"`
+	MeetsSAIDI  bool    `json:"meets_saidi"`
+	MeetsSAIFI  bool    `json:"meets_saifi"`
+}
+
+// Compare 將指標與 profile 的 TargetSAIDI / TargetSAIFI 比較
+func Compare(indices Indices, profile *profiles.Profile) *Comparison {
+	chars := profile.Characteristics
+	comparison := &Comparison{
+		ProfileType: profile.Type,
+		TargetSAIDI: chars.TargetSAIDI,
+		TargetSAIFI: chars.TargetSAIFI,
+		MeetsSAIDI:  indices.SAIDI <= chars.TargetSAIDI,
+		MeetsSAIFI:  indices.SAIFI <= chars.TargetSAIFI,
+	}
+	if chars.TargetSAIDI > 0 {
+		comparison.SAIDIRatio = round(indices.SAIDI/chars.TargetSAIDI, 4)
+	}
+	if chars.TargetSAIFI > 0 {
+		comparison.SAIFIRatio = round(indices.SAIFI/chars.TargetSAIFI, 4)
+	}
+	return comparison
+}
@@ -0,0 +1,8 @@
+package shortcircuit
+
+import "errors"
+
+var (
+	ErrMeshedNetwork  = errors.New("short-circuit analysis requires a radial network")
+	ErrInvalidOptions = errors.New("invalid short-circuit options")
+)
@@ -0,0 +1,229 @@
+package shortcircuit
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strings"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Analyze 計算各節點的三相、單相接地與線間故障電流，並檢查開關啟斷容量
+func Analyze(t *topology.Topology, opts Options) (*Result, error) {
+	for name, value := range map[string]float64{
+		"source_short_circuit_mva": opts.SourceShortCircuitMVA,
+		"source_x_r_ratio":         opts.SourceXRRatio,
+		"source_z0_z1_ratio":       opts.SourceZ0Z1Ratio,
+		"prefault_voltage_pu":      opts.PrefaultVoltagePU,
+		"fault_resistance_ohm":     opts.FaultResistanceOhm,
+	} {
+		if value < 0 {
+			return nil, fmt.Errorf("%w: %s must not be negative", ErrInvalidOptions, name)
+		}
+	}
+
+	n, err := powerflow.BuildNetwork(t, powerflow.Options{})
+	if err != nil {
+		return nil, err
+	}
+	if n.Meshed {
+		return nil, ErrMeshedNetwork
+	}
+
+	nodes := make([]topology.Node, len(n.Buses))
+	for _, node := range t.Nodes {
+		if index, exists := n.BusIndex(node.ID); exists && nodes[index].ID == "" {
+			nodes[index] = node
+		}
+	}
+	lines := make(map[string]topology.Line, len(t.Lines))
+	for _, line := range t.Lines {
+		lines[line.ID] = line
+	}
+
+	// 各匯流排的正序與零序戴維寧阻抗（pu），grounded 為 false 表示沒有零序路徑
+	z1 := make([]complex128, len(n.Buses))
+	z0 := make([]complex128, len(n.Buses))
+	grounded := make([]bool, len(n.Buses))
+
+	source := nodes[n.Source]
+	z1[n.Source], z0[n.Source], grounded[n.Source] = sourceImpedance(source, opts, n.BaseMVA)
+
+	for _, index := range n.Order[1:] {
+		bus := n.Buses[index]
+		branch := n.Branches[bus.ParentBranch]
+		parent := bus.Parent
+
+		// branch.Z 已包含下游變壓器阻抗
+		z1[index] = z1[parent] + branch.Z
+
+		line := lines[branch.LineID]
+		params := powerflow.LineParameters(t, line)
+		zBase := branch.BaseKV * branch.BaseKV / n.BaseMVA
+		lineZ0 := complex(
+			topology.FloatProperty(line.Properties, "r0_ohm_per_km", params.ROhmPerKM*defaultZ0Multiplier)*params.LengthKM,
+			topology.FloatProperty(line.Properties, "x0_ohm_per_km", params.XOhmPerKM*defaultZ0Multiplier)*params.LengthKM,
+		) / complex(zBase, 0)
+
+		z0[index], grounded[index] = z0[parent]+lineZ0, grounded[parent]
+		if node := nodes[index]; node.Type == topology.NodeTypeTransformer {
+			if zt, ok := transformerImpedance(node, n.BaseMVA); ok {
+				z0[index], grounded[index] = zeroSequenceThrough(connection(node), z0[index], grounded[index], zt)
+			}
+		}
+	}
+
+	prefault := opts.PrefaultVoltagePU
+	if prefault == 0 {
+		prefault = defaultPrefaultPU
+	}
+
+	result := &Result{
+		Nodes:    make([]NodeResult, 0, len(t.Nodes)),
+		Switches: []SwitchResult{},
+		Summary:  Summary{SourceNodeID: source.ID},
+	}
+	faultKA := make([]float64, len(n.Buses))
+	first := true
+	for index, bus := range n.Buses {
+		node := nodes[index]
+		if !bus.Energized {
+			result.Nodes = append(result.Nodes, NodeResult{NodeID: node.ID, BaseKV: bus.BaseKV})
+			result.Summary.DeenergizedNodes++
+			continue
+		}
+
+		zBase := bus.BaseKV * bus.BaseKV / n.BaseMVA
+		baseKA := n.BaseCurrentA(bus.BaseKV) / 1000
+		zf := complex(opts.FaultResistanceOhm/zBase, 0)
+
+		nodeResult := NodeResult{
+			NodeID:       node.ID,
+			Energized:    true,
+			BaseKV:       bus.BaseKV,
+			ThreePhaseKA: round(prefault/cmplx.Abs(z1[index])*baseKA, 4),
+			LineToLineKA: round(math.Sqrt(3)*prefault/cmplx.Abs(2*z1[index])*baseKA, 4),
+			Z1Ohm:        toOhm(z1[index], zBase),
+		}
+		if real(z1[index]) > 0 {
+			nodeResult.XRRatio = round(imag(z1[index])/real(z1[index]), 2)
+		}
+		nodeResult.ThreePhaseMVA = round(math.Sqrt(3)*bus.BaseKV*nodeResult.ThreePhaseKA, 2)
+		if grounded[index] {
+			nodeResult.LineToGroundKA = round(3*prefault/cmplx.Abs(2*z1[index]+z0[index]+3*zf)*baseKA, 4)
+			z0Ohm := toOhm(z0[index], zBase)
+			nodeResult.Z0Ohm = &z0Ohm
+		}
+		result.Nodes = append(result.Nodes, nodeResult)
+		faultKA[index] = math.Max(nodeResult.ThreePhaseKA, math.Max(nodeResult.LineToGroundKA, nodeResult.LineToLineKA))
+
+		summary := &result.Summary
+		if first {
+			summary.MinThreePhaseKA, summary.MinLineToGroundKA = nodeResult.ThreePhaseKA, nodeResult.LineToGroundKA
+			first = false
+		}
+		summary.MaxThreePhaseKA = math.Max(summary.MaxThreePhaseKA, nodeResult.ThreePhaseKA)
+		summary.MinThreePhaseKA = math.Min(summary.MinThreePhaseKA, nodeResult.ThreePhaseKA)
+		summary.MaxLineToGroundKA = math.Max(summary.MaxLineToGroundKA, nodeResult.LineToGroundKA)
+		summary.MinLineToGroundKA = math.Min(summary.MinLineToGroundKA, nodeResult.LineToGroundKA)
+	}
+
+	// 開關需啟斷其所在節點的最大故障電流
+	for index, bus := range n.Buses {
+		node := nodes[index]
+		if !bus.Energized || node.Type != topology.NodeTypeSwitch {
+			continue
+		}
+		check := SwitchResult{
+			NodeID:               node.ID,
+			SwitchType:           topology.StringProperty(node.Properties, "type", ""),
+			InterruptingRatingKA: topology.FloatProperty(node.Properties, "interrupting_rating_ka", 0),
+			MaxFaultCurrentKA:    faultKA[index],
+		}
+		if check.InterruptingRatingKA > 0 {
+			check.DutyPercent = round(check.MaxFaultCurrentKA/check.InterruptingRatingKA*100, 2)
+			check.Exceeded = check.MaxFaultCurrentKA > check.InterruptingRatingKA
+			if check.Exceeded {
+				result.Summary.ExceededSwitches++
+			}
+		} else {
+			result.Summary.UnratedSwitches++
+		}
+		result.Switches = append(result.Switches, check)
+	}
+
+	return result, nil
+}
+
+// sourceImpedance 計算電源端正序與零序阻抗（pu）
+func sourceImpedance(source topology.Node, opts Options, baseMVA float64) (complex128, complex128, bool) {
+	mva := sourceParameter(opts.SourceShortCircuitMVA, source, "source_short_circuit_mva", defaultSourceMVA)
+	xr := sourceParameter(opts.SourceXRRatio, source, "source_x_r_ratio", defaultSourceXRRatio)
+	z0z1 := sourceParameter(opts.SourceZ0Z1Ratio, source, "source_z0_z1_ratio", defaultZ0Z1Ratio)
+
+	zs := impedance(baseMVA/mva, xr)
+	z1, z0 := zs, zs*complex(z0z1, 0)
+
+	// 電源節點為主變壓器時，系統阻抗位於一次側，再串接主變壓器阻抗
+	if source.Type == topology.NodeTypeTransformer {
+		if zt, ok := transformerImpedance(source, baseMVA); ok {
+			z0, grounded := zeroSequenceThrough(connection(source), z0, true, zt)
+			return z1 + zt, z0, grounded
+		}
+	}
+	return z1, z0, true
+}
+
+func sourceParameter(option float64, source topology.Node, key string, defaultValue float64) float64 {
+	if option > 0 {
+		return option
+	}
+	if value := topology.FloatProperty(source.Properties, key, 0); value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// transformerImpedance 變壓器阻抗（pu），未指定額定容量時無法換算
+func transformerImpedance(node topology.Node, baseMVA float64) (complex128, bool) {
+	var props topology.TransformerProperties
+	if err := topology.DecodeProperties(node.Properties, &props); err != nil || props.RatedCapacityKVA <= 0 {
+		return 0, false
+	}
+	zPercent, xr := powerflow.TransformerImpedance(props)
+	return impedance(zPercent/100*(baseMVA*1000/props.RatedCapacityKVA), xr), true
+}
+
+func connection(node topology.Node) string {
+	return strings.ToLower(topology.StringProperty(node.Properties, "connection", ConnectionDyn))
+}
+
+// zeroSequenceThrough 依變壓器接線計算二次側零序阻抗
+// upstream 為變壓器一次側（含供電線路）的零序阻抗
+func zeroSequenceThrough(conn string, upstream complex128, upstreamGrounded bool, zt complex128) (complex128, bool) {
+	switch conn {
+	case ConnectionYNyn:
+		return upstream + zt, upstreamGrounded
+	case ConnectionYd, ConnectionDd:
+		return 0, false
+	default:
+		return zt, true
+	}
+}
+
+// impedance 由大小與 X/R 比建立阻抗
+func impedance(magnitude, xr float64) complex128 {
+	r := magnitude / math.Sqrt(1+xr*xr)
+	return complex(r, r*xr)
+}
+
+func toOhm(z complex128, zBase float64) Impedance {
+	return Impedance{R: round(real(z)*zBase, 6), X: round(imag(z)*zBase, 6)}
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
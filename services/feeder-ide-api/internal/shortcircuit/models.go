@@ -0,0 +1,83 @@
+// Package shortcircuit 以對稱分量法計算輻射狀 feeder 各節點的故障電流
+//
+// 正序（同時作為負序）阻抗取自線路 r/x 與變壓器短路阻抗，零序阻抗取自線路 r0/x0
+// （未指定時為正序的 3 倍）；變壓器依接線方式決定零序路徑：dyn 隔斷上游零序並以變壓器阻抗接地，
+// ynyn 讓零序通過，yd / dd 二次側不接地（下游單相接地故障電流為 0）。
+// 變電所以系統短路容量與 X/R 比表示電源阻抗；電源節點為變壓器時另加上主變壓器阻抗。
+package shortcircuit
+
+// 未指定參數時使用的預設值
+const (
+	defaultSourceMVA     = 250.0 // 系統短路容量（MVA）
+	defaultSourceXRRatio = 10.0
+	defaultZ0Z1Ratio     = 1.0
+	defaultZ0Multiplier  = 3.0 // 線路零序阻抗 / 正序阻抗
+	defaultPrefaultPU    = 1.0
+)
+
+// 變壓器接線方式
+const (
+	ConnectionDyn  = "dyn"
+	ConnectionYNyn = "ynyn"
+	ConnectionYd   = "yd"
+	ConnectionDd   = "dd"
+)
+
+// Options 故障分析參數；電源參數未指定時依序取電源節點屬性
+// source_short_circuit_mva / source_x_r_ratio / source_z0_z1_ratio，再使用預設值
+type Options struct {
+	SourceShortCircuitMVA float64 `json:"source_short_circuit_mva,omitempty"` // 變電所上游系統三相短路容量（MVA）
+	SourceXRRatio         float64 `json:"source_x_r_ratio,omitempty"`         // 電源阻抗 X/R 比
+	SourceZ0Z1Ratio       float64 `json:"source_z0_z1_ratio,omitempty"`       // 電源零序 / 正序阻抗比
+	PrefaultVoltagePU     float64 `json:"prefault_voltage_pu,omitempty"`      // 故障前電壓（pu）
+	FaultResistanceOhm    float64 `json:"fault_resistance_ohm,omitempty"`     // 單相接地故障電阻（Ω）
+}
+
+// Result 故障分析結果
+type Result struct {
+	Nodes    []NodeResult   `json:"nodes"`
+	Switches []SwitchResult `json:"switches"`
+	Summary  Summary        `json:"summary"`
+}
+
+// NodeResult 節點故障電流
+type NodeResult struct {
+	NodeID         string     `json:"node_id"`
+	Energized      bool       `json:"energized"`
+	BaseKV         float64    `json:"base_kv"`
+	ThreePhaseKA   float64    `json:"three_phase_ka"`
+	LineToGroundKA float64    `json:"line_to_ground_ka"`
+	LineToLineKA   float64    `json:"line_to_line_ka"`
+	ThreePhaseMVA  float64    `json:"three_phase_mva"`
+	XRRatio        float64    `json:"x_r_ratio"`
+	Z1Ohm          Impedance  `json:"z1_ohm"`
+	Z0Ohm          *Impedance `json:"z0_ohm,omitempty"` // 沒有零序路徑時為空
+}
+
+// Impedance 戴維寧等效阻抗（Ω，於節點電壓等級）
+type Impedance struct {
+	R float64 `json:"r"`
+	X float64 `json:"x"`
+}
+
+// SwitchResult 開關啟斷容量檢查
+type SwitchResult struct {
+	NodeID               string  `json:"node_id"`
+	SwitchType           string  `json:"switch_type"`
+	InterruptingRatingKA float64 `json:"interrupting_rating_ka,omitempty"` // 未指定時不檢查
+	MaxFaultCurrentKA    float64 `json:"max_fault_current_ka"`
+	DutyPercent          float64 `json:"duty_percent,omitempty"`
+	Exceeded             bool    `json:"exceeded"`
+}
+
+// Summary 整體統計
+type Summary struct {
+	SourceNodeID      string  `json:"source_node_id"`
+	MaxThreePhaseKA   float64 `json:"max_three_phase_ka"`
+	MinThreePhaseKA   float64 `json:"min_three_phase_ka"`
+	MaxLineToGroundKA float64 `json:"max_line_to_ground_ka"`
+	MinLineToGroundKA float64 `json:"min_line_to_ground_ka"`
+	ExceededSwitches  int     `json:"exceeded_switches"`
+	UnratedSwitches   int     `json:"unrated_switches"`
+	DeenergizedNodes  int     `json:"deenergized_nodes"`
+}
@@ -0,0 +1,285 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// builtinCreatedAt 內建範本的建立時間（固定值，讓回應內容穩定）
+var builtinCreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// 內建範本的參數
+const (
+	paramLateralCount           = "lateral_count"
+	paramTransformersPerLateral = "transformers_per_lateral"
+	paramTrunkSectionKM         = "trunk_section_km"
+	paramLateralSectionKM       = "lateral_section_km"
+	paramLoadKW                 = "load_kw_per_transformer"
+)
+
+// 變電所一次側電壓與標準配電變壓器容量（kVA）
+const substationPrimaryKV = 69.0
+
+var transformerSizes = []float64{25, 50, 75, 100, 167, 250, 333, 500, 750, 1000, 1500, 2000, 2500}
+
+// radialDesign 輻射狀 feeder 範本的設備設計（參數以外的固定部分）
+type radialDesign struct {
+	SubstationKVA    float64
+	Trunk            topology.LineProperties // 主幹線導線
+	Lateral          topology.LineProperties // 分歧線與接戶導線
+	LateralSwitch    string                  // 分歧線起點的開關類型
+	ServiceVoltageKV float64
+	PowerFactor      float64
+	Customers        int // 每個配電變壓器的用戶數
+}
+
+// radialDefaults 輻射狀 feeder 範本的參數預設值
+type radialDefaults struct {
+	VoltageKV              float64
+	Laterals               int
+	TransformersPerLateral int
+	TrunkSectionKM         float64
+	LateralSectionKM       float64
+	LoadKW                 float64
+}
+
+// builtinTemplates 各 profile 的典型 feeder 範本
+var builtinTemplates = []*Template{
+	radialTemplate("std-rural-overhead-radial", "Rural overhead radial feeder",
+		"Long 24.9 kV overhead trunk with a mid-line recloser and fused single-transformer laterals",
+		"rural",
+		radialDesign{
+			SubstationKVA:    10000,
+			Trunk:            topology.LineProperties{ROhmPerKM: 0.190, XOhmPerKM: 0.400, AmpacityA: 530},
+			Lateral:          topology.LineProperties{ROhmPerKM: 0.550, XOhmPerKM: 0.450, AmpacityA: 230},
+			LateralSwitch:    "fuse",
+			ServiceVoltageKV: 0.24,
+			PowerFactor:      0.95,
+			Customers:        3,
+		},
+		radialDefaults{VoltageKV: 24.9, Laterals: 4, TransformersPerLateral: 3, TrunkSectionKM: 3.0, LateralSectionKM: 1.0, LoadKW: 20},
+	),
+	radialTemplate("std-suburban-overhead-radial", "Suburban overhead radial feeder",
+		"12.47 kV overhead trunk with a mid-line recloser and fused residential laterals",
+		"suburban",
+		radialDesign{
+			SubstationKVA:    20000,
+			Trunk:            topology.LineProperties{ROhmPerKM: 0.130, XOhmPerKM: 0.390, AmpacityA: 600},
+			Lateral:          topology.LineProperties{ROhmPerKM: 0.306, XOhmPerKM: 0.450, AmpacityA: 400},
+			LateralSwitch:    "fuse",
+			ServiceVoltageKV: 0.24,
+			PowerFactor:      0.95,
+			Customers:        6,
+		},
+		radialDefaults{VoltageKV: 12.47, Laterals: 6, TransformersPerLateral: 4, TrunkSectionKM: 0.8, LateralSectionKM: 0.3, LoadKW: 40},
+	),
+	radialTemplate("std-urban-underground-radial", "Urban underground radial feeder",
+		"12.47 kV underground cable trunk with sectionalized commercial laterals and pad-mount transformers",
+		"urban",
+		radialDesign{
+			SubstationKVA:    30000,
+			Trunk:            topology.LineProperties{ROhmPerKM: 0.090, XOhmPerKM: 0.110, AmpacityA: 450},
+			Lateral:          topology.LineProperties{ROhmPerKM: 0.540, XOhmPerKM: 0.140, AmpacityA: 200},
+			LateralSwitch:    "sectionalizer",
+			ServiceVoltageKV: 0.48,
+			PowerFactor:      0.90,
+			Customers:        2,
+		},
+		radialDefaults{VoltageKV: 12.47, Laterals: 8, TransformersPerLateral: 4, TrunkSectionKM: 0.3, LateralSectionKM: 0.15, LoadKW: 150},
+	),
+}
+
+// builtinIndex 依 ID 索引內建範本
+var builtinIndex = func() map[string]*Template {
+	index := make(map[string]*Template, len(builtinTemplates))
+	for _, tmpl := range builtinTemplates {
+		if err := tmpl.Normalize(); err != nil {
+			panic(fmt.Sprintf("invalid builtin template %s: %v", tmpl.ID, err))
+		}
+		preview, err := tmpl.Instantiate(nil)
+		if err != nil {
+			panic(fmt.Sprintf("invalid builtin template %s: %v", tmpl.ID, err))
+		}
+		if result := topology.Validate(preview); !result.Valid {
+			panic(fmt.Sprintf("builtin template %s produces an invalid topology: %v", tmpl.ID, (&topology.ValidationError{Result: result}).Error()))
+		}
+		tmpl.Nodes = preview.Nodes
+		tmpl.Lines = preview.Lines
+		tmpl.NodeCount = len(preview.Nodes)
+		tmpl.LineCount = len(preview.Lines)
+		index[tmpl.ID] = tmpl
+	}
+	return index
+}()
+
+// Builtin 回傳內建範本的副本
+func Builtin() []*Template {
+	templates := make([]*Template, 0, len(builtinTemplates))
+	for _, tmpl := range builtinTemplates {
+		templates = append(templates, tmpl.Clone())
+	}
+	return templates
+}
+
+// builtinTemplate 依 ID 取得內建範本的副本
+func builtinTemplate(id string) (*Template, bool) {
+	tmpl, ok := builtinIndex[id]
+	if !ok {
+		return nil, false
+	}
+	return tmpl.Clone(), true
+}
+
+func radialTemplate(id, name, description, profileType string, design radialDesign, defaults radialDefaults) *Template {
+	return &Template{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		ProfileType: profileType,
+		Parameters: []Parameter{
+			{Name: FeederVoltageParameter, Description: "Primary feeder voltage", Unit: "kV", Type: ParameterNumber,
+				Default: defaults.VoltageKV, Min: floatPtr(2.4), Max: floatPtr(34.5)},
+			{Name: paramLateralCount, Description: "Number of laterals tapped from the trunk", Type: ParameterInteger,
+				Default: float64(defaults.Laterals), Min: floatPtr(1), Max: floatPtr(30)},
+			{Name: paramTransformersPerLateral, Description: "Distribution transformers along each lateral", Type: ParameterInteger,
+				Default: float64(defaults.TransformersPerLateral), Min: floatPtr(1), Max: floatPtr(20)},
+			{Name: paramTrunkSectionKM, Description: "Trunk length between lateral taps", Unit: "km", Type: ParameterNumber,
+				Default: defaults.TrunkSectionKM, Min: floatPtr(0.01), Max: floatPtr(20)},
+			{Name: paramLateralSectionKM, Description: "Lateral length between transformers", Unit: "km", Type: ParameterNumber,
+				Default: defaults.LateralSectionKM, Min: floatPtr(0.01), Max: floatPtr(10)},
+			{Name: paramLoadKW, Description: "Load served by each distribution transformer", Unit: "kW", Type: ParameterNumber,
+				Default: defaults.LoadKW, Min: floatPtr(0), Max: floatPtr(2000)},
+		},
+		Scope:     ScopeGlobal,
+		Builtin:   true,
+		CreatedAt: builtinCreatedAt,
+		UpdatedAt: builtinCreatedAt,
+		build: func(values map[string]float64) *topology.Topology {
+			return design.build(values)
+		},
+	}
+}
+
+// build 產生輻射狀 feeder：變電所、饋線斷路器、主幹（中段設復閉器）與由主幹各匯流排分出的分歧線，
+// 分歧線起點設開關，沿線每段接一個配電變壓器
+func (d radialDesign) build(values map[string]float64) *topology.Topology {
+	voltage := values[FeederVoltageParameter]
+	laterals := int(values[paramLateralCount])
+	perLateral := int(values[paramTransformersPerLateral])
+	loadKW := values[paramLoadKW]
+
+	b := &radialBuilder{topo: &topology.Topology{Nodes: []topology.Node{}, Lines: []topology.Line{}}}
+	b.addNode(topology.Node{
+		ID:   "sub",
+		Type: topology.NodeTypeTransformer,
+		Name: "Substation",
+		Properties: map[string]interface{}{
+			"is_source":          true,
+			"primary_voltage":    substationPrimaryKV,
+			"secondary_voltage":  voltage,
+			"rated_voltage_kv":   voltage,
+			"rated_capacity_kva": d.SubstationKVA,
+			"impedance_percent":  8.0,
+			"x_r_ratio":          10.0,
+		},
+	})
+	b.addNode(switchNode("brk-1", "Feeder breaker", "breaker"))
+	b.connect("sub", "brk-1", d.Trunk, 0.05)
+
+	previous := "brk-1"
+	for i := 1; i <= laterals; i++ {
+		trunkID := fmt.Sprintf("trunk-%d", i)
+		b.addNode(topology.Node{ID: trunkID, Type: topology.NodeTypeBus, Name: fmt.Sprintf("Trunk %d", i)})
+		b.connect(previous, trunkID, d.Trunk, values[paramTrunkSectionKM])
+		previous = trunkID
+		if laterals > 1 && i == (laterals+1)/2 {
+			b.addNode(switchNode("rec-1", "Mid-line recloser", "recloser"))
+			b.connect(previous, "rec-1", d.Trunk, 0.01)
+			previous = "rec-1"
+		}
+
+		switchID := fmt.Sprintf("lat-%d-sw", i)
+		b.addNode(switchNode(switchID, fmt.Sprintf("Lateral %d %s", i, d.LateralSwitch), d.LateralSwitch))
+		b.connect(trunkID, switchID, d.Lateral, 0.01)
+
+		upstream := switchID
+		for j := 1; j <= perLateral; j++ {
+			busID := fmt.Sprintf("lat-%d-%d", i, j)
+			b.addNode(topology.Node{ID: busID, Type: topology.NodeTypeBus, Name: fmt.Sprintf("Lateral %d bus %d", i, j)})
+			b.connect(upstream, busID, d.Lateral, values[paramLateralSectionKM])
+			upstream = busID
+
+			xfmrID := fmt.Sprintf("xfmr-%d-%d", i, j)
+			b.addNode(topology.Node{
+				ID:   xfmrID,
+				Type: topology.NodeTypeTransformer,
+				Name: fmt.Sprintf("Distribution transformer %d-%d", i, j),
+				Properties: map[string]interface{}{
+					"primary_voltage":    voltage,
+					"secondary_voltage":  d.ServiceVoltageKV,
+					"rated_voltage_kv":   d.ServiceVoltageKV,
+					"rated_capacity_kva": transformerSize(loadKW / d.PowerFactor),
+					"load_kw":            loadKW,
+					"power_factor":       d.PowerFactor,
+					"customers":          d.Customers,
+				},
+			})
+			b.connect(busID, xfmrID, d.Lateral, 0.03)
+		}
+	}
+
+	formats.AutoLayout(b.topo, nil)
+	return b.topo
+}
+
+// radialBuilder 產生範本拓樸時的狀態
+type radialBuilder struct {
+	topo     *topology.Topology
+	nextLine int
+}
+
+func (b *radialBuilder) addNode(node topology.Node) {
+	b.topo.Nodes = append(b.topo.Nodes, node)
+}
+
+func (b *radialBuilder) connect(from, to string, conductor topology.LineProperties, lengthKM float64) {
+	b.nextLine++
+	b.topo.Lines = append(b.topo.Lines, topology.Line{
+		ID:         fmt.Sprintf("line-%d", b.nextLine),
+		FromNodeID: from,
+		ToNodeID:   to,
+		Properties: map[string]interface{}{
+			"length_km":    math.Round(lengthKM*1000) / 1000,
+			"r_ohm_per_km": conductor.ROhmPerKM,
+			"x_ohm_per_km": conductor.XOhmPerKM,
+			"ampacity_a":   conductor.AmpacityA,
+		},
+	})
+}
+
+func switchNode(id, name, switchType string) topology.Node {
+	return topology.Node{
+		ID:   id,
+		Type: topology.NodeTypeSwitch,
+		Name: name,
+		Properties: map[string]interface{}{
+			"type":         switchType,
+			"is_closed":    true,
+			"is_automated": switchType == "breaker" || switchType == "recloser",
+		},
+	}
+}
+
+// transformerSize 取不小於 kVA / 0.8 的標準容量
+func transformerSize(kva float64) float64 {
+	for _, size := range transformerSizes {
+		if size*0.8 >= kva {
+			return size
+		}
+	}
+	return transformerSizes[len(transformerSizes)-1]
+}
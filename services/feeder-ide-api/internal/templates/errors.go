@@ -0,0 +1,10 @@
+package templates
+
+import "errors"
+
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrInvalidTemplate   = errors.New("invalid template")
+	ErrInvalidParameters = errors.New("invalid template parameters")
+	ErrReadOnly          = errors.New("template is read-only")
+)
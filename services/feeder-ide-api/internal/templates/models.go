@@ -0,0 +1,479 @@
+// Package templates 拓樸範本庫：系統內建的參數化 feeder 範本，以及用戶由拓樸發佈的範本
+//
+// 內建範本由程式依參數（饋線電壓、分歧線數等）產生結構；用戶範本保存發佈時的節點與線路，
+// 參數以 targets 指定代入的元素屬性。由用戶範本建立的拓樸與複製拓樸相同，使用新的節點與線路 ID。
+package templates
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 範本範圍
+const (
+	ScopeGlobal       = "global"       // 所有用戶可見（內建範本與未啟用認證時發佈的範本）
+	ScopeUser         = "user"         // 僅發佈者可見
+	ScopeOrganization = "organization" // 組織成員可見
+)
+
+// 參數類型
+const (
+	ParameterNumber  = "number"
+	ParameterInteger = "integer"
+)
+
+// FeederVoltageParameter 由拓樸發佈範本且未指定參數時自動建立的饋線電壓參數
+const FeederVoltageParameter = "feeder_voltage_kv"
+
+// parameterName 參數名稱格式
+var parameterName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedProperties 不是數值、不能由參數代入的屬性
+var reservedProperties = map[string]bool{
+	"type": true, "is_source": true, "is_closed": true, "is_automated": true, "is_controllable": true,
+	"catalog_id": true, "mrid": true, "protection": true,
+}
+
+// Template 拓樸範本
+type Template struct {
+	ID               string      `json:"id"`
+	Name             string      `json:"name"`
+	Description      string      `json:"description,omitempty"`
+	ProfileType      string      `json:"profile_type"` // rural, suburban, urban
+	Parameters       []Parameter `json:"parameters"`
+	Scope            string      `json:"scope"`
+	OwnerID          *string     `json:"owner_id,omitempty"`
+	OrganizationID   *string     `json:"organization_id,omitempty"`
+	SourceTopologyID *string     `json:"source_topology_id,omitempty"` // 發佈來源拓樸
+	Builtin          bool        `json:"builtin"`                      // 系統內建範本，不可修改
+	// Nodes 與 Lines 用戶範本的內容；內建範本為預設參數產生的結果，列表時不回傳
+	Nodes     []topology.Node `json:"nodes,omitempty"`
+	Lines     []topology.Line `json:"lines,omitempty"`
+	NodeCount int             `json:"node_count"`
+	LineCount int             `json:"line_count"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	// build 內建範本依參數值產生節點與線路
+	build func(values map[string]float64) *topology.Topology
+}
+
+// Parameter 範本參數
+type Parameter struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+	Type        string   `json:"type"` // number（預設）或 integer
+	Default     float64  `json:"default"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	// Targets 用戶範本的參數代入位置；內建範本的參數由產生程式使用，沒有 targets
+	Targets []Target `json:"targets,omitempty"`
+}
+
+// Target 參數代入位置：符合條件的元素的 Property 屬性設為參數值
+type Target struct {
+	Element    string   `json:"element"`               // node 或 line
+	NodeType   string   `json:"node_type,omitempty"`   // 僅代入此類型的節點
+	ElementIDs []string `json:"element_ids,omitempty"` // 僅代入這些元素（範本內的 ID），未指定時代入所有符合的元素
+	Property   string   `json:"property"`
+}
+
+// Visibility 查詢者身分，用於判斷可見的範本
+type Visibility struct {
+	UserID          *string
+	OrganizationIDs []string
+}
+
+// Filter 列表條件
+type Filter struct {
+	ProfileType string
+	Query       string // 名稱或說明包含的文字（不分大小寫）
+	Visibility  Visibility
+}
+
+// VisibleTo 判斷範本是否對查詢者可見
+func (t *Template) VisibleTo(v Visibility) bool {
+	switch t.Scope {
+	case ScopeGlobal:
+		return true
+	case ScopeUser:
+		return t.OwnerID != nil && v.UserID != nil && *t.OwnerID == *v.UserID
+	case ScopeOrganization:
+		if t.OrganizationID == nil {
+			return false
+		}
+		for _, id := range v.OrganizationIDs {
+			if id == *t.OrganizationID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EditableBy 判斷查詢者是否可修改或刪除範本：內建範本不可修改；
+// 全域範本只能在未啟用認證時（匿名）修改，用戶範本限發佈者，組織範本限組織成員
+func (t *Template) EditableBy(v Visibility) bool {
+	if t.Builtin {
+		return false
+	}
+	if t.Scope == ScopeGlobal {
+		return v.UserID == nil
+	}
+	return t.VisibleTo(v)
+}
+
+// Clone 深拷貝範本
+func (t *Template) Clone() *Template {
+	copied := *t
+	copied.Parameters = make([]Parameter, len(t.Parameters))
+	for i, param := range t.Parameters {
+		copied.Parameters[i] = param.clone()
+	}
+	content := (&topology.Topology{Nodes: t.Nodes, Lines: t.Lines}).Clone()
+	copied.Nodes = content.Nodes
+	copied.Lines = content.Lines
+	return &copied
+}
+
+// Summary 不含節點與線路的副本（列表用）
+func (t *Template) Summary() *Template {
+	copied := *t
+	copied.Parameters = make([]Parameter, len(t.Parameters))
+	for i, param := range t.Parameters {
+		copied.Parameters[i] = param.clone()
+	}
+	copied.Nodes = nil
+	copied.Lines = nil
+	return &copied
+}
+
+func (p Parameter) clone() Parameter {
+	if p.Min != nil {
+		min := *p.Min
+		p.Min = &min
+	}
+	if p.Max != nil {
+		max := *p.Max
+		p.Max = &max
+	}
+	if p.Targets != nil {
+		targets := make([]Target, len(p.Targets))
+		for i, target := range p.Targets {
+			target.ElementIDs = append([]string(nil), target.ElementIDs...)
+			targets[i] = target
+		}
+		p.Targets = targets
+	}
+	return p
+}
+
+// Normalize 檢查範本內容與參數定義，並更新節點與線路數
+func (t *Template) Normalize() error {
+	if t.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	}
+	if t.ProfileType == "" {
+		return fmt.Errorf("%w: profile_type is required", ErrInvalidTemplate)
+	}
+	if t.Parameters == nil {
+		t.Parameters = []Parameter{}
+	}
+	if t.build == nil && len(t.Nodes) == 0 {
+		return fmt.Errorf("%w: template has no nodes", ErrInvalidTemplate)
+	}
+
+	seen := make(map[string]bool, len(t.Parameters))
+	for i := range t.Parameters {
+		param := &t.Parameters[i]
+		if !parameterName.MatchString(param.Name) {
+			return fmt.Errorf("%w: parameter name %q must be lowercase letters, digits and underscores", ErrInvalidTemplate, param.Name)
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("%w: duplicate parameter %q", ErrInvalidTemplate, param.Name)
+		}
+		seen[param.Name] = true
+
+		if param.Type == "" {
+			param.Type = ParameterNumber
+		}
+		if param.Type != ParameterNumber && param.Type != ParameterInteger {
+			return fmt.Errorf("%w: parameter %s: type must be number or integer", ErrInvalidTemplate, param.Name)
+		}
+		if param.Min != nil && param.Max != nil && *param.Min > *param.Max {
+			return fmt.Errorf("%w: parameter %s: min is greater than max", ErrInvalidTemplate, param.Name)
+		}
+		if err := param.check(param.Default); err != nil {
+			return fmt.Errorf("%w: default of %v", ErrInvalidTemplate, err)
+		}
+
+		if t.build != nil {
+			continue
+		}
+		if len(param.Targets) == 0 {
+			return fmt.Errorf("%w: parameter %s has no targets", ErrInvalidTemplate, param.Name)
+		}
+		for _, target := range param.Targets {
+			if err := t.checkTarget(target); err != nil {
+				return fmt.Errorf("%w: parameter %s: %v", ErrInvalidTemplate, param.Name, err)
+			}
+		}
+	}
+
+	if t.build == nil {
+		t.NodeCount = len(t.Nodes)
+		t.LineCount = len(t.Lines)
+	}
+	return nil
+}
+
+// checkTarget 確認代入位置至少符合一個元素
+func (t *Template) checkTarget(target Target) error {
+	if target.Property == "" {
+		return fmt.Errorf("target property is required")
+	}
+	if reservedProperties[target.Property] {
+		return fmt.Errorf("property %s cannot be set by a parameter", target.Property)
+	}
+	switch target.Element {
+	case topology.ElementNode:
+	case topology.ElementLine:
+		if target.NodeType != "" {
+			return fmt.Errorf("node_type only applies to node targets")
+		}
+	default:
+		return fmt.Errorf("target element must be node or line")
+	}
+	content := &topology.Topology{Nodes: t.Nodes, Lines: t.Lines}
+	for _, id := range target.ElementIDs {
+		if (target.Element == topology.ElementNode && content.NodeIndex(id) < 0) ||
+			(target.Element == topology.ElementLine && content.LineIndex(id) < 0) {
+			return fmt.Errorf("target %s %s does not exist", target.Element, id)
+		}
+	}
+	if t.countMatches(target) == 0 {
+		return fmt.Errorf("target %s.%s matches no element", target.Element, target.Property)
+	}
+	return nil
+}
+
+// check 確認參數值符合類型與範圍
+func (p *Parameter) check(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("parameter %s must be a finite number", p.Name)
+	}
+	if p.Type == ParameterInteger && value != math.Trunc(value) {
+		return fmt.Errorf("parameter %s must be an integer", p.Name)
+	}
+	if p.Min != nil && value < *p.Min {
+		return fmt.Errorf("parameter %s must be at least %g", p.Name, *p.Min)
+	}
+	if p.Max != nil && value > *p.Max {
+		return fmt.Errorf("parameter %s must be at most %g", p.Name, *p.Max)
+	}
+	return nil
+}
+
+// ResolveValues 合併參數值與預設值；未定義的參數或不符類型與範圍的值回傳 ErrInvalidParameters
+func (t *Template) ResolveValues(values map[string]float64) (map[string]float64, error) {
+	defined := make(map[string]bool, len(t.Parameters))
+	for _, param := range t.Parameters {
+		defined[param.Name] = true
+	}
+	unknown := []string{}
+	for name := range values {
+		if !defined[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: unknown parameter %s", ErrInvalidParameters, unknown[0])
+	}
+
+	resolved := make(map[string]float64, len(t.Parameters))
+	for i := range t.Parameters {
+		param := &t.Parameters[i]
+		value, exists := values[param.Name]
+		if !exists {
+			value = param.Default
+		}
+		if err := param.check(value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+		}
+		resolved[param.Name] = value
+	}
+	return resolved, nil
+}
+
+// Instantiate 代入參數產生新拓樸（尚未指定擁有者與 ID）；用戶範本的節點與線路使用新的 ID
+func (t *Template) Instantiate(values map[string]float64) (*topology.Topology, error) {
+	resolved, err := t.ResolveValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var topo *topology.Topology
+	if t.build != nil {
+		topo = t.build(resolved)
+	} else {
+		topo = (&topology.Topology{Nodes: t.Nodes, Lines: t.Lines}).Clone()
+		for _, param := range t.Parameters {
+			for _, target := range param.Targets {
+				substitute(topo, target, resolved[param.Name])
+			}
+		}
+		topo = topo.Duplicate()
+	}
+
+	topo.Name = t.Name
+	topo.Description = t.Description
+	topo.ProfileType = t.ProfileType
+	return topo, nil
+}
+
+// substitute 將參數值寫入符合代入位置的元素屬性
+func substitute(topo *topology.Topology, target Target, value float64) {
+	if target.Element == topology.ElementLine {
+		for i := range topo.Lines {
+			line := &topo.Lines[i]
+			if target.matchesLine(line) {
+				line.Properties = setProperty(line.Properties, target.Property, value)
+			}
+		}
+		return
+	}
+	for i := range topo.Nodes {
+		node := &topo.Nodes[i]
+		if target.matchesNode(node) {
+			node.Properties = setProperty(node.Properties, target.Property, value)
+		}
+	}
+}
+
+func setProperty(props map[string]interface{}, key string, value float64) map[string]interface{} {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	props[key] = value
+	return props
+}
+
+func (t Target) matchesNode(node *topology.Node) bool {
+	if t.NodeType != "" && node.Type != t.NodeType {
+		return false
+	}
+	return t.matchesID(node.ID)
+}
+
+func (t Target) matchesLine(line *topology.Line) bool {
+	return t.matchesID(line.ID)
+}
+
+func (t Target) matchesID(id string) bool {
+	if len(t.ElementIDs) == 0 {
+		return true
+	}
+	for _, elementID := range t.ElementIDs {
+		if elementID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// countMatches 代入位置符合的元素數
+func (t *Template) countMatches(target Target) int {
+	count := 0
+	if target.Element == topology.ElementLine {
+		for i := range t.Lines {
+			if target.matchesLine(&t.Lines[i]) {
+				count++
+			}
+		}
+		return count
+	}
+	for i := range t.Nodes {
+		if target.matchesNode(&t.Nodes[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// InferParameters 由拓樸推導預設的範本參數：電源節點的二次側電壓作為饋線電壓，
+// 代入電源節點的 secondary_voltage / rated_voltage_kv，以及一次側（或 voltage_kv）等於饋線電壓的節點。
+// 沒有可辨識的饋線電壓時回傳空陣列
+func InferParameters(t *topology.Topology) []Parameter {
+	sources := topology.SourceNodes(t)
+	if len(sources) == 0 {
+		return []Parameter{}
+	}
+	voltage := topology.FloatProperty(sources[0].Properties, "secondary_voltage", 0)
+	if voltage <= 0 {
+		voltage = topology.FloatProperty(sources[0].Properties, "rated_voltage_kv", 0)
+	}
+	if voltage <= 0 {
+		return []Parameter{}
+	}
+
+	sourceIDs := map[string][]string{}
+	for _, source := range sources {
+		for _, key := range []string{"secondary_voltage", "rated_voltage_kv"} {
+			if topology.FloatProperty(source.Properties, key, 0) == voltage {
+				sourceIDs[key] = append(sourceIDs[key], source.ID)
+			}
+		}
+	}
+	isSource := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		isSource[source.ID] = true
+	}
+	feederIDs := map[string][]string{}
+	for _, node := range t.Nodes {
+		if isSource[node.ID] {
+			continue
+		}
+		for _, key := range []string{"primary_voltage", "voltage_kv"} {
+			if topology.FloatProperty(node.Properties, key, 0) == voltage {
+				feederIDs[key] = append(feederIDs[key], node.ID)
+			}
+		}
+	}
+
+	targets := []Target{}
+	for _, key := range []string{"secondary_voltage", "rated_voltage_kv"} {
+		if ids := sourceIDs[key]; len(ids) > 0 {
+			targets = append(targets, Target{Element: topology.ElementNode, ElementIDs: ids, Property: key})
+		}
+	}
+	for _, key := range []string{"primary_voltage", "voltage_kv"} {
+		if ids := feederIDs[key]; len(ids) > 0 {
+			targets = append(targets, Target{Element: topology.ElementNode, ElementIDs: ids, Property: key})
+		}
+	}
+	if len(targets) == 0 {
+		return []Parameter{}
+	}
+
+	return []Parameter{{
+		Name:        FeederVoltageParameter,
+		Description: "Primary feeder voltage",
+		Unit:        "kV",
+		Type:        ParameterNumber,
+		Default:     voltage,
+		Min:         floatPtr(0.1),
+		Max:         floatPtr(69),
+		Targets:     targets,
+	}}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,259 @@
+package templates
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresRepository PostgreSQL 實作
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository 建立新的 PostgreSQL repository
+func NewPostgresRepository() (*PostgresRepository, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return &PostgresRepository{
+		db: database.DB,
+	}, nil
+}
+
+// summaryColumns 列表回傳的欄位（不含節點與線路）
+const summaryColumns = `id, name, description, profile_type, parameters, node_count, line_count, scope, owner_id, organization_id, source_topology_id, created_at, updated_at`
+
+func (r *PostgresRepository) Create(tmpl *Template) error {
+	if tmpl.ID == "" {
+		tmpl.ID = uuid.New().String()
+	}
+	now := time.Now()
+	tmpl.CreatedAt = now
+	tmpl.UpdatedAt = now
+
+	params, nodes, lines, err := marshalContent(tmpl)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO topology_templates (id, name, description, profile_type, parameters, nodes, lines, node_count, line_count,
+		                                scope, owner_id, organization_id, source_topology_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err = r.db.Exec(query,
+		tmpl.ID,
+		tmpl.Name,
+		tmpl.Description,
+		tmpl.ProfileType,
+		params,
+		nodes,
+		lines,
+		tmpl.NodeCount,
+		tmpl.LineCount,
+		tmpl.Scope,
+		tmpl.OwnerID,
+		tmpl.OrganizationID,
+		tmpl.SourceTopologyID,
+		tmpl.CreatedAt,
+		tmpl.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetByID(id string) (*Template, error) {
+	if tmpl, ok := builtinTemplate(id); ok {
+		return tmpl, nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	query := `SELECT ` + summaryColumns + `, nodes, lines FROM topology_templates WHERE id = $1`
+	var nodes, lines []byte
+	tmpl, err := scanTemplate(r.db.QueryRow(query, id), &nodes, &lines)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	if err := json.Unmarshal(nodes, &tmpl.Nodes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal nodes: %w", err)
+	}
+	if err := json.Unmarshal(lines, &tmpl.Lines); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lines: %w", err)
+	}
+	return tmpl, nil
+}
+
+func (r *PostgresRepository) Update(tmpl *Template) error {
+	if _, ok := builtinIndex[tmpl.ID]; ok {
+		return ErrReadOnly
+	}
+	tmpl.UpdatedAt = time.Now()
+
+	params, err := json.Marshal(tmpl.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameters: %w", err)
+	}
+
+	query := `
+		UPDATE topology_templates
+		SET name = $2, description = $3, profile_type = $4, parameters = $5, updated_at = $6
+		WHERE id = $1
+		RETURNING created_at
+	`
+	err = r.db.QueryRow(query,
+		tmpl.ID,
+		tmpl.Name,
+		tmpl.Description,
+		tmpl.ProfileType,
+		params,
+		tmpl.UpdatedAt,
+	).Scan(&tmpl.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrTemplateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Delete(id string) error {
+	if _, ok := builtinIndex[id]; ok {
+		return ErrReadOnly
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrTemplateNotFound
+	}
+
+	res, err := r.db.Exec(`DELETE FROM topology_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) List(filter Filter) ([]*Template, error) {
+	query := `SELECT ` + summaryColumns + ` FROM topology_templates WHERE (scope = 'global'`
+	args := []interface{}{}
+	if filter.Visibility.UserID != nil {
+		args = append(args, *filter.Visibility.UserID)
+		query += ` OR (scope = 'user' AND owner_id = $` + strconv.Itoa(len(args)) + `)`
+	}
+	if len(filter.Visibility.OrganizationIDs) > 0 {
+		args = append(args, pq.Array(filter.Visibility.OrganizationIDs))
+		query += ` OR (scope = 'organization' AND organization_id = ANY($` + strconv.Itoa(len(args)) + `::uuid[]))`
+	}
+	query += `)`
+	if filter.ProfileType != "" {
+		args = append(args, filter.ProfileType)
+		query += ` AND profile_type = $` + strconv.Itoa(len(args))
+	}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		n := strconv.Itoa(len(args))
+		query += ` AND (name ILIKE $` + n + ` OR description ILIKE $` + n + `)`
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*Template{}
+	for _, tmpl := range builtinTemplates {
+		if filter.matches(tmpl) {
+			templates = append(templates, tmpl.Summary())
+		}
+	}
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate templates: %w", err)
+	}
+	sortTemplates(templates)
+	return templates, nil
+}
+
+// marshalContent 序列化參數、節點與線路
+func marshalContent(tmpl *Template) (params, nodes, lines []byte, err error) {
+	if params, err = json.Marshal(tmpl.Parameters); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal parameters: %w", err)
+	}
+	if nodes, err = json.Marshal(tmpl.Nodes); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal nodes: %w", err)
+	}
+	if lines, err = json.Marshal(tmpl.Lines); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal lines: %w", err)
+	}
+	return params, nodes, lines, nil
+}
+
+// rowScanner 同時支援 *sql.Row 與 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTemplate 讀取 summaryColumns 與 extra 指定的其他欄位
+func scanTemplate(row rowScanner, extra ...interface{}) (*Template, error) {
+	var tmpl Template
+	var params []byte
+	var ownerID, organizationID, sourceTopologyID sql.NullString
+	dest := []interface{}{
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Description,
+		&tmpl.ProfileType,
+		&params,
+		&tmpl.NodeCount,
+		&tmpl.LineCount,
+		&tmpl.Scope,
+		&ownerID,
+		&organizationID,
+		&sourceTopologyID,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &tmpl.Parameters); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+	}
+	if ownerID.Valid {
+		tmpl.OwnerID = &ownerID.String
+	}
+	if organizationID.Valid {
+		tmpl.OrganizationID = &organizationID.String
+	}
+	if sourceTopologyID.Valid {
+		tmpl.SourceTopologyID = &sourceTopologyID.String
+	}
+	return &tmpl, nil
+}
@@ -0,0 +1,146 @@
+package templates
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository 定義範本儲存介面；內建範本不經過儲存層，由實作合併到查詢結果
+type Repository interface {
+	Create(tmpl *Template) error
+	GetByID(id string) (*Template, error)
+	Update(tmpl *Template) error
+	Delete(id string) error
+	// List 列出符合條件的範本（不含節點與線路）
+	List(filter Filter) ([]*Template, error)
+}
+
+// InMemoryRepository 記憶體實作（開發用）
+type InMemoryRepository struct {
+	mu        sync.RWMutex
+	templates map[string]*Template
+}
+
+// NewInMemoryRepository 建立新的記憶體 repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		templates: make(map[string]*Template),
+	}
+}
+
+func (r *InMemoryRepository) Create(tmpl *Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tmpl.ID == "" {
+		tmpl.ID = uuid.New().String()
+	}
+	now := time.Now()
+	tmpl.CreatedAt = now
+	tmpl.UpdatedAt = now
+	r.templates[tmpl.ID] = tmpl.Clone()
+	return nil
+}
+
+func (r *InMemoryRepository) GetByID(id string) (*Template, error) {
+	if tmpl, ok := builtinTemplate(id); ok {
+		return tmpl, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tmpl, exists := r.templates[id]
+	if !exists {
+		return nil, ErrTemplateNotFound
+	}
+	return tmpl.Clone(), nil
+}
+
+func (r *InMemoryRepository) Update(tmpl *Template) error {
+	if _, ok := builtinIndex[tmpl.ID]; ok {
+		return ErrReadOnly
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.templates[tmpl.ID]
+	if !exists {
+		return ErrTemplateNotFound
+	}
+	tmpl.CreatedAt = existing.CreatedAt
+	tmpl.UpdatedAt = time.Now()
+	r.templates[tmpl.ID] = tmpl.Clone()
+	return nil
+}
+
+func (r *InMemoryRepository) Delete(id string) error {
+	if _, ok := builtinIndex[id]; ok {
+		return ErrReadOnly
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[id]; !exists {
+		return ErrTemplateNotFound
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+func (r *InMemoryRepository) List(filter Filter) ([]*Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := []*Template{}
+	for _, tmpl := range builtinTemplates {
+		if filter.matches(tmpl) {
+			templates = append(templates, tmpl.Summary())
+		}
+	}
+	for _, tmpl := range r.templates {
+		if filter.matches(tmpl) {
+			templates = append(templates, tmpl.Summary())
+		}
+	}
+	sortTemplates(templates)
+	return templates, nil
+}
+
+// matches 判斷範本是否符合列表條件
+func (f Filter) matches(tmpl *Template) bool {
+	if f.ProfileType != "" && tmpl.ProfileType != f.ProfileType {
+		return false
+	}
+	if !tmpl.VisibleTo(f.Visibility) {
+		return false
+	}
+	if f.Query != "" {
+		query := strings.ToLower(f.Query)
+		text := strings.ToLower(tmpl.Name + " " + tmpl.Description)
+		if !strings.Contains(text, query) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortTemplates 內建範本優先（維持定義順序），其餘依名稱排序
+func sortTemplates(templates []*Template) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		a, b := templates[i], templates[j]
+		if a.Builtin != b.Builtin {
+			return a.Builtin
+		}
+		if a.Builtin {
+			return false
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,187 @@
+package topology
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Diff 兩個拓樸版本之間的結構差異
+type Diff struct {
+	FromRevision int           `json:"from_revision"`
+	ToRevision   int           `json:"to_revision"`
+	Fields       []FieldChange `json:"fields"` // name, description, profile_type
+	Nodes        ElementDiff   `json:"nodes"`
+	Lines        ElementDiff   `json:"lines"`
+}
+
+// FieldChange 拓樸欄位變更
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ElementDiff 節點或線路的新增、刪除與修改
+type ElementDiff struct {
+	Added    []string        `json:"added"`
+	Removed  []string        `json:"removed"`
+	Modified []ElementChange `json:"modified"`
+}
+
+// ElementChange 單一元素的變更內容
+type ElementChange struct {
+	ID                 string   `json:"id"`
+	Fields             []string `json:"fields,omitempty"` // 變更的欄位（type, name, position, geo, from_node_id ...）
+	AddedProperties    []string `json:"added_properties,omitempty"`
+	RemovedProperties  []string `json:"removed_properties,omitempty"`
+	ModifiedProperties []string `json:"modified_properties,omitempty"`
+}
+
+// IsEmpty 判斷是否沒有任何差異
+func (d *Diff) IsEmpty() bool {
+	return len(d.Fields) == 0 && d.Nodes.isEmpty() && d.Lines.isEmpty()
+}
+
+func (d ElementDiff) isEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// DiffTopologies 計算 from 到 to 的結構差異
+func DiffTopologies(from, to *Topology) *Diff {
+	diff := &Diff{
+		Fields: []FieldChange{},
+	}
+
+	if from.Name != to.Name {
+		diff.Fields = append(diff.Fields, FieldChange{Field: "name", From: from.Name, To: to.Name})
+	}
+	if from.Description != to.Description {
+		diff.Fields = append(diff.Fields, FieldChange{Field: "description", From: from.Description, To: to.Description})
+	}
+	if from.ProfileType != to.ProfileType {
+		diff.Fields = append(diff.Fields, FieldChange{Field: "profile_type", From: from.ProfileType, To: to.ProfileType})
+	}
+
+	fromNodes := make(map[string]Node, len(from.Nodes))
+	for _, node := range from.Nodes {
+		fromNodes[node.ID] = node
+	}
+	toNodes := make(map[string]Node, len(to.Nodes))
+	for _, node := range to.Nodes {
+		toNodes[node.ID] = node
+	}
+	diff.Nodes = diffElements(keysOf(fromNodes), keysOf(toNodes), func(id string) (ElementChange, bool) {
+		a, b := fromNodes[id], toNodes[id]
+		change := ElementChange{ID: id}
+		if a.Type != b.Type {
+			change.Fields = append(change.Fields, "type")
+		}
+		if a.Name != b.Name {
+			change.Fields = append(change.Fields, "name")
+		}
+		if a.Position != b.Position {
+			change.Fields = append(change.Fields, "position")
+		}
+		if !reflect.DeepEqual(a.Geo, b.Geo) {
+			change.Fields = append(change.Fields, "geo")
+		}
+		diffProperties(a.Properties, b.Properties, &change)
+		return change, !change.isEmpty()
+	})
+
+	fromLines := make(map[string]Line, len(from.Lines))
+	for _, line := range from.Lines {
+		fromLines[line.ID] = line
+	}
+	toLines := make(map[string]Line, len(to.Lines))
+	for _, line := range to.Lines {
+		toLines[line.ID] = line
+	}
+	diff.Lines = diffElements(keysOf(fromLines), keysOf(toLines), func(id string) (ElementChange, bool) {
+		a, b := fromLines[id], toLines[id]
+		change := ElementChange{ID: id}
+		if a.FromNodeID != b.FromNodeID {
+			change.Fields = append(change.Fields, "from_node_id")
+		}
+		if a.ToNodeID != b.ToNodeID {
+			change.Fields = append(change.Fields, "to_node_id")
+		}
+		if a.Name != b.Name {
+			change.Fields = append(change.Fields, "name")
+		}
+		if !reflect.DeepEqual(a.Geometry, b.Geometry) {
+			change.Fields = append(change.Fields, "geometry")
+		}
+		diffProperties(a.Properties, b.Properties, &change)
+		return change, !change.isEmpty()
+	})
+
+	return diff
+}
+
+func (c ElementChange) isEmpty() bool {
+	return len(c.Fields) == 0 && len(c.AddedProperties) == 0 &&
+		len(c.RemovedProperties) == 0 && len(c.ModifiedProperties) == 0
+}
+
+// diffElements 依 ID 比對兩組元素，compare 回傳共同元素的變更
+func diffElements(fromIDs, toIDs []string, compare func(id string) (ElementChange, bool)) ElementDiff {
+	result := ElementDiff{
+		Added:    []string{},
+		Removed:  []string{},
+		Modified: []ElementChange{},
+	}
+
+	inFrom := make(map[string]bool, len(fromIDs))
+	for _, id := range fromIDs {
+		inFrom[id] = true
+	}
+	inTo := make(map[string]bool, len(toIDs))
+	for _, id := range toIDs {
+		inTo[id] = true
+	}
+
+	for _, id := range toIDs {
+		if !inFrom[id] {
+			result.Added = append(result.Added, id)
+		}
+	}
+	for _, id := range fromIDs {
+		if !inTo[id] {
+			result.Removed = append(result.Removed, id)
+			continue
+		}
+		if change, changed := compare(id); changed {
+			result.Modified = append(result.Modified, change)
+		}
+	}
+
+	return result
+}
+
+// diffProperties 比對屬性鍵值
+func diffProperties(from, to map[string]interface{}, change *ElementChange) {
+	for _, key := range keysOf(to) {
+		value, exists := from[key]
+		if !exists {
+			change.AddedProperties = append(change.AddedProperties, key)
+		} else if !reflect.DeepEqual(value, to[key]) {
+			change.ModifiedProperties = append(change.ModifiedProperties, key)
+		}
+	}
+	for _, key := range keysOf(from) {
+		if _, exists := to[key]; !exists {
+			change.RemovedProperties = append(change.RemovedProperties, key)
+		}
+	}
+}
+
+// keysOf 取得排序後的鍵
+func keysOf[T any](elements map[string]T) []string {
+	keys := make([]string, 0, len(elements))
+	for key := range elements {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
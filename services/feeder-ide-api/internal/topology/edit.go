@@ -0,0 +1,178 @@
+package topology
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NodeIndex 回傳節點在 Nodes 中的索引，不存在時回傳 -1
+func (t *Topology) NodeIndex(id string) int {
+	for i := range t.Nodes {
+		if t.Nodes[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// LineIndex 回傳線路在 Lines 中的索引，不存在時回傳 -1
+func (t *Topology) LineIndex(id string) int {
+	for i := range t.Lines {
+		if t.Lines[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddNode 新增節點；未指定 ID 時自動產生
+func (t *Topology) AddNode(node Node) (Node, error) {
+	if node.ID == "" {
+		node.ID = uuid.New().String()
+	}
+	if t.NodeIndex(node.ID) >= 0 {
+		return Node{}, ErrDuplicateElement
+	}
+	t.Nodes = append(t.Nodes, node)
+	return node, nil
+}
+
+// UpdateNode 以新內容取代指定節點（ID 不可變更）
+func (t *Topology) UpdateNode(id string, node Node) (Node, error) {
+	index := t.NodeIndex(id)
+	if index < 0 {
+		return Node{}, ErrNodeNotFound
+	}
+	if node.ID != "" && node.ID != id {
+		return Node{}, ErrElementIDMismatch
+	}
+	node.ID = id
+	t.Nodes[index] = node
+	return node, nil
+}
+
+// RemoveNode 移除節點及所有連接的線路，回傳被一併移除的線路 ID
+func (t *Topology) RemoveNode(id string) ([]string, error) {
+	index := t.NodeIndex(id)
+	if index < 0 {
+		return nil, ErrNodeNotFound
+	}
+	t.Nodes = append(t.Nodes[:index], t.Nodes[index+1:]...)
+
+	removed := []string{}
+	lines := make([]Line, 0, len(t.Lines))
+	for _, line := range t.Lines {
+		if line.FromNodeID == id || line.ToNodeID == id {
+			removed = append(removed, line.ID)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	t.Lines = lines
+	return removed, nil
+}
+
+// AddLine 新增線路；未指定 ID 時自動產生
+func (t *Topology) AddLine(line Line) (Line, error) {
+	if line.ID == "" {
+		line.ID = uuid.New().String()
+	}
+	if t.LineIndex(line.ID) >= 0 {
+		return Line{}, ErrDuplicateElement
+	}
+	t.Lines = append(t.Lines, line)
+	return line, nil
+}
+
+// UpdateLine 以新內容取代指定線路（ID 不可變更）
+func (t *Topology) UpdateLine(id string, line Line) (Line, error) {
+	index := t.LineIndex(id)
+	if index < 0 {
+		return Line{}, ErrLineNotFound
+	}
+	if line.ID != "" && line.ID != id {
+		return Line{}, ErrElementIDMismatch
+	}
+	line.ID = id
+	t.Lines[index] = line
+	return line, nil
+}
+
+// RemoveLine 移除線路
+func (t *Topology) RemoveLine(id string) error {
+	index := t.LineIndex(id)
+	if index < 0 {
+		return ErrLineNotFound
+	}
+	t.Lines = append(t.Lines[:index], t.Lines[index+1:]...)
+	return nil
+}
+
+// WithSwitchStates 回傳套用開關閉合狀態後的副本，只複製被修改的開關節點 properties，其餘內容與原拓樸共用
+func (t *Topology) WithSwitchStates(closed map[string]bool) *Topology {
+	copied := *t
+	copied.Nodes = make([]Node, len(t.Nodes))
+	copy(copied.Nodes, t.Nodes)
+	for i, node := range copied.Nodes {
+		state, exists := closed[node.ID]
+		if !exists || node.Type != NodeTypeSwitch {
+			continue
+		}
+		props := make(map[string]interface{}, len(node.Properties)+1)
+		for key, value := range node.Properties {
+			props[key] = value
+		}
+		props["is_closed"] = state
+		copied.Nodes[i].Properties = props
+	}
+	return &copied
+}
+
+// SwitchStates 回傳所有開關節點的閉合狀態（未指定 is_closed 視為閉合）
+func (t *Topology) SwitchStates() map[string]bool {
+	states := make(map[string]bool)
+	for _, node := range t.Nodes {
+		if node.Type == NodeTypeSwitch {
+			states[node.ID] = BoolProperty(node.Properties, "is_closed", true)
+		}
+	}
+	return states
+}
+
+// mridProperty 保存 CIM mRID 的屬性鍵（見 formats/cim）
+const mridProperty = "mrid"
+
+// Duplicate 深拷貝拓樸並為所有節點與線路產生新的 ID，線路端點改參照新的節點 ID。
+// 副本代表另一組設備，因此移除元素的 CIM mRID（匯出時依新 ID 重新產生）；
+// ID、擁有者、版本與時間戳記一併清除，由呼叫端建立新拓樸時設定
+func (t *Topology) Duplicate() *Topology {
+	clone := t.Clone()
+	clone.ID = ""
+	clone.UserID = nil
+	clone.OrganizationID = nil
+	clone.Version = 0
+	clone.CreatedAt = time.Time{}
+	clone.UpdatedAt = time.Time{}
+
+	nodeIDs := make(map[string]string, len(clone.Nodes))
+	for i := range clone.Nodes {
+		node := &clone.Nodes[i]
+		id := uuid.New().String()
+		nodeIDs[node.ID] = id
+		node.ID = id
+		delete(node.Properties, mridProperty)
+	}
+	for i := range clone.Lines {
+		line := &clone.Lines[i]
+		line.ID = uuid.New().String()
+		if id, exists := nodeIDs[line.FromNodeID]; exists {
+			line.FromNodeID = id
+		}
+		if id, exists := nodeIDs[line.ToNodeID]; exists {
+			line.ToNodeID = id
+		}
+		delete(line.Properties, mridProperty)
+	}
+	return clone
+}
@@ -3,7 +3,23 @@ package topology
 import "errors"
 
 var (
-	ErrTopologyNotFound = errors.New("topology not found")
-	ErrInvalidTopology  = errors.New("invalid topology")
+	ErrTopologyNotFound     = errors.New("topology not found")
+	ErrInvalidTopology      = errors.New("invalid topology")
+	ErrRevisionNotFound     = errors.New("revision not found")
+	ErrVersionConflict      = errors.New("topology has been modified by another user")
+	ErrNodeNotFound         = errors.New("node not found")
+	ErrLineNotFound         = errors.New("line not found")
+	ErrDuplicateElement     = errors.New("element with the same id already exists")
+	ErrElementIDMismatch    = errors.New("element id does not match path")
+	ErrInvalidPatch         = errors.New("invalid patch")
+	ErrPatchTestFailed      = errors.New("patch test operation failed")
+	ErrInvalidBBox          = errors.New("invalid bbox, expected min_lon,min_lat,max_lon,max_lat")
+	ErrInvalidQuery         = errors.New("invalid list query")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrInvalidRole          = errors.New("invalid role")
+	ErrInsufficientRole     = errors.New("insufficient role for this topology")
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+	ErrShareLinkNotFound    = errors.New("share link not found")
+	ErrNotShareable         = errors.New("topologies without an owner cannot be shared")
+	ErrOrganizationOwned    = errors.New("topology is owned by an organization")
 )
-
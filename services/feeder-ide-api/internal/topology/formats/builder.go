@@ -0,0 +1,277 @@
+package formats
+
+import (
+	"fmt"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Builder 由外部格式的「匯流排 + 元件」模型建立拓樸
+//
+// 外部格式中的變壓器與開關是連接兩個匯流排的雙端元件，而拓樸中它們是節點。
+// AddDevice 會在第二端匯流排建立元件節點並以連接線接到第一端；Finish 時若第一端匯流排
+// 只接一條線路，會將其併入元件節點以避免多出零長度線路。
+type Builder struct {
+	topo      *topology.Topology
+	normalize func(string) string
+	nodes     map[string]int // 正規化後的匯流排名稱 -> Nodes 索引
+	lineIDs   map[string]bool
+	claimed   map[string]bool // 已被設備（變壓器、開關、DER、EV）佔用的節點
+	sourceID  string
+	devices   []builderDevice
+	removed   map[string]bool
+	dropLines map[string]bool
+	warnings  []string
+}
+
+type builderDevice struct {
+	NodeID    string
+	InputBus  string
+	Connector string
+}
+
+// NewBuilder 建立 Builder；normalize 用於比對匯流排與線路名稱（例如不分大小寫），nil 表示完全比對
+func NewBuilder(normalize func(string) string) *Builder {
+	if normalize == nil {
+		normalize = func(s string) string { return s }
+	}
+	return &Builder{
+		topo: &topology.Topology{
+			Nodes: []topology.Node{},
+			Lines: []topology.Line{},
+		},
+		normalize: normalize,
+		nodes:     map[string]int{},
+		lineIDs:   map[string]bool{},
+		claimed:   map[string]bool{},
+		removed:   map[string]bool{},
+		dropLines: map[string]bool{},
+		warnings:  []string{},
+	}
+}
+
+// Topology 取得建立中的拓樸
+func (b *Builder) Topology() *topology.Topology {
+	return b.topo
+}
+
+// Warn 加入一筆警告
+func (b *Builder) Warn(format string, args ...interface{}) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+// Warnings 取得所有警告
+func (b *Builder) Warnings() []string {
+	return b.warnings
+}
+
+// Node 取得或建立匯流排節點（回傳的指標在新增節點後可能失效）
+func (b *Builder) Node(bus string) *topology.Node {
+	key := b.normalize(bus)
+	if index, exists := b.nodes[key]; exists {
+		return &b.topo.Nodes[index]
+	}
+	b.nodes[key] = len(b.topo.Nodes)
+	b.topo.Nodes = append(b.topo.Nodes, topology.Node{
+		ID:         bus,
+		Type:       topology.NodeTypeBus,
+		Name:       bus,
+		Properties: map[string]interface{}{},
+	})
+	return &b.topo.Nodes[len(b.topo.Nodes)-1]
+}
+
+// HasNode 判斷匯流排節點是否存在
+func (b *Builder) HasNode(bus string) bool {
+	_, exists := b.nodes[b.normalize(bus)]
+	return exists
+}
+
+// SetSource 將匯流排標記為電源
+func (b *Builder) SetSource(bus string, voltageKV float64) *topology.Node {
+	node := b.Node(bus)
+	node.Properties["is_source"] = true
+	if voltageKV > 0 {
+		node.Properties["voltage_kv"] = voltageKV
+	}
+	b.sourceID = node.ID
+	return node
+}
+
+// EquipmentNode 取得可設置設備類型的節點：匯流排未被佔用時直接使用，否則建立新節點並以零長度線路連到匯流排
+func (b *Builder) EquipmentNode(bus, name, nodeType string) *topology.Node {
+	target := b.Node(bus)
+	if !b.claimed[target.ID] && target.ID != b.sourceID {
+		b.claimed[target.ID] = true
+		target.Type = nodeType
+		return target
+	}
+
+	id := name
+	for suffix := 2; b.HasNode(id); suffix++ {
+		id = fmt.Sprintf("%s_%d", name, suffix)
+	}
+	targetID := target.ID
+	node := b.Node(id)
+	node.Type = nodeType
+	b.claimed[node.ID] = true
+	b.Connect(targetID, node.ID, name)
+	return b.Node(id)
+}
+
+// AddDevice 在 bus2 建立雙端元件節點，並以連接線接到 bus1
+func (b *Builder) AddDevice(name, bus1, bus2, nodeType string) *topology.Node {
+	input := b.Node(bus1).ID
+	nodeID := b.EquipmentNode(bus2, name, nodeType).ID
+	connector := b.Connect(input, nodeID, name)
+	b.devices = append(b.devices, builderDevice{NodeID: nodeID, InputBus: input, Connector: connector})
+	return b.Node(nodeID)
+}
+
+// Connect 建立零長度連接線並回傳 ID
+func (b *Builder) Connect(from, to, name string) string {
+	return b.AddLine(topology.Line{
+		ID:         name,
+		FromNodeID: from,
+		ToNodeID:   to,
+		Name:       name,
+		Properties: map[string]interface{}{"length_km": 0.0},
+	})
+}
+
+// AddLine 加入線路（ID 重複時加上序號）並回傳 ID
+func (b *Builder) AddLine(line topology.Line) string {
+	id := line.ID
+	for suffix := 2; b.lineIDs[b.normalize(id)]; suffix++ {
+		id = fmt.Sprintf("%s_%d", line.ID, suffix)
+	}
+	line.ID = id
+	b.lineIDs[b.normalize(id)] = true
+	b.topo.Lines = append(b.topo.Lines, line)
+	return id
+}
+
+// Finish 合併元件上游匯流排、移除多餘元素、套用座標並為其餘節點自動排版
+// coords 以正規化後的匯流排名稱為鍵
+func (b *Builder) Finish(coords map[string]topology.Position) *topology.Topology {
+	b.mergeInputBuses()
+
+	nodes := make([]topology.Node, 0, len(b.topo.Nodes))
+	positioned := map[string]bool{}
+	for _, node := range b.topo.Nodes {
+		if b.removed[node.ID] {
+			continue
+		}
+		if position, exists := coords[b.normalize(node.ID)]; exists {
+			node.Position = position
+			positioned[node.ID] = true
+		}
+		if len(node.Properties) == 0 {
+			node.Properties = nil
+		}
+		nodes = append(nodes, node)
+	}
+	b.topo.Nodes = nodes
+
+	lines := make([]topology.Line, 0, len(b.topo.Lines))
+	for _, line := range b.topo.Lines {
+		if !b.dropLines[line.ID] {
+			lines = append(lines, line)
+		}
+	}
+	b.topo.Lines = lines
+
+	if len(positioned) < len(b.topo.Nodes) {
+		AutoLayout(b.topo, positioned)
+	}
+	return b.topo
+}
+
+// mergeInputBuses 將只連接一條線路的元件上游匯流排併入元件節點
+// 電源匯流排若只接該元件，則由元件節點成為電源（例如變電所主變壓器）
+func (b *Builder) mergeInputBuses() {
+	for _, dev := range b.devices {
+		input := b.Node(dev.InputBus)
+		if b.claimed[input.ID] || b.removed[input.ID] || hasLoad(input) {
+			continue
+		}
+
+		var others []int
+		for i, line := range b.topo.Lines {
+			if b.dropLines[line.ID] || line.ID == dev.Connector {
+				continue
+			}
+			if line.FromNodeID == input.ID || line.ToNodeID == input.ID {
+				others = append(others, i)
+			}
+		}
+
+		isSource := input.ID == b.sourceID
+		if (isSource && len(others) != 0) || (!isSource && len(others) != 1) {
+			continue
+		}
+
+		if isSource {
+			target := b.Node(dev.NodeID)
+			target.Properties["is_source"] = true
+			if _, hasSecondary := target.Properties["secondary_voltage"]; !hasSecondary {
+				if kv, exists := input.Properties["voltage_kv"]; exists {
+					target.Properties["voltage_kv"] = kv
+				}
+			}
+			b.sourceID = target.ID
+		} else {
+			line := &b.topo.Lines[others[0]]
+			if line.FromNodeID == input.ID {
+				line.FromNodeID = dev.NodeID
+			} else {
+				line.ToNodeID = dev.NodeID
+			}
+		}
+		b.removed[input.ID] = true
+		b.dropLines[dev.Connector] = true
+	}
+}
+
+func hasLoad(node *topology.Node) bool {
+	_, exists := node.Properties["load_kw"]
+	return exists
+}
+
+// InputSides 拓樸中接在變壓器或開關上游端的線路，鍵為 {線路 ID, 元件節點 ID}
+// 終點為元件節點的線路視為上游；開關若沒有任何流入線路，則取第一條相連線路
+type InputSides map[[2]string]bool
+
+// FindInputSides 計算拓樸中雙端元件的上游端線路
+func FindInputSides(t *topology.Topology) InputSides {
+	sides := InputSides{}
+	for _, node := range t.Nodes {
+		if !IsTwoTerminal(node) {
+			continue
+		}
+		incoming := 0
+		var outgoing []string
+		for _, line := range t.Lines {
+			if line.ToNodeID == node.ID {
+				sides[[2]string{line.ID, node.ID}] = true
+				incoming++
+			} else if line.FromNodeID == node.ID {
+				outgoing = append(outgoing, line.ID)
+			}
+		}
+		if node.Type == topology.NodeTypeSwitch && incoming == 0 && len(outgoing) > 1 {
+			sides[[2]string{outgoing[0], node.ID}] = true
+		}
+	}
+	return sides
+}
+
+// Has 判斷線路的 nodeID 端是否接在元件上游端
+func (s InputSides) Has(lineID, nodeID string) bool {
+	return s[[2]string{lineID, nodeID}]
+}
+
+// IsTwoTerminal 判斷節點在外部格式中是否為雙端元件（變壓器、開關）
+func IsTwoTerminal(node topology.Node) bool {
+	return node.Type == topology.NodeTypeTransformer || node.Type == topology.NodeTypeSwitch
+}
@@ -0,0 +1,53 @@
+// Package cim 在拓樸與 CIM（IEC 61968/61970，CGMES 風格）RDF/XML 之間轉換
+//
+// 對應方式：
+//   - bus 節點 -> ConnectivityNode（並產生對應的 TopologicalNode）
+//   - transformer -> PowerTransformer 與兩個 PowerTransformerEnd
+//   - switch -> Breaker / Recloser / Sectionaliser / Fuse（依 SwitchProperties.Type）
+//   - der -> PowerElectronicsConnection 與 PhotoVoltaicUnit / BatteryUnit / PowerElectronicsWindUnit
+//   - ev_charger 與節點負載 -> EnergyConsumer；電源節點 -> EnergySource
+//   - Line -> ACLineSegment；連接關係以 Terminal 表示
+//
+// 單位採 CGMES 慣例：電壓 kV、功率 MW / MVAr / MVA、長度 km、阻抗 ohm。
+// 元素的 mRID 取自 Properties["mrid"]，沒有時以拓樸與元素 ID 產生固定的 UUID；Terminal 等附屬物件的 mRID 由元素的 mRID 產生。
+// 匯入時（包含本系統的匯出檔）會將 mRID 寫回 Properties["mrid"]，匯出再匯入後 mRID 保持不變。
+// 匯出時每個節點與線路的完整內容另以 ide: 擴充命名空間保存，匯入本系統的匯出檔時以擴充內容為準，可無損還原。
+package cim
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// 命名空間
+const (
+	nsRDF = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsCIM = "http://iec.ch/TC57/2013/CIM-schema-cim16#"
+	nsMD  = "http://iec.ch/TC57/61970-552/ModelDescription/1#"
+	nsIDE = "http://feeder-platform.org/ide/cim-extension#"
+)
+
+// mridProperty 保存 CIM mRID 的屬性鍵
+const mridProperty = "mrid"
+
+// mridNamespace 產生固定 mRID 用的 UUID 命名空間
+var mridNamespace = uuid.MustParse("5b0f4c1e-3d55-4a52-9f0e-6a3c2f1d7e21")
+
+// ErrNoNetwork 檔案中沒有任何可轉換的網路元素
+var ErrNoNetwork = errors.New("no network elements found in CIM model")
+
+// Codec CIM RDF/XML 格式
+type Codec struct{}
+
+func (Codec) Name() string {
+	return "cim"
+}
+
+func (Codec) ContentType() string {
+	return "application/rdf+xml"
+}
+
+func (Codec) Extension() string {
+	return ".xml"
+}
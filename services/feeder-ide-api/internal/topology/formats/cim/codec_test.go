@@ -0,0 +1,134 @@
+package cim
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// sampleTopology 含所有節點類型的小型饋線
+func sampleTopology(id string) *topology.Topology {
+	return &topology.Topology{
+		ID:          id,
+		Name:        "Sample feeder",
+		Description: "CIM round trip",
+		ProfileType: "suburban",
+		Nodes: []topology.Node{
+			{ID: "sub", Type: topology.NodeTypeBus, Name: "Substation", Properties: map[string]interface{}{"is_source": true, "voltage_kv": 22.8}},
+			{ID: "cb", Type: topology.NodeTypeSwitch, Name: "CB-1", Properties: map[string]interface{}{"type": "breaker", "is_closed": true}},
+			{ID: "b1", Type: topology.NodeTypeBus, Name: "Bus 1", Properties: map[string]interface{}{"load_kw": 300.0, "mrid": "external-bus-1"}},
+			{ID: "tx", Type: topology.NodeTypeTransformer, Name: "TX-1", Properties: map[string]interface{}{"primary_voltage": 22.8, "secondary_voltage": 0.38, "rated_capacity_kva": 500.0}},
+			{ID: "lv", Type: topology.NodeTypeBus, Name: "LV bus", Properties: map[string]interface{}{"load_kw": 120.0, "load_kvar": 30.0}},
+			{ID: "pv", Type: topology.NodeTypeDER, Name: "PV-1", Properties: map[string]interface{}{"type": "pv", "rated_power_kw": 50.0}},
+			{ID: "ev", Type: topology.NodeTypeEVCharger, Name: "EV-1", Properties: map[string]interface{}{"rated_power_kw": 22.0}},
+		},
+		Lines: []topology.Line{
+			{ID: "l1", FromNodeID: "sub", ToNodeID: "cb", Properties: map[string]interface{}{"length_km": 0.0}},
+			{ID: "l2", FromNodeID: "cb", ToNodeID: "b1", Name: "Main", Properties: map[string]interface{}{"length_km": 2.5}},
+			{ID: "l3", FromNodeID: "b1", ToNodeID: "tx", Properties: map[string]interface{}{"length_km": 0.1}},
+			{ID: "l4", FromNodeID: "tx", ToNodeID: "lv", Properties: map[string]interface{}{"length_km": 0.05}},
+			{ID: "l5", FromNodeID: "lv", ToNodeID: "pv", Properties: map[string]interface{}{"length_km": 0.02}},
+			{ID: "l6", FromNodeID: "lv", ToNodeID: "ev", Properties: map[string]interface{}{"length_km": 0.02}},
+		},
+	}
+}
+
+// exportedMRIDs 匯出檔中所有 IdentifiedObject.mRID（排序後）
+func exportedMRIDs(t *testing.T, data []byte) []string {
+	t.Helper()
+	objects, err := readRDF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readRDF: %v", err)
+	}
+	mrids := []string{}
+	for _, obj := range objects {
+		if mrid := obj.Props["IdentifiedObject.mRID"]; mrid != "" {
+			mrids = append(mrids, mrid)
+		}
+	}
+	sort.Strings(mrids)
+	return mrids
+}
+
+func encode(t *testing.T, topo *topology.Topology) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := (Codec{}).Encode(&buf, topo); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTripPreservesMRIDs(t *testing.T) {
+	first := encode(t, sampleTopology("topology-a"))
+
+	result, err := (Codec{}).Decode(bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	// 匯入後會建立新的拓樸 ID
+	imported := result.Topology
+	imported.ID = "topology-b"
+	second := encode(t, imported)
+
+	before, after := exportedMRIDs(t, first), exportedMRIDs(t, second)
+	if len(before) == 0 {
+		t.Fatal("first export has no mRIDs")
+	}
+	if len(before) != len(after) {
+		t.Fatalf("mRID count changed: %d -> %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Fatalf("mRIDs changed after round trip:\nbefore %v\nafter  %v", before, after)
+		}
+	}
+
+	// 已指定的 mRID 原樣輸出
+	found := false
+	for _, mrid := range before {
+		if mrid == "external-bus-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Properties[\"mrid\"] was not used as the bus mRID")
+	}
+}
+
+func TestRoundTripRestoresTopology(t *testing.T) {
+	original := sampleTopology("topology-a")
+	result, err := (Codec{}).Decode(bytes.NewReader(encode(t, original)))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	got := result.Topology
+
+	if got.Name != original.Name || got.Description != original.Description || got.ProfileType != original.ProfileType {
+		t.Errorf("model = (%q, %q, %q), want (%q, %q, %q)",
+			got.Name, got.Description, got.ProfileType, original.Name, original.Description, original.ProfileType)
+	}
+	if len(got.Nodes) != len(original.Nodes) {
+		t.Fatalf("nodes = %d, want %d", len(got.Nodes), len(original.Nodes))
+	}
+	for i, want := range original.Nodes {
+		node := got.Nodes[i]
+		if node.ID != want.ID || node.Type != want.Type || node.Name != want.Name {
+			t.Errorf("node %d = (%s, %s, %s), want (%s, %s, %s)", i, node.ID, node.Type, node.Name, want.ID, want.Type, want.Name)
+		}
+		if topology.StringProperty(node.Properties, mridProperty, "") == "" {
+			t.Errorf("node %s: mrid property not restored", node.ID)
+		}
+	}
+	if len(got.Lines) != len(original.Lines) {
+		t.Fatalf("lines = %d, want %d", len(got.Lines), len(original.Lines))
+	}
+	for i, want := range original.Lines {
+		line := got.Lines[i]
+		if line.ID != want.ID || line.FromNodeID != want.FromNodeID || line.ToNodeID != want.ToNodeID {
+			t.Errorf("line %d = %s (%s -> %s), want %s (%s -> %s)", i, line.ID, line.FromNodeID, line.ToNodeID, want.ID, want.FromNodeID, want.ToNodeID)
+		}
+	}
+}
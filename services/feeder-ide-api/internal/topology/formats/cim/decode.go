@@ -0,0 +1,527 @@
+package cim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// 開關類別對應的 SwitchProperties.Type
+var switchTypes = map[string]string{
+	"Breaker":         "breaker",
+	"Recloser":        "recloser",
+	"Sectionaliser":   "sectionalizer",
+	"LoadBreakSwitch": "sectionalizer",
+	"Disconnector":    "sectionalizer",
+	"Switch":          "sectionalizer",
+	"Fuse":            "fuse",
+}
+
+// PowerElectronicsUnit 類別對應的 DER 類型
+var derTypes = map[string]string{
+	"PhotoVoltaicUnit":         "pv",
+	"BatteryUnit":              "battery",
+	"PowerElectronicsWindUnit": "wind",
+}
+
+// 直接轉換或僅用於輔助判斷的類別；其他類別會被忽略並列入警告
+var supportedClasses = map[string]bool{
+	"FullModel":                  true,
+	"Feeder":                     true,
+	"Substation":                 true,
+	"VoltageLevel":               true,
+	"Line":                       true,
+	"GeographicalRegion":         true,
+	"SubGeographicalRegion":      true,
+	"BaseVoltage":                true,
+	"ConnectivityNode":           true,
+	"TopologicalNode":            true,
+	"Terminal":                   true,
+	"EnergySource":               true,
+	"ExternalNetworkInjection":   true,
+	"ACLineSegment":              true,
+	"Jumper":                     true,
+	"PowerTransformer":           true,
+	"PowerTransformerEnd":        true,
+	"EnergyConsumer":             true,
+	"ConformLoad":                true,
+	"NonConformLoad":             true,
+	"PowerElectronicsConnection": true,
+	"Diagram":                    true,
+	"DiagramObject":              true,
+	"DiagramObjectPoint":         true,
+}
+
+// Decode 解析 CIM RDF/XML 並轉換為拓樸
+// 含 ide: 擴充內容的檔案（本系統匯出）以擴充內容還原；其他檔案依 CIM 類別轉換
+func (Codec) Decode(r io.Reader) (*formats.DecodeResult, error) {
+	objects, err := readRDF(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objects {
+		if obj.Ext[extNode] != "" || obj.Ext[extLine] != "" {
+			return decodeExtension(objects)
+		}
+	}
+
+	d := newDecoder(objects)
+	if err := d.build(); err != nil {
+		return nil, err
+	}
+	return &formats.DecodeResult{
+		Topology: d.Finish(d.coords),
+		Warnings: d.Warnings(),
+	}, nil
+}
+
+// decodeExtension 由 ide: 擴充內容無損還原拓樸
+func decodeExtension(objects []*object) (*formats.DecodeResult, error) {
+	t := &topology.Topology{
+		Nodes: []topology.Node{},
+		Lines: []topology.Line{},
+	}
+	ignored := map[string]int{}
+
+	for _, obj := range objects {
+		if data := obj.Ext[extModel]; data != "" {
+			var info modelInfo
+			if err := json.Unmarshal([]byte(data), &info); err != nil {
+				return nil, fmt.Errorf("invalid %s extension: %w", extModel, err)
+			}
+			t.Name, t.Description, t.ProfileType = info.Name, info.Description, info.ProfileType
+		}
+		if data := obj.Ext[extNode]; data != "" {
+			var node topology.Node
+			if err := json.Unmarshal([]byte(data), &node); err != nil {
+				return nil, fmt.Errorf("%s %s: invalid %s extension: %w", obj.Class, obj.ID, extNode, err)
+			}
+			node.Properties = withMRID(node.Properties, obj)
+			t.Nodes = append(t.Nodes, node)
+		}
+		if data := obj.Ext[extLine]; data != "" {
+			var line topology.Line
+			if err := json.Unmarshal([]byte(data), &line); err != nil {
+				return nil, fmt.Errorf("%s %s: invalid %s extension: %w", obj.Class, obj.ID, extLine, err)
+			}
+			line.Properties = withMRID(line.Properties, obj)
+			t.Lines = append(t.Lines, line)
+		}
+		if !supportedClasses[obj.Class] && derTypes[obj.Class] == "" && switchTypes[obj.Class] == "" {
+			ignored[obj.Class]++
+		}
+	}
+
+	return &formats.DecodeResult{Topology: t, Warnings: ignoredWarnings(ignored)}, nil
+}
+
+// withMRID 將擴充內容所在物件的 mRID 寫回 Properties["mrid"]，
+// 匯入後的拓樸 ID 與原本不同，再次匯出時仍沿用原本的 mRID
+func withMRID(props map[string]interface{}, obj *object) map[string]interface{} {
+	if topology.StringProperty(props, mridProperty, "") != "" {
+		return props
+	}
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	props[mridProperty] = obj.mRID()
+	return props
+}
+
+// decoder 將一般 CIM 模型轉換為拓樸
+type decoder struct {
+	*formats.Builder
+	objects   []*object
+	byID      map[string]*object
+	terminals map[string][]*object // 設備 ID -> 端點（依 sequenceNumber 排序）
+	nodeOf    map[string]string    // CIM 物件 ID -> 拓樸節點 ID（用於座標）
+	coords    map[string]topology.Position
+}
+
+func newDecoder(objects []*object) *decoder {
+	d := &decoder{
+		Builder:   formats.NewBuilder(nil),
+		objects:   objects,
+		byID:      map[string]*object{},
+		terminals: map[string][]*object{},
+		nodeOf:    map[string]string{},
+		coords:    map[string]topology.Position{},
+	}
+	for _, obj := range objects {
+		if obj.ID != "" {
+			d.byID[obj.ID] = obj
+		}
+	}
+	for _, obj := range objects {
+		if obj.Class == "Terminal" {
+			if equipment := obj.Refs["Terminal.ConductingEquipment"]; equipment != "" {
+				d.terminals[equipment] = append(d.terminals[equipment], obj)
+			}
+		}
+	}
+	for _, terminals := range d.terminals {
+		sort.SliceStable(terminals, func(i, j int) bool {
+			return sequenceNumber(terminals[i]) < sequenceNumber(terminals[j])
+		})
+	}
+	return d
+}
+
+func sequenceNumber(terminal *object) float64 {
+	if terminal.has("ACDCTerminal.sequenceNumber") {
+		return terminal.float("ACDCTerminal.sequenceNumber", 0)
+	}
+	return terminal.float("Terminal.sequenceNumber", 0)
+}
+
+// bus 端點所在的匯流排（ConnectivityNode，沒有時使用 TopologicalNode）
+func (d *decoder) bus(terminal *object) (string, bool) {
+	for _, key := range []string{"Terminal.ConnectivityNode", "Terminal.TopologicalNode"} {
+		if id := terminal.Refs[key]; id != "" {
+			return d.busNode(id), true
+		}
+	}
+	return "", false
+}
+
+// busNode 取得或建立 ConnectivityNode / TopologicalNode 對應的匯流排節點
+func (d *decoder) busNode(id string) string {
+	if !d.HasNode(id) {
+		node := d.Node(id)
+		if target, exists := d.byID[id]; exists {
+			node.Name = target.name()
+			node.Properties[mridProperty] = target.mRID()
+		}
+	}
+	d.nodeOf[id] = id
+	return id
+}
+
+// equipmentBuses 設備各端點的匯流排；端點數不足時回傳 false 並加入警告
+func (d *decoder) equipmentBuses(obj *object, count int) ([]string, bool) {
+	buses := []string{}
+	for _, terminal := range d.terminals[obj.ID] {
+		if bus, ok := d.bus(terminal); ok {
+			buses = append(buses, bus)
+		}
+	}
+	if len(buses) < count {
+		d.Warn("%s %s skipped: %d connected terminal(s) required", obj.Class, obj.name(), count)
+		return nil, false
+	}
+	if len(buses) > count {
+		d.Warn("%s %s: only the first %d terminal(s) are imported", obj.Class, obj.name(), count)
+	}
+	return buses[:count], true
+}
+
+// nominalKV 設備的額定電壓（BaseVoltage）
+func (d *decoder) nominalKV(obj *object) float64 {
+	if base, exists := d.byID[obj.Refs["ConductingEquipment.BaseVoltage"]]; exists {
+		return base.float("BaseVoltage.nominalVoltage", 0)
+	}
+	return 0
+}
+
+func (d *decoder) build() error {
+	byClass := map[string][]*object{}
+	ignored := map[string]int{}
+	for _, obj := range d.objects {
+		byClass[obj.Class] = append(byClass[obj.Class], obj)
+		if !supportedClasses[obj.Class] && derTypes[obj.Class] == "" && switchTypes[obj.Class] == "" {
+			ignored[obj.Class]++
+		}
+	}
+
+	for _, feeder := range byClass["Feeder"] {
+		d.Topology().Name = feeder.text("IdentifiedObject.name")
+		break
+	}
+	if d.Topology().Name == "" {
+		for _, model := range byClass["FullModel"] {
+			d.Topology().Name = model.text("Model.description")
+		}
+	}
+
+	// 依文件順序建立匯流排，讓節點順序與檔案一致
+	for _, obj := range d.objects {
+		if obj.Class == "ConnectivityNode" {
+			d.busNode(obj.ID)
+		}
+	}
+
+	sources := append(byClass["EnergySource"], byClass["ExternalNetworkInjection"]...)
+	for i, source := range sources {
+		if i > 0 {
+			d.Warn("%d additional source(s) ignored, only %s is imported as the feeder source", len(sources)-1, sources[0].name())
+			break
+		}
+		buses, ok := d.equipmentBuses(source, 1)
+		if !ok {
+			continue
+		}
+		kv := source.float("EnergySource.nominalVoltage", 0)
+		if kv <= 0 {
+			kv = d.nominalKV(source)
+		}
+		d.SetSource(buses[0], kv)
+	}
+
+	for _, line := range byClass["ACLineSegment"] {
+		d.line(line)
+	}
+	for _, obj := range byClass["Jumper"] {
+		d.connector(obj)
+	}
+	for _, obj := range d.objects {
+		if _, isSwitch := switchTypes[obj.Class]; isSwitch {
+			d.switchDevice(obj)
+		}
+	}
+	for _, transformer := range byClass["PowerTransformer"] {
+		d.transformer(transformer, byClass["PowerTransformerEnd"])
+	}
+	for _, class := range []string{"EnergyConsumer", "ConformLoad", "NonConformLoad"} {
+		for _, load := range byClass[class] {
+			d.load(load)
+		}
+	}
+	for _, pec := range byClass["PowerElectronicsConnection"] {
+		d.der(pec)
+	}
+
+	if len(d.Topology().Nodes) == 0 {
+		return ErrNoNetwork
+	}
+
+	d.diagram(byClass["DiagramObject"], byClass["DiagramObjectPoint"])
+	for _, warning := range ignoredWarnings(ignored) {
+		d.Warn("%s", warning)
+	}
+	return nil
+}
+
+func (d *decoder) line(obj *object) {
+	buses, ok := d.equipmentBuses(obj, 2)
+	if !ok {
+		return
+	}
+
+	props := map[string]interface{}{mridProperty: obj.mRID()}
+	length := obj.float("Conductor.length", 0)
+	props["length_km"] = length
+	if length > 0 {
+		if obj.has("ACLineSegment.r") {
+			props["r_ohm_per_km"] = obj.float("ACLineSegment.r", 0) / length
+		}
+		if obj.has("ACLineSegment.x") {
+			props["x_ohm_per_km"] = obj.float("ACLineSegment.x", 0) / length
+		}
+	}
+
+	d.AddLine(topology.Line{
+		ID:         obj.mRID(),
+		FromNodeID: buses[0],
+		ToNodeID:   buses[1],
+		Name:       obj.name(),
+		Properties: props,
+	})
+}
+
+// connector Jumper 以零長度線路連接
+func (d *decoder) connector(obj *object) {
+	buses, ok := d.equipmentBuses(obj, 2)
+	if !ok {
+		return
+	}
+	d.AddLine(topology.Line{
+		ID:         obj.mRID(),
+		FromNodeID: buses[0],
+		ToNodeID:   buses[1],
+		Name:       obj.name(),
+		Properties: map[string]interface{}{mridProperty: obj.mRID(), "length_km": 0.0},
+	})
+}
+
+func (d *decoder) switchDevice(obj *object) {
+	buses, ok := d.equipmentBuses(obj, 2)
+	if !ok {
+		return
+	}
+
+	open := obj.text("Switch.normalOpen") == "true"
+	if obj.has("Switch.open") {
+		open = obj.text("Switch.open") == "true"
+	}
+	switchType := switchTypes[obj.Class]
+
+	node := d.AddDevice(obj.mRID(), buses[0], buses[1], topology.NodeTypeSwitch)
+	node.Name = obj.name()
+	node.Properties[mridProperty] = obj.mRID()
+	node.Properties["type"] = switchType
+	node.Properties["is_closed"] = !open
+	node.Properties["is_automated"] = switchType != "sectionalizer" && switchType != "fuse"
+	if rating := obj.float("Switch.ratedCurrent", 0); switchType == "fuse" && rating > 0 {
+		node.Properties["protection"] = map[string]interface{}{"curve": "fuse", "fuse_rating_a": rating}
+	}
+	d.nodeOf[obj.ID] = node.ID
+}
+
+func (d *decoder) transformer(obj *object, allEnds []*object) {
+	ends := []*object{}
+	for _, end := range allEnds {
+		if end.Refs["PowerTransformerEnd.PowerTransformer"] == obj.ID {
+			ends = append(ends, end)
+		}
+	}
+	sort.SliceStable(ends, func(i, j int) bool {
+		return ends[i].float("TransformerEnd.endNumber", 0) < ends[j].float("TransformerEnd.endNumber", 0)
+	})
+	if len(ends) < 2 {
+		d.Warn("PowerTransformer %s skipped: two PowerTransformerEnd objects required", obj.name())
+		return
+	}
+	if len(ends) > 2 {
+		d.Warn("PowerTransformer %s: only the first two windings are imported", obj.name())
+	}
+
+	buses := []string{}
+	for _, end := range ends[:2] {
+		terminal, exists := d.byID[end.Refs["TransformerEnd.Terminal"]]
+		if !exists {
+			break
+		}
+		if bus, ok := d.bus(terminal); ok {
+			buses = append(buses, bus)
+		}
+	}
+	if len(buses) < 2 {
+		d.Warn("PowerTransformer %s skipped: both windings must be connected", obj.name())
+		return
+	}
+
+	primary := ends[0].float("PowerTransformerEnd.ratedU", 0)
+	secondary := ends[1].float("PowerTransformerEnd.ratedU", 0)
+	mva := ends[0].float("PowerTransformerEnd.ratedS", 0)
+
+	node := d.AddDevice(obj.mRID(), buses[0], buses[1], topology.NodeTypeTransformer)
+	node.Name = obj.name()
+	node.Properties[mridProperty] = obj.mRID()
+	node.Properties["primary_voltage"] = primary
+	node.Properties["secondary_voltage"] = secondary
+	node.Properties["rated_voltage_kv"] = secondary
+	if mva > 0 {
+		node.Properties["rated_capacity_kva"] = mva * 1000
+	}
+
+	// 繞組阻抗（歐姆）換算為以額定容量為基準的百分比
+	r := ends[0].float("PowerTransformerEnd.r", 0) + ends[1].float("PowerTransformerEnd.r", 0)*ratio(primary, secondary)
+	x := ends[0].float("PowerTransformerEnd.x", 0) + ends[1].float("PowerTransformerEnd.x", 0)*ratio(primary, secondary)
+	if mva > 0 && primary > 0 && (r != 0 || x != 0) {
+		node.Properties["impedance_percent"] = math.Hypot(r, x) / (primary * primary / mva) * 100
+		if r > 0 {
+			node.Properties["x_r_ratio"] = x / r
+		}
+	}
+	d.nodeOf[obj.ID] = node.ID
+}
+
+// ratio 二次側阻抗折算到一次側的倍數
+func ratio(primary, secondary float64) float64 {
+	if secondary <= 0 {
+		return 1
+	}
+	return (primary / secondary) * (primary / secondary)
+}
+
+func (d *decoder) load(obj *object) {
+	buses, ok := d.equipmentBuses(obj, 1)
+	if !ok {
+		return
+	}
+	node := d.Node(buses[0])
+	node.Properties["load_kw"] = topology.FloatProperty(node.Properties, "load_kw", 0) + obj.float("EnergyConsumer.p", 0)*1000
+	if q := obj.float("EnergyConsumer.q", 0); q != 0 || node.Properties["load_kvar"] != nil {
+		node.Properties["load_kvar"] = topology.FloatProperty(node.Properties, "load_kvar", 0) + q*1000
+	}
+}
+
+func (d *decoder) der(obj *object) {
+	buses, ok := d.equipmentBuses(obj, 1)
+	if !ok {
+		return
+	}
+
+	derType, ratedMW := "", obj.float("PowerElectronicsConnection.ratedS", 0)
+	for _, unit := range d.objects {
+		if unit.Refs["PowerElectronicsUnit.PowerElectronicsConnection"] != obj.ID || derTypes[unit.Class] == "" {
+			continue
+		}
+		if derType != "" {
+			d.Warn("PowerElectronicsConnection %s: only the first PowerElectronicsUnit is imported", obj.name())
+			break
+		}
+		derType = derTypes[unit.Class]
+		if ratedMW <= 0 {
+			ratedMW = unit.float("PowerElectronicsUnit.maxP", 0)
+		}
+	}
+	if derType == "" {
+		d.Warn("PowerElectronicsConnection %s has no supported unit, imported as pv", obj.name())
+		derType = "pv"
+	}
+
+	node := d.EquipmentNode(buses[0], obj.mRID(), topology.NodeTypeDER)
+	node.Name = obj.name()
+	node.Properties[mridProperty] = obj.mRID()
+	node.Properties["type"] = derType
+	node.Properties["rated_power_kw"] = ratedMW * 1000
+	// CIM 以負載方向為正，發電為負值
+	if obj.has("PowerElectronicsConnection.p") {
+		node.Properties["output_kw"] = -obj.float("PowerElectronicsConnection.p", 0) * 1000
+	}
+	d.nodeOf[obj.ID] = node.ID
+}
+
+// diagram 套用 DiagramObject 的第一個座標點
+func (d *decoder) diagram(diagramObjects, points []*object) {
+	first := map[string]*object{}
+	for _, point := range points {
+		id := point.Refs["DiagramObjectPoint.DiagramObject"]
+		if current, exists := first[id]; !exists || point.float("DiagramObjectPoint.sequenceNumber", 0) < current.float("DiagramObjectPoint.sequenceNumber", 0) {
+			first[id] = point
+		}
+	}
+	for _, diagramObject := range diagramObjects {
+		nodeID, exists := d.nodeOf[diagramObject.Refs["DiagramObject.IdentifiedObject"]]
+		point := first[diagramObject.ID]
+		if !exists || point == nil {
+			continue
+		}
+		d.coords[nodeID] = topology.Position{
+			X: point.float("DiagramObjectPoint.xPosition", 0),
+			Y: point.float("DiagramObjectPoint.yPosition", 0),
+		}
+	}
+}
+
+// ignoredWarnings 未支援類別的彙總警告（依類別名稱排序）
+func ignoredWarnings(ignored map[string]int) []string {
+	classes := make([]string, 0, len(ignored))
+	for class := range ignored {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	warnings := []string{}
+	for _, class := range classes {
+		warnings = append(warnings, fmt.Sprintf("%d %s object(s) ignored (unsupported class)", ignored[class], class))
+	}
+	return warnings
+}
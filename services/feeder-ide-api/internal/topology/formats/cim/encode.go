@@ -0,0 +1,456 @@
+package cim
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+	"github.com/google/uuid"
+)
+
+// 擴充屬性名稱
+const (
+	extModel = "Model.topology" // 拓樸名稱、描述與場景類型（JSON）
+	extNode  = "Element.node"   // 完整節點內容（JSON）
+	extLine  = "Element.line"   // 完整線路內容（JSON）
+)
+
+// modelInfo ide:Model.topology 的內容
+type modelInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ProfileType string `json:"profile_type,omitempty"`
+}
+
+// switchClasses 開關類型對應的 CIM 類別
+var switchClasses = map[string]string{
+	"breaker":       "Breaker",
+	"recloser":      "Recloser",
+	"sectionalizer": "Sectionaliser",
+	"fuse":          "Fuse",
+}
+
+// derUnitClasses DER 類型對應的 PowerElectronicsUnit 類別
+var derUnitClasses = map[string]string{
+	"pv":      "PhotoVoltaicUnit",
+	"battery": "BatteryUnit",
+	"wind":    "PowerElectronicsWindUnit",
+}
+
+// Encode 將拓樸輸出為 CIM RDF/XML
+func (Codec) Encode(w io.Writer, t *topology.Topology) error {
+	e := newEncoder(t)
+	out := newRDFWriter(w)
+	e.write(out)
+	return out.close()
+}
+
+// encoder 輸出 RDF/XML 所需的 mRID 與電壓對應
+type encoder struct {
+	topo     *topology.Topology
+	inputs   formats.InputSides
+	baseKV   map[string]float64 // 節點 ID -> 電壓等級
+	cn       map[string]string  // 節點 ID -> ConnectivityNode mRID
+	inputCN  map[string]string  // 雙端元件節點 ID -> 上游端 ConnectivityNode mRID
+	voltages map[float64]string // 電壓等級 -> BaseVoltage mRID
+	feeder   string
+}
+
+func newEncoder(t *topology.Topology) *encoder {
+	e := &encoder{
+		topo:     t,
+		inputs:   formats.FindInputSides(t),
+		baseKV:   map[string]float64{},
+		cn:       map[string]string{},
+		inputCN:  map[string]string{},
+		voltages: map[float64]string{},
+		feeder:   modelMRID(t.ID, "feeder"),
+	}
+
+	// 電壓等級與開關狀態無關，以全部開關閉合的網路計算；拓樸無法建立網路時不輸出 BaseVoltage
+	closed := t.Clone()
+	for i, node := range closed.Nodes {
+		if node.Type == topology.NodeTypeSwitch && node.Properties != nil {
+			closed.Nodes[i].Properties["is_closed"] = true
+		}
+	}
+	if network, err := powerflow.BuildNetwork(closed, powerflow.Options{}); err == nil {
+		for _, bus := range network.Buses {
+			if bus.BaseKV > 0 {
+				e.baseKV[bus.NodeID] = bus.BaseKV
+			}
+		}
+	}
+
+	sourceFound := false
+	for _, node := range t.Nodes {
+		mrid := e.mrid(node.ID, node.Properties)
+		if node.Type == topology.NodeTypeBus {
+			e.cn[node.ID] = mrid
+		} else {
+			e.cn[node.ID] = derivedMRID(mrid, "cn")
+		}
+		if formats.IsTwoTerminal(node) {
+			e.inputCN[node.ID] = derivedMRID(mrid, "cn_in")
+		}
+		// 饋線以電源節點識別，匯出後再匯入（拓樸 ID 不同）仍得到相同的 Feeder mRID
+		if topology.IsSource(node) && !sourceFound {
+			e.feeder = derivedMRID(mrid, "feeder")
+			sourceFound = true
+		}
+	}
+	return e
+}
+
+// modelMRID 以拓樸 ID 產生固定的 mRID
+func modelMRID(topologyID, kind string) string {
+	return uuid.NewSHA1(mridNamespace, []byte(topologyID+"/"+kind)).String()
+}
+
+// mrid 元素主要物件的 mRID：優先使用 Properties["mrid"]
+func (e *encoder) mrid(elementID string, props map[string]interface{}) string {
+	if mrid := topology.StringProperty(props, mridProperty, ""); mrid != "" && ncName.MatchString(mrid) {
+		return mrid
+	}
+	return uuid.NewSHA1(mridNamespace, []byte(e.topo.ID+"/"+elementID+"/")).String()
+}
+
+// derivedMRID 元素附屬物件（Terminal、End 等）的 mRID，由主要物件的 mRID 產生，
+// 因此只要主要物件的 mRID 保留，附屬物件的 mRID 也不會改變
+func derivedMRID(mrid, kind string) string {
+	return uuid.NewSHA1(mridNamespace, []byte(mrid+"/"+kind)).String()
+}
+
+// lineCN 線路端點的 ConnectivityNode；接到雙端元件上游端時使用上游端節點
+func (e *encoder) lineCN(line topology.Line, nodeID string) string {
+	if e.inputs.Has(line.ID, nodeID) {
+		return e.inputCN[nodeID]
+	}
+	return e.cn[nodeID]
+}
+
+func (e *encoder) write(out *rdfWriter) {
+	t := e.topo
+
+	info, _ := json.Marshal(modelInfo{Name: t.Name, Description: t.Description, ProfileType: t.ProfileType})
+	out.raw("  <md:FullModel rdf:about=\"urn:uuid:%s\">\n", modelMRID(t.ID, "model"))
+	out.raw("    <md:Model.created>%s</md:Model.created>\n", time.Now().UTC().Format(time.RFC3339))
+	out.raw("    <md:Model.description>%s</md:Model.description>\n", escape(t.Name))
+	out.raw("    <md:Model.modelingAuthoritySet>http://feeder-platform.org/ide</md:Model.modelingAuthoritySet>\n")
+	out.raw("    <md:Model.profile>http://entsoe.eu/CIM/EquipmentCore/3/1</md:Model.profile>\n")
+	out.ext(extModel, string(info))
+	out.raw("  </md:FullModel>\n")
+
+	out.begin("Feeder", e.feeder)
+	out.text("IdentifiedObject.mRID", e.feeder)
+	out.text("IdentifiedObject.name", t.Name)
+	out.end("Feeder")
+
+	e.writeBaseVoltages(out)
+
+	// 依節點順序輸出，匯入時擴充內容的順序即為原本的節點順序
+	for _, node := range t.Nodes {
+		e.node(out, node)
+	}
+	for _, line := range t.Lines {
+		e.line(out, line)
+	}
+}
+
+// writeBaseVoltages 輸出所有電壓等級（由高到低）
+func (e *encoder) writeBaseVoltages(out *rdfWriter) {
+	levels := []float64{}
+	for _, node := range e.topo.Nodes {
+		for _, kv := range []float64{e.baseKV[node.ID], e.primaryKV(node)} {
+			if _, exists := e.voltages[kv]; kv > 0 && !exists {
+				// 電壓等級為共用的參考資料，mRID 只依電壓產生
+				e.voltages[kv] = uuid.NewSHA1(mridNamespace, []byte("basevoltage/"+strconv.FormatFloat(kv, 'g', -1, 64))).String()
+				levels = append(levels, kv)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(levels)))
+
+	for _, kv := range levels {
+		out.begin("BaseVoltage", e.voltages[kv])
+		out.text("IdentifiedObject.mRID", e.voltages[kv])
+		out.text("IdentifiedObject.name", strconv.FormatFloat(kv, 'g', -1, 64)+" kV")
+		out.float("BaseVoltage.nominalVoltage", kv)
+		out.end("BaseVoltage")
+	}
+}
+
+// connectivityNode 輸出 ConnectivityNode 與對應的 TopologicalNode；payload 為 bus 節點的擴充內容
+func (e *encoder) connectivityNode(out *rdfWriter, mrid, name string, kv float64, payload string) {
+	tn := uuid.NewSHA1(mridNamespace, []byte(mrid+"/tn")).String()
+
+	out.begin("TopologicalNode", tn)
+	out.text("IdentifiedObject.mRID", tn)
+	out.text("IdentifiedObject.name", name)
+	if base, exists := e.voltages[kv]; exists {
+		out.ref("TopologicalNode.BaseVoltage", base)
+	}
+	out.end("TopologicalNode")
+
+	out.begin("ConnectivityNode", mrid)
+	out.text("IdentifiedObject.mRID", mrid)
+	out.text("IdentifiedObject.name", name)
+	out.ref("ConnectivityNode.ConnectivityNodeContainer", e.feeder)
+	out.ref("ConnectivityNode.TopologicalNode", tn)
+	if payload != "" {
+		out.ext(extNode, payload)
+	}
+	out.end("ConnectivityNode")
+}
+
+// terminal 輸出設備端點
+func (e *encoder) terminal(out *rdfWriter, mrid, equipment, cn string, sequence int) {
+	out.begin("Terminal", mrid)
+	out.text("IdentifiedObject.mRID", mrid)
+	out.text("ACDCTerminal.sequenceNumber", strconv.Itoa(sequence))
+	out.enum("Terminal.phases", "PhaseCode.ABC")
+	out.ref("Terminal.ConductingEquipment", equipment)
+	out.ref("Terminal.ConnectivityNode", cn)
+	out.end("Terminal")
+}
+
+// equipment 輸出設備共通屬性
+func (e *encoder) equipment(out *rdfWriter, mrid, name string, kv float64) {
+	out.text("IdentifiedObject.mRID", mrid)
+	out.text("IdentifiedObject.name", name)
+	out.ref("Equipment.EquipmentContainer", e.feeder)
+	if base, exists := e.voltages[kv]; exists {
+		out.ref("ConductingEquipment.BaseVoltage", base)
+	}
+}
+
+// node 輸出節點的主要設備、負載與電源
+func (e *encoder) node(out *rdfWriter, node topology.Node) {
+	payload, _ := json.Marshal(node)
+	mrid := e.mrid(node.ID, node.Properties)
+	kv := e.baseKV[node.ID]
+	cn := e.cn[node.ID]
+
+	// bus 的主要物件即為 ConnectivityNode，完整節點內容放在 ConnectivityNode 上
+	if node.Type == topology.NodeTypeBus {
+		e.connectivityNode(out, cn, node.Name, kv, string(payload))
+	} else {
+		e.connectivityNode(out, cn, node.Name, kv, "")
+	}
+	if input, exists := e.inputCN[node.ID]; exists {
+		e.connectivityNode(out, input, node.Name+" input", e.primaryKV(node), "")
+	}
+
+	switch node.Type {
+	case topology.NodeTypeTransformer:
+		e.transformer(out, node, mrid, string(payload))
+	case topology.NodeTypeSwitch:
+		var props topology.SwitchProperties
+		props.IsClosed = true
+		_ = topology.DecodeProperties(node.Properties, &props)
+		class, exists := switchClasses[props.Type]
+		if !exists {
+			class = "Switch"
+		}
+		out.begin(class, mrid)
+		e.equipment(out, mrid, node.Name, kv)
+		out.bool("Switch.normalOpen", !props.IsClosed)
+		out.bool("Switch.open", !props.IsClosed)
+		out.ext(extNode, string(payload))
+		out.end(class)
+		e.terminal(out, derivedMRID(mrid, "t1"), mrid, e.inputCN[node.ID], 1)
+		e.terminal(out, derivedMRID(mrid, "t2"), mrid, cn, 2)
+	case topology.NodeTypeDER:
+		e.der(out, node, mrid, string(payload))
+	case topology.NodeTypeEVCharger:
+		demand := powerflow.NodeDemand(node)
+		out.begin("EnergyConsumer", mrid)
+		e.equipment(out, mrid, node.Name, kv)
+		out.float("EnergyConsumer.p", demand.LoadKW/1000)
+		out.float("EnergyConsumer.q", demand.LoadKVAR/1000)
+		out.ext(extNode, string(payload))
+		out.end("EnergyConsumer")
+		e.terminal(out, derivedMRID(mrid, "t1"), mrid, cn, 1)
+	}
+
+	if node.Type != topology.NodeTypeEVCharger {
+		demand := powerflow.NodeDemand(node)
+		if demand.LoadKW != 0 || demand.LoadKVAR != 0 {
+			load := derivedMRID(mrid, "load")
+			out.begin("EnergyConsumer", load)
+			e.equipment(out, load, node.Name+" load", kv)
+			out.float("EnergyConsumer.p", demand.LoadKW/1000)
+			out.float("EnergyConsumer.q", demand.LoadKVAR/1000)
+			out.end("EnergyConsumer")
+			e.terminal(out, derivedMRID(mrid, "load_t1"), load, cn, 1)
+		}
+	}
+
+	if topology.IsSource(node) {
+		// 電源為變壓器時，EnergySource 接在一次側
+		sourceCN, sourceKV := cn, kv
+		if node.Type == topology.NodeTypeTransformer {
+			sourceCN, sourceKV = e.inputCN[node.ID], e.primaryKV(node)
+		}
+		if voltage := topology.FloatProperty(node.Properties, "voltage_kv", 0); voltage > 0 && node.Type != topology.NodeTypeTransformer {
+			sourceKV = voltage
+		}
+		source := derivedMRID(mrid, "source")
+		out.begin("EnergySource", source)
+		e.equipment(out, source, node.Name+" source", sourceKV)
+		out.float("EnergySource.nominalVoltage", sourceKV)
+		out.float("EnergySource.voltageMagnitude", sourceKV)
+		out.end("EnergySource")
+		e.terminal(out, derivedMRID(mrid, "source_t1"), source, sourceCN, 1)
+	}
+}
+
+func (e *encoder) transformer(out *rdfWriter, node topology.Node, mrid, payload string) {
+	var props topology.TransformerProperties
+	_ = topology.DecodeProperties(node.Properties, &props)
+
+	secondary := e.baseKV[node.ID]
+	if props.SecondaryVoltage > 0 {
+		secondary = props.SecondaryVoltage
+	}
+	primary := e.primaryKV(node)
+	kva := props.RatedCapacityKVA
+	if kva <= 0 {
+		kva = 1000
+	}
+	mva := kva / 1000
+
+	// 短路阻抗換算為一次側歐姆值
+	zPercent, xr := powerflow.TransformerImpedance(props)
+	r, x := 0.0, 0.0
+	if primary > 0 {
+		z := zPercent / 100 * primary * primary / mva
+		r = z / math.Sqrt(1+xr*xr)
+		x = r * xr
+	}
+
+	out.begin("PowerTransformer", mrid)
+	e.equipment(out, mrid, node.Name, secondary)
+	out.ext(extNode, payload)
+	out.end("PowerTransformer")
+
+	ends := []struct {
+		kind string
+		cn   string
+		kv   float64
+		r, x float64
+	}{
+		{"end1", e.inputCN[node.ID], primary, r, x},
+		{"end2", e.cn[node.ID], secondary, 0, 0},
+	}
+	for i, end := range ends {
+		endID := derivedMRID(mrid, end.kind)
+		terminal := derivedMRID(mrid, "t"+strconv.Itoa(i+1))
+		e.terminal(out, terminal, mrid, end.cn, i+1)
+
+		out.begin("PowerTransformerEnd", endID)
+		out.text("IdentifiedObject.mRID", endID)
+		out.text("IdentifiedObject.name", node.Name+" "+end.kind)
+		out.text("TransformerEnd.endNumber", strconv.Itoa(i+1))
+		out.ref("TransformerEnd.Terminal", terminal)
+		if base, exists := e.voltages[end.kv]; exists {
+			out.ref("TransformerEnd.BaseVoltage", base)
+		}
+		out.ref("PowerTransformerEnd.PowerTransformer", mrid)
+		out.float("PowerTransformerEnd.ratedU", end.kv)
+		out.float("PowerTransformerEnd.ratedS", mva)
+		out.float("PowerTransformerEnd.r", end.r)
+		out.float("PowerTransformerEnd.x", end.x)
+		if i == 0 {
+			out.enum("PowerTransformerEnd.connectionKind", "WindingConnection.D")
+		} else {
+			out.enum("PowerTransformerEnd.connectionKind", "WindingConnection.Yn")
+		}
+		out.end("PowerTransformerEnd")
+	}
+}
+
+func (e *encoder) der(out *rdfWriter, node topology.Node, mrid, payload string) {
+	var props topology.DERProperties
+	_ = topology.DecodeProperties(node.Properties, &props)
+	demand := powerflow.NodeDemand(node)
+	kv := e.baseKV[node.ID]
+
+	// CIM 以負載方向為正，發電為負值
+	out.begin("PowerElectronicsConnection", mrid)
+	e.equipment(out, mrid, node.Name, kv)
+	out.float("PowerElectronicsConnection.ratedS", props.RatedPowerKW/1000)
+	if kv > 0 {
+		out.float("PowerElectronicsConnection.ratedU", kv)
+	}
+	out.float("PowerElectronicsConnection.p", -demand.GenerationKW/1000)
+	out.float("PowerElectronicsConnection.q", 0)
+	out.ext(extNode, payload)
+	out.end("PowerElectronicsConnection")
+	e.terminal(out, derivedMRID(mrid, "t1"), mrid, e.cn[node.ID], 1)
+
+	if class, exists := derUnitClasses[props.Type]; exists {
+		unit := derivedMRID(mrid, "unit")
+		out.begin(class, unit)
+		out.text("IdentifiedObject.mRID", unit)
+		out.text("IdentifiedObject.name", node.Name)
+		out.ref("PowerElectronicsUnit.PowerElectronicsConnection", mrid)
+		out.float("PowerElectronicsUnit.maxP", props.RatedPowerKW/1000)
+		out.float("PowerElectronicsUnit.minP", 0)
+		out.end(class)
+	}
+}
+
+func (e *encoder) line(out *rdfWriter, line topology.Line) {
+	payload, _ := json.Marshal(line)
+	mrid := e.mrid(line.ID, line.Properties)
+	params := powerflow.LineParameters(e.topo, line)
+
+	out.begin("ACLineSegment", mrid)
+	e.equipment(out, mrid, lineName(line), e.baseKV[line.ToNodeID])
+	out.float("Conductor.length", params.LengthKM)
+	out.float("ACLineSegment.r", params.ROhmPerKM*params.LengthKM)
+	out.float("ACLineSegment.x", params.XOhmPerKM*params.LengthKM)
+	out.ext(extLine, string(payload))
+	out.end("ACLineSegment")
+
+	e.terminal(out, derivedMRID(mrid, "t1"), mrid, e.lineCN(line, line.FromNodeID), 1)
+	e.terminal(out, derivedMRID(mrid, "t2"), mrid, e.lineCN(line, line.ToNodeID), 2)
+}
+
+// primaryKV 雙端元件上游端電壓：變壓器優先使用 primary_voltage，其次為上游匯流排電壓
+func (e *encoder) primaryKV(node topology.Node) float64 {
+	if !formats.IsTwoTerminal(node) {
+		return 0
+	}
+	if node.Type == topology.NodeTypeTransformer {
+		if primary := topology.FloatProperty(node.Properties, "primary_voltage", 0); primary > 0 {
+			return primary
+		}
+	}
+	for _, line := range e.topo.Lines {
+		if e.inputs.Has(line.ID, node.ID) {
+			other := line.FromNodeID
+			if other == node.ID {
+				other = line.ToNodeID
+			}
+			if kv := e.baseKV[other]; kv > 0 {
+				return kv
+			}
+		}
+	}
+	return e.baseKV[node.ID]
+}
+
+func lineName(line topology.Line) string {
+	if line.Name != "" {
+		return line.Name
+	}
+	return line.ID
+}
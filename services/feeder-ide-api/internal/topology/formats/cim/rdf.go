@@ -0,0 +1,209 @@
+package cim
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// object RDF/XML 中的一個物件
+type object struct {
+	ID    string            // 正規化後的 rdf:ID / rdf:about
+	Class string            // 類別名稱（不含命名空間），例如 ACLineSegment
+	Props map[string]string // 屬性文字值，鍵為 Class.attribute（例如 IdentifiedObject.name）
+	Refs  map[string]string // 參照其他物件，值為正規化後的 ID
+	Ext   map[string]string // ide: 擴充屬性
+}
+
+// text 取得屬性值
+func (o *object) text(key string) string {
+	return o.Props[key]
+}
+
+// float 取得數值屬性，不存在或無法解析時回傳預設值
+func (o *object) float(key string, defaultValue float64) float64 {
+	value, exists := o.Props[key]
+	if !exists {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
+
+// has 判斷屬性是否存在
+func (o *object) has(key string) bool {
+	_, exists := o.Props[key]
+	return exists
+}
+
+// mRID 取得物件的 mRID（優先使用 IdentifiedObject.mRID）
+func (o *object) mRID() string {
+	if mrid := strings.TrimSpace(o.Props["IdentifiedObject.mRID"]); mrid != "" {
+		return mrid
+	}
+	return o.ID
+}
+
+// name 取得物件名稱，沒有時使用 mRID
+func (o *object) name() string {
+	if name := o.Props["IdentifiedObject.name"]; name != "" {
+		return name
+	}
+	return o.mRID()
+}
+
+// normalizeID 移除 "#"、"urn:uuid:" 與 "_" 前綴
+func normalizeID(id string) string {
+	id = strings.TrimPrefix(strings.TrimSpace(id), "#")
+	id = strings.TrimPrefix(id, "urn:uuid:")
+	return strings.TrimPrefix(id, "_")
+}
+
+// readRDF 讀取 RDF/XML 中的所有物件（依文件順序）
+func readRDF(r io.Reader) ([]*object, error) {
+	decoder := xml.NewDecoder(r)
+
+	objects := []*object{}
+	depth := 0
+	var current *object
+	var property xml.StartElement
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RDF/XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 1:
+				if t.Name.Local != "RDF" {
+					return nil, fmt.Errorf("invalid RDF/XML: root element is %s", t.Name.Local)
+				}
+			case 2:
+				current = &object{
+					Class: t.Name.Local,
+					Props: map[string]string{},
+					Refs:  map[string]string{},
+					Ext:   map[string]string{},
+				}
+				for _, attr := range t.Attr {
+					if attr.Name.Space == nsRDF && (attr.Name.Local == "ID" || attr.Name.Local == "about") {
+						current.ID = normalizeID(attr.Value)
+					}
+				}
+			case 3:
+				property = t
+				text.Reset()
+				for _, attr := range t.Attr {
+					if attr.Name.Space == nsRDF && attr.Name.Local == "resource" {
+						current.Refs[t.Name.Local] = normalizeID(attr.Value)
+					}
+				}
+			}
+		case xml.CharData:
+			if depth == 3 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch depth {
+			case 2:
+				objects = append(objects, current)
+				current = nil
+			case 3:
+				if _, isRef := current.Refs[property.Name.Local]; !isRef {
+					value := strings.TrimSpace(text.String())
+					if property.Name.Space == nsIDE {
+						current.Ext[property.Name.Local] = value
+					} else {
+						current.Props[property.Name.Local] = value
+					}
+				}
+			}
+			depth--
+		}
+	}
+
+	return objects, nil
+}
+
+// ncName 合法的 rdf:ID 字元（加上 "_" 前綴後為 NCName）
+var ncName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// rdfWriter 輸出 RDF/XML
+type rdfWriter struct {
+	w *bufio.Writer
+}
+
+func newRDFWriter(w io.Writer) *rdfWriter {
+	out := &rdfWriter{w: bufio.NewWriter(w)}
+	out.w.WriteString(xml.Header)
+	fmt.Fprintf(out.w, "<rdf:RDF xmlns:rdf=%q xmlns:cim=%q xmlns:md=%q xmlns:ide=%q>\n", nsRDF, nsCIM, nsMD, nsIDE)
+	return out
+}
+
+// begin 開始一個 cim: 物件
+func (r *rdfWriter) begin(class, id string) {
+	fmt.Fprintf(r.w, "  <cim:%s rdf:ID=\"_%s\">\n", class, escape(id))
+}
+
+func (r *rdfWriter) end(class string) {
+	fmt.Fprintf(r.w, "  </cim:%s>\n", class)
+}
+
+// text 輸出 cim: 文字屬性
+func (r *rdfWriter) text(key, value string) {
+	fmt.Fprintf(r.w, "    <cim:%s>%s</cim:%s>\n", key, escape(value), key)
+}
+
+func (r *rdfWriter) float(key string, value float64) {
+	r.text(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (r *rdfWriter) bool(key string, value bool) {
+	r.text(key, strconv.FormatBool(value))
+}
+
+// ref 輸出 cim: 參照屬性
+func (r *rdfWriter) ref(key, id string) {
+	fmt.Fprintf(r.w, "    <cim:%s rdf:resource=\"#_%s\"/>\n", key, escape(id))
+}
+
+// enum 輸出列舉值參照（例如 PhaseCode.ABC）
+func (r *rdfWriter) enum(key, value string) {
+	fmt.Fprintf(r.w, "    <cim:%s rdf:resource=\"%s%s\"/>\n", key, nsCIM, escape(value))
+}
+
+// ext 輸出 ide: 擴充屬性
+func (r *rdfWriter) ext(key, value string) {
+	fmt.Fprintf(r.w, "    <ide:%s>%s</ide:%s>\n", key, escape(value), key)
+}
+
+// raw 輸出未包裝的內容（例如 md:FullModel）
+func (r *rdfWriter) raw(format string, args ...interface{}) {
+	fmt.Fprintf(r.w, format, args...)
+}
+
+func (r *rdfWriter) close() error {
+	r.w.WriteString("</rdf:RDF>\n")
+	return r.w.Flush()
+}
+
+func escape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
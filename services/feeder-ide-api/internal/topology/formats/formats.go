@@ -0,0 +1,84 @@
+// Package formats 定義拓樸與外部模擬工具檔案格式之間的轉換介面
+package formats
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/layout"
+)
+
+// ErrUnknownFormat 不支援的檔案格式
+var ErrUnknownFormat = errors.New("unknown format")
+
+// ErrUnsupportedTopology 拓樸無法以該格式表示（例如 OpenDSS 的 Circuit 必須接在電源上）
+var ErrUnsupportedTopology = errors.New("topology cannot be represented in this format")
+
+// Codec 將拓樸編碼為外部格式，或由外部格式解析為拓樸
+type Codec interface {
+	// Name 格式名稱（例如 opendss），用於 ?format= 參數
+	Name() string
+	// ContentType 匯出檔案的 MIME 類型
+	ContentType() string
+	// Extension 匯出檔案的副檔名（含句點）
+	Extension() string
+	Encode(w io.Writer, t *topology.Topology) error
+	Decode(r io.Reader) (*DecodeResult, error)
+}
+
+// DecodeResult 解析結果；Warnings 列出被忽略或近似轉換的內容
+type DecodeResult struct {
+	Topology *topology.Topology `json:"topology"`
+	Warnings []string           `json:"warnings"`
+}
+
+// AutoLayout 為沒有座標的節點產生由電源往下展開的整齊樹排版
+// positioned 中標記的節點保留原位置；其餘節點依與電源的距離分層排列
+func AutoLayout(t *topology.Topology, positioned map[string]bool) {
+	locked := make([]string, 0, len(positioned))
+	for id, ok := range positioned {
+		if ok {
+			locked = append(locked, id)
+		}
+	}
+
+	result, err := layout.Compute(t, layout.Options{Algorithm: layout.AlgorithmTree, LockedNodeIDs: locked})
+	if err != nil {
+		return
+	}
+	result.Apply(t)
+}
+
+// geoLayoutExtent 經緯度投影後較長邊的畫布長度
+const geoLayoutExtent = 1200.0
+
+// GeoLayout 將有經緯度但沒有座標的節點以等距圓柱投影放到畫布上（北方朝上），並標記於 positioned
+// 所有節點位於同一點時無法決定比例，不做任何處理
+func GeoLayout(t *topology.Topology, positioned map[string]bool) {
+	bbox, ok := t.BBox()
+	if !ok {
+		return
+	}
+
+	midLat := (bbox.MinLat + bbox.MaxLat) / 2 * math.Pi / 180
+	width := (bbox.MaxLon - bbox.MinLon) * math.Cos(midLat)
+	height := bbox.MaxLat - bbox.MinLat
+	extent := math.Max(width, height)
+	if extent == 0 {
+		return
+	}
+	scale := geoLayoutExtent / extent
+
+	for i, node := range t.Nodes {
+		if node.Geo == nil || positioned[node.ID] {
+			continue
+		}
+		t.Nodes[i].Position = topology.Position{
+			X: math.Round((node.Geo.Lon-bbox.MinLon)*math.Cos(midLat)*scale*10) / 10,
+			Y: math.Round((bbox.MaxLat-node.Geo.Lat)*scale*10) / 10,
+		}
+		positioned[node.ID] = true
+	}
+}
@@ -0,0 +1,394 @@
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// ErrNoNodes 檔案中沒有任何節點或線路
+var ErrNoNodes = errors.New("no Point or LineString features found")
+
+// Decode 解析 GeoJSON FeatureCollection 並轉換為拓樸
+func (Codec) Decode(r io.Reader) (*formats.DecodeResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var collection featureCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	switch collection.Type {
+	case "FeatureCollection":
+	case "Feature":
+		// 單一 feature 視為只有一個元素的集合
+		var single feature
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+		}
+		collection = featureCollection{Type: "FeatureCollection", Features: []feature{single}}
+	default:
+		return nil, ErrNotFeatureCollection
+	}
+
+	d := &decoder{
+		topo: &topology.Topology{
+			Name:        collection.Name,
+			Description: collection.Description,
+			ProfileType: collection.ProfileType,
+			Nodes:       []topology.Node{},
+			Lines:       []topology.Line{},
+		},
+		nodeIndex:  map[string]int{},
+		lineIDs:    map[string]bool{},
+		positioned: map[string]bool{},
+		ignored:    map[string]int{},
+		warnings:   []string{},
+	}
+
+	// 先建立所有節點，線路才能以 ID 或座標對應到節點
+	for i, f := range collection.Features {
+		if isNode(f) {
+			d.node(i, f)
+		}
+	}
+	for i, f := range collection.Features {
+		if isLine(f) {
+			d.line(i, f)
+		} else if !isNode(f) {
+			geometryType := "null"
+			if f.Geometry != nil {
+				geometryType = f.Geometry.Type
+			}
+			d.ignored[geometryType]++
+		}
+	}
+
+	if len(d.topo.Nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	types := make([]string, 0, len(d.ignored))
+	for geometryType := range d.ignored {
+		types = append(types, geometryType)
+	}
+	sort.Strings(types)
+	for _, geometryType := range types {
+		d.warn("%d %s feature(s) ignored (only Point and LineString are supported)", d.ignored[geometryType], geometryType)
+	}
+
+	formats.GeoLayout(d.topo, d.positioned)
+	if len(d.positioned) < len(d.topo.Nodes) {
+		formats.AutoLayout(d.topo, d.positioned)
+	}
+
+	return &formats.DecodeResult{Topology: d.topo, Warnings: d.warnings}, nil
+}
+
+// decoder 解析過程中的狀態
+type decoder struct {
+	topo       *topology.Topology
+	nodeIndex  map[string]int
+	lineIDs    map[string]bool
+	positioned map[string]bool
+	ignored    map[string]int
+	warnings   []string
+}
+
+func (d *decoder) warn(format string, args ...interface{}) {
+	d.warnings = append(d.warnings, fmt.Sprintf(format, args...))
+}
+
+func element(f feature) string {
+	value, _ := f.Properties["element"].(string)
+	return value
+}
+
+func isNode(f feature) bool {
+	switch element(f) {
+	case elementNode:
+		return true
+	case "":
+		return f.Geometry != nil && f.Geometry.Type == "Point"
+	}
+	return false
+}
+
+func isLine(f feature) bool {
+	switch element(f) {
+	case elementLine:
+		return true
+	case "":
+		return f.Geometry != nil && (f.Geometry.Type == "LineString" || f.Geometry.Type == "MultiLineString")
+	}
+	return false
+}
+
+// featureID 取得 feature 的 ID（feature.id，其次為 properties.id）
+func featureID(f feature) string {
+	for _, value := range []interface{}{f.ID, f.Properties["id"]} {
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+func (d *decoder) node(index int, f feature) {
+	id := featureID(f)
+	if id == "" {
+		id = fmt.Sprintf("node-%d", index+1)
+	}
+	if _, exists := d.nodeIndex[id]; exists {
+		d.warn("feature %d: duplicate node id %q, renamed", index, id)
+		id = d.uniqueNodeID(id)
+	}
+
+	node := topology.Node{ID: id, Type: topology.NodeTypeBus, Name: id}
+	if f.Geometry != nil && f.Geometry.Type == "Point" {
+		point, err := decodePoint(f.Geometry.Coordinates)
+		if err != nil {
+			d.warn("node %s: invalid Point coordinates, geo ignored: %v", id, err)
+		} else {
+			node.Geo = &point
+		}
+	}
+
+	if element(f) == elementNode {
+		if nodeType, ok := f.Properties["node_type"].(string); ok && nodeType != "" {
+			node.Type = nodeType
+		}
+		if name, ok := f.Properties["name"].(string); ok {
+			node.Name = name
+		}
+		if raw, exists := f.Properties["position"]; exists && raw != nil {
+			if err := remarshal(raw, &node.Position); err == nil {
+				d.positioned[id] = true
+			}
+		}
+		if props, ok := f.Properties["properties"].(map[string]interface{}); ok {
+			node.Properties = props
+		}
+	} else {
+		// 一般 GIS 資料：name 以外的屬性直接保存為節點屬性
+		if name, ok := f.Properties["name"].(string); ok && name != "" {
+			node.Name = name
+		}
+		props := map[string]interface{}{}
+		for key, value := range f.Properties {
+			if key != "name" && key != "id" {
+				props[key] = value
+			}
+		}
+		if len(props) > 0 {
+			node.Properties = props
+		}
+	}
+
+	d.nodeIndex[id] = len(d.topo.Nodes)
+	d.topo.Nodes = append(d.topo.Nodes, node)
+}
+
+func (d *decoder) uniqueNodeID(id string) string {
+	unique := id
+	for suffix := 2; ; suffix++ {
+		if _, exists := d.nodeIndex[unique]; !exists {
+			return unique
+		}
+		unique = fmt.Sprintf("%s_%d", id, suffix)
+	}
+}
+
+func (d *decoder) line(index int, f feature) {
+	id := featureID(f)
+	if id == "" {
+		id = fmt.Sprintf("line-%d", index+1)
+	}
+	if d.lineIDs[id] {
+		d.warn("feature %d: duplicate line id %q, renamed", index, id)
+		unique := id
+		for suffix := 2; d.lineIDs[unique]; suffix++ {
+			unique = fmt.Sprintf("%s_%d", id, suffix)
+		}
+		id = unique
+	}
+
+	var path []topology.GeoPoint
+	if f.Geometry != nil {
+		var err error
+		path, err = d.decodePath(id, f.Geometry)
+		if err != nil {
+			d.warn("line %s: invalid %s coordinates, geometry ignored: %v", id, f.Geometry.Type, err)
+			path = nil
+		}
+	}
+
+	line := topology.Line{ID: id}
+	own := element(f) == elementLine
+	if own {
+		line.FromNodeID, _ = f.Properties["from_node_id"].(string)
+		line.ToNodeID, _ = f.Properties["to_node_id"].(string)
+		line.Name, _ = f.Properties["name"].(string)
+		if props, ok := f.Properties["properties"].(map[string]interface{}); ok {
+			line.Properties = props
+		}
+	} else {
+		if name, ok := f.Properties["name"].(string); ok {
+			line.Name = name
+		}
+		props := map[string]interface{}{}
+		for key, value := range f.Properties {
+			if key != "name" && key != "id" {
+				props[key] = value
+			}
+		}
+		if len(props) > 0 {
+			line.Properties = props
+		}
+	}
+
+	// 沒有指定端點節點時，依折線兩端座標對應或建立節點
+	if line.FromNodeID == "" || line.ToNodeID == "" {
+		if len(path) < 2 {
+			d.warn("line %s skipped: no end nodes and fewer than two coordinates", id)
+			return
+		}
+		if line.FromNodeID == "" {
+			line.FromNodeID = d.snap(path[0], id+"-from")
+		}
+		if line.ToNodeID == "" {
+			line.ToNodeID = d.snap(path[len(path)-1], id+"-to")
+		}
+	}
+
+	// 去除與端點節點重合的頭尾座標，其餘為折點；端點節點沒有經緯度時，一般 GIS 資料以折線端點補上
+	if len(path) > 0 && f.Geometry.Type != "MultiPoint" {
+		if from := d.nodeGeo(line.FromNodeID); from != nil && near(*from, path[0]) {
+			path = path[1:]
+		} else if from == nil && !own && d.setGeo(line.FromNodeID, path[0]) {
+			path = path[1:]
+		}
+	}
+	if len(path) > 0 && f.Geometry.Type != "MultiPoint" {
+		last := path[len(path)-1]
+		if to := d.nodeGeo(line.ToNodeID); to != nil && near(*to, last) {
+			path = path[:len(path)-1]
+		} else if to == nil && !own && d.setGeo(line.ToNodeID, last) {
+			path = path[:len(path)-1]
+		}
+	}
+	if len(path) > 0 {
+		line.Geometry = path
+	}
+
+	d.lineIDs[id] = true
+	d.topo.Lines = append(d.topo.Lines, line)
+}
+
+// decodePath 解析線路座標；MultiLineString 只取第一段
+func (d *decoder) decodePath(id string, geom *geometry) ([]topology.GeoPoint, error) {
+	switch geom.Type {
+	case "LineString", "MultiPoint":
+		return decodePoints(geom.Coordinates)
+	case "MultiLineString":
+		var parts []json.RawMessage
+		if err := json.Unmarshal(geom.Coordinates, &parts); err != nil {
+			return nil, err
+		}
+		if len(parts) == 0 {
+			return nil, errors.New("empty MultiLineString")
+		}
+		if len(parts) > 1 {
+			d.warn("line %s: MultiLineString has %d parts, only the first is imported", id, len(parts))
+		}
+		return decodePoints(parts[0])
+	}
+	return nil, fmt.Errorf("unsupported geometry type %s", geom.Type)
+}
+
+// snap 取得座標上的節點，沒有時建立新的 bus 節點
+func (d *decoder) snap(point topology.GeoPoint, id string) string {
+	for _, node := range d.topo.Nodes {
+		if node.Geo != nil && near(*node.Geo, point) {
+			return node.ID
+		}
+	}
+	id = d.uniqueNodeID(id)
+	geo := point
+	d.nodeIndex[id] = len(d.topo.Nodes)
+	d.topo.Nodes = append(d.topo.Nodes, topology.Node{ID: id, Type: topology.NodeTypeBus, Name: id, Geo: &geo})
+	return id
+}
+
+func (d *decoder) nodeGeo(id string) *topology.GeoPoint {
+	if index, exists := d.nodeIndex[id]; exists {
+		return d.topo.Nodes[index].Geo
+	}
+	return nil
+}
+
+// setGeo 為既有節點設定經緯度，節點不存在時回傳 false
+func (d *decoder) setGeo(id string, point topology.GeoPoint) bool {
+	index, exists := d.nodeIndex[id]
+	if !exists {
+		return false
+	}
+	geo := point
+	d.topo.Nodes[index].Geo = &geo
+	return true
+}
+
+func near(a, b topology.GeoPoint) bool {
+	return math.Abs(a.Lat-b.Lat) <= snapToleranceDeg && math.Abs(a.Lon-b.Lon) <= snapToleranceDeg
+}
+
+// decodePoint 解析 [經度, 緯度(, 高度)]
+func decodePoint(raw json.RawMessage) (topology.GeoPoint, error) {
+	var coords []float64
+	if err := json.Unmarshal(raw, &coords); err != nil {
+		return topology.GeoPoint{}, err
+	}
+	if len(coords) < 2 {
+		return topology.GeoPoint{}, errors.New("position needs at least two values")
+	}
+	point := topology.GeoPoint{Lat: coords[1], Lon: coords[0]}
+	return point, point.Validate()
+}
+
+func decodePoints(raw json.RawMessage) ([]topology.GeoPoint, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	points := make([]topology.GeoPoint, 0, len(items))
+	for _, item := range items {
+		point, err := decodePoint(item)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// remarshal 將 map 形式的值轉換為結構
+func remarshal(value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,87 @@
+package geojson
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// Encode 將拓樸輸出為 GeoJSON FeatureCollection
+func (Codec) Encode(w io.Writer, t *topology.Topology) error {
+	collection := featureCollection{
+		Type:        "FeatureCollection",
+		Name:        t.Name,
+		Description: t.Description,
+		ProfileType: t.ProfileType,
+		Features:    make([]feature, 0, len(t.Nodes)+len(t.Lines)),
+	}
+
+	for _, node := range t.Nodes {
+		var geom *geometry
+		if node.Geo != nil {
+			geom = newGeometry("Point", position(*node.Geo))
+		}
+		collection.Features = append(collection.Features, feature{
+			Type:     "Feature",
+			ID:       node.ID,
+			Geometry: geom,
+			Properties: map[string]interface{}{
+				"element":    elementNode,
+				"node_type":  node.Type,
+				"name":       node.Name,
+				"position":   node.Position,
+				"properties": node.Properties,
+			},
+		})
+	}
+
+	for _, line := range t.Lines {
+		props := map[string]interface{}{
+			"element":      elementLine,
+			"from_node_id": line.FromNodeID,
+			"to_node_id":   line.ToNodeID,
+			"name":         line.Name,
+			"properties":   line.Properties,
+		}
+
+		var geom *geometry
+		if path := t.LinePath(line); path != nil {
+			geom = newGeometry("LineString", positions(path))
+			props["geo_length_km"] = math.Round(topology.PathLengthKM(path)*1e6) / 1e6
+		} else if len(line.Geometry) > 0 {
+			// 端點節點沒有經緯度時只輸出折點
+			geom = newGeometry("MultiPoint", positions(line.Geometry))
+		}
+
+		collection.Features = append(collection.Features, feature{
+			Type:       "Feature",
+			ID:         line.ID,
+			Geometry:   geom,
+			Properties: props,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+func newGeometry(geometryType string, coordinates interface{}) *geometry {
+	data, _ := json.Marshal(coordinates)
+	return &geometry{Type: geometryType, Coordinates: data}
+}
+
+// position GeoJSON 座標順序為 [經度, 緯度]
+func position(p topology.GeoPoint) []float64 {
+	return []float64{p.Lon, p.Lat}
+}
+
+func positions(points []topology.GeoPoint) [][]float64 {
+	result := make([][]float64, len(points))
+	for i, p := range points {
+		result[i] = position(p)
+	}
+	return result
+}
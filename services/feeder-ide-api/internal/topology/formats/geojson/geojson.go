@@ -0,0 +1,62 @@
+// Package geojson 在拓樸與 GeoJSON FeatureCollection（RFC 7946，WGS84）之間轉換
+//
+// 每個節點輸出為 Point feature、每條線路輸出為 LineString feature（起點節點、折點、終點節點），
+// 沒有經緯度的元素 geometry 為 null。feature 的 properties.element 標示 "node" 或 "line"，
+// 並保存類型、名稱、畫布座標與完整屬性，因此本系統匯出的檔案可無損匯入。
+//
+// 匯入一般 GIS 資料時，沒有 element 標示的 Point 視為 bus 節點、LineString 視為線路；
+// 線路端點依座標對應到既有節點，找不到時在端點建立新的 bus 節點。
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// 元素標示
+const (
+	elementNode = "node"
+	elementLine = "line"
+)
+
+// snapToleranceDeg 線路端點對應到節點的座標誤差（約 1 公尺）
+const snapToleranceDeg = 1e-5
+
+// ErrNotFeatureCollection 檔案不是 GeoJSON FeatureCollection
+var ErrNotFeatureCollection = errors.New("not a GeoJSON FeatureCollection")
+
+// Codec GeoJSON 格式
+type Codec struct{}
+
+func (Codec) Name() string {
+	return "geojson"
+}
+
+func (Codec) ContentType() string {
+	return "application/geo+json"
+}
+
+func (Codec) Extension() string {
+	return ".geojson"
+}
+
+// featureCollection 拓樸名稱、描述與場景類型以 foreign member 保存
+type featureCollection struct {
+	Type        string    `json:"type"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ProfileType string    `json:"profile_type,omitempty"`
+	Features    []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
@@ -0,0 +1,192 @@
+package opendss
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// sampleTopology 含所有節點類型與一個開路開關的小型饋線
+func sampleTopology() *topology.Topology {
+	return &topology.Topology{
+		ID:   "topology-a",
+		Name: "Sample",
+		Nodes: []topology.Node{
+			{ID: "sub", Type: topology.NodeTypeBus, Position: topology.Position{X: 0, Y: 0}, Properties: map[string]interface{}{"is_source": true, "voltage_kv": 22.8}},
+			{ID: "cb", Type: topology.NodeTypeSwitch, Position: topology.Position{X: 100, Y: 0}, Properties: map[string]interface{}{"type": "breaker", "is_closed": true}},
+			{ID: "b1", Type: topology.NodeTypeBus, Position: topology.Position{X: 200, Y: 0}, Properties: map[string]interface{}{"load_kw": 300.0, "load_kvar": 100.0}},
+			{ID: "tie", Type: topology.NodeTypeSwitch, Position: topology.Position{X: 200, Y: 100}, Properties: map[string]interface{}{"type": "sectionalizer", "is_closed": false}},
+			{ID: "tx", Type: topology.NodeTypeTransformer, Position: topology.Position{X: 300, Y: 0}, Properties: map[string]interface{}{"primary_voltage": 22.8, "secondary_voltage": 0.38, "rated_capacity_kva": 500.0}},
+			{ID: "lv", Type: topology.NodeTypeBus, Position: topology.Position{X: 400, Y: 0}, Properties: map[string]interface{}{"load_kw": 120.0, "load_kvar": 30.0}},
+			{ID: "pv", Type: topology.NodeTypeDER, Position: topology.Position{X: 500, Y: 0}, Properties: map[string]interface{}{"type": "pv", "rated_power_kw": 50.0}},
+			{ID: "ev", Type: topology.NodeTypeEVCharger, Position: topology.Position{X: 500, Y: 100}, Properties: map[string]interface{}{"rated_power_kw": 22.0}},
+		},
+		Lines: []topology.Line{
+			{ID: "l1", FromNodeID: "sub", ToNodeID: "cb", Properties: map[string]interface{}{"length_km": 0.1}},
+			{ID: "l2", FromNodeID: "cb", ToNodeID: "b1", Properties: map[string]interface{}{"length_km": 2.5, "r_ohm_per_km": 0.2, "x_ohm_per_km": 0.4, "ampacity_a": 300.0}},
+			{ID: "l3", FromNodeID: "b1", ToNodeID: "tie", Properties: map[string]interface{}{"length_km": 0.5}},
+			{ID: "l4", FromNodeID: "b1", ToNodeID: "tx", Properties: map[string]interface{}{"length_km": 0.1}},
+			{ID: "l5", FromNodeID: "tx", ToNodeID: "lv", Properties: map[string]interface{}{"length_km": 0.05}},
+			{ID: "l6", FromNodeID: "lv", ToNodeID: "pv", Properties: map[string]interface{}{"length_km": 0.02}},
+			{ID: "l7", FromNodeID: "lv", ToNodeID: "ev", Properties: map[string]interface{}{"length_km": 0.02}},
+		},
+	}
+}
+
+func encode(t *testing.T, topo *topology.Topology) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := (Codec{}).Encode(&buf, topo); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return buf.String()
+}
+
+func decode(t *testing.T, script string) *formats.DecodeResult {
+	t.Helper()
+	result, err := (Codec{}).Decode(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return result
+}
+
+func TestRoundTrip(t *testing.T) {
+	original := sampleTopology()
+	result := decode(t, encode(t, original))
+	if len(result.Warnings) > 0 {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+	got := result.Topology
+
+	nodes := map[string]topology.Node{}
+	for _, node := range got.Nodes {
+		nodes[node.ID] = node
+	}
+	if len(nodes) != len(original.Nodes) {
+		t.Fatalf("nodes = %d, want %d", len(nodes), len(original.Nodes))
+	}
+
+	tests := []struct {
+		nodeID   string
+		nodeType string
+		props    map[string]interface{}
+	}{
+		{"sub", topology.NodeTypeBus, map[string]interface{}{"is_source": true, "voltage_kv": 22.8}},
+		{"cb", topology.NodeTypeSwitch, map[string]interface{}{"type": "breaker", "is_closed": true}},
+		{"b1", topology.NodeTypeBus, map[string]interface{}{"load_kw": 300.0, "load_kvar": 100.0}},
+		{"tie", topology.NodeTypeSwitch, map[string]interface{}{"type": "sectionalizer", "is_closed": false}},
+		{"tx", topology.NodeTypeTransformer, map[string]interface{}{"primary_voltage": 22.8, "secondary_voltage": 0.38, "rated_capacity_kva": 500.0}},
+		{"lv", topology.NodeTypeBus, map[string]interface{}{"load_kw": 120.0, "load_kvar": 30.0}},
+		{"pv", topology.NodeTypeDER, map[string]interface{}{"type": "pv", "rated_power_kw": 50.0}},
+		{"ev", topology.NodeTypeEVCharger, map[string]interface{}{"rated_power_kw": 22.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nodeID, func(t *testing.T) {
+			node, exists := nodes[tt.nodeID]
+			if !exists {
+				t.Fatalf("node %s missing", tt.nodeID)
+			}
+			if node.Type != tt.nodeType {
+				t.Errorf("type = %s, want %s", node.Type, tt.nodeType)
+			}
+			for key, want := range tt.props {
+				if got := node.Properties[key]; got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+
+	// 座標以 SetBusXY 保存
+	for _, want := range original.Nodes {
+		if got := nodes[want.ID].Position; got != want.Position {
+			t.Errorf("node %s position = %+v, want %+v", want.ID, got, want.Position)
+		}
+	}
+
+	lines := map[string]topology.Line{}
+	for _, line := range got.Lines {
+		lines[line.ID] = line
+	}
+	for _, want := range original.Lines {
+		line, exists := lines[want.ID]
+		if !exists {
+			t.Errorf("line %s missing", want.ID)
+			continue
+		}
+		if line.FromNodeID != want.FromNodeID || line.ToNodeID != want.ToNodeID {
+			t.Errorf("line %s = %s -> %s, want %s -> %s", want.ID, line.FromNodeID, line.ToNodeID, want.FromNodeID, want.ToNodeID)
+		}
+		for key, value := range want.Properties {
+			if line.Properties[key] != value {
+				t.Errorf("line %s %s = %v, want %v", want.ID, key, line.Properties[key], value)
+			}
+		}
+	}
+}
+
+func TestRoundTripPreservesPowerFlow(t *testing.T) {
+	// 匯入的拓樸與原拓樸電氣上等價：潮流結果相同
+	original := sampleTopology()
+	imported := decode(t, encode(t, original)).Topology
+
+	want, err := powerflow.Solve(original, powerflow.Options{})
+	if err != nil {
+		t.Fatalf("Solve original: %v", err)
+	}
+	got, err := powerflow.Solve(imported, powerflow.Options{})
+	if err != nil {
+		t.Fatalf("Solve imported: %v", err)
+	}
+
+	voltages := map[string]float64{}
+	for _, node := range got.Nodes {
+		voltages[node.NodeID] = node.VoltagePU
+	}
+	for _, node := range want.Nodes {
+		if math.Abs(voltages[node.NodeID]-node.VoltagePU) > 1e-9 {
+			t.Errorf("node %s voltage = %.9f pu, want %.9f", node.NodeID, voltages[node.NodeID], node.VoltagePU)
+		}
+	}
+	if math.Abs(got.Summary.TotalLossesKW-want.Summary.TotalLossesKW) > 1e-6 {
+		t.Errorf("losses = %.6f kW, want %.6f", got.Summary.TotalLossesKW, want.Summary.TotalLossesKW)
+	}
+	if got.Summary.DeenergizedNodes != want.Summary.DeenergizedNodes {
+		t.Errorf("deenergized nodes = %d, want %d", got.Summary.DeenergizedNodes, want.Summary.DeenergizedNodes)
+	}
+}
+
+func TestEncodeRequiresSource(t *testing.T) {
+	tests := []struct {
+		name string
+		topo *topology.Topology
+	}{
+		{"empty topology", &topology.Topology{}},
+		{"ring without source", &topology.Topology{
+			Nodes: []topology.Node{{ID: "a", Type: topology.NodeTypeBus}, {ID: "b", Type: topology.NodeTypeBus}},
+			Lines: []topology.Line{{ID: "l1", FromNodeID: "a", ToNodeID: "b"}, {ID: "l2", FromNodeID: "b", ToNodeID: "a"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (Codec{}).Encode(&buf, tt.topo); !errors.Is(err, formats.ErrUnsupportedTopology) {
+				t.Errorf("error = %v, want ErrUnsupportedTopology", err)
+			}
+		})
+	}
+}
+
+func TestDecodeWithoutCircuit(t *testing.T) {
+	_, err := (Codec{}).Decode(strings.NewReader("New Line.l1 bus1=a bus2=b length=1\n"))
+	if !errors.Is(err, ErrNoCircuit) {
+		t.Errorf("error = %v, want ErrNoCircuit", err)
+	}
+}
@@ -0,0 +1,503 @@
+package opendss
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// OpenDSS 預設值（與 OpenDSS 本身一致）
+const (
+	dssDefaultBaseKV     = 115.0
+	dssDefaultLoadPF     = 0.88
+	dssDefaultPVPmpp     = 500.0
+	dssDefaultStorageKW  = 25.0
+	dssDefaultGeneratorK = 1000.0
+	dssDefaultXHL        = 7.0
+	dssDefaultPercentR   = 0.2 // 每個繞組
+)
+
+// 每單位長度換算為公里
+var unitKM = map[string]float64{
+	"none": 1,
+	"km":   1,
+	"m":    0.001,
+	"cm":   0.00001,
+	"mi":   1.609344,
+	"kft":  0.3048,
+	"ft":   0.0003048,
+	"in":   0.0000254,
+}
+
+// 直接支援或用於輔助判斷的類別；其他類別會被忽略並列入警告
+var supportedClasses = map[string]bool{
+	"circuit":     true,
+	"vsource":     true,
+	"line":        true,
+	"linecode":    true,
+	"transformer": true,
+	"load":        true,
+	"pvsystem":    true,
+	"storage":     true,
+	"generator":   true,
+	"recloser":    true,
+	"relay":       true,
+}
+
+// element 一個 DSS 物件（New 與後續 Edit 的參數依序累積）
+type element struct {
+	Class  string
+	Name   string
+	Params []param
+}
+
+func (e *element) get(key string) (string, bool) {
+	return get(e.Params, key)
+}
+
+func (e *element) float(key string, defaultValue float64) float64 {
+	value, ok := e.get(key)
+	if !ok {
+		return defaultValue
+	}
+	f, err := parseNumber(value)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
+
+func (e *element) bool(key string) bool {
+	value, _ := e.get(key)
+	switch strings.ToLower(value) {
+	case "yes", "y", "true", "t":
+		return true
+	}
+	return false
+}
+
+func (e *element) ref() string {
+	return e.Class + "." + e.Name
+}
+
+// decoder 由 DSS 指令建立拓樸的狀態（DSS 名稱不分大小寫）
+type decoder struct {
+	*formats.Builder
+	switches map[string]string // 小寫開關線路名稱 -> 節點 ID
+}
+
+// Decode 解析 DSS script 並轉換為拓樸
+func (Codec) Decode(r io.Reader) (*formats.DecodeResult, error) {
+	commands, err := parseScript(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{
+		Builder:  formats.NewBuilder(strings.ToLower),
+		switches: map[string]string{},
+	}
+
+	elements, switchStates, coords := d.collect(commands)
+	if err := d.build(elements, switchStates); err != nil {
+		return nil, err
+	}
+	topo := d.Finish(coords)
+
+	return &formats.DecodeResult{Topology: topo, Warnings: d.Warnings()}, nil
+}
+
+// collect 執行指令，收集物件、開關狀態與匯流排座標
+func (d *decoder) collect(commands []command) ([]*element, map[string]bool, map[string]topology.Position) {
+	elements := []*element{}
+	byRef := map[string]*element{}
+	switchStates := map[string]bool{}
+	coords := map[string]topology.Position{}
+
+	for _, cmd := range commands {
+		switch cmd.Verb {
+		case "new", "edit":
+			class, name, params := cmd.object()
+			if class == "" || name == "" {
+				d.Warn("line %d: missing object name", cmd.Line)
+				continue
+			}
+			key := class + "." + strings.ToLower(name)
+			if cmd.Verb == "edit" {
+				existing, exists := byRef[key]
+				if !exists {
+					d.Warn("line %d: edit of undefined object %s.%s", cmd.Line, class, name)
+					continue
+				}
+				existing.Params = append(existing.Params, params...)
+				continue
+			}
+			e := &element{Class: class, Name: name, Params: params}
+			if existing, exists := byRef[key]; exists {
+				// 重新定義會覆蓋之前的物件
+				*existing = *e
+				continue
+			}
+			byRef[key] = e
+			elements = append(elements, e)
+			if class == "circuit" {
+				// New Circuit 會同時建立 Vsource.Source，常見的 Edit Vsource.Source 作用於同一個電源
+				byRef["vsource.source"] = e
+			}
+		case "open", "close":
+			class, name, _ := cmd.object()
+			if class != "line" {
+				d.Warn("line %d: %s is only supported for switch lines", cmd.Line, cmd.Verb)
+				continue
+			}
+			switchStates[strings.ToLower(name)] = cmd.Verb == "close"
+		case "setbusxy":
+			bus, _ := get(cmd.Params, "bus")
+			x, errX := parseNumber(valueOr(cmd.Params, "x"))
+			y, errY := parseNumber(valueOr(cmd.Params, "y"))
+			if bus == "" || errX != nil || errY != nil {
+				d.Warn("line %d: invalid SetBusXY", cmd.Line)
+				continue
+			}
+			coords[strings.ToLower(busName(bus))] = topology.Position{X: x, Y: y}
+		case "clear":
+			elements = []*element{}
+			byRef = map[string]*element{}
+			switchStates = map[string]bool{}
+			coords = map[string]topology.Position{}
+		case "redirect", "compile", "buscoords", "batchedit":
+			d.Warn("line %d: %s is not supported, referenced content was not imported", cmd.Line, cmd.Verb)
+		}
+	}
+
+	return elements, switchStates, coords
+}
+
+func valueOr(params []param, key string) string {
+	value, _ := get(params, key)
+	return value
+}
+
+// build 依類別建立節點與線路
+func (d *decoder) build(elements []*element, switchStates map[string]bool) error {
+	byClass := map[string][]*element{}
+	for _, e := range elements {
+		byClass[e.Class] = append(byClass[e.Class], e)
+	}
+
+	circuits := byClass["circuit"]
+	if len(circuits) == 0 {
+		return ErrNoCircuit
+	}
+	circuit := circuits[0]
+	d.Topology().Name = circuit.Name
+	if len(byClass["vsource"]) > 0 {
+		d.Warn("%d additional Vsource element(s) ignored, only the circuit source is imported", len(byClass["vsource"]))
+	}
+
+	sourceBus := "sourcebus"
+	if bus, ok := circuit.get("bus1"); ok {
+		sourceBus = busName(bus)
+	}
+	d.SetSource(sourceBus, circuit.float("basekv", dssDefaultBaseKV))
+
+	lineCodes := map[string]*element{}
+	for _, code := range byClass["linecode"] {
+		lineCodes[strings.ToLower(code.Name)] = code
+	}
+
+	for _, line := range byClass["line"] {
+		if line.bool("switch") {
+			d.addSwitch(line, switchStates)
+			continue
+		}
+		d.addLine(line, lineCodes)
+	}
+	for _, xfmr := range byClass["transformer"] {
+		d.addTransformer(xfmr)
+	}
+	d.applyProtection(byClass["recloser"], "recloser")
+	d.applyProtection(byClass["relay"], "breaker")
+
+	for _, load := range byClass["load"] {
+		d.addLoad(load)
+	}
+	for _, pv := range byClass["pvsystem"] {
+		pmpp := pv.float("pmpp", pv.float("kva", dssDefaultPVPmpp))
+		node := d.addDER(pv, "pv", pmpp)
+		if irradiance := pv.float("irradiance", 1); node != nil && irradiance != 1 {
+			node.Properties["output_kw"] = pmpp * irradiance
+		}
+	}
+	for _, storage := range byClass["storage"] {
+		d.addDER(storage, "battery", storage.float("kwrated", dssDefaultStorageKW))
+	}
+	for _, generator := range byClass["generator"] {
+		d.Warn("Generator.%s imported as a wind DER", generator.Name)
+		d.addDER(generator, "wind", generator.float("kw", dssDefaultGeneratorK))
+	}
+
+	// 其他類別彙總成一筆警告
+	ignored := map[string]int{}
+	for _, e := range elements {
+		if !supportedClasses[e.Class] {
+			ignored[e.Class]++
+		}
+	}
+	for _, class := range sortedKeys(ignored) {
+		d.Warn("%d %s element(s) ignored (unsupported class)", ignored[class], class)
+	}
+
+	return nil
+}
+
+func (d *decoder) addLine(e *element, lineCodes map[string]*element) {
+	bus1, ok1 := e.get("bus1")
+	bus2, ok2 := e.get("bus2")
+	if !ok1 || !ok2 {
+		d.Warn("%s skipped: bus1 and bus2 are required", e.ref())
+		return
+	}
+	from := d.Node(busName(bus1)).ID
+	to := d.Node(busName(bus2)).ID
+
+	props := map[string]interface{}{}
+	units := strings.ToLower(valueOr(e.Params, "units"))
+	lengthFactor, known := unitKM[units]
+	if !known {
+		lengthFactor = 1
+	}
+	props["length_km"] = e.float("length", 1) * lengthFactor
+
+	// 阻抗優先使用線路本身的 r1/x1，其次為 linecode
+	impedance, impedanceUnits := e, units
+	if _, hasR := e.get("r1"); !hasR {
+		if codeName, hasCode := e.get("linecode"); hasCode {
+			code, exists := lineCodes[strings.ToLower(codeName)]
+			if !exists {
+				d.Warn("%s references undefined linecode %s", e.ref(), codeName)
+			} else {
+				impedance = code
+				impedanceUnits = strings.ToLower(valueOr(code.Params, "units"))
+				if _, hasR := code.get("r1"); !hasR {
+					d.Warn("LineCode.%s has no sequence impedance (r1/x1), default impedance used", code.Name)
+				}
+			}
+		}
+	}
+	impedanceFactor, known := unitKM[impedanceUnits]
+	if !known {
+		impedanceFactor = 1
+	}
+	if r1, ok := impedance.get("r1"); ok {
+		if r, err := parseNumber(r1); err == nil {
+			props["r_ohm_per_km"] = r / impedanceFactor
+		}
+	}
+	if x1, ok := impedance.get("x1"); ok {
+		if x, err := parseNumber(x1); err == nil {
+			props["x_ohm_per_km"] = x / impedanceFactor
+		}
+	}
+	if _, ok := e.get("normamps"); ok {
+		props["ampacity_a"] = e.float("normamps", 0)
+	} else if impedance != e {
+		if _, ok := impedance.get("normamps"); ok {
+			props["ampacity_a"] = impedance.float("normamps", 0)
+		}
+	}
+
+	d.AddLine(topology.Line{
+		ID:         e.Name,
+		FromNodeID: from,
+		ToNodeID:   to,
+		Name:       e.Name,
+		Properties: props,
+	})
+}
+
+func (d *decoder) addSwitch(e *element, switchStates map[string]bool) {
+	bus1, ok1 := e.get("bus1")
+	bus2, ok2 := e.get("bus2")
+	if !ok1 || !ok2 {
+		d.Warn("%s skipped: bus1 and bus2 are required", e.ref())
+		return
+	}
+
+	node := d.AddDevice(e.Name, busName(bus1), busName(bus2), topology.NodeTypeSwitch)
+	closed, exists := switchStates[strings.ToLower(e.Name)]
+	if !exists {
+		closed = true
+	}
+	node.Properties["type"] = "sectionalizer"
+	node.Properties["is_closed"] = closed
+	node.Properties["is_automated"] = false
+	d.switches[strings.ToLower(e.Name)] = node.ID
+}
+
+func (d *decoder) addTransformer(e *element) {
+	windings := int(e.float("windings", 2))
+	if windings != 2 {
+		d.Warn("%s has %d windings, only the first two are imported", e.ref(), windings)
+	}
+
+	// 繞組參數可用 buses=[...] 陣列或 wdg=N bus=... kv=... 逐一指定
+	buses := make([]string, 2)
+	kvs := make([]float64, 2)
+	kvas := make([]float64, 2)
+	percentR := []float64{dssDefaultPercentR, dssDefaultPercentR}
+	loadLoss := -1.0
+	winding := 0
+	for _, p := range e.Params {
+		switch p.Key {
+		case "wdg":
+			if w, err := strconv.Atoi(p.Value); err == nil {
+				winding = w - 1
+			}
+		case "bus", "kv", "kva", "%r":
+			if winding < 0 || winding > 1 {
+				continue
+			}
+			value, _ := parseNumber(p.Value)
+			switch p.Key {
+			case "bus":
+				buses[winding] = busName(p.Value)
+			case "kv":
+				kvs[winding] = value
+			case "kva":
+				kvas[winding] = value
+			case "%r":
+				percentR[winding] = value
+			}
+		case "buses":
+			for i, bus := range splitList(p.Value) {
+				if i < 2 {
+					buses[i] = busName(bus)
+				}
+			}
+		case "kvs", "kvas", "%rs":
+			for i, item := range splitList(p.Value) {
+				value, err := parseNumber(item)
+				if err != nil || i > 1 {
+					continue
+				}
+				switch p.Key {
+				case "kvs":
+					kvs[i] = value
+				case "kvas":
+					kvas[i] = value
+				case "%rs":
+					percentR[i] = value
+				}
+			}
+		case "%loadloss":
+			loadLoss, _ = parseNumber(p.Value)
+		}
+	}
+	if buses[0] == "" || buses[1] == "" {
+		d.Warn("%s skipped: two winding buses are required", e.ref())
+		return
+	}
+
+	r := percentR[0] + percentR[1]
+	if loadLoss >= 0 {
+		r = loadLoss
+	}
+	x := e.float("xhl", e.float("x12", dssDefaultXHL))
+
+	node := d.AddDevice(e.Name, buses[0], buses[1], topology.NodeTypeTransformer)
+	if kvas[0] > 0 {
+		node.Properties["rated_capacity_kva"] = kvas[0]
+	}
+	if kvs[0] > 0 {
+		node.Properties["primary_voltage"] = kvs[0]
+	}
+	if kvs[1] > 0 {
+		node.Properties["secondary_voltage"] = kvs[1]
+		node.Properties["rated_voltage_kv"] = kvs[1]
+	}
+	node.Properties["impedance_percent"] = math.Hypot(r, x)
+	if r > 0 {
+		node.Properties["x_r_ratio"] = x / r
+	}
+}
+
+// applyProtection 將 Recloser / Relay 對應到其監控的開關
+func (d *decoder) applyProtection(elements []*element, switchType string) {
+	for _, e := range elements {
+		monitored, _ := e.get("monitoredobj")
+		class, name := splitObject(monitored)
+		nodeID, exists := d.switches[strings.ToLower(name)]
+		if class != "line" || !exists {
+			d.Warn("%s ignored: it must monitor a switch line", e.ref())
+			continue
+		}
+		node := d.Node(nodeID)
+		node.Properties["type"] = switchType
+		node.Properties["is_automated"] = true
+	}
+}
+
+func (d *decoder) addLoad(e *element) {
+	bus, ok := e.get("bus1")
+	if !ok {
+		d.Warn("%s skipped: bus1 is required", e.ref())
+		return
+	}
+
+	pf := e.float("pf", dssDefaultLoadPF)
+	kw := e.float("kw", 10)
+	if _, hasKW := e.get("kw"); !hasKW {
+		if kva, hasKVA := e.get("kva"); hasKVA {
+			if value, err := parseNumber(kva); err == nil {
+				kw = value * math.Abs(pf)
+			}
+		}
+	}
+	kvar := e.float("kvar", 0)
+	if _, hasKVAR := e.get("kvar"); !hasKVAR && pf != 0 {
+		kvar = kw * math.Tan(math.Acos(math.Min(math.Abs(pf), 1)))
+		if pf < 0 {
+			kvar = -kvar
+		}
+	}
+
+	// 名稱以 ev_ 開頭的負載視為 EV 充電樁
+	if strings.HasPrefix(strings.ToLower(e.Name), evLoadPrefix) {
+		node := d.EquipmentNode(busName(bus), e.Name, topology.NodeTypeEVCharger)
+		node.Properties["rated_power_kw"] = kw
+		node.Properties["load_kvar"] = kvar
+		return
+	}
+
+	node := d.Node(busName(bus))
+	node.Properties["load_kw"] = topology.FloatProperty(node.Properties, "load_kw", 0) + kw
+	node.Properties["load_kvar"] = topology.FloatProperty(node.Properties, "load_kvar", 0) + kvar
+}
+
+func (d *decoder) addDER(e *element, derType string, ratedKW float64) *topology.Node {
+	bus, ok := e.get("bus1")
+	if !ok {
+		d.Warn("%s skipped: bus1 is required", e.ref())
+		return nil
+	}
+	node := d.EquipmentNode(busName(bus), e.Name, topology.NodeTypeDER)
+	node.Properties["type"] = derType
+	node.Properties["rated_power_kw"] = ratedKW
+	node.Properties["is_controllable"] = derType == "battery"
+	return node
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
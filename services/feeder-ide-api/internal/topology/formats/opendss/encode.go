@@ -0,0 +1,314 @@
+package opendss
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/powerflow"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+	"github.com/feeder-platform/feeder-ide-api/internal/topology/formats"
+)
+
+// Encode 將拓樸輸出為 DSS script
+// OpenDSS 的 Circuit 本身就是電源，沒有電源節點的拓樸無法輸出，回傳 formats.ErrUnsupportedTopology
+func (Codec) Encode(w io.Writer, t *topology.Topology) error {
+	// 電壓等級與開關狀態無關，以全部開關閉合的網路計算，避免開路區段取不到電壓
+	closed := t.Clone()
+	for i, node := range closed.Nodes {
+		if node.Type == topology.NodeTypeSwitch && node.Properties != nil {
+			closed.Nodes[i].Properties["is_closed"] = true
+		}
+	}
+	network, err := powerflow.BuildNetwork(closed, powerflow.Options{})
+	if err == powerflow.ErrEmptyTopology || err == powerflow.ErrNoSource {
+		return fmt.Errorf("%w: OpenDSS circuit requires a source node: %v", formats.ErrUnsupportedTopology, err)
+	}
+	if err != nil {
+		return err
+	}
+	e := newEncoder(t, network)
+
+	out := bufio.NewWriter(w)
+	e.write(out)
+	return out.Flush()
+}
+
+// encoder 輸出 DSS script 所需的名稱與電壓對應
+type encoder struct {
+	topo    *topology.Topology
+	network *powerflow.Network
+	buses   map[string]string // 節點 ID -> DSS 匯流排名稱
+	inputs  formats.InputSides
+	names   map[string]map[string]bool // 類別 -> 已使用的小寫物件名稱
+}
+
+func newEncoder(t *topology.Topology, network *powerflow.Network) *encoder {
+	e := &encoder{
+		topo:    t,
+		network: network,
+		buses:   map[string]string{},
+		inputs:  formats.FindInputSides(t),
+		names:   map[string]map[string]bool{},
+	}
+
+	used := map[string]bool{}
+	for _, node := range t.Nodes {
+		name := uniqueName(sanitize(node.ID), used)
+		// 保留上游端匯流排名稱，避免與其他節點衝突
+		used[strings.ToLower(name+inputSuffix)] = true
+		e.buses[node.ID] = name
+	}
+
+	return e
+}
+
+// baseKV 節點所在電壓等級
+func (e *encoder) baseKV(nodeID string) float64 {
+	index, _ := e.network.BusIndex(nodeID)
+	return e.network.Buses[index].BaseKV
+}
+
+// lineBus 線路端點的 DSS 匯流排；接到元件上游端時使用 "_in" 匯流排
+func (e *encoder) lineBus(line topology.Line, nodeID string) string {
+	bus := e.buses[nodeID]
+	if e.inputs.Has(line.ID, nodeID) {
+		return bus + inputSuffix
+	}
+	return bus
+}
+
+// object 取得同類別中不重複的 DSS 物件名稱
+func (e *encoder) object(class, id string) string {
+	if e.names[class] == nil {
+		e.names[class] = map[string]bool{}
+	}
+	return uniqueName(sanitize(id), e.names[class])
+}
+
+func (e *encoder) write(w *bufio.Writer) {
+	t := e.topo
+	source := e.network.Buses[e.network.Source]
+
+	fmt.Fprintf(w, "! Feeder IDE export: %s\n", strings.ReplaceAll(t.Name, "\n", " "))
+	if t.ID != "" {
+		fmt.Fprintf(w, "! topology_id=%s profile_type=%s\n", t.ID, t.ProfileType)
+	}
+	fmt.Fprintln(w, "Clear")
+	fmt.Fprintln(w)
+
+	circuitName := sanitize(t.Name)
+	if circuitName == "" {
+		circuitName = "feeder"
+	}
+	// 電源為變壓器時，Circuit 接在一次側，讓主變壓器阻抗也納入 OpenDSS 計算
+	sourceBus, sourceKV := e.buses[source.NodeID], source.BaseKV
+	if source.Type == topology.NodeTypeTransformer {
+		sourceBus += inputSuffix
+		sourceKV = e.primaryKV(t.Nodes[e.nodeIndex(source.NodeID)], source.BaseKV)
+	}
+	fmt.Fprintf(w, "New Circuit.%s bus1=%s basekv=%s pu=1.0 phases=3\n", circuitName, sourceBus, num(sourceKV))
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "! Lines")
+	for _, line := range t.Lines {
+		params := powerflow.LineParameters(t, line)
+		fmt.Fprintf(w, "New Line.%s phases=3 bus1=%s bus2=%s length=%s units=km r1=%s x1=%s normamps=%s\n",
+			e.object("line", line.ID),
+			e.lineBus(line, line.FromNodeID),
+			e.lineBus(line, line.ToNodeID),
+			num(params.LengthKM), num(params.ROhmPerKM), num(params.XOhmPerKM), num(params.AmpacityA))
+	}
+
+	var loads, ders, switches, transformers []string
+	for _, node := range t.Nodes {
+		bus := e.buses[node.ID]
+		kv := e.baseKV(node.ID)
+
+		switch node.Type {
+		case topology.NodeTypeTransformer:
+			transformers = append(transformers, e.transformer(node, bus, kv))
+		case topology.NodeTypeSwitch:
+			switches = append(switches, e.switchLine(node, bus)...)
+		case topology.NodeTypeDER:
+			if der := e.der(node, bus, kv); der != "" {
+				ders = append(ders, der)
+			}
+		}
+
+		demand := powerflow.NodeDemand(node)
+		if demand.LoadKW == 0 && demand.LoadKVAR == 0 {
+			continue
+		}
+		name := node.ID
+		if node.Type == topology.NodeTypeEVCharger {
+			name = evLoadPrefix + name
+		}
+		loads = append(loads, fmt.Sprintf("New Load.%s phases=3 bus1=%s kv=%s kw=%s kvar=%s model=1",
+			e.object("load", name), bus, num(kv), num(demand.LoadKW), num(demand.LoadKVAR)))
+	}
+
+	writeSection(w, "Transformers", transformers)
+	writeSection(w, "Switches", switches)
+	writeSection(w, "Loads", loads)
+	writeSection(w, "Distributed energy resources", ders)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "! Bus coordinates")
+	for _, node := range t.Nodes {
+		fmt.Fprintf(w, "SetBusXY bus=%s x=%s y=%s\n", e.buses[node.ID], num(node.Position.X), num(node.Position.Y))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Set VoltageBases=[%s]\n", strings.Join(e.voltageBases(), " "))
+	fmt.Fprintln(w, "CalcVoltageBases")
+	fmt.Fprintln(w, "Solve")
+}
+
+func writeSection(w *bufio.Writer, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "! %s\n", title)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func (e *encoder) transformer(node topology.Node, bus string, kv float64) string {
+	var props topology.TransformerProperties
+	_ = topology.DecodeProperties(node.Properties, &props)
+
+	primary := e.primaryKV(node, kv)
+	kva := props.RatedCapacityKVA
+	if kva <= 0 {
+		kva = 1000
+	}
+	zPercent, xr := powerflow.TransformerImpedance(props)
+	r := zPercent / math.Sqrt(1+xr*xr)
+
+	return fmt.Sprintf("New Transformer.%s phases=3 windings=2 buses=[%s %s] conns=[delta wye] kvs=[%s %s] kvas=[%s %s] xhl=%s %%loadloss=%s",
+		e.object("transformer", node.ID), bus+inputSuffix, bus, num(primary), num(kv), num(kva), num(kva), num(r*xr), num(r))
+}
+
+// primaryKV 變壓器一次側電壓：優先使用 primary_voltage，其次為上游匯流排電壓
+func (e *encoder) primaryKV(node topology.Node, kv float64) float64 {
+	if primary := topology.FloatProperty(node.Properties, "primary_voltage", 0); primary > 0 {
+		return primary
+	}
+	if index, ok := e.network.BusIndex(node.ID); ok {
+		if parent := e.network.Buses[index].Parent; parent >= 0 {
+			return e.network.Buses[parent].BaseKV
+		}
+	}
+	return kv
+}
+
+func (e *encoder) nodeIndex(id string) int {
+	for i, node := range e.topo.Nodes {
+		if node.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (e *encoder) switchLine(node topology.Node, bus string) []string {
+	var props topology.SwitchProperties
+	props.IsClosed = true
+	_ = topology.DecodeProperties(node.Properties, &props)
+
+	name := e.object("line", node.ID)
+	lines := []string{fmt.Sprintf("New Line.%s phases=3 bus1=%s bus2=%s switch=yes", name, bus+inputSuffix, bus)}
+	switch props.Type {
+	case "recloser":
+		lines = append(lines, fmt.Sprintf("New Recloser.%s monitoredobj=Line.%s monitoredterm=1", e.object("recloser", node.ID), name))
+	case "breaker":
+		lines = append(lines, fmt.Sprintf("New Relay.%s monitoredobj=Line.%s monitoredterm=1", e.object("relay", node.ID), name))
+	}
+	if !props.IsClosed {
+		lines = append(lines, fmt.Sprintf("Open Line.%s term=1", name))
+	}
+	return lines
+}
+
+func (e *encoder) der(node topology.Node, bus string, kv float64) string {
+	var props topology.DERProperties
+	if err := topology.DecodeProperties(node.Properties, &props); err != nil {
+		return ""
+	}
+	rated := props.RatedPowerKW
+	demand := powerflow.NodeDemand(node)
+
+	switch props.Type {
+	case "pv":
+		irradiance := 1.0
+		if rated > 0 {
+			irradiance = demand.GenerationKW / rated
+		}
+		return fmt.Sprintf("New PVSystem.%s phases=3 bus1=%s kv=%s kva=%s pmpp=%s irradiance=%s",
+			e.object("pvsystem", node.ID), bus, num(kv), num(rated), num(rated), num(irradiance))
+	case "battery":
+		return fmt.Sprintf("New Storage.%s phases=3 bus1=%s kv=%s kwrated=%s kwhrated=%s",
+			e.object("storage", node.ID), bus, num(kv), num(rated), num(rated*4))
+	case "wind":
+		return fmt.Sprintf("New Generator.%s phases=3 bus1=%s kv=%s kw=%s pf=1",
+			e.object("generator", node.ID), bus, num(kv), num(demand.GenerationKW))
+	}
+	return ""
+}
+
+// voltageBases 所有匯流排電壓等級（由高到低）
+func (e *encoder) voltageBases() []string {
+	seen := map[float64]bool{}
+	bases := []float64{}
+	for _, bus := range e.network.Buses {
+		if !seen[bus.BaseKV] {
+			seen[bus.BaseKV] = true
+			bases = append(bases, bus.BaseKV)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(bases)))
+
+	values := make([]string, len(bases))
+	for i, base := range bases {
+		values[i] = num(base)
+	}
+	return values
+}
+
+// sanitize 將 ID 轉換為合法的 DSS 名稱（僅保留英數字、底線與連字號）
+func sanitize(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// uniqueName DSS 名稱不分大小寫，重複時加上序號
+func uniqueName(name string, used map[string]bool) string {
+	if name == "" {
+		name = "x"
+	}
+	unique := name
+	for suffix := 2; used[strings.ToLower(unique)]; suffix++ {
+		unique = name + "_" + strconv.Itoa(suffix)
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+func num(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
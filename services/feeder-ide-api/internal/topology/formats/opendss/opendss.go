@@ -0,0 +1,38 @@
+// Package opendss 在拓樸與 OpenDSS script（.dss）之間轉換
+//
+// 對應方式：
+//   - 節點對應匯流排；電源節點成為 Circuit 的 bus1
+//   - 線路對應 Line（length 以 km 表示，r1/x1 為 ohm/km）
+//   - 變壓器與開關節點在 DSS 中是雙端元件：匯入線路接在 "<節點>_in"，元件再接到節點本身
+//   - 負載對應 Load；EV 充電樁以 "ev_" 開頭的 Load 表示
+//   - DER 依類型對應 PVSystem（pv）、Storage（battery）、Generator（wind）
+//   - 節點座標以 SetBusXY 輸出；匯入時沒有座標的匯流排會自動排版
+//
+// 匯入僅支援 key=value 語法的常用子集，無法轉換的內容會列在 DecodeResult.Warnings
+package opendss
+
+import "errors"
+
+// ErrNoCircuit script 中沒有定義 Circuit
+var ErrNoCircuit = errors.New("no circuit defined in script")
+
+// evLoadPrefix EV 充電樁負載名稱前綴
+const evLoadPrefix = "ev_"
+
+// inputSuffix 變壓器與開關上游端匯流排的名稱後綴
+const inputSuffix = "_in"
+
+// Codec OpenDSS 格式
+type Codec struct{}
+
+func (Codec) Name() string {
+	return "opendss"
+}
+
+func (Codec) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (Codec) Extension() string {
+	return ".dss"
+}
@@ -0,0 +1,231 @@
+package opendss
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// param DSS 指令參數；Key 為空時代表位置參數
+type param struct {
+	Key   string // 小寫
+	Value string
+}
+
+// command 一行（含 ~ / More 續行）DSS 指令
+type command struct {
+	Line   int
+	Verb   string // 小寫（new, edit, open, close, setbusxy ...）
+	Params []param
+}
+
+// object 取得指令的物件參數（New Line.L1 或 New object=Line.L1），回傳小寫類別與原始名稱
+func (c command) object() (class, name string, rest []param) {
+	if len(c.Params) == 0 {
+		return "", "", nil
+	}
+	first := c.Params[0]
+	if first.Key != "" && first.Key != "object" {
+		return "", "", c.Params
+	}
+	class, name = splitObject(first.Value)
+	return class, name, c.Params[1:]
+}
+
+func splitObject(value string) (class, name string) {
+	dot := strings.Index(value, ".")
+	if dot < 0 {
+		return strings.ToLower(value), ""
+	}
+	return strings.ToLower(value[:dot]), value[dot+1:]
+}
+
+// get 取得最後一次出現的參數值
+func get(params []param, key string) (string, bool) {
+	value, found := "", false
+	for _, p := range params {
+		if p.Key == key {
+			value, found = p.Value, true
+		}
+	}
+	return value, found
+}
+
+// parseScript 將 DSS script 解析為指令
+// 支援 ! 與 // 行註解、/* */ 區塊註解、~ 與 More 續行，以及 "..."、'...'、[...]、(...)、{...} 包住的值
+func parseScript(r io.Reader) ([]command, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	commands := []command{}
+	inBlockComment := false
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(scanner.Text())
+
+		if inBlockComment {
+			end := strings.Index(text, "*/")
+			if end < 0 {
+				continue
+			}
+			inBlockComment = false
+			text = strings.TrimSpace(text[end+2:])
+		}
+		if strings.HasPrefix(text, "/*") {
+			if end := strings.Index(text, "*/"); end >= 0 {
+				text = strings.TrimSpace(text[end+2:])
+			} else {
+				inBlockComment = true
+				continue
+			}
+		}
+
+		tokens, err := tokenize(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		verb := strings.ToLower(tokens[0])
+		if verb == "~" || verb == "more" {
+			if len(commands) == 0 {
+				return nil, fmt.Errorf("line %d: continuation without a command", lineNumber)
+			}
+			last := &commands[len(commands)-1]
+			last.Params = append(last.Params, toParams(tokens[1:])...)
+			continue
+		}
+		// "~r1=0.1" 這類沒有空白的續行
+		if strings.HasPrefix(verb, "~") {
+			if len(commands) == 0 {
+				return nil, fmt.Errorf("line %d: continuation without a command", lineNumber)
+			}
+			tokens[0] = tokens[0][1:]
+			last := &commands[len(commands)-1]
+			last.Params = append(last.Params, toParams(tokens)...)
+			continue
+		}
+
+		commands = append(commands, command{
+			Line:   lineNumber,
+			Verb:   verb,
+			Params: toParams(tokens[1:]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	return commands, nil
+}
+
+// tokenize 將一行切成單字，"=" 為獨立 token；註解之後的內容會被忽略
+func tokenize(text string) ([]string, error) {
+	closers := map[byte]byte{'"': '"', '\'': '\'', '[': ']', '(': ')', '{': '}'}
+
+	tokens := []string{}
+	for i := 0; i < len(text); {
+		ch := text[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == ',':
+			i++
+		case ch == '!' || (ch == '/' && i+1 < len(text) && text[i+1] == '/'):
+			return tokens, nil
+		case ch == '=':
+			tokens = append(tokens, "=")
+			i++
+		case closers[ch] != 0:
+			end := strings.IndexByte(text[i+1:], closers[ch])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %q", string(ch))
+			}
+			tokens = append(tokens, strings.TrimSpace(text[i+1:i+1+end]))
+			i += end + 2
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t,=!", rune(text[i])) {
+				i++
+			}
+			tokens = append(tokens, text[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// toParams 將 token 組合為 key=value 或位置參數
+func toParams(tokens []string) []param {
+	params := []param{}
+	for i := 0; i < len(tokens); i++ {
+		if i+1 < len(tokens) && tokens[i+1] == "=" {
+			value := ""
+			if i+2 < len(tokens) {
+				value = tokens[i+2]
+			}
+			params = append(params, param{Key: strings.ToLower(tokens[i]), Value: value})
+			i += 2
+			continue
+		}
+		params = append(params, param{Value: tokens[i]})
+	}
+	return params
+}
+
+// splitList 拆開陣列值（例如 buses=[a b] 或 kvs=(12.47, 0.48)）
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == '|'
+	})
+}
+
+// busName 移除匯流排名稱中的相位（bus1=b1.1.2.3 -> b1）
+func busName(value string) string {
+	if dot := strings.Index(value, "."); dot >= 0 {
+		return value[:dot]
+	}
+	return value
+}
+
+// parseNumber 解析數值；支援 DSS 以括號包住的 RPN 運算式（例如 (.5 1000 /)，括號已由 tokenize 移除）
+func parseNumber(value string) (float64, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 1 {
+		return strconv.ParseFloat(fields[0], 64)
+	}
+
+	stack := []float64{}
+	for _, field := range fields {
+		if len(field) == 1 && strings.Contains("+-*/", field) {
+			if len(stack) < 2 {
+				return 0, errors.New("invalid RPN expression")
+			}
+			a, b := stack[len(stack)-2], stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			switch field {
+			case "+":
+				stack = append(stack, a+b)
+			case "-":
+				stack = append(stack, a-b)
+			case "*":
+				stack = append(stack, a*b)
+			case "/":
+				stack = append(stack, a/b)
+			}
+			continue
+		}
+		number, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, err
+		}
+		stack = append(stack, number)
+	}
+	if len(stack) != 1 {
+		return 0, errors.New("invalid RPN expression")
+	}
+	return stack[0], nil
+}
@@ -0,0 +1,144 @@
+package topology
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusKM 地球平均半徑
+const earthRadiusKM = 6371.0088
+
+// GeoPoint WGS84 經緯度（度）
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Validate 檢查經緯度範圍
+func (p GeoPoint) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p.Lat)
+	}
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p.Lon)
+	}
+	return nil
+}
+
+// HaversineKM 兩點間的大圓距離（公里）
+func HaversineKM(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// PathLengthKM 折線總長（公里）
+func PathLengthKM(path []GeoPoint) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += HaversineKM(path[i-1], path[i])
+	}
+	return total
+}
+
+// LinePath 線路的完整折線（起點節點、折點、終點節點），任一端節點沒有經緯度時回傳 nil
+func (t *Topology) LinePath(line Line) []GeoPoint {
+	var from, to *GeoPoint
+	for i := range t.Nodes {
+		switch t.Nodes[i].ID {
+		case line.FromNodeID:
+			from = t.Nodes[i].Geo
+		case line.ToNodeID:
+			to = t.Nodes[i].Geo
+		}
+	}
+	if from == nil || to == nil {
+		return nil
+	}
+
+	path := make([]GeoPoint, 0, len(line.Geometry)+2)
+	path = append(path, *from)
+	path = append(path, line.Geometry...)
+	return append(path, *to)
+}
+
+// GeoLengthKM 依經緯度折線計算的線路長度，兩端節點缺少經緯度時回傳 false
+func (t *Topology) GeoLengthKM(line Line) (float64, bool) {
+	path := t.LinePath(line)
+	if path == nil {
+		return 0, false
+	}
+	return PathLengthKM(path), true
+}
+
+// BBox 經緯度範圍（GeoJSON 順序：最小經度、最小緯度、最大經度、最大緯度）
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// ParseBBox 解析 "min_lon,min_lat,max_lon,max_lat"
+func ParseBBox(value string) (BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BBox{}, ErrInvalidBBox
+	}
+	values := make([]float64, 4)
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, ErrInvalidBBox
+		}
+		values[i] = f
+	}
+
+	bbox := BBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if bbox.MinLon > bbox.MaxLon || bbox.MinLat > bbox.MaxLat ||
+		(GeoPoint{Lat: bbox.MinLat, Lon: bbox.MinLon}).Validate() != nil ||
+		(GeoPoint{Lat: bbox.MaxLat, Lon: bbox.MaxLon}).Validate() != nil {
+		return BBox{}, ErrInvalidBBox
+	}
+	return bbox, nil
+}
+
+// Intersects 判斷兩個範圍是否相交（含邊界）
+func (b BBox) Intersects(other BBox) bool {
+	return b.MinLon <= other.MaxLon && b.MaxLon >= other.MinLon &&
+		b.MinLat <= other.MaxLat && b.MaxLat >= other.MinLat
+}
+
+func (b *BBox) extend(p GeoPoint) {
+	b.MinLon = math.Min(b.MinLon, p.Lon)
+	b.MinLat = math.Min(b.MinLat, p.Lat)
+	b.MaxLon = math.Max(b.MaxLon, p.Lon)
+	b.MaxLat = math.Max(b.MaxLat, p.Lat)
+}
+
+// BBox 拓樸所有節點與線路折點的經緯度範圍，沒有任何經緯度時回傳 false
+func (t *Topology) BBox() (BBox, bool) {
+	bbox := BBox{MinLon: math.Inf(1), MinLat: math.Inf(1), MaxLon: math.Inf(-1), MaxLat: math.Inf(-1)}
+	found := false
+	for _, node := range t.Nodes {
+		if node.Geo != nil {
+			bbox.extend(*node.Geo)
+			found = true
+		}
+	}
+	for _, line := range t.Lines {
+		for _, point := range line.Geometry {
+			bbox.extend(point)
+			found = true
+		}
+	}
+	if !found {
+		return BBox{}, false
+	}
+	return bbox, true
+}
@@ -0,0 +1,214 @@
+package graph
+
+import "sort"
+
+// Island 經閉合開關相連的節點群組
+type Island struct {
+	ID                int      `json:"id"`
+	Energized         bool     `json:"energized"`  // 含有電源
+	SourceIDs         []string `json:"source_ids"` // 島內的電源節點
+	NodeIDs           []string `json:"node_ids"`
+	LineIDs           []string `json:"line_ids"`
+	BoundarySwitchIDs []string `json:"boundary_switch_ids"` // 與本島相鄰的開路開關
+}
+
+// Loop 拓樸中的一個基本迴路
+type Loop struct {
+	NodeIDs       []string `json:"node_ids"`
+	LineIDs       []string `json:"line_ids"`
+	OpenSwitchIDs []string `json:"open_switch_ids"`
+	Closed        bool     `json:"closed"` // 迴路上沒有開路開關，網路在此形成環狀運轉
+}
+
+// Level 節點在饋線中的層級
+type Level struct {
+	NodeID     string  `json:"node_id"`
+	Energized  bool    `json:"energized"`
+	ParentID   string  `json:"parent_id,omitempty"`
+	SourceID   string  `json:"source_id,omitempty"`
+	Depth      int     `json:"depth"`       // 距電源的節點數（未供電為 -1）
+	DistanceKM float64 `json:"distance_km"` // 沿線路至電源的距離
+}
+
+// Islands 依目前的開關狀態找出所有孤島；開路開關是島與島的邊界，本身不屬於任何島
+func (g *Graph) Islands() []Island {
+	isSource := make([]bool, len(g.ids))
+	for _, source := range g.sources {
+		isSource[source] = true
+	}
+
+	islands := []Island{}
+	assigned := make([]int, len(g.ids))
+	for i := range assigned {
+		assigned[i] = -1
+	}
+
+	for start := range g.ids {
+		if assigned[start] >= 0 || g.openSwitch[start] {
+			continue
+		}
+
+		island := Island{
+			ID:                len(islands),
+			SourceIDs:         []string{},
+			NodeIDs:           []string{},
+			LineIDs:           []string{},
+			BoundarySwitchIDs: []string{},
+		}
+		boundary := make(map[int]bool)
+		assigned[start] = island.ID
+		queue := []int{start}
+		for head := 0; head < len(queue); head++ {
+			node := queue[head]
+			island.NodeIDs = append(island.NodeIDs, g.ids[node])
+			if isSource[node] {
+				island.SourceIDs = append(island.SourceIDs, g.ids[node])
+			}
+			for _, ei := range g.adjacent[node] {
+				next := g.edges[ei].Other(node)
+				if g.openSwitch[next] {
+					boundary[next] = true
+					continue
+				}
+				if assigned[next] < 0 {
+					assigned[next] = island.ID
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		for _, edge := range g.edges {
+			if assigned[edge.From] == island.ID && assigned[edge.To] == island.ID {
+				island.LineIDs = append(island.LineIDs, edge.LineID)
+			}
+		}
+		for node := range boundary {
+			island.BoundarySwitchIDs = append(island.BoundarySwitchIDs, g.ids[node])
+		}
+		sort.Strings(island.BoundarySwitchIDs)
+		island.Energized = len(island.SourceIDs) > 0
+		islands = append(islands, island)
+	}
+	return islands
+}
+
+// Loops 不論開關狀態找出拓樸的基本迴路（以生成樹外的每條線路各形成一個迴路）
+func (g *Graph) Loops() []Loop {
+	parent := make([]int, len(g.ids))
+	parentEdge := make([]int, len(g.ids))
+	depth := make([]int, len(g.ids))
+	visited := make([]bool, len(g.ids))
+	inTree := make([]bool, len(g.edges))
+	for i := range parent {
+		parent[i] = -1
+		parentEdge[i] = -1
+	}
+
+	// 由電源優先建立生成森林，使迴路以離電源較遠的線路為閉合線路
+	starts := append(append([]int{}, g.sources...), g.order...)
+	for i := range g.ids {
+		starts = append(starts, i)
+	}
+	for _, start := range starts {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		queue := []int{start}
+		for head := 0; head < len(queue); head++ {
+			node := queue[head]
+			for _, ei := range g.adjacent[node] {
+				next := g.edges[ei].Other(node)
+				if visited[next] {
+					continue
+				}
+				visited[next] = true
+				parent[next] = node
+				parentEdge[next] = ei
+				depth[next] = depth[node] + 1
+				inTree[ei] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	loops := []Loop{}
+	for ei, edge := range g.edges {
+		if inTree[ei] {
+			continue
+		}
+
+		// 由線路兩端往上走到共同祖先
+		left, right := []int{edge.From}, []int{edge.To}
+		leftEdges, rightEdges := []int{}, []int{}
+		a, b := edge.From, edge.To
+		for a != b {
+			if depth[a] >= depth[b] {
+				leftEdges = append(leftEdges, parentEdge[a])
+				a = parent[a]
+				left = append(left, a)
+			} else {
+				rightEdges = append(rightEdges, parentEdge[b])
+				b = parent[b]
+				right = append(right, b)
+			}
+		}
+
+		nodes := left
+		for i := len(right) - 2; i >= 0; i-- {
+			nodes = append(nodes, right[i])
+		}
+		edges := leftEdges
+		for i := len(rightEdges) - 1; i >= 0; i-- {
+			edges = append(edges, rightEdges[i])
+		}
+		edges = append(edges, ei)
+
+		loop := Loop{
+			NodeIDs:       make([]string, 0, len(nodes)),
+			LineIDs:       make([]string, 0, len(edges)),
+			OpenSwitchIDs: []string{},
+		}
+		for _, node := range nodes {
+			loop.NodeIDs = append(loop.NodeIDs, g.ids[node])
+			if g.openSwitch[node] {
+				loop.OpenSwitchIDs = append(loop.OpenSwitchIDs, g.ids[node])
+			}
+		}
+		for _, e := range edges {
+			loop.LineIDs = append(loop.LineIDs, g.edges[e].LineID)
+		}
+		loop.Closed = len(loop.OpenSwitchIDs) == 0
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// Levels 回傳每個節點在饋線中的層級，依拓樸節點順序排列
+func (g *Graph) Levels() []Level {
+	levels := make([]Level, len(g.ids))
+	for node, id := range g.ids {
+		level := Level{NodeID: id, Energized: g.energized[node], Depth: -1}
+		if g.energized[node] {
+			level.Depth = g.depth[node]
+			level.DistanceKM = round(g.distance[node])
+			level.SourceID = g.ids[g.root[node]]
+			if g.parent[node] >= 0 {
+				level.ParentID = g.ids[g.parent[node]]
+			}
+		}
+		levels[node] = level
+	}
+	return levels
+}
+
+// MaxDepth 已供電節點的最大層級
+func (g *Graph) MaxDepth() int {
+	maxDepth := 0
+	for _, node := range g.order {
+		if g.depth[node] > maxDepth {
+			maxDepth = g.depth[node]
+		}
+	}
+	return maxDepth
+}
@@ -0,0 +1,10 @@
+package graph
+
+import "errors"
+
+var (
+	ErrNodeNotFound  = errors.New("node not found")
+	ErrNotEnergized  = errors.New("node is not connected to a source through closed switches")
+	ErrNoPath        = errors.New("no path between nodes")
+	ErrInvalidSwitch = errors.New("switch not found")
+)
@@ -0,0 +1,259 @@
+// Package graph 提供拓樸的鄰接表表示與圖形查詢：電氣路徑、上下游追蹤、孤島、迴路與饋線層級
+package graph
+
+import (
+	"sync"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 未指定 length_km 且沒有經緯度時的線路長度（與分析模組的預設一致）
+const defaultLengthKM = 1.0
+
+// cacheSize 快取的拓樸版本數量上限
+const cacheSize = 64
+
+// Edge 代表一條線路
+type Edge struct {
+	LineID   string
+	From     int
+	To       int
+	LengthKM float64
+}
+
+// Other 取得線路另一端的節點
+func (e *Edge) Other(node int) int {
+	if e.From == node {
+		return e.To
+	}
+	return e.From
+}
+
+// Graph 拓樸的鄰接表表示，建立時即由電源走訪出輻射樹；建立後不可修改，可安全地併發讀取
+type Graph struct {
+	topologyID string
+	version    int
+
+	ids        []string
+	types      []string
+	openSwitch []bool
+	index      map[string]int
+	edges      []Edge
+	adjacent   [][]int
+	sources    []int
+
+	// 由電源經閉合開關廣度優先走訪的結果；開路開關本身可到達但不再往下延伸
+	order      []int
+	energized  []bool
+	root       []int
+	parent     []int
+	parentEdge []int
+	children   [][]int
+	depth      []int
+	distance   []float64
+}
+
+// New 由拓樸建立圖形
+func New(t *topology.Topology) *Graph {
+	g := &Graph{
+		topologyID: t.ID,
+		version:    t.Version,
+		ids:        make([]string, 0, len(t.Nodes)),
+		types:      make([]string, 0, len(t.Nodes)),
+		openSwitch: make([]bool, 0, len(t.Nodes)),
+		index:      make(map[string]int, len(t.Nodes)),
+		edges:      make([]Edge, 0, len(t.Lines)),
+	}
+
+	for _, node := range t.Nodes {
+		if _, exists := g.index[node.ID]; exists {
+			continue
+		}
+		g.index[node.ID] = len(g.ids)
+		g.ids = append(g.ids, node.ID)
+		g.types = append(g.types, node.Type)
+		g.openSwitch = append(g.openSwitch, node.Type == topology.NodeTypeSwitch &&
+			!topology.BoolProperty(node.Properties, "is_closed", true))
+	}
+	g.adjacent = make([][]int, len(g.ids))
+
+	for _, line := range t.Lines {
+		from, okFrom := g.index[line.FromNodeID]
+		to, okTo := g.index[line.ToNodeID]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+		lengthKM := defaultLengthKM
+		if geoLength, ok := t.GeoLengthKM(line); ok {
+			lengthKM = geoLength
+		}
+		g.adjacent[from] = append(g.adjacent[from], len(g.edges))
+		g.adjacent[to] = append(g.adjacent[to], len(g.edges))
+		g.edges = append(g.edges, Edge{
+			LineID:   line.ID,
+			From:     from,
+			To:       to,
+			LengthKM: topology.FloatProperty(line.Properties, "length_km", lengthKM),
+		})
+	}
+
+	for _, source := range topology.SourceNodes(t) {
+		if index, ok := g.index[source.ID]; ok {
+			g.sources = append(g.sources, index)
+			// 電源節點即使是開關也視為閉合
+			g.openSwitch[index] = false
+		}
+	}
+
+	g.buildTree()
+	return g
+}
+
+// buildTree 由所有電源同時廣度優先走訪，決定供電狀態、上游方向與層級
+func (g *Graph) buildTree() {
+	count := len(g.ids)
+	g.energized = make([]bool, count)
+	g.root = make([]int, count)
+	g.parent = make([]int, count)
+	g.parentEdge = make([]int, count)
+	g.children = make([][]int, count)
+	g.depth = make([]int, count)
+	g.distance = make([]float64, count)
+	for i := range g.parent {
+		g.root[i] = -1
+		g.parent[i] = -1
+		g.parentEdge[i] = -1
+	}
+
+	g.order = make([]int, 0, count)
+	for _, source := range g.sources {
+		if g.energized[source] {
+			continue
+		}
+		g.energized[source] = true
+		g.root[source] = source
+		g.order = append(g.order, source)
+	}
+
+	for head := 0; head < len(g.order); head++ {
+		current := g.order[head]
+		if g.openSwitch[current] {
+			continue
+		}
+		for _, ei := range g.adjacent[current] {
+			edge := &g.edges[ei]
+			next := edge.Other(current)
+			if g.energized[next] {
+				continue
+			}
+			g.energized[next] = true
+			g.root[next] = g.root[current]
+			g.parent[next] = current
+			g.parentEdge[next] = ei
+			g.depth[next] = g.depth[current] + 1
+			g.distance[next] = g.distance[current] + edge.LengthKM
+			g.children[current] = append(g.children[current], next)
+			g.order = append(g.order, next)
+		}
+	}
+}
+
+// TopologyID 建立圖形的拓樸 ID
+func (g *Graph) TopologyID() string {
+	return g.topologyID
+}
+
+// Version 建立圖形的拓樸版本
+func (g *Graph) Version() int {
+	return g.version
+}
+
+// NodeIndex 依節點 ID 取得索引
+func (g *Graph) NodeIndex(nodeID string) (int, bool) {
+	index, exists := g.index[nodeID]
+	return index, exists
+}
+
+// NodeID 取得索引對應的節點 ID
+func (g *Graph) NodeID(index int) string {
+	return g.ids[index]
+}
+
+// Edges 取得所有線路
+func (g *Graph) Edges() []Edge {
+	return g.edges
+}
+
+// EdgesAt 取得與節點相連的線路索引
+func (g *Graph) EdgesAt(node int) []int {
+	return g.adjacent[node]
+}
+
+// IsOpenSwitch 判斷節點是否為開路開關
+func (g *Graph) IsOpenSwitch(node int) bool {
+	return g.openSwitch[node]
+}
+
+// IsSwitch 判斷節點是否為開關
+func (g *Graph) IsSwitch(node int) bool {
+	return g.types[node] == topology.NodeTypeSwitch
+}
+
+// Energized 判斷節點是否經閉合開關連接到電源
+func (g *Graph) Energized(node int) bool {
+	return g.energized[node]
+}
+
+// Parent 取得輻射樹中的上游節點（電源或未供電節點為 -1）
+func (g *Graph) Parent(node int) int {
+	return g.parent[node]
+}
+
+// Children 取得輻射樹中的下游節點
+func (g *Graph) Children(node int) []int {
+	return g.children[node]
+}
+
+// graphCache 依拓樸 ID 與版本快取已建立的圖形，超過上限時淘汰最早加入的項目
+type graphCache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]*Graph
+	order   []cacheKey
+}
+
+type cacheKey struct {
+	topologyID string
+	version    int
+}
+
+var cache = &graphCache{entries: make(map[cacheKey]*Graph)}
+
+// For 取得拓樸的圖形，相同 ID 與版本的拓樸共用快取；未儲存（沒有 ID）的拓樸每次重新建立
+func For(t *topology.Topology) *Graph {
+	if t.ID == "" {
+		return New(t)
+	}
+
+	key := cacheKey{topologyID: t.ID, version: t.Version}
+	cache.mu.Lock()
+	g, exists := cache.entries[key]
+	cache.mu.Unlock()
+	if exists {
+		return g
+	}
+
+	g = New(t)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cached, exists := cache.entries[key]; exists {
+		return cached
+	}
+	if len(cache.order) >= cacheSize {
+		delete(cache.entries, cache.order[0])
+		cache.order = cache.order[1:]
+	}
+	cache.entries[key] = g
+	cache.order = append(cache.order, key)
+	return g
+}
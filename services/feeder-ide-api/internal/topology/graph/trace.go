@@ -0,0 +1,218 @@
+package graph
+
+import (
+	"container/heap"
+	"math"
+)
+
+// 追蹤方向
+const (
+	DirectionUpstream   = "upstream"
+	DirectionDownstream = "downstream"
+)
+
+// Trace 上游或下游追蹤結果
+type Trace struct {
+	From       string   `json:"from"`
+	Direction  string   `json:"direction"`
+	SourceID   string   `json:"source_id"`   // 供電的電源節點
+	Depth      int      `json:"depth"`       // 起點距電源的節點層級
+	DistanceKM float64  `json:"distance_km"` // 起點沿線路至電源的距離
+	NodeIDs    []string `json:"node_ids"`    // 上游：起點到電源依序排列；下游：含起點的廣度優先順序
+	LineIDs    []string `json:"line_ids"`
+	SwitchIDs  []string `json:"switch_ids"` // 經過的開關（下游追蹤含邊界的開路開關）
+}
+
+// Path 兩節點間的路徑
+type Path struct {
+	From          string   `json:"from"`
+	To            string   `json:"to"`
+	NodeIDs       []string `json:"node_ids"`
+	LineIDs       []string `json:"line_ids"`
+	SwitchIDs     []string `json:"switch_ids"`
+	OpenSwitchIDs []string `json:"open_switch_ids"` // 路徑經過的開路開關（僅 IgnoreSwitches 時可能出現）
+	LengthKM      float64  `json:"length_km"`
+	Hops          int      `json:"hops"`
+}
+
+// PathOptions 路徑查詢選項
+type PathOptions struct {
+	IgnoreSwitches bool // 允許穿過開路開關（例如規劃聯絡路徑）
+}
+
+// Upstream 追蹤節點到電源的供電路徑
+func (g *Graph) Upstream(nodeID string) (*Trace, error) {
+	start, err := g.energizedNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := g.newTrace(start, DirectionUpstream)
+	for node := start; node >= 0; node = g.parent[node] {
+		g.appendNode(trace, node)
+		if g.parentEdge[node] >= 0 {
+			trace.LineIDs = append(trace.LineIDs, g.edges[g.parentEdge[node]].LineID)
+		}
+	}
+	return trace, nil
+}
+
+// Downstream 追蹤由節點（或開關）供電的所有下游節點；開路開關沒有下游
+func (g *Graph) Downstream(nodeID string) (*Trace, error) {
+	start, err := g.energizedNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := g.newTrace(start, DirectionDownstream)
+	queue := []int{start}
+	for head := 0; head < len(queue); head++ {
+		node := queue[head]
+		g.appendNode(trace, node)
+		if node != start {
+			trace.LineIDs = append(trace.LineIDs, g.edges[g.parentEdge[node]].LineID)
+		}
+		queue = append(queue, g.children[node]...)
+	}
+	return trace, nil
+}
+
+// ShortestPath 以線路長度為權重找出兩節點間的最短路徑；預設不穿過開路開關（起訖點本身除外）
+func (g *Graph) ShortestPath(fromID, toID string, opts PathOptions) (*Path, error) {
+	from, ok := g.index[fromID]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	to, ok := g.index[toID]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+
+	dist := make([]float64, len(g.ids))
+	hops := make([]int, len(g.ids))
+	prevEdge := make([]int, len(g.ids))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prevEdge[i] = -1
+	}
+	dist[from] = 0
+
+	queue := &distanceQueue{{node: from}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(distanceItem)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		if item.node == to {
+			break
+		}
+		if item.node != from && g.openSwitch[item.node] && !opts.IgnoreSwitches {
+			continue
+		}
+		for _, ei := range g.adjacent[item.node] {
+			edge := &g.edges[ei]
+			next := edge.Other(item.node)
+			candidate := dist[item.node] + edge.LengthKM
+			// 長度相同時取節點數較少的路徑
+			if candidate < dist[next] || (candidate == dist[next] && hops[item.node]+1 < hops[next]) {
+				dist[next] = candidate
+				hops[next] = hops[item.node] + 1
+				prevEdge[next] = ei
+				heap.Push(queue, distanceItem{node: next, dist: candidate})
+			}
+		}
+	}
+	if math.IsInf(dist[to], 1) {
+		return nil, ErrNoPath
+	}
+
+	nodes := []int{to}
+	edges := []int{}
+	for node := to; node != from; {
+		ei := prevEdge[node]
+		edges = append(edges, ei)
+		node = g.edges[ei].Other(node)
+		nodes = append(nodes, node)
+	}
+
+	path := &Path{
+		From:          fromID,
+		To:            toID,
+		NodeIDs:       make([]string, 0, len(nodes)),
+		LineIDs:       make([]string, 0, len(edges)),
+		SwitchIDs:     []string{},
+		OpenSwitchIDs: []string{},
+		LengthKM:      round(dist[to]),
+		Hops:          len(edges),
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
+		path.NodeIDs = append(path.NodeIDs, g.ids[node])
+		if g.IsSwitch(node) {
+			path.SwitchIDs = append(path.SwitchIDs, g.ids[node])
+			if g.openSwitch[node] {
+				path.OpenSwitchIDs = append(path.OpenSwitchIDs, g.ids[node])
+			}
+		}
+	}
+	for i := len(edges) - 1; i >= 0; i-- {
+		path.LineIDs = append(path.LineIDs, g.edges[edges[i]].LineID)
+	}
+	return path, nil
+}
+
+// energizedNode 取得已供電節點的索引
+func (g *Graph) energizedNode(nodeID string) (int, error) {
+	node, ok := g.index[nodeID]
+	if !ok {
+		return -1, ErrNodeNotFound
+	}
+	if !g.energized[node] {
+		return -1, ErrNotEnergized
+	}
+	return node, nil
+}
+
+func (g *Graph) newTrace(start int, direction string) *Trace {
+	return &Trace{
+		From:       g.ids[start],
+		Direction:  direction,
+		SourceID:   g.ids[g.root[start]],
+		Depth:      g.depth[start],
+		DistanceKM: round(g.distance[start]),
+		NodeIDs:    []string{},
+		LineIDs:    []string{},
+		SwitchIDs:  []string{},
+	}
+}
+
+func (g *Graph) appendNode(trace *Trace, node int) {
+	trace.NodeIDs = append(trace.NodeIDs, g.ids[node])
+	if g.IsSwitch(node) {
+		trace.SwitchIDs = append(trace.SwitchIDs, g.ids[node])
+	}
+}
+
+// round 四捨五入到小數點後四位
+func round(value float64) float64 {
+	return math.Round(value*1e4) / 1e4
+}
+
+type distanceItem struct {
+	node int
+	dist float64
+}
+
+// distanceQueue Dijkstra 使用的最小堆積
+type distanceQueue []distanceItem
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(distanceItem)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,9 @@
+package layout
+
+import "errors"
+
+var (
+	ErrInvalidOptions = errors.New("invalid layout options")
+	ErrEmptyTopology  = errors.New("topology has no nodes")
+	ErrNoGeoNodes     = errors.New("geo layout requires nodes with geo coordinates")
+)
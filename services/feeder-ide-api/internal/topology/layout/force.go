@@ -0,0 +1,121 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 力導向排版參數
+const (
+	// forcePairBudget 限制總計算量（節點對數 × 迭代次數），大型拓樸自動減少迭代次數
+	forcePairBudget    = 5e7
+	minForceIterations = 10
+	// layerStrength 每次迭代把節點拉回所屬層級的比例，讓環狀網路仍保有由電源往下的方向感
+	layerStrength = 0.2
+	// repulsionCutoff 超過此距離（以理想線長的倍數計）的節點對不互相排斥
+	repulsionCutoff = 4.0
+)
+
+// force 以 Fruchterman-Reingold 力導向演算法由初始排版調整座標
+// 鎖定的節點不移動；layered 時節點沿電源方向的座標受層級約束，與初始排版的層級保持一致
+func (g *forest) force(positions []topology.Position, locked []bool, orientation string, layered bool, opts Options) {
+	count := len(g.ids)
+	if count < 2 {
+		return
+	}
+	// 層級約束的軸與目標值（沿用初始排版中節點的層級座標）
+	vertical := orientation == OrientationTopDown || orientation == OrientationBottomUp
+	target := make([]float64, count)
+	for node, p := range positions {
+		if vertical {
+			target[node] = p.Y
+		} else {
+			target[node] = p.X
+		}
+	}
+
+	k := (opts.SpacingX + opts.SpacingY) / 2
+	cutoff := repulsionCutoff * k
+	iterations := opts.Iterations
+	if budget := int(forcePairBudget / float64(count*count)); budget < iterations {
+		iterations = budget
+	}
+	if iterations < minForceIterations {
+		iterations = minForceIterations
+	}
+
+	temperature := k * math.Sqrt(float64(count)) / 2
+	cooling := temperature / float64(iterations+1)
+	dx := make([]float64, count)
+	dy := make([]float64, count)
+
+	for iter := 0; iter < iterations; iter++ {
+		for i := range dx {
+			dx[i], dy[i] = 0, 0
+		}
+
+		for i := 0; i < count; i++ {
+			for j := i + 1; j < count; j++ {
+				vx := positions[i].X - positions[j].X
+				vy := positions[i].Y - positions[j].Y
+				if math.Abs(vx) > cutoff || math.Abs(vy) > cutoff {
+					continue
+				}
+				dist := math.Hypot(vx, vy)
+				if dist < 1e-3 {
+					// 重疊的節點以固定方向分開，維持結果可重現
+					vx, vy, dist = float64(j-i), 1, math.Hypot(float64(j-i), 1)
+				}
+				if dist > cutoff {
+					continue
+				}
+				f := k * k / dist
+				dx[i] += vx / dist * f
+				dy[i] += vy / dist * f
+				dx[j] -= vx / dist * f
+				dy[j] -= vy / dist * f
+			}
+		}
+
+		for i := 0; i < count; i++ {
+			for _, j := range g.adjacent[i] {
+				if j < i {
+					continue
+				}
+				vx := positions[i].X - positions[j].X
+				vy := positions[i].Y - positions[j].Y
+				dist := math.Hypot(vx, vy)
+				if dist < 1e-3 {
+					continue
+				}
+				f := dist * dist / k
+				dx[i] -= vx / dist * f
+				dy[i] -= vy / dist * f
+				dx[j] += vx / dist * f
+				dy[j] += vy / dist * f
+			}
+		}
+
+		for i := 0; i < count; i++ {
+			if locked[i] {
+				continue
+			}
+			length := math.Hypot(dx[i], dy[i])
+			if length > 0 {
+				step := math.Min(length, temperature)
+				positions[i].X += dx[i] / length * step
+				positions[i].Y += dy[i] / length * step
+			}
+			if !layered {
+				continue
+			}
+			if vertical {
+				positions[i].Y += (target[i] - positions[i].Y) * layerStrength
+			} else {
+				positions[i].X += (target[i] - positions[i].X) * layerStrength
+			}
+		}
+		temperature -= cooling
+	}
+}
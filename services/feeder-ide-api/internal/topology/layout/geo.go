@@ -0,0 +1,110 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// geoExtentPerNode 經緯度投影後較長邊的畫布長度，以同層間距乘上節點數平方根計算，使節點密度與其他排版相近
+const geoExtentPerNode = 2.0
+
+// geo 以等距圓柱投影（北方朝上）放置有經緯度的節點，其餘節點由相鄰已定位節點的位置出發，
+// 再以力導向演算法在固定經緯度節點的情況下調整；與經緯度節點不相連的區塊以整齊樹排在右側
+func (g *forest) geo(locked []bool, opts Options) ([]topology.Position, error) {
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	anchored := 0
+	for _, point := range g.geoPoints {
+		if point == nil {
+			continue
+		}
+		anchored++
+		minLat, maxLat = math.Min(minLat, point.Lat), math.Max(maxLat, point.Lat)
+		minLon, maxLon = math.Min(minLon, point.Lon), math.Max(maxLon, point.Lon)
+	}
+	if anchored == 0 {
+		return nil, ErrNoGeoNodes
+	}
+
+	midLat := (minLat + maxLat) / 2 * math.Pi / 180
+	extent := math.Max((maxLon-minLon)*math.Cos(midLat), maxLat-minLat)
+	scale := 0.0
+	if extent > 0 {
+		scale = geoExtentPerNode * opts.SpacingX * math.Sqrt(float64(len(g.ids))) / extent
+	}
+
+	positions := make([]topology.Position, len(g.ids))
+	placed := make([]bool, len(g.ids))
+	fixed := make([]bool, len(g.ids))
+	queue := []int{}
+	for node, point := range g.geoPoints {
+		if point == nil {
+			continue
+		}
+		positions[node] = topology.Position{
+			X: (point.Lon - minLon) * math.Cos(midLat) * scale,
+			Y: (maxLat - point.Lat) * scale,
+		}
+		placed[node] = true
+		fixed[node] = true
+		queue = append(queue, node)
+	}
+
+	// 由已定位節點往外擴散，新節點放在已定位鄰居的重心並稍微錯開
+	for head := 0; head < len(queue); head++ {
+		node := queue[head]
+		offset := 0
+		for _, next := range g.adjacent[node] {
+			if placed[next] {
+				continue
+			}
+			sumX, sumY, count := 0.0, 0.0, 0
+			for _, neighbor := range g.adjacent[next] {
+				if placed[neighbor] {
+					sumX += positions[neighbor].X
+					sumY += positions[neighbor].Y
+					count++
+				}
+			}
+			offset++
+			angle := float64(offset) * 2 * math.Pi / float64(len(g.adjacent[node])+1)
+			positions[next] = topology.Position{
+				X: sumX/float64(count) + math.Cos(angle)*opts.SpacingX/2,
+				Y: sumY/float64(count) + math.Sin(angle)*opts.SpacingY/2,
+			}
+			placed[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	if len(queue) < len(g.ids) {
+		width := 0.0
+		for node := range g.ids {
+			if placed[node] {
+				width = math.Max(width, positions[node].X)
+			}
+		}
+		tree := g.tidyTree(opts)
+		minX := math.Inf(1)
+		for node := range g.ids {
+			if !placed[node] {
+				minX = math.Min(minX, tree[node].X)
+			}
+		}
+		for node := range g.ids {
+			if !placed[node] {
+				positions[node] = topology.Position{X: width + opts.SpacingX*2 + tree[node].X - minX, Y: tree[node].Y}
+			}
+		}
+	}
+
+	pinned := make([]bool, len(g.ids))
+	for node := range g.ids {
+		pinned[node] = fixed[node] || locked[node]
+	}
+	if len(queue) > anchored {
+		g.force(positions, pinned, OrientationTopDown, false, opts)
+	}
+	return positions, nil
+}
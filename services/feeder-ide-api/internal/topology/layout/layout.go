@@ -0,0 +1,328 @@
+// Package layout 為拓樸計算畫布座標：輻射樹（整齊樹 / 分層）、弱環狀網路（受約束的力導向）與以經緯度定位的排版
+package layout
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// 排版演算法
+const (
+	AlgorithmAuto    = "auto"
+	AlgorithmTree    = "tree"
+	AlgorithmLayered = "layered"
+	AlgorithmForce   = "force"
+	AlgorithmGeo     = "geo"
+)
+
+// 排版方向（電源所在的一側）
+const (
+	OrientationTopDown   = "top_down"
+	OrientationBottomUp  = "bottom_up"
+	OrientationLeftRight = "left_right"
+	OrientationRightLeft = "right_left"
+)
+
+// 預設間距（畫布座標）與力導向迭代次數
+const (
+	DefaultSpacingX   = 120.0
+	DefaultSpacingY   = 100.0
+	DefaultIterations = 300
+	maxIterations     = 5000
+)
+
+// Options 排版選項
+type Options struct {
+	Algorithm     string   `json:"algorithm,omitempty"`   // auto（預設）、tree、layered、force、geo
+	Orientation   string   `json:"orientation,omitempty"` // top_down（預設）、bottom_up、left_right、right_left；geo 固定北方朝上
+	SpacingX      float64  `json:"spacing_x,omitempty"`   // 同層節點間距
+	SpacingY      float64  `json:"spacing_y,omitempty"`   // 層與層間距
+	Iterations    int      `json:"iterations,omitempty"`  // 力導向迭代次數
+	LockedNodeIDs []string `json:"locked_node_ids,omitempty"`
+}
+
+func (o Options) withDefaults() Options {
+	if o.Algorithm == "" {
+		o.Algorithm = AlgorithmAuto
+	}
+	if o.Orientation == "" {
+		o.Orientation = OrientationTopDown
+	}
+	if o.SpacingX == 0 {
+		o.SpacingX = DefaultSpacingX
+	}
+	if o.SpacingY == 0 {
+		o.SpacingY = DefaultSpacingY
+	}
+	if o.Iterations == 0 {
+		o.Iterations = DefaultIterations
+	}
+	return o
+}
+
+// Validate 檢查選項
+func (o Options) Validate() error {
+	o = o.withDefaults()
+	switch o.Algorithm {
+	case AlgorithmAuto, AlgorithmTree, AlgorithmLayered, AlgorithmForce, AlgorithmGeo:
+	default:
+		return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidOptions, o.Algorithm)
+	}
+	switch o.Orientation {
+	case OrientationTopDown, OrientationBottomUp, OrientationLeftRight, OrientationRightLeft:
+	default:
+		return fmt.Errorf("%w: unknown orientation %q", ErrInvalidOptions, o.Orientation)
+	}
+	if o.SpacingX < 0 || o.SpacingY < 0 {
+		return fmt.Errorf("%w: spacing must be positive", ErrInvalidOptions)
+	}
+	if o.Iterations < 0 || o.Iterations > maxIterations {
+		return fmt.Errorf("%w: iterations must be between 1 and %d", ErrInvalidOptions, maxIterations)
+	}
+	return nil
+}
+
+// NodePosition 節點的畫布座標
+type NodePosition struct {
+	NodeID   string            `json:"node_id"`
+	Position topology.Position `json:"position"`
+}
+
+// Result 排版結果
+type Result struct {
+	Algorithm   string         `json:"algorithm"` // 實際使用的演算法（auto 會解析為其他演算法）
+	Orientation string         `json:"orientation"`
+	Width       float64        `json:"width"`
+	Height      float64        `json:"height"`
+	Positions   []NodePosition `json:"positions"` // 依拓樸節點順序，含鎖定的節點
+}
+
+// Apply 將排版結果寫入拓樸節點座標，不修改其他內容
+func (r *Result) Apply(t *topology.Topology) {
+	positions := make(map[string]topology.Position, len(r.Positions))
+	for _, p := range r.Positions {
+		positions[p.NodeID] = p.Position
+	}
+	for i, node := range t.Nodes {
+		if position, ok := positions[node.ID]; ok {
+			t.Nodes[i].Position = position
+		}
+	}
+}
+
+// Compute 計算拓樸的排版，不修改拓樸
+func Compute(t *topology.Topology, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	if len(t.Nodes) == 0 {
+		return nil, ErrEmptyTopology
+	}
+
+	g := newForest(t)
+	locked := make([]bool, len(g.ids))
+	for _, id := range opts.LockedNodeIDs {
+		if index, ok := g.index[id]; ok {
+			locked[index] = true
+		}
+	}
+
+	algorithm := opts.Algorithm
+	if algorithm == AlgorithmAuto {
+		algorithm = g.suggest()
+	}
+
+	var positions []topology.Position
+	orientation := opts.Orientation
+	switch algorithm {
+	case AlgorithmTree:
+		positions = orient(g.tidyTree(opts), orientation)
+	case AlgorithmLayered:
+		positions = orient(g.layered(opts), orientation)
+	case AlgorithmForce:
+		positions = orient(g.tidyTree(opts), orientation)
+		for node := range g.ids {
+			if locked[node] {
+				positions[node] = g.positions[node]
+			}
+		}
+		g.force(positions, locked, orientation, true, opts)
+	case AlgorithmGeo:
+		var err error
+		if positions, err = g.geo(locked, opts); err != nil {
+			return nil, err
+		}
+		orientation = OrientationTopDown
+	}
+
+	anyLocked := false
+	for node := range g.ids {
+		if locked[node] {
+			positions[node] = g.positions[node]
+			anyLocked = true
+		}
+	}
+	// 沒有鎖定節點時將排版移到原點
+	if !anyLocked {
+		normalize(positions)
+	}
+
+	result := &Result{
+		Algorithm:   algorithm,
+		Orientation: orientation,
+		Positions:   make([]NodePosition, 0, len(t.Nodes)),
+	}
+	for node, id := range g.ids {
+		position := topology.Position{X: round(positions[node].X), Y: round(positions[node].Y)}
+		result.Width = math.Max(result.Width, position.X)
+		result.Height = math.Max(result.Height, position.Y)
+		result.Positions = append(result.Positions, NodePosition{NodeID: id, Position: position})
+	}
+	return result, nil
+}
+
+// forest 不論開關狀態、由電源優先建立的生成森林
+type forest struct {
+	ids       []string
+	index     map[string]int
+	positions []topology.Position
+	geoPoints []*topology.GeoPoint
+	adjacent  [][]int
+	roots     []int
+	parent    []int
+	children  [][]int
+	depth     []int
+	order     []int // 廣度優先順序
+	extra     int   // 生成森林以外的線路數（迴路數）
+}
+
+func newForest(t *topology.Topology) *forest {
+	g := &forest{index: make(map[string]int, len(t.Nodes))}
+	for _, node := range t.Nodes {
+		if _, exists := g.index[node.ID]; exists {
+			continue
+		}
+		g.index[node.ID] = len(g.ids)
+		g.ids = append(g.ids, node.ID)
+		g.positions = append(g.positions, node.Position)
+		g.geoPoints = append(g.geoPoints, node.Geo)
+	}
+
+	count := len(g.ids)
+	g.adjacent = make([][]int, count)
+	seen := make(map[[2]int]bool, len(t.Lines))
+	for _, line := range t.Lines {
+		from, okFrom := g.index[line.FromNodeID]
+		to, okTo := g.index[line.ToNodeID]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+		// 平行線路只畫一次
+		key := [2]int{from, to}
+		if to < from {
+			key = [2]int{to, from}
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		g.adjacent[from] = append(g.adjacent[from], to)
+		g.adjacent[to] = append(g.adjacent[to], from)
+	}
+
+	g.parent = make([]int, count)
+	g.children = make([][]int, count)
+	g.depth = make([]int, count)
+	visited := make([]bool, count)
+	starts := []int{}
+	for _, source := range topology.SourceNodes(t) {
+		starts = append(starts, g.index[source.ID])
+	}
+	for node := range g.ids {
+		starts = append(starts, node)
+	}
+
+	edges := len(seen)
+	for _, start := range starts {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		g.parent[start] = -1
+		g.roots = append(g.roots, start)
+		g.order = append(g.order, start)
+		for head := len(g.order) - 1; head < len(g.order); head++ {
+			node := g.order[head]
+			for _, next := range g.adjacent[node] {
+				if visited[next] {
+					continue
+				}
+				visited[next] = true
+				g.parent[next] = node
+				g.depth[next] = g.depth[node] + 1
+				g.children[node] = append(g.children[node], next)
+				g.order = append(g.order, next)
+			}
+		}
+	}
+	g.extra = edges - (count - len(g.roots))
+	return g
+}
+
+// suggest 依拓樸特性選擇演算法：多數節點有經緯度時用 geo，有迴路時用 force，其餘用 tree
+func (g *forest) suggest() string {
+	withGeo := 0
+	for _, point := range g.geoPoints {
+		if point != nil {
+			withGeo++
+		}
+	}
+	switch {
+	case withGeo*2 >= len(g.ids):
+		return AlgorithmGeo
+	case g.extra > 0:
+		return AlgorithmForce
+	default:
+		return AlgorithmTree
+	}
+}
+
+// orient 將由上往下的排版轉換為指定方向
+func orient(positions []topology.Position, orientation string) []topology.Position {
+	maxY := 0.0
+	for _, p := range positions {
+		maxY = math.Max(maxY, p.Y)
+	}
+	for i, p := range positions {
+		switch orientation {
+		case OrientationBottomUp:
+			positions[i] = topology.Position{X: p.X, Y: maxY - p.Y}
+		case OrientationLeftRight:
+			positions[i] = topology.Position{X: p.Y, Y: p.X}
+		case OrientationRightLeft:
+			positions[i] = topology.Position{X: maxY - p.Y, Y: p.X}
+		}
+	}
+	return positions
+}
+
+// normalize 平移座標使最小值為 0
+func normalize(positions []topology.Position) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, p := range positions {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+	}
+	for i := range positions {
+		positions[i].X -= minX
+		positions[i].Y -= minY
+	}
+}
+
+// round 四捨五入到小數點後一位
+func round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,131 @@
+package layout
+
+import (
+	"sort"
+
+	"github.com/feeder-platform/feeder-ide-api/internal/topology"
+)
+
+// layeredSweeps 分層排版重心排序的上下掃描次數
+const layeredSweeps = 4
+
+// tidyTree 整齊樹排版：葉節點依序佔用欄位，父節點置中於第一個與最後一個子節點之上；各連通區塊由左至右排列
+func (g *forest) tidyTree(opts Options) []topology.Position {
+	positions := make([]topology.Position, len(g.ids))
+	next := 0.0
+
+	var place func(node int) float64
+	place = func(node int) float64 {
+		var x float64
+		if len(g.children[node]) == 0 {
+			x = next
+			next++
+		} else {
+			first := place(g.children[node][0])
+			last := first
+			for _, child := range g.children[node][1:] {
+				last = place(child)
+			}
+			x = (first + last) / 2
+		}
+		positions[node] = topology.Position{
+			X: x * opts.SpacingX,
+			Y: float64(g.depth[node]) * opts.SpacingY,
+		}
+		return x
+	}
+
+	for _, root := range g.roots {
+		place(root)
+	}
+	return positions
+}
+
+// layered 分層排版：節點依與電源的層級分列，以相鄰層的重心排序減少線路交錯，每層置中；各連通區塊由左至右排列
+func (g *forest) layered(opts Options) []topology.Position {
+	positions := make([]topology.Position, len(g.ids))
+	component := make([]int, len(g.ids))
+	for i, root := range g.roots {
+		component[root] = i
+	}
+	for _, node := range g.order {
+		if g.parent[node] >= 0 {
+			component[node] = component[g.parent[node]]
+		}
+	}
+
+	layersByComponent := make([][][]int, len(g.roots))
+	for _, node := range g.order {
+		c := component[node]
+		for len(layersByComponent[c]) <= g.depth[node] {
+			layersByComponent[c] = append(layersByComponent[c], nil)
+		}
+		layersByComponent[c][g.depth[node]] = append(layersByComponent[c][g.depth[node]], node)
+	}
+
+	rank := make([]float64, len(g.ids))
+	offset := 0.0
+	for _, layers := range layersByComponent {
+		for _, layer := range layers {
+			for i, node := range layer {
+				rank[node] = float64(i)
+			}
+		}
+
+		for sweep := 0; sweep < layeredSweeps; sweep++ {
+			if sweep%2 == 0 {
+				for d := 1; d < len(layers); d++ {
+					g.sortByBarycenter(layers[d], rank, d-1)
+				}
+			} else {
+				for d := len(layers) - 2; d >= 0; d-- {
+					g.sortByBarycenter(layers[d], rank, d+1)
+				}
+			}
+		}
+
+		width := 0
+		for _, layer := range layers {
+			if len(layer) > width {
+				width = len(layer)
+			}
+		}
+		for d, layer := range layers {
+			start := offset + float64(width-len(layer))/2
+			for i, node := range layer {
+				positions[node] = topology.Position{
+					X: (start + float64(i)) * opts.SpacingX,
+					Y: float64(d) * opts.SpacingY,
+				}
+			}
+		}
+		offset += float64(width)
+	}
+	return positions
+}
+
+// sortByBarycenter 依節點在相鄰層（depth 為 neighborDepth）鄰居的平均位置排序，沒有鄰居的節點保持原位置
+func (g *forest) sortByBarycenter(layer []int, rank []float64, neighborDepth int) {
+	barycenter := make(map[int]float64, len(layer))
+	for _, node := range layer {
+		sum, count := 0.0, 0
+		for _, next := range g.adjacent[node] {
+			if g.depth[next] == neighborDepth {
+				sum += rank[next]
+				count++
+			}
+		}
+		if count > 0 {
+			barycenter[node] = sum / float64(count)
+		} else {
+			barycenter[node] = rank[node]
+		}
+	}
+
+	sort.SliceStable(layer, func(i, j int) bool {
+		return barycenter[layer[i]] < barycenter[layer[j]]
+	})
+	for i, node := range layer {
+		rank[node] = float64(i)
+	}
+}
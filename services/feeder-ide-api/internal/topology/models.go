@@ -4,23 +4,35 @@ import "time"
 
 // Topology 代表一個配電 feeder 拓樸
 type Topology struct {
-	ID          string    `json:"id"`
-	UserID      *string   `json:"user_id,omitempty"` // 可選，無註冊用戶為 nil
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	ProfileType string    `json:"profile_type"` // rural, suburban, urban
-	Nodes       []Node    `json:"nodes"`
-	Lines       []Line    `json:"lines"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             string    `json:"id"`
+	UserID         *string   `json:"user_id,omitempty"`         // 可選，無註冊用戶為 nil
+	OrganizationID *string   `json:"organization_id,omitempty"` // 組織擁有的拓樸，此時 UserID 為 nil
+	Name           string    `json:"name"`
+	Description    string    `json:"description,omitempty"`
+	ProfileType    string    `json:"profile_type"` // rural, suburban, urban
+	Nodes          []Node    `json:"nodes"`
+	Lines          []Line    `json:"lines"`
+	Version        int       `json:"version"` // 每次更新遞增，用於樂觀鎖（ETag）
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// 節點類型
+const (
+	NodeTypeBus         = "bus"
+	NodeTypeTransformer = "transformer"
+	NodeTypeSwitch      = "switch"
+	NodeTypeEVCharger   = "ev_charger"
+	NodeTypeDER         = "der"
+)
+
 // Node 代表拓樸中的節點（bus）
 type Node struct {
 	ID       string  `json:"id"`
 	Type     string  `json:"type"` // bus, transformer, switch, ev_charger, der
 	Name     string  `json:"name"`
 	Position Position `json:"position"`
+	Geo      *GeoPoint `json:"geo,omitempty"` // 可選的 WGS84 經緯度
 	Properties map[string]interface{} `json:"properties,omitempty"`
 }
 
@@ -30,9 +42,18 @@ type Line struct {
 	FromNodeID string  `json:"from_node_id"`
 	ToNodeID   string  `json:"to_node_id"`
 	Name       string  `json:"name,omitempty"`
+	Geometry   []GeoPoint `json:"geometry,omitempty"` // 可選的線路折點（WGS84，不含兩端節點）
 	Properties map[string]interface{} `json:"properties,omitempty"`
 }
 
+// LineProperties 線路電氣參數
+type LineProperties struct {
+	LengthKM  float64 `json:"length_km"`
+	ROhmPerKM float64 `json:"r_ohm_per_km"`
+	XOhmPerKM float64 `json:"x_ohm_per_km"`
+	AmpacityA float64 `json:"ampacity_a"`
+}
+
 // Position 代表節點在畫布上的位置
 type Position struct {
 	X float64 `json:"x"`
@@ -45,13 +66,17 @@ type TransformerProperties struct {
 	RatedCapacityKVA float64 `json:"rated_capacity_kva"`
 	PrimaryVoltage    float64 `json:"primary_voltage"`
 	SecondaryVoltage  float64 `json:"secondary_voltage"`
+	ImpedancePercent  float64 `json:"impedance_percent,omitempty"` // 短路阻抗（%，以額定容量為基準）
+	XRRatio           float64 `json:"x_r_ratio,omitempty"`
+	Connection        string  `json:"connection,omitempty"` // dyn（預設）、ynyn、yd、dd，決定零序路徑
 }
 
 // SwitchProperties 開關屬性
 type SwitchProperties struct {
-	Type        string `json:"type"` // sectionalizer, recloser, breaker
+	Type        string `json:"type"` // sectionalizer, recloser, breaker, fuse
 	IsClosed    bool   `json:"is_closed"`
 	IsAutomated bool   `json:"is_automated"`
+	InterruptingRatingKA float64 `json:"interrupting_rating_ka,omitempty"` // 啟斷容量（kA）
 }
 
 // EVChargerProperties EV 充電樁屬性
@@ -68,3 +93,70 @@ type DERProperties struct {
 	IsControllable bool  `json:"is_controllable"`
 }
 
+// Clone 深拷貝拓樸（包含節點、線路與屬性）
+func (t *Topology) Clone() *Topology {
+	if t == nil {
+		return nil
+	}
+
+	clone := *t
+	if t.UserID != nil {
+		userID := *t.UserID
+		clone.UserID = &userID
+	}
+	if t.OrganizationID != nil {
+		organizationID := *t.OrganizationID
+		clone.OrganizationID = &organizationID
+	}
+
+	if t.Nodes != nil {
+		clone.Nodes = make([]Node, len(t.Nodes))
+		for i, node := range t.Nodes {
+			node.Properties = cloneProperties(node.Properties)
+			if node.Geo != nil {
+				geo := *node.Geo
+				node.Geo = &geo
+			}
+			clone.Nodes[i] = node
+		}
+	}
+
+	if t.Lines != nil {
+		clone.Lines = make([]Line, len(t.Lines))
+		for i, line := range t.Lines {
+			line.Properties = cloneProperties(line.Properties)
+			if line.Geometry != nil {
+				line.Geometry = append([]GeoPoint(nil), line.Geometry...)
+			}
+			clone.Lines[i] = line
+		}
+	}
+
+	return &clone
+}
+
+func cloneProperties(props map[string]interface{}) map[string]interface{} {
+	if props == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(props))
+	for key, value := range props {
+		clone[key] = cloneValue(value)
+	}
+	return clone
+}
+
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return cloneProperties(v)
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, item := range v {
+			clone[i] = cloneValue(item)
+		}
+		return clone
+	default:
+		return v
+	}
+}
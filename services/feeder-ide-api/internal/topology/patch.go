@@ -0,0 +1,339 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch 操作類型（RFC 6902）
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+	PatchOpMove    = "move"
+	PatchOpCopy    = "copy"
+	PatchOpTest    = "test"
+)
+
+// patchableFields 允許透過 JSON Patch 修改的頂層欄位（id、version、時間戳記等由伺服器管理）
+var patchableFields = map[string]bool{
+	"name":         true,
+	"description":  true,
+	"profile_type": true,
+	"nodes":        true,
+	"lines":        true,
+}
+
+// PatchOperation 單一 JSON Patch 操作
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyPatch 將 JSON Patch 套用到拓樸副本並回傳結果；任一操作失敗則整份 patch 不生效
+func ApplyPatch(t *Topology, ops []PatchOperation) (*Topology, error) {
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal topology: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal topology: %w", err)
+	}
+
+	for i, op := range ops {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patched topology: %w", err)
+	}
+	var patched Topology
+	if err := json.Unmarshal(raw, &patched); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	result := t.Clone()
+	result.Name = patched.Name
+	result.Description = patched.Description
+	result.ProfileType = patched.ProfileType
+	result.Nodes = patched.Nodes
+	result.Lines = patched.Lines
+	if result.Nodes == nil {
+		result.Nodes = []Node{}
+	}
+	if result.Lines == nil {
+		result.Lines = []Line{}
+	}
+	return result, nil
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPatchable(path); err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: invalid value: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case PatchOpAdd:
+			return addValue(doc, path, value)
+		case PatchOpReplace:
+			if _, err := getValue(doc, path); err != nil {
+				return nil, err
+			}
+			doc, _, err = removeValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, value)
+		default:
+			current, err := getValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrPatchTestFailed
+			}
+			return doc, nil
+		}
+	case PatchOpRemove:
+		doc, _, err = removeValue(doc, path)
+		return doc, err
+	case PatchOpMove, PatchOpCopy:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPatchable(from); err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == PatchOpMove {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: cannot move a value into its own child", ErrInvalidPatch)
+			}
+			doc, value, err = removeValue(doc, from)
+		} else {
+			value, err = getValue(doc, from)
+			value = deepCopyJSON(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer 解析 JSON Pointer（RFC 6901）
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func checkPatchable(path []string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("%w: cannot replace the whole document", ErrInvalidPatch)
+	}
+	if !patchableFields[path[0]] {
+		return fmt.Errorf("%w: field %q is read-only", ErrInvalidPatch, path[0])
+	}
+	return nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, exists := container[token]
+			if !exists {
+				return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+	}
+	return current, nil
+}
+
+// addValue 在路徑位置加入值；父容器必須存在
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(container), true)
+		if err != nil {
+			return nil, err
+		}
+		updated := make([]interface{}, 0, len(container)+1)
+		updated = append(updated, container[:index]...)
+		updated = append(updated, value)
+		updated = append(updated, container[index:]...)
+		return setValue(doc, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+	}
+}
+
+// removeValue 移除路徑位置的值並回傳被移除的值
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		value, exists := container[last]
+		if !exists {
+			return nil, nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+		delete(container, last)
+		return doc, value, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(container), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := container[index]
+		updated := make([]interface{}, 0, len(container)-1)
+		updated = append(updated, container[:index]...)
+		updated = append(updated, container[index+1:]...)
+		doc, err = setValue(doc, path[:len(path)-1], updated)
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+	}
+}
+
+// setValue 以新值取代路徑位置（用於替換重新配置過的陣列）
+func setValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+	default:
+		return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+	}
+	return doc, nil
+}
+
+// arrayIndex 解析陣列索引；allowEnd 時接受 "-" 與等於長度的索引（附加到尾端）
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" {
+		if allowEnd {
+			return length, nil
+		}
+		return 0, fmt.Errorf("%w: index out of range", ErrInvalidPatch)
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("%w: index out of range", ErrInvalidPatch)
+	}
+	return index, nil
+}
+
+func deepCopyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopyJSON(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopyJSON(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
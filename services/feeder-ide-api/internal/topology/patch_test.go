@@ -0,0 +1,291 @@
+package topology
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func patchBase() *Topology {
+	return &Topology{
+		ID:          "t1",
+		Name:        "Feeder",
+		ProfileType: "rural",
+		Version:     3,
+		Nodes: []Node{
+			{ID: "a", Type: NodeTypeBus, Name: "A", Properties: map[string]interface{}{"is_source": true}},
+			{ID: "b", Type: NodeTypeBus, Name: "B", Properties: map[string]interface{}{"load_kw": 100.0}},
+			{ID: "c", Type: NodeTypeBus, Name: "C"},
+		},
+		Lines: []Line{
+			{ID: "l1", FromNodeID: "a", ToNodeID: "b"},
+			{ID: "l2", FromNodeID: "b", ToNodeID: "c"},
+		},
+	}
+}
+
+func rawJSON(t *testing.T, value interface{}) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func nodeIDs(topo *Topology) []string {
+	ids := make([]string, 0, len(topo.Nodes))
+	for _, node := range topo.Nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     func(t *testing.T) []PatchOperation
+		wantErr error
+		check   func(t *testing.T, got *Topology)
+	}{
+		{
+			name: "replace name",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "/name", Value: rawJSON(t, "Renamed")}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if got.Name != "Renamed" {
+					t.Errorf("name = %q", got.Name)
+				}
+			},
+		},
+		{
+			name: "add node at end",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpAdd, Path: "/nodes/-", Value: rawJSON(t, Node{ID: "d", Type: NodeTypeBus, Name: "D"})}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if ids := nodeIDs(got); !equalStrings(ids, []string{"a", "b", "c", "d"}) {
+					t.Errorf("nodes = %v", ids)
+				}
+			},
+		},
+		{
+			name: "add node at index",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpAdd, Path: "/nodes/1", Value: rawJSON(t, Node{ID: "d", Type: NodeTypeBus})}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if ids := nodeIDs(got); !equalStrings(ids, []string{"a", "d", "b", "c"}) {
+					t.Errorf("nodes = %v", ids)
+				}
+			},
+		},
+		{
+			name: "remove line",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpRemove, Path: "/lines/0"}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if len(got.Lines) != 1 || got.Lines[0].ID != "l2" {
+					t.Errorf("lines = %+v", got.Lines)
+				}
+			},
+		},
+		{
+			name: "replace nested property",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "/nodes/1/properties/load_kw", Value: rawJSON(t, 250)}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if v := FloatProperty(got.Nodes[1].Properties, "load_kw", 0); v != 250 {
+					t.Errorf("load_kw = %v", v)
+				}
+			},
+		},
+		{
+			name: "escaped pointer token",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpAdd, Path: "/nodes/2/properties", Value: rawJSON(t, map[string]interface{}{})},
+					{Op: PatchOpAdd, Path: "/nodes/2/properties/a~1b~0c", Value: rawJSON(t, "x")}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if v := StringProperty(got.Nodes[2].Properties, "a/b~c", ""); v != "x" {
+					t.Errorf("properties = %v", got.Nodes[2].Properties)
+				}
+			},
+		},
+		{
+			name: "move node",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpMove, From: "/nodes/0", Path: "/nodes/-"}}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if ids := nodeIDs(got); !equalStrings(ids, []string{"b", "c", "a"}) {
+					t.Errorf("nodes = %v", ids)
+				}
+			},
+		},
+		{
+			name: "copy is independent of source",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{
+					{Op: PatchOpCopy, From: "/nodes/1", Path: "/nodes/-"},
+					{Op: PatchOpReplace, Path: "/nodes/3/id", Value: rawJSON(t, "b2")},
+					{Op: PatchOpReplace, Path: "/nodes/3/properties/load_kw", Value: rawJSON(t, 5)},
+				}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if ids := nodeIDs(got); !equalStrings(ids, []string{"a", "b", "c", "b2"}) {
+					t.Errorf("nodes = %v", ids)
+				}
+				if v := FloatProperty(got.Nodes[1].Properties, "load_kw", 0); v != 100 {
+					t.Errorf("source load_kw = %v, want 100", v)
+				}
+			},
+		},
+		{
+			name: "test passes",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{
+					{Op: PatchOpTest, Path: "/nodes/0/id", Value: rawJSON(t, "a")},
+					{Op: PatchOpAdd, Path: "/description", Value: rawJSON(t, "checked")},
+				}
+			},
+			check: func(t *testing.T, got *Topology) {
+				if got.Description != "checked" {
+					t.Errorf("description = %q", got.Description)
+				}
+			},
+		},
+		{
+			name: "test fails",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpTest, Path: "/nodes/0/id", Value: rawJSON(t, "b")}}
+			},
+			wantErr: ErrPatchTestFailed,
+		},
+		{
+			name: "read-only field",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "/version", Value: rawJSON(t, 10)}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "whole document",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "", Value: rawJSON(t, map[string]interface{}{})}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "path without leading slash",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "name", Value: rawJSON(t, "x")}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "index with leading zero",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpRemove, Path: "/nodes/01"}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "index out of range",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpRemove, Path: "/nodes/3"}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "replace missing member",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "/nodes/2/properties/load_kw", Value: rawJSON(t, 1)}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "missing value",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpAdd, Path: "/name"}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "unknown op",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: "merge", Path: "/name", Value: rawJSON(t, "x")}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "move into own child",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpMove, From: "/nodes/0", Path: "/nodes/0/properties/self"}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "wrong value type",
+			ops: func(t *testing.T) []PatchOperation {
+				return []PatchOperation{{Op: PatchOpReplace, Path: "/nodes", Value: rawJSON(t, "not a list")}}
+			},
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := patchBase()
+			got, err := ApplyPatch(original, tt.ops(t))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyPatch: %v", err)
+			}
+			tt.check(t, got)
+
+			// 伺服器管理的欄位不變，原拓樸不被修改
+			if got.ID != original.ID || got.Version != original.Version {
+				t.Errorf("id/version = %s/%d, want %s/%d", got.ID, got.Version, original.ID, original.Version)
+			}
+			if ids := nodeIDs(original); !equalStrings(ids, []string{"a", "b", "c"}) || original.Name != "Feeder" {
+				t.Errorf("original modified: name %q, nodes %v", original.Name, ids)
+			}
+		})
+	}
+}
+
+func TestApplyPatchIsAtomic(t *testing.T) {
+	original := patchBase()
+	_, err := ApplyPatch(original, []PatchOperation{
+		{Op: PatchOpReplace, Path: "/name", Value: rawJSON(t, "Renamed")},
+		{Op: PatchOpRemove, Path: "/nodes/9"},
+	})
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("error = %v, want ErrInvalidPatch", err)
+	}
+	if original.Name != "Feeder" {
+		t.Errorf("name = %q after failed patch", original.Name)
+	}
+}
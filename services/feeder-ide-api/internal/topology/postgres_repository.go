@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/feeder-platform/feeder-ide-api/internal/database"
@@ -35,6 +37,7 @@ func (r *PostgresRepository) Create(topology *Topology) error {
 		topology.CreatedAt = now
 	}
 	topology.UpdatedAt = now
+	topology.Version = 1
 
 	// 序列化 nodes 和 lines 為 JSONB
 	nodesJSON, err := json.Marshal(topology.Nodes)
@@ -48,11 +51,24 @@ func (r *PostgresRepository) Create(topology *Topology) error {
 	}
 
 	query := `
-		INSERT INTO topologies (id, user_id, name, description, profile_type, nodes, lines, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO topologies (id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at,
+		                        geo_min_lon, geo_min_lat, geo_max_lon, geo_max_lat, node_count, line_count, node_type_counts,
+		                        organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
+	bounds := geoBounds(topology)
+	nodeTypeCounts, err := json.Marshal(topology.NodeTypeCounts())
+	if err != nil {
+		return fmt.Errorf("failed to marshal node type counts: %w", err)
+	}
 
-	_, err = r.db.Exec(query,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
 		topology.ID,
 		topology.UserID,
 		topology.Name,
@@ -60,14 +76,33 @@ func (r *PostgresRepository) Create(topology *Topology) error {
 		topology.ProfileType,
 		nodesJSON,
 		linesJSON,
+		topology.Version,
 		topology.CreatedAt,
 		topology.UpdatedAt,
+		bounds[0],
+		bounds[1],
+		bounds[2],
+		bounds[3],
+		len(topology.Nodes),
+		len(topology.Lines),
+		nodeTypeCounts,
+		topology.OrganizationID,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to create topology: %w", err)
 	}
 
+	// 建立第一個版本
+	revision := newRevision(topology, 1, RevisionInfo{AuthorID: topology.UserID, Message: InitialRevisionMessage})
+	if err := insertRevision(tx, revision); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -81,19 +116,21 @@ func (r *PostgresRepository) GetByIDAndUserID(id string, userID *string) (*Topol
 
 	if userID == nil {
 		// 無用戶ID檢查（允許訪問任何拓樸，用於 demo 模式）
-		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, created_at, updated_at
+		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
 		         FROM topologies WHERE id = $1`
 		args = []interface{}{id}
 	} else {
-		// 檢查用戶ID（註冊用戶只能訪問自己的拓樸）
-		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, created_at, updated_at
-		         FROM topologies WHERE id = $1 AND (user_id = $2 OR user_id IS NULL)`
+		// 檢查用戶ID（註冊用戶只能訪問自己或共用給自己的拓樸）
+		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
+		         FROM topologies
+		         WHERE id = $1 AND (user_id = $2 OR EXISTS (
+		             SELECT 1 FROM topology_collaborators c WHERE c.topology_id = topologies.id AND c.user_id = $2))`
 		args = []interface{}{id, *userID}
 	}
 
 	var topology Topology
 	var nodesJSON, linesJSON []byte
-	var userIDPtr sql.NullString
+	var userIDPtr, organizationIDPtr sql.NullString
 
 	err := r.db.QueryRow(query, args...).Scan(
 		&topology.ID,
@@ -103,8 +140,10 @@ func (r *PostgresRepository) GetByIDAndUserID(id string, userID *string) (*Topol
 		&topology.ProfileType,
 		&nodesJSON,
 		&linesJSON,
+		&topology.Version,
 		&topology.CreatedAt,
 		&topology.UpdatedAt,
+		&organizationIDPtr,
 	)
 
 	if err == sql.ErrNoRows {
@@ -118,6 +157,10 @@ func (r *PostgresRepository) GetByIDAndUserID(id string, userID *string) (*Topol
 		userIDStr := userIDPtr.String
 		topology.UserID = &userIDStr
 	}
+	if organizationIDPtr.Valid {
+		organizationIDStr := organizationIDPtr.String
+		topology.OrganizationID = &organizationIDStr
+	}
 
 	// 反序列化 nodes 和 lines
 	if err := json.Unmarshal(nodesJSON, &topology.Nodes); err != nil {
@@ -131,7 +174,16 @@ func (r *PostgresRepository) GetByIDAndUserID(id string, userID *string) (*Topol
 	return &topology, nil
 }
 
-func (r *PostgresRepository) Update(id string, topology *Topology) error {
+func (r *PostgresRepository) Update(id string, topology *Topology, info RevisionInfo) error {
+	return r.update(id, topology, info, nil)
+}
+
+func (r *PostgresRepository) UpdateIfVersion(id string, expectedVersion int, topology *Topology, info RevisionInfo) error {
+	return r.update(id, topology, info, &expectedVersion)
+}
+
+// update 更新拓樸並寫入新版本；expectedVersion 不為 nil 時以 compare-and-swap 方式更新
+func (r *PostgresRepository) update(id string, topology *Topology, info RevisionInfo, expectedVersion *int) error {
 	topology.UpdatedAt = time.Now()
 
 	// 序列化 nodes 和 lines
@@ -147,11 +199,27 @@ func (r *PostgresRepository) Update(id string, topology *Topology) error {
 
 	query := `
 		UPDATE topologies
-		SET name = $1, description = $2, profile_type = $3, nodes = $4, lines = $5, updated_at = $6
-		WHERE id = $7
+		SET name = $1, description = $2, profile_type = $3, nodes = $4, lines = $5, updated_at = $6, version = version + 1,
+		    geo_min_lon = $9, geo_min_lat = $10, geo_max_lon = $11, geo_max_lat = $12,
+		    node_count = $13, line_count = $14, node_type_counts = $15
+		WHERE id = $7 AND ($8::INTEGER IS NULL OR version = $8)
+		RETURNING version
 	`
+	bounds := geoBounds(topology)
+	nodeTypeCounts, err := json.Marshal(topology.NodeTypeCounts())
+	if err != nil {
+		return fmt.Errorf("failed to marshal node type counts: %w", err)
+	}
 
-	result, err := r.db.Exec(query,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// UPDATE 會鎖定該列，版本檢查與遞增在同一敘述內完成
+	var version int
+	err = tx.QueryRow(query,
 		topology.Name,
 		topology.Description,
 		topology.ProfileType,
@@ -159,29 +227,55 @@ func (r *PostgresRepository) Update(id string, topology *Topology) error {
 		linesJSON,
 		topology.UpdatedAt,
 		id,
-	)
+		expectedVersion,
+		bounds[0],
+		bounds[1],
+		bounds[2],
+		bounds[3],
+		len(topology.Nodes),
+		len(topology.Lines),
+		nodeTypeCounts,
+	).Scan(&version)
 
+	if err == sql.ErrNoRows {
+		if expectedVersion != nil {
+			if err := r.ensureExists(id); err != nil {
+				return err
+			}
+			return ErrVersionConflict
+		}
+		return ErrTopologyNotFound
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update topology: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	topology.ID = id
+	topology.Version = version
+
+	if err := insertRevision(tx, newRevision(topology, version, info)); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return ErrTopologyNotFound
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	topology.ID = id
 	return nil
 }
 
 func (r *PostgresRepository) Delete(id string) error {
-	query := `DELETE FROM topologies WHERE id = $1`
+	return r.delete(id, nil)
+}
 
-	result, err := r.db.Exec(query, id)
+func (r *PostgresRepository) DeleteIfVersion(id string, expectedVersion int) error {
+	return r.delete(id, &expectedVersion)
+}
+
+func (r *PostgresRepository) delete(id string, expectedVersion *int) error {
+	query := `DELETE FROM topologies WHERE id = $1 AND ($2::INTEGER IS NULL OR version = $2)`
+
+	result, err := r.db.Exec(query, id, expectedVersion)
 	if err != nil {
 		return fmt.Errorf("failed to delete topology: %w", err)
 	}
@@ -192,6 +286,12 @@ func (r *PostgresRepository) Delete(id string) error {
 	}
 
 	if rowsAffected == 0 {
+		if expectedVersion != nil {
+			if err := r.ensureExists(id); err != nil {
+				return err
+			}
+			return ErrVersionConflict
+		}
 		return ErrTopologyNotFound
 	}
 
@@ -199,7 +299,8 @@ func (r *PostgresRepository) Delete(id string) error {
 }
 
 func (r *PostgresRepository) List() ([]*Topology, error) {
-	return r.ListByUserID(nil)
+	return r.queryTopologies(`SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
+	                          FROM topologies ORDER BY created_at DESC`)
 }
 
 func (r *PostgresRepository) ListByUserID(userID *string) ([]*Topology, error) {
@@ -207,17 +308,39 @@ func (r *PostgresRepository) ListByUserID(userID *string) ([]*Topology, error) {
 	var args []interface{}
 
 	if userID == nil {
-		// 列出所有拓樸（demo 模式）
-		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, created_at, updated_at
-		         FROM topologies ORDER BY created_at DESC`
+		// 列出沒有擁有者的拓樸（demo 模式）
+		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
+		         FROM topologies WHERE user_id IS NULL AND organization_id IS NULL ORDER BY created_at DESC`
 		args = []interface{}{}
 	} else {
 		// 列出用戶的拓樸
-		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, created_at, updated_at
+		query = `SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
 		         FROM topologies WHERE user_id = $1 ORDER BY created_at DESC`
 		args = []interface{}{*userID}
 	}
 
+	return r.queryTopologies(query, args...)
+}
+
+func (r *PostgresRepository) ListByBBox(userID *string, bbox BBox) ([]*Topology, error) {
+	// 以經緯度範圍欄位判斷相交，沒有經緯度的拓樸（欄位為 NULL）不會被選出
+	query := `SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
+	          FROM topologies
+	          WHERE geo_min_lon <= $3 AND geo_max_lon >= $1 AND geo_min_lat <= $4 AND geo_max_lat >= $2`
+	args := []interface{}{bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat}
+	if userID != nil {
+		query += ` AND user_id = $5`
+		args = append(args, *userID)
+	} else {
+		query += ` AND user_id IS NULL AND organization_id IS NULL`
+	}
+	query += ` ORDER BY created_at DESC`
+
+	return r.queryTopologies(query, args...)
+}
+
+// queryTopologies 執行查詢並掃描拓樸列
+func (r *PostgresRepository) queryTopologies(query string, args ...interface{}) ([]*Topology, error) {
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query topologies: %w", err)
@@ -228,7 +351,7 @@ func (r *PostgresRepository) ListByUserID(userID *string) ([]*Topology, error) {
 	for rows.Next() {
 		var topology Topology
 		var nodesJSON, linesJSON []byte
-		var userIDPtr sql.NullString
+		var userIDPtr, organizationIDPtr sql.NullString
 
 		err := rows.Scan(
 			&topology.ID,
@@ -238,8 +361,10 @@ func (r *PostgresRepository) ListByUserID(userID *string) ([]*Topology, error) {
 			&topology.ProfileType,
 			&nodesJSON,
 			&linesJSON,
+			&topology.Version,
 			&topology.CreatedAt,
 			&topology.UpdatedAt,
+			&organizationIDPtr,
 		)
 
 		if err != nil {
@@ -250,6 +375,10 @@ func (r *PostgresRepository) ListByUserID(userID *string) ([]*Topology, error) {
 			userIDStr := userIDPtr.String
 			topology.UserID = &userIDStr
 		}
+		if organizationIDPtr.Valid {
+			organizationIDStr := organizationIDPtr.String
+			topology.OrganizationID = &organizationIDStr
+		}
 
 		// 反序列化 nodes 和 lines
 		if err := json.Unmarshal(nodesJSON, &topology.Nodes); err != nil {
@@ -270,13 +399,209 @@ func (r *PostgresRepository) ListByUserID(userID *string) ([]*Topology, error) {
 	return topologies, nil
 }
 
+func (r *PostgresRepository) Query(q ListQuery) (*ListPage, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(q)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		if _, err := uuid.Parse(cursor.ID); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if q.UserID != nil {
+		conditions = append(conditions, "user_id = "+arg(*q.UserID))
+	}
+	if q.OrganizationID != nil {
+		conditions = append(conditions, "organization_id = "+arg(*q.OrganizationID))
+	}
+	if q.ShareTokenHash != "" {
+		conditions = append(conditions, fmt.Sprintf(`id IN (SELECT l.topology_id FROM topology_share_links l
+		                                                   WHERE l.token_hash = %s AND l.revoked_at IS NULL
+		                                                     AND (l.expires_at IS NULL OR l.expires_at > %s))`, arg(q.ShareTokenHash), arg(time.Now())))
+	}
+	if q.anonymous() {
+		conditions = append(conditions, "user_id IS NULL AND organization_id IS NULL")
+	}
+	if q.ProfileType != "" {
+		conditions = append(conditions, "profile_type = "+arg(q.ProfileType))
+	}
+	if terms := SearchTerms(q.Search); len(terms) > 0 {
+		// 詞只含字母與數字，不會帶入 tsquery 運算子；:* 為前綴比對，與記憶體實作一致
+		conditions = append(conditions, "search_vector @@ to_tsquery('simple', "+arg(strings.Join(terms, ":* & ")+":*")+")")
+	}
+	if q.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+arg(*q.CreatedAfter))
+	}
+	if q.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+arg(*q.CreatedBefore))
+	}
+	if q.UpdatedAfter != nil {
+		conditions = append(conditions, "updated_at >= "+arg(*q.UpdatedAfter))
+	}
+	if q.UpdatedBefore != nil {
+		conditions = append(conditions, "updated_at < "+arg(*q.UpdatedBefore))
+	}
+	if q.BBox != nil {
+		conditions = append(conditions, fmt.Sprintf("geo_min_lon <= %s AND geo_max_lon >= %s AND geo_min_lat <= %s AND geo_max_lat >= %s",
+			arg(q.BBox.MaxLon), arg(q.BBox.MinLon), arg(q.BBox.MaxLat), arg(q.BBox.MinLat)))
+	}
+
+	// 名稱以 "C" 排序規則比較（逐位元組），與 Go 字串比較相同
+	sortColumn := q.Sort
+	if q.Sort == SortName {
+		sortColumn = `name COLLATE "C"`
+	}
+	direction, operator := "DESC", "<"
+	if q.Order == OrderAsc {
+		direction, operator = "ASC", ">"
+	}
+
+	if cursor != nil {
+		var value interface{} = cursor.Value
+		if q.Sort != SortName {
+			value = cursor.cursorTime()
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s::uuid)", sortColumn, operator, arg(value), arg(cursor.ID)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// 多取一筆以判斷是否還有下一頁
+	suffix := fmt.Sprintf("%s ORDER BY %s %s, id %s LIMIT %s", where, sortColumn, direction, direction, arg(q.Limit+1))
+
+	page := &ListPage{}
+	if q.Projection == ProjectionFull {
+		topologies, err := r.queryTopologies(`SELECT id, user_id, name, description, profile_type, nodes, lines, version, created_at, updated_at, organization_id
+		                                      FROM topologies`+suffix, args...)
+		if err != nil {
+			return nil, err
+		}
+		if len(topologies) > q.Limit {
+			topologies = topologies[:q.Limit]
+			last := topologies[len(topologies)-1]
+			page.NextCursor = encodeCursor(q, sortValue(q.Sort, last.Name, last.CreatedAt, last.UpdatedAt), last.ID)
+		}
+		page.Topologies = topologies
+		return page, nil
+	}
+
+	summaries, err := r.querySummaries(`SELECT `+summaryColumns+` FROM topologies t`+suffix, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(summaries) > q.Limit {
+		summaries = summaries[:q.Limit]
+		last := summaries[len(summaries)-1]
+		page.NextCursor = encodeCursor(q, sortValue(q.Sort, last.Name, last.CreatedAt, last.UpdatedAt), last.ID)
+	}
+	page.Summaries = summaries
+	return page, nil
+}
+
+// summaryColumns 摘要查詢欄位（對應 scanSummary），t 為 topologies 表別名
+const summaryColumns = `t.id, t.user_id, t.name, COALESCE(t.description, ''), t.profile_type, t.version, t.created_at, t.updated_at,
+	t.node_count, t.line_count, t.node_type_counts, t.geo_min_lon, t.geo_min_lat, t.geo_max_lon, t.geo_max_lat, t.organization_id`
+
+// querySummaries 執行查詢並掃描拓樸摘要列（只讀取統計欄位，不載入 nodes/lines）
+func (r *PostgresRepository) querySummaries(query string, args ...interface{}) ([]*Summary, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query topologies: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []*Summary{}
+	for rows.Next() {
+		summary, err := scanSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// scanSummary 掃描 summaryColumns，extra 為其後的額外欄位
+func scanSummary(row rowScanner, extra ...interface{}) (*Summary, error) {
+	var summary Summary
+	var userID, organizationID sql.NullString
+	var nodeTypeCounts []byte
+	var minLon, minLat, maxLon, maxLat sql.NullFloat64
+
+	dest := []interface{}{
+		&summary.ID,
+		&userID,
+		&summary.Name,
+		&summary.Description,
+		&summary.ProfileType,
+		&summary.Version,
+		&summary.CreatedAt,
+		&summary.UpdatedAt,
+		&summary.NodeCount,
+		&summary.LineCount,
+		&nodeTypeCounts,
+		&minLon,
+		&minLat,
+		&maxLon,
+		&maxLat,
+		&organizationID,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, fmt.Errorf("failed to scan topology summary: %w", err)
+	}
+
+	if userID.Valid {
+		userIDStr := userID.String
+		summary.UserID = &userIDStr
+	}
+	if organizationID.Valid {
+		organizationIDStr := organizationID.String
+		summary.OrganizationID = &organizationIDStr
+	}
+	if err := json.Unmarshal(nodeTypeCounts, &summary.NodeCounts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal node type counts: %w", err)
+	}
+	if minLon.Valid && minLat.Valid && maxLon.Valid && maxLat.Valid {
+		summary.BBox = &BBox{MinLon: minLon.Float64, MinLat: minLat.Float64, MaxLon: maxLon.Float64, MaxLat: maxLat.Float64}
+	}
+	return &summary, nil
+}
+
+// geoBounds 拓樸經緯度範圍欄位值（min_lon, min_lat, max_lon, max_lat），沒有經緯度時皆為 NULL
+func geoBounds(topology *Topology) [4]interface{} {
+	bbox, ok := topology.BBox()
+	if !ok {
+		return [4]interface{}{}
+	}
+	return [4]interface{}{bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat}
+}
+
 func (r *PostgresRepository) CountByUserID(userID *string) (int, error) {
 	var query string
 	var args []interface{}
 
 	if userID == nil {
 		// 統計所有拓樸（demo 模式）
-		query = `SELECT COUNT(*) FROM topologies WHERE user_id IS NULL`
+		query = `SELECT COUNT(*) FROM topologies WHERE user_id IS NULL AND organization_id IS NULL`
 		args = []interface{}{}
 	} else {
 		// 統計用戶的拓樸
@@ -293,3 +618,601 @@ func (r *PostgresRepository) CountByUserID(userID *string) (int, error) {
 	return count, nil
 }
 
+func (r *PostgresRepository) CountByOrganizationID(organizationID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM topologies WHERE organization_id = $1`, organizationID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count topologies: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *PostgresRepository) GetOrganizationID(id string) (*string, error) {
+	var organizationID sql.NullString
+	err := r.db.QueryRow(`SELECT organization_id FROM topologies WHERE id = $1`, id).Scan(&organizationID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTopologyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topology: %w", err)
+	}
+	if !organizationID.Valid {
+		return nil, nil
+	}
+
+	return &organizationID.String, nil
+}
+
+func (r *PostgresRepository) ListRevisions(topologyID string) ([]*Revision, error) {
+	if err := r.ensureExists(topologyID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, topology_id, revision, author_id, message, name, description, profile_type, nodes, lines, created_at
+	          FROM topology_revisions WHERE topology_id = $1 ORDER BY revision DESC`
+
+	rows, err := r.db.Query(query, topologyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*Revision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *PostgresRepository) GetRevision(topologyID string, revision int) (*Revision, error) {
+	if err := r.ensureExists(topologyID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, topology_id, revision, author_id, message, name, description, profile_type, nodes, lines, created_at
+	          FROM topology_revisions WHERE topology_id = $1 AND revision = $2`
+
+	result, err := scanRevision(r.db.QueryRow(query, topologyID, revision))
+	if err == sql.ErrNoRows {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ensureExists 確認拓樸存在
+func (r *PostgresRepository) ensureExists(id string) error {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM topologies WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check topology: %w", err)
+	}
+	if !exists {
+		return ErrTopologyNotFound
+	}
+	return nil
+}
+
+// insertRevision 在交易中寫入版本快照
+func insertRevision(tx *sql.Tx, revision *Revision) error {
+	nodesJSON, err := json.Marshal(revision.Nodes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal nodes: %w", err)
+	}
+
+	linesJSON, err := json.Marshal(revision.Lines)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lines: %w", err)
+	}
+
+	query := `
+		INSERT INTO topology_revisions (id, topology_id, revision, author_id, message, name, description, profile_type, nodes, lines, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err = tx.Exec(query,
+		revision.ID,
+		revision.TopologyID,
+		revision.Revision,
+		revision.AuthorID,
+		revision.Message,
+		revision.Name,
+		revision.Description,
+		revision.ProfileType,
+		nodesJSON,
+		linesJSON,
+		revision.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create revision: %w", err)
+	}
+
+	return nil
+}
+
+// rowScanner 可同時接受 *sql.Row 與 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRevision(row rowScanner) (*Revision, error) {
+	var revision Revision
+	var nodesJSON, linesJSON []byte
+	var authorID, message, description sql.NullString
+
+	err := row.Scan(
+		&revision.ID,
+		&revision.TopologyID,
+		&revision.Revision,
+		&authorID,
+		&message,
+		&revision.Name,
+		&description,
+		&revision.ProfileType,
+		&nodesJSON,
+		&linesJSON,
+		&revision.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan revision: %w", err)
+	}
+
+	if authorID.Valid {
+		author := authorID.String
+		revision.AuthorID = &author
+	}
+	revision.Message = message.String
+	revision.Description = description.String
+
+	if err := json.Unmarshal(nodesJSON, &revision.Nodes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal nodes: %w", err)
+	}
+
+	if err := json.Unmarshal(linesJSON, &revision.Lines); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lines: %w", err)
+	}
+
+	return &revision, nil
+}
+
+func (r *PostgresRepository) Authorize(id string, principal Principal) (*Topology, Role, error) {
+	tokenHash := ""
+	if principal.ShareToken != "" {
+		tokenHash = HashShareToken(principal.ShareToken)
+	}
+
+	// 一次取得拓樸、共用角色與共用連結是否有效
+	query := `SELECT t.id, t.user_id, t.name, t.description, t.profile_type, t.nodes, t.lines, t.version, t.created_at, t.updated_at, t.organization_id,
+	                 c.role,
+	                 EXISTS (SELECT 1 FROM topology_share_links l
+	                         WHERE l.topology_id = t.id AND l.token_hash = $3 AND l.revoked_at IS NULL
+	                           AND (l.expires_at IS NULL OR l.expires_at > $4))
+	          FROM topologies t
+	          LEFT JOIN topology_collaborators c ON c.topology_id = t.id AND c.user_id = $2
+	          WHERE t.id = $1`
+
+	var collaboratorRole sql.NullString
+	var linkActive bool
+	topology, err := scanTopology(r.db.QueryRow(query, id, principal.UserID, tokenHash, time.Now()), &collaboratorRole, &linkActive)
+	if err == sql.ErrNoRows {
+		return nil, RoleNone, ErrTopologyNotFound
+	}
+	if err != nil {
+		return nil, RoleNone, err
+	}
+
+	role := topology.ownerRole(principal)
+	if collaboratorRole.Valid {
+		role = maxRole(role, Role(collaboratorRole.String))
+	}
+	if linkActive {
+		role = maxRole(role, RoleViewer)
+	}
+	if role == RoleNone {
+		return nil, RoleNone, ErrTopologyNotFound
+	}
+	return topology, role, nil
+}
+
+// scanTopology 掃描完整拓樸列，extra 為其後的額外欄位；查無資料時回傳 sql.ErrNoRows
+func scanTopology(row rowScanner, extra ...interface{}) (*Topology, error) {
+	var topology Topology
+	var nodesJSON, linesJSON []byte
+	var userID, organizationID, description sql.NullString
+
+	dest := []interface{}{
+		&topology.ID,
+		&userID,
+		&topology.Name,
+		&description,
+		&topology.ProfileType,
+		&nodesJSON,
+		&linesJSON,
+		&topology.Version,
+		&topology.CreatedAt,
+		&topology.UpdatedAt,
+		&organizationID,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topology: %w", err)
+	}
+
+	if userID.Valid {
+		userIDStr := userID.String
+		topology.UserID = &userIDStr
+	}
+	if organizationID.Valid {
+		organizationIDStr := organizationID.String
+		topology.OrganizationID = &organizationIDStr
+	}
+	topology.Description = description.String
+
+	if err := json.Unmarshal(nodesJSON, &topology.Nodes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal nodes: %w", err)
+	}
+	if err := json.Unmarshal(linesJSON, &topology.Lines); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lines: %w", err)
+	}
+
+	return &topology, nil
+}
+
+func (r *PostgresRepository) ListCollaborators(topologyID string) ([]*Collaborator, error) {
+	if err := r.ensureExists(topologyID); err != nil {
+		return nil, err
+	}
+
+	// 依角色（高到低）與共用時間排列，與記憶體實作一致
+	query := `SELECT topology_id, user_id, role, granted_by, created_at, updated_at
+	          FROM topology_collaborators WHERE topology_id = $1
+	          ORDER BY CASE role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC, created_at, user_id`
+
+	rows, err := r.db.Query(query, topologyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collaborators: %w", err)
+	}
+	defer rows.Close()
+
+	collaborators := []*Collaborator{}
+	for rows.Next() {
+		var collaborator Collaborator
+		var grantedBy sql.NullString
+		if err := rows.Scan(
+			&collaborator.TopologyID,
+			&collaborator.UserID,
+			&collaborator.Role,
+			&grantedBy,
+			&collaborator.CreatedAt,
+			&collaborator.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan collaborator: %w", err)
+		}
+		if grantedBy.Valid {
+			grantedByStr := grantedBy.String
+			collaborator.GrantedBy = &grantedByStr
+		}
+		collaborators = append(collaborators, &collaborator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return collaborators, nil
+}
+
+func (r *PostgresRepository) SetCollaborator(collaborator *Collaborator) error {
+	if !collaborator.Role.Valid() {
+		return ErrInvalidRole
+	}
+
+	if err := r.ensureShareable(collaborator.TopologyID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO topology_collaborators (topology_id, user_id, role, granted_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (topology_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role, granted_by = EXCLUDED.granted_by, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		collaborator.TopologyID,
+		collaborator.UserID,
+		collaborator.Role,
+		collaborator.GrantedBy,
+		time.Now(),
+	).Scan(&collaborator.CreatedAt, &collaborator.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set collaborator: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) RemoveCollaborator(topologyID, userID string) error {
+	if err := r.ensureExists(topologyID); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`DELETE FROM topology_collaborators WHERE topology_id = $1 AND user_id = $2`, topologyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrCollaboratorNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) CreateShareLink(link *ShareLink) error {
+	if err := r.ensureShareable(link.TopologyID); err != nil {
+		return err
+	}
+
+	if link.ID == "" {
+		link.ID = uuid.New().String()
+	}
+	link.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO topology_share_links (id, topology_id, token_hash, role, created_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.Exec(query,
+		link.ID,
+		link.TopologyID,
+		link.TokenHash,
+		link.Role,
+		link.CreatedBy,
+		link.ExpiresAt,
+		link.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) ListShareLinks(topologyID string) ([]*ShareLink, error) {
+	if err := r.ensureExists(topologyID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, topology_id, token_hash, role, created_by, expires_at, revoked_at, created_at
+	          FROM topology_share_links WHERE topology_id = $1 ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, topologyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []*ShareLink{}
+	for rows.Next() {
+		var link ShareLink
+		var createdBy sql.NullString
+		var expiresAt, revokedAt sql.NullTime
+		if err := rows.Scan(
+			&link.ID,
+			&link.TopologyID,
+			&link.TokenHash,
+			&link.Role,
+			&createdBy,
+			&expiresAt,
+			&revokedAt,
+			&link.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		if createdBy.Valid {
+			createdByStr := createdBy.String
+			link.CreatedBy = &createdByStr
+		}
+		if expiresAt.Valid {
+			link.ExpiresAt = &expiresAt.Time
+		}
+		if revokedAt.Valid {
+			link.RevokedAt = &revokedAt.Time
+		}
+		links = append(links, &link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return links, nil
+}
+
+func (r *PostgresRepository) RevokeShareLink(topologyID, linkID string) error {
+	if err := r.ensureExists(topologyID); err != nil {
+		return err
+	}
+
+	// 已撤銷的連結保留原撤銷時間
+	result, err := r.db.Exec(`UPDATE topology_share_links SET revoked_at = COALESCE(revoked_at, $3)
+	                          WHERE topology_id = $1 AND id = $2`, topologyID, linkID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrShareLinkNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) TransferOwnership(topologyID, newOwnerID string, previousOwnerRole Role) error {
+	if previousOwnerRole != RoleNone && !previousOwnerRole.Valid() {
+		return ErrInvalidRole
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previousOwner, err := lockPersonalTopology(tx, topologyID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE topologies SET user_id = $2 WHERE id = $1`, topologyID, newOwnerID); err != nil {
+		return fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM topology_collaborators WHERE topology_id = $1 AND user_id = $2`, topologyID, newOwnerID); err != nil {
+		return fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+
+	if previousOwnerRole != RoleNone && previousOwner != newOwnerID {
+		if err := upsertCollaborator(tx, topologyID, previousOwner, previousOwnerRole, newOwnerID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) TransferToOrganization(topologyID, organizationID string, previousOwnerRole Role) error {
+	if previousOwnerRole != RoleNone && !previousOwnerRole.Valid() {
+		return ErrInvalidRole
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previousOwner, err := lockPersonalTopology(tx, topologyID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE topologies SET user_id = NULL, organization_id = $2 WHERE id = $1`, topologyID, organizationID); err != nil {
+		return fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+
+	if previousOwnerRole != RoleNone {
+		if err := upsertCollaborator(tx, topologyID, previousOwner, previousOwnerRole, previousOwner); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockPersonalTopology 鎖定可轉移的個人拓樸列（避免同時轉移），回傳目前的擁有者
+func lockPersonalTopology(tx *sql.Tx, topologyID string) (string, error) {
+	var owner, organizationID sql.NullString
+	err := tx.QueryRow(`SELECT user_id, organization_id FROM topologies WHERE id = $1 FOR UPDATE`, topologyID).Scan(&owner, &organizationID)
+	if err == sql.ErrNoRows {
+		return "", ErrTopologyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get topology: %w", err)
+	}
+	if organizationID.Valid {
+		return "", ErrOrganizationOwned
+	}
+	if !owner.Valid {
+		return "", ErrNotShareable
+	}
+	return owner.String, nil
+}
+
+// upsertCollaborator 在交易中新增或更新共用對象的角色
+func upsertCollaborator(tx *sql.Tx, topologyID, userID string, role Role, grantedBy string) error {
+	query := `
+		INSERT INTO topology_collaborators (topology_id, user_id, role, granted_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (topology_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role, granted_by = EXCLUDED.granted_by, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.Exec(query, topologyID, userID, role, grantedBy, time.Now()); err != nil {
+		return fmt.Errorf("failed to set collaborator: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListSharedWith(userID string) ([]*SharedTopology, error) {
+	query := `SELECT ` + summaryColumns + `, c.role
+	          FROM topology_collaborators c
+	          JOIN topologies t ON t.id = c.topology_id
+	          WHERE c.user_id = $1
+	          ORDER BY t.updated_at DESC, t.id DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shared topologies: %w", err)
+	}
+	defer rows.Close()
+
+	shared := []*SharedTopology{}
+	for rows.Next() {
+		var role Role
+		summary, err := scanSummary(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		shared = append(shared, &SharedTopology{Summary: summary, Role: role})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return shared, nil
+}
+
+// ensureShareable 確認拓樸存在且有擁有者（用戶或組織）
+func (r *PostgresRepository) ensureShareable(id string) error {
+	var hasOwner bool
+	err := r.db.QueryRow(`SELECT user_id IS NOT NULL OR organization_id IS NOT NULL FROM topologies WHERE id = $1`, id).Scan(&hasOwner)
+	if err == sql.ErrNoRows {
+		return ErrTopologyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check topology: %w", err)
+	}
+	if !hasOwner {
+		return ErrNotShareable
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeProperties 將自由格式的 Properties 解碼為型別化結構（例如 TransformerProperties）
+func DecodeProperties(props map[string]interface{}, v interface{}) error {
+	if len(props) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(props)
+	if err != nil {
+		return fmt.Errorf("failed to marshal properties: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode properties: %w", err)
+	}
+
+	return nil
+}
+
+// FloatProperty 取得數值屬性，不存在或型別不符時回傳預設值
+func FloatProperty(props map[string]interface{}, key string, defaultValue float64) float64 {
+	value, exists := props[key]
+	if !exists {
+		return defaultValue
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+
+	return defaultValue
+}
+
+// BoolProperty 取得布林屬性，不存在或型別不符時回傳預設值
+func BoolProperty(props map[string]interface{}, key string, defaultValue bool) bool {
+	value, exists := props[key]
+	if !exists {
+		return defaultValue
+	}
+
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return defaultValue
+}
+
+// StringProperty 取得字串屬性，不存在或型別不符時回傳預設值
+func StringProperty(props map[string]interface{}, key string, defaultValue string) string {
+	value, exists := props[key]
+	if !exists {
+		return defaultValue
+	}
+
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return defaultValue
+}
+
+// IsSource 判斷節點是否被標記為電源（變電所）
+func IsSource(node Node) bool {
+	return BoolProperty(node.Properties, "is_source", false)
+}
+
+// SourceNodes 取得拓樸中的電源節點
+// 優先使用標記 is_source 的節點；若沒有任何標記，則視沒有上游線路的變壓器為變電所
+func SourceNodes(t *Topology) []Node {
+	sources := []Node{}
+	for _, node := range t.Nodes {
+		if IsSource(node) {
+			sources = append(sources, node)
+		}
+	}
+	if len(sources) > 0 {
+		return sources
+	}
+
+	hasUpstream := make(map[string]bool, len(t.Lines))
+	for _, line := range t.Lines {
+		hasUpstream[line.ToNodeID] = true
+	}
+
+	for _, node := range t.Nodes {
+		if node.Type == NodeTypeTransformer && !hasUpstream[node.ID] {
+			sources = append(sources, node)
+		}
+	}
+	return sources
+}
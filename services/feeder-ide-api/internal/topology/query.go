@@ -0,0 +1,361 @@
+package topology
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 列表排序欄位
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortName      = "name"
+)
+
+// 列表排序方向
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// 列表投影：summary 只回傳統計資訊（不載入 nodes/lines），full 回傳完整拓樸
+const (
+	ProjectionSummary = "summary"
+	ProjectionFull    = "full"
+)
+
+// 每頁筆數
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ListQuery 拓樸列表查詢條件（InMemoryRepository 與 PostgresRepository 語意一致）
+type ListQuery struct {
+	// UserID、OrganizationID 與 ShareTokenHash 皆未指定時只列出沒有擁有者的 demo 拓樸（與 ListByUserID(nil) 相同）
+	UserID *string // 只列出該用戶擁有的拓樸
+	// OrganizationID 只列出該組織擁有的拓樸
+	OrganizationID *string
+	// ShareTokenHash 只持有共用連結的匿名呼叫端：只列出該連結（有效期間內）的拓樸
+	ShareTokenHash string
+	ProfileType    string // 完全比對，空字串不篩選
+	// Search 全文搜尋名稱與描述：切成詞後每個詞都必須是某個字詞的前綴（不分大小寫）
+	Search string
+	// 日期範圍：After 為包含（>=），Before 為不包含（<）
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	BBox          *BBox // 經緯度範圍相交，沒有經緯度的拓樸不會被選出
+
+	Sort       string // created_at（預設）、updated_at、name
+	Order      string // desc（預設）、asc
+	Limit      int    // 0 表示 DefaultListLimit
+	Cursor     string // 上一頁回傳的 NextCursor
+	Projection string // summary（預設）、full
+}
+
+// Normalize 套用預設值並檢查參數
+func (q *ListQuery) Normalize() error {
+	switch q.Sort {
+	case "":
+		q.Sort = SortCreatedAt
+	case SortCreatedAt, SortUpdatedAt, SortName:
+	default:
+		return fmt.Errorf("%w: sort must be one of created_at, updated_at, name", ErrInvalidQuery)
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = OrderDesc
+	case OrderAsc, OrderDesc:
+	default:
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidQuery)
+	}
+
+	switch q.Projection {
+	case "":
+		q.Projection = ProjectionSummary
+	case ProjectionSummary, ProjectionFull:
+	default:
+		return fmt.Errorf("%w: view must be summary or full", ErrInvalidQuery)
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultListLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxListLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxListLimit)
+	}
+	return nil
+}
+
+// SearchTerms 將搜尋字串切成小寫詞（以非字母、數字字元分隔），
+// 用於記憶體比對與組成 PostgreSQL tsquery，兩邊使用同一套切詞規則
+func SearchTerms(search string) []string {
+	return strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Summary 拓樸摘要（列表用，不含 nodes/lines）
+type Summary struct {
+	ID             string         `json:"id"`
+	UserID         *string        `json:"user_id,omitempty"`
+	OrganizationID *string        `json:"organization_id,omitempty"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description,omitempty"`
+	ProfileType    string         `json:"profile_type"`
+	Version        int            `json:"version"`
+	NodeCount      int            `json:"node_count"`
+	LineCount      int            `json:"line_count"`
+	NodeCounts     map[string]int `json:"node_counts"` // 各節點類型數量
+	BBox           *BBox          `json:"bbox,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// NodeTypeCounts 各節點類型數量
+func (t *Topology) NodeTypeCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, node := range t.Nodes {
+		counts[node.Type]++
+	}
+	return counts
+}
+
+// Summarize 建立拓樸摘要
+func (t *Topology) Summarize() *Summary {
+	summary := &Summary{
+		ID:          t.ID,
+		Name:        t.Name,
+		Description: t.Description,
+		ProfileType: t.ProfileType,
+		Version:     t.Version,
+		NodeCount:   len(t.Nodes),
+		LineCount:   len(t.Lines),
+		NodeCounts:  t.NodeTypeCounts(),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	if t.UserID != nil {
+		userID := *t.UserID
+		summary.UserID = &userID
+	}
+	if t.OrganizationID != nil {
+		organizationID := *t.OrganizationID
+		summary.OrganizationID = &organizationID
+	}
+	if bbox, ok := t.BBox(); ok {
+		summary.BBox = &bbox
+	}
+	return summary
+}
+
+// ListPage 一頁列表結果，依 Projection 填入 Summaries 或 Topologies
+type ListPage struct {
+	Summaries  []*Summary
+	Topologies []*Topology
+	NextCursor string // 空字串表示沒有下一頁
+}
+
+// HasMore 是否還有下一頁
+func (p *ListPage) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// listCursor 分頁游標內容（keyset：最後一筆的排序值與 ID）
+type listCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// sortValue 拓樸在指定排序欄位的值（游標用字串表示）
+func sortValue(sortKey string, name string, createdAt, updatedAt time.Time) string {
+	switch sortKey {
+	case SortName:
+		return name
+	case SortUpdatedAt:
+		return updatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return createdAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// encodeCursor 以最後一筆資料產生下一頁游標
+func encodeCursor(q ListQuery, value, id string) string {
+	data, _ := json.Marshal(listCursor{Sort: q.Sort, Order: q.Order, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析游標；排序欄位或方向與查詢不同時視為無效
+func decodeCursor(q ListQuery) (*listCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != q.Sort || cursor.Order != q.Order {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+	}
+	if cursor.Sort != SortName {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return &cursor, nil
+}
+
+// cursorTime 游標中的時間值（decodeCursor 已檢查格式）
+func (c *listCursor) cursorTime() time.Time {
+	value, _ := time.Parse(time.RFC3339Nano, c.Value)
+	return value
+}
+
+// anonymous 是否為未登入且沒有共用連結的查詢（只能看到 demo 拓樸）
+func (q ListQuery) anonymous() bool {
+	return q.UserID == nil && q.OrganizationID == nil && q.ShareTokenHash == ""
+}
+
+// matches 記憶體實作的篩選條件（不含游標；ShareTokenHash 由 repository 比對共用連結）
+func (q ListQuery) matches(t *Topology, terms []string) bool {
+	if q.anonymous() && t.hasOwner() {
+		return false
+	}
+	if q.UserID != nil && (t.UserID == nil || *t.UserID != *q.UserID) {
+		return false
+	}
+	if q.OrganizationID != nil && (t.OrganizationID == nil || *t.OrganizationID != *q.OrganizationID) {
+		return false
+	}
+	if q.ProfileType != "" && t.ProfileType != q.ProfileType {
+		return false
+	}
+	if q.CreatedAfter != nil && t.CreatedAt.Before(*q.CreatedAfter) {
+		return false
+	}
+	if q.CreatedBefore != nil && !t.CreatedAt.Before(*q.CreatedBefore) {
+		return false
+	}
+	if q.UpdatedAfter != nil && t.UpdatedAt.Before(*q.UpdatedAfter) {
+		return false
+	}
+	if q.UpdatedBefore != nil && !t.UpdatedAt.Before(*q.UpdatedBefore) {
+		return false
+	}
+	if q.BBox != nil {
+		if bounds, ok := t.BBox(); !ok || !bounds.Intersects(*q.BBox) {
+			return false
+		}
+	}
+	if len(terms) > 0 {
+		words := SearchTerms(t.Name + " " + t.Description)
+		for _, term := range terms {
+			if !hasPrefixWord(words, term) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasPrefixWord 是否有字詞以 term 開頭（對應 tsquery 的 term:* 前綴比對）
+func hasPrefixWord(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareForSort 依排序欄位比較兩個拓樸（升冪），排序值相同時以 ID 決定
+func compareForSort(sortKey string, a, b *Topology) int {
+	switch sortKey {
+	case SortName:
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+	case SortUpdatedAt:
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+	default:
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// after 拓樸是否排在游標之後
+func (c *listCursor) after(sortKey, order string, t *Topology) bool {
+	var cmp int
+	switch sortKey {
+	case SortName:
+		cmp = strings.Compare(t.Name, c.Value)
+	case SortUpdatedAt:
+		cmp = t.UpdatedAt.Compare(c.cursorTime())
+	default:
+		cmp = t.CreatedAt.Compare(c.cursorTime())
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(t.ID, c.ID)
+	}
+	if order == OrderDesc {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// paginate 排序、套用游標並切出一頁（記憶體實作用）
+func paginate(q ListQuery, cursor *listCursor, topologies []*Topology) *ListPage {
+	sort.Slice(topologies, func(i, j int) bool {
+		cmp := compareForSort(q.Sort, topologies[i], topologies[j])
+		if q.Order == OrderDesc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	page := make([]*Topology, 0, q.Limit)
+	next := ""
+	for _, t := range topologies {
+		if cursor != nil && !cursor.after(q.Sort, q.Order, t) {
+			continue
+		}
+		if len(page) == q.Limit {
+			last := page[len(page)-1]
+			next = encodeCursor(q, sortValue(q.Sort, last.Name, last.CreatedAt, last.UpdatedAt), last.ID)
+			break
+		}
+		page = append(page, t)
+	}
+
+	result := &ListPage{NextCursor: next}
+	if q.Projection == ProjectionFull {
+		result.Topologies = make([]*Topology, 0, len(page))
+		for _, t := range page {
+			result.Topologies = append(result.Topologies, t.Clone())
+		}
+	} else {
+		result.Summaries = make([]*Summary, 0, len(page))
+		for _, t := range page {
+			result.Summaries = append(result.Summaries, t.Summarize())
+		}
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package topology
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var queryBaseTime = time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func timePtr(value time.Time) *time.Time {
+	return &value
+}
+
+func TestListQueryNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   ListQuery
+		want    ListQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: ListQuery{},
+			want:  ListQuery{Sort: SortCreatedAt, Order: OrderDesc, Limit: DefaultListLimit, Projection: ProjectionSummary},
+		},
+		{
+			name:  "explicit values kept",
+			query: ListQuery{Sort: SortName, Order: OrderAsc, Limit: MaxListLimit, Projection: ProjectionFull},
+			want:  ListQuery{Sort: SortName, Order: OrderAsc, Limit: MaxListLimit, Projection: ProjectionFull},
+		},
+		{name: "unknown sort", query: ListQuery{Sort: "version"}, wantErr: true},
+		{name: "unknown order", query: ListQuery{Order: "up"}, wantErr: true},
+		{name: "unknown projection", query: ListQuery{Projection: "nodes"}, wantErr: true},
+		{name: "negative limit", query: ListQuery{Limit: -1}, wantErr: true},
+		{name: "limit above max", query: ListQuery{Limit: MaxListLimit + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.query
+			err := q.Normalize()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("error = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize: %v", err)
+			}
+			if q.Sort != tt.want.Sort || q.Order != tt.want.Order || q.Limit != tt.want.Limit || q.Projection != tt.want.Projection {
+				t.Errorf("normalized = %s/%s/%d/%s, want %s/%s/%d/%s",
+					q.Sort, q.Order, q.Limit, q.Projection, tt.want.Sort, tt.want.Order, tt.want.Limit, tt.want.Projection)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := queryBaseTime.Add(1500 * time.Millisecond)
+	updated := queryBaseTime.Add(time.Hour)
+
+	tests := []struct {
+		sort  string
+		order string
+		value string
+	}{
+		{SortCreatedAt, OrderDesc, created.Format(time.RFC3339Nano)},
+		{SortUpdatedAt, OrderAsc, updated.Format(time.RFC3339Nano)},
+		{SortName, OrderAsc, "Feeder 北區"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort+"/"+tt.order, func(t *testing.T) {
+			q := ListQuery{Sort: tt.sort, Order: tt.order}
+			if value := sortValue(tt.sort, "Feeder 北區", created, updated); value != tt.value {
+				t.Fatalf("sortValue = %q, want %q", value, tt.value)
+			}
+			q.Cursor = encodeCursor(q, tt.value, "topology-1")
+
+			cursor, err := decodeCursor(q)
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if cursor.Sort != tt.sort || cursor.Order != tt.order || cursor.Value != tt.value || cursor.ID != "topology-1" {
+				t.Errorf("cursor = %+v", cursor)
+			}
+		})
+	}
+
+	// 沒有游標表示第一頁
+	if cursor, err := decodeCursor(ListQuery{Sort: SortCreatedAt, Order: OrderDesc}); cursor != nil || err != nil {
+		t.Errorf("empty cursor = %+v, %v", cursor, err)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	q := ListQuery{Sort: SortCreatedAt, Order: OrderDesc}
+	raw := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"created_at","o":"desc","v":"2024-03-01T08:00:00Z","id":"a"}`))},
+		{"not json", raw("cursor")},
+		{"missing id", raw(`{"s":"created_at","o":"desc","v":"2024-03-01T08:00:00Z"}`)},
+		{"different sort", encodeCursor(ListQuery{Sort: SortName, Order: OrderDesc}, "a", "a")},
+		{"different order", encodeCursor(ListQuery{Sort: SortCreatedAt, Order: OrderAsc}, "2024-03-01T08:00:00Z", "a")},
+		{"bad time value", encodeCursor(q, "yesterday", "a")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := q
+			query.Cursor = tt.cursor
+			if _, err := decodeCursor(query); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestListQueryMatches(t *testing.T) {
+	demo := &Topology{
+		ID: "demo", Name: "Demo feeder", Description: "Rural sample", ProfileType: "rural",
+		CreatedAt: queryBaseTime, UpdatedAt: queryBaseTime,
+	}
+	owned := &Topology{
+		ID: "owned", UserID: stringPtr("alice"), Name: "Alice 北區饋線", ProfileType: "urban",
+		CreatedAt: queryBaseTime.Add(24 * time.Hour), UpdatedAt: queryBaseTime.Add(48 * time.Hour),
+		Nodes: []Node{{ID: "a", Type: NodeTypeBus, Geo: &GeoPoint{Lat: 25.03, Lon: 121.56}}},
+	}
+	orgOwned := &Topology{
+		ID: "org", OrganizationID: stringPtr("utility"), Name: "Utility feeder", ProfileType: "suburban",
+		CreatedAt: queryBaseTime, UpdatedAt: queryBaseTime,
+	}
+	taipei := &BBox{MinLon: 121.4, MinLat: 24.9, MaxLon: 121.7, MaxLat: 25.2}
+	kaohsiung := &BBox{MinLon: 120.2, MinLat: 22.5, MaxLon: 120.4, MaxLat: 22.7}
+
+	tests := []struct {
+		name  string
+		query ListQuery
+		topo  *Topology
+		want  bool
+	}{
+		{"anonymous sees demo", ListQuery{}, demo, true},
+		{"anonymous does not see user topology", ListQuery{}, owned, false},
+		{"anonymous does not see organization topology", ListQuery{}, orgOwned, false},
+		{"share holder is not anonymous", ListQuery{ShareTokenHash: "hash"}, owned, true},
+		{"owner", ListQuery{UserID: stringPtr("alice")}, owned, true},
+		{"other user", ListQuery{UserID: stringPtr("bob")}, owned, false},
+		{"user query skips demo", ListQuery{UserID: stringPtr("alice")}, demo, false},
+		{"organization", ListQuery{OrganizationID: stringPtr("utility")}, orgOwned, true},
+		{"other organization", ListQuery{OrganizationID: stringPtr("other")}, orgOwned, false},
+		{"organization query skips user topology", ListQuery{OrganizationID: stringPtr("utility")}, owned, false},
+		{"profile type", ListQuery{ProfileType: "rural"}, demo, true},
+		{"profile type mismatch", ListQuery{ProfileType: "urban"}, demo, false},
+		{"created after is inclusive", ListQuery{CreatedAfter: timePtr(queryBaseTime)}, demo, true},
+		{"created after", ListQuery{CreatedAfter: timePtr(queryBaseTime.Add(time.Second))}, demo, false},
+		{"created before is exclusive", ListQuery{CreatedBefore: timePtr(queryBaseTime)}, demo, false},
+		{"created before", ListQuery{CreatedBefore: timePtr(queryBaseTime.Add(time.Second))}, demo, true},
+		{"updated range", ListQuery{UserID: stringPtr("alice"), UpdatedAfter: timePtr(queryBaseTime.Add(24 * time.Hour)), UpdatedBefore: timePtr(queryBaseTime.Add(72 * time.Hour))}, owned, true},
+		{"updated before", ListQuery{UserID: stringPtr("alice"), UpdatedBefore: timePtr(queryBaseTime.Add(24 * time.Hour))}, owned, false},
+		{"search prefix", ListQuery{Search: "dem"}, demo, true},
+		{"search matches description", ListQuery{Search: "SAMPLE"}, demo, true},
+		{"search all terms required", ListQuery{Search: "demo urban"}, demo, false},
+		{"search middle of word", ListQuery{Search: "eeder"}, demo, false},
+		{"search CJK word", ListQuery{UserID: stringPtr("alice"), Search: "北區"}, owned, true},
+		{"bbox intersects", ListQuery{UserID: stringPtr("alice"), BBox: taipei}, owned, true},
+		{"bbox outside", ListQuery{UserID: stringPtr("alice"), BBox: kaohsiung}, owned, false},
+		{"bbox without coordinates", ListQuery{BBox: taipei}, demo, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.matches(tt.topo, SearchTerms(tt.query.Search)); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// queryRepository 五個依序建立的用戶拓樸、一個 demo 拓樸與一個其他用戶的拓樸
+func queryRepository(t *testing.T) *InMemoryRepository {
+	t.Helper()
+	repo := NewInMemoryRepository()
+	create := func(topo *Topology) {
+		if err := repo.Create(topo); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		create(&Topology{
+			ID:          fmt.Sprintf("t%d", i),
+			UserID:      stringPtr("alice"),
+			Name:        fmt.Sprintf("Feeder %c", 'E'-i),
+			ProfileType: "urban",
+			CreatedAt:   queryBaseTime.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	create(&Topology{ID: "demo", Name: "Demo", ProfileType: "rural", CreatedAt: queryBaseTime})
+	create(&Topology{ID: "other", UserID: stringPtr("bob"), Name: "Bob", ProfileType: "rural", CreatedAt: queryBaseTime})
+	return repo
+}
+
+// queryAll 逐頁查詢直到沒有下一頁，回傳依序取得的 ID
+func queryAll(t *testing.T, repo *InMemoryRepository, q ListQuery) []string {
+	t.Helper()
+	ids := []string{}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := repo.Query(q)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		for _, summary := range page.Summaries {
+			ids = append(ids, summary.ID)
+		}
+		if !page.HasMore() {
+			return ids
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	repo := queryRepository(t)
+	alice := stringPtr("alice")
+
+	tests := []struct {
+		name  string
+		query ListQuery
+		want  []string
+	}{
+		{"created desc", ListQuery{UserID: alice, Limit: 2}, []string{"t4", "t3", "t2", "t1", "t0"}},
+		{"created asc", ListQuery{UserID: alice, Order: OrderAsc, Limit: 2}, []string{"t0", "t1", "t2", "t3", "t4"}},
+		{"name asc", ListQuery{UserID: alice, Sort: SortName, Order: OrderAsc, Limit: 3}, []string{"t4", "t3", "t2", "t1", "t0"}},
+		{"exact page size", ListQuery{UserID: alice, Limit: 5}, []string{"t4", "t3", "t2", "t1", "t0"}},
+		{"anonymous", ListQuery{Limit: 1}, []string{"demo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryAll(t, repo, tt.query); !equalStrings(got, tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryShareToken(t *testing.T) {
+	repo := queryRepository(t)
+	link, err := NewShareLink("t2", stringPtr("alice"), nil)
+	if err != nil {
+		t.Fatalf("NewShareLink: %v", err)
+	}
+	if err := repo.CreateShareLink(link); err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+
+	// 共用連結只列出該連結的拓樸
+	if got := queryAll(t, repo, ListQuery{ShareTokenHash: link.TokenHash}); !equalStrings(got, []string{"t2"}) {
+		t.Errorf("share holder ids = %v, want [t2]", got)
+	}
+	if got := queryAll(t, repo, ListQuery{ShareTokenHash: HashShareToken("unknown")}); len(got) != 0 {
+		t.Errorf("unknown token ids = %v, want none", got)
+	}
+
+	if err := repo.RevokeShareLink("t2", link.ID); err != nil {
+		t.Fatalf("RevokeShareLink: %v", err)
+	}
+	if got := queryAll(t, repo, ListQuery{ShareTokenHash: link.TokenHash}); len(got) != 0 {
+		t.Errorf("revoked token ids = %v, want none", got)
+	}
+}
@@ -1,7 +1,9 @@
 package topology
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,23 +13,57 @@ type Repository interface {
 	Create(topology *Topology) error
 	GetByID(id string) (*Topology, error)
 	GetByIDAndUserID(id string, userID *string) (*Topology, error) // 添加用戶ID檢查
-	Update(id string, topology *Topology) error
+	Update(id string, topology *Topology, info RevisionInfo) error // 更新並產生新版本
+	// UpdateIfVersion 僅在目前版本等於 expectedVersion 時更新（compare-and-swap），否則回傳 ErrVersionConflict
+	UpdateIfVersion(id string, expectedVersion int, topology *Topology, info RevisionInfo) error
 	Delete(id string) error
+	DeleteIfVersion(id string, expectedVersion int) error
 	List() ([]*Topology, error)
-	ListByUserID(userID *string) ([]*Topology, error) // 根據用戶ID列出拓樸
+	ListByUserID(userID *string) ([]*Topology, error) // 根據用戶ID列出拓樸，nil 時只列出 demo 拓樸
+	ListByBBox(userID *string, bbox BBox) ([]*Topology, error) // 列出經緯度範圍與 bbox 相交的拓樸（userID 語意同 ListByUserID）
 	CountByUserID(userID *string) (int, error)        // 統計用戶拓樸數量
+	CountByOrganizationID(organizationID string) (int, error) // 統計組織擁有的拓樸數量
+	// GetOrganizationID 取得擁有拓樸的組織（不載入 nodes/lines），個人或 demo 拓樸回傳 nil
+	GetOrganizationID(id string) (*string, error)
+	// Query 依條件篩選、排序並以游標分頁列出拓樸，游標無效時回傳 ErrInvalidCursor
+	Query(q ListQuery) (*ListPage, error)
+
+	// 版本歷史
+	ListRevisions(topologyID string) ([]*Revision, error)
+	GetRevision(topologyID string, revision int) (*Revision, error)
+
+	// 共用與權限
+	// Authorize 取得拓樸與 principal 的角色（擁有者、共用對象或有效的共用連結），沒有任何權限時回傳 ErrTopologyNotFound
+	Authorize(id string, principal Principal) (*Topology, Role, error)
+	ListCollaborators(topologyID string) ([]*Collaborator, error)
+	SetCollaborator(collaborator *Collaborator) error // 新增或更新共用對象的角色
+	RemoveCollaborator(topologyID, userID string) error
+	CreateShareLink(link *ShareLink) error
+	ListShareLinks(topologyID string) ([]*ShareLink, error)
+	RevokeShareLink(topologyID, linkID string) error
+	// TransferOwnership 將擁有權轉移給 newOwnerID；previousOwnerRole 不為 RoleNone 時原擁有者保留該角色
+	TransferOwnership(topologyID, newOwnerID string, previousOwnerRole Role) error
+	// TransferToOrganization 將個人拓樸轉移給組織；previousOwnerRole 不為 RoleNone 時原擁有者另保留該共用角色
+	TransferToOrganization(topologyID, organizationID string, previousOwnerRole Role) error
+	ListSharedWith(userID string) ([]*SharedTopology, error) // 共用給用戶的拓樸（由新到舊）
 }
 
 // InMemoryRepository 記憶體實作（開發用）
 type InMemoryRepository struct {
-	mu         sync.RWMutex
-	topologies map[string]*Topology
+	mu            sync.RWMutex
+	topologies    map[string]*Topology
+	revisions     map[string][]*Revision
+	collaborators map[string]map[string]*Collaborator // topologyID -> userID -> 共用對象
+	shareLinks    map[string][]*ShareLink
 }
 
 // NewInMemoryRepository 建立新的記憶體 repository
 func NewInMemoryRepository() *InMemoryRepository {
 	return &InMemoryRepository{
-		topologies: make(map[string]*Topology),
+		topologies:    make(map[string]*Topology),
+		revisions:     make(map[string][]*Revision),
+		collaborators: make(map[string]map[string]*Collaborator),
+		shareLinks:    make(map[string][]*ShareLink),
 	}
 }
 
@@ -38,7 +74,18 @@ func (r *InMemoryRepository) Create(topology *Topology) error {
 	if topology.ID == "" {
 		topology.ID = uuid.New().String()
 	}
-	r.topologies[topology.ID] = topology
+	// 與 PostgreSQL 實作相同的時間戳記規則，列表排序與日期篩選才會一致
+	now := time.Now()
+	if topology.CreatedAt.IsZero() {
+		topology.CreatedAt = now
+	}
+	topology.UpdatedAt = now
+	topology.Version = 1
+	// 儲存副本，避免呼叫端修改影響已儲存的資料
+	r.topologies[topology.ID] = topology.Clone()
+	r.revisions[topology.ID] = []*Revision{
+		newRevision(topology, 1, RevisionInfo{AuthorID: topology.UserID, Message: InitialRevisionMessage}),
+	}
 	return nil
 }
 
@@ -50,34 +97,73 @@ func (r *InMemoryRepository) GetByID(id string) (*Topology, error) {
 	if !exists {
 		return nil, ErrTopologyNotFound
 	}
-	return topology, nil
+	return topology.Clone(), nil
+}
+
+func (r *InMemoryRepository) Update(id string, topology *Topology, info RevisionInfo) error {
+	return r.update(id, topology, info, nil)
 }
 
-func (r *InMemoryRepository) Update(id string, topology *Topology) error {
+func (r *InMemoryRepository) UpdateIfVersion(id string, expectedVersion int, topology *Topology, info RevisionInfo) error {
+	return r.update(id, topology, info, &expectedVersion)
+}
+
+func (r *InMemoryRepository) update(id string, topology *Topology, info RevisionInfo, expectedVersion *int) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.topologies[id]; !exists {
+	existing, exists := r.topologies[id]
+	if !exists {
 		return ErrTopologyNotFound
 	}
+	if expectedVersion != nil && existing.Version != *expectedVersion {
+		return ErrVersionConflict
+	}
+
 	topology.ID = id
-	r.topologies[id] = topology
+	topology.UpdatedAt = time.Now()
+	topology.Version = existing.Version + 1
+	r.topologies[id] = topology.Clone()
+	r.revisions[id] = append(r.revisions[id], newRevision(topology, topology.Version, info))
 	return nil
 }
 
 func (r *InMemoryRepository) Delete(id string) error {
+	return r.delete(id, nil)
+}
+
+func (r *InMemoryRepository) DeleteIfVersion(id string, expectedVersion int) error {
+	return r.delete(id, &expectedVersion)
+}
+
+func (r *InMemoryRepository) delete(id string, expectedVersion *int) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.topologies[id]; !exists {
+	existing, exists := r.topologies[id]
+	if !exists {
 		return ErrTopologyNotFound
 	}
+	if expectedVersion != nil && existing.Version != *expectedVersion {
+		return ErrVersionConflict
+	}
+
 	delete(r.topologies, id)
+	delete(r.revisions, id)
+	delete(r.collaborators, id)
+	delete(r.shareLinks, id)
 	return nil
 }
 
 func (r *InMemoryRepository) List() ([]*Topology, error) {
-	return r.ListByUserID(nil)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topologies := make([]*Topology, 0, len(r.topologies))
+	for _, topology := range r.topologies {
+		topologies = append(topologies, topology.Clone())
+	}
+	return topologies, nil
 }
 
 func (r *InMemoryRepository) GetByIDAndUserID(id string, userID *string) (*Topology, error) {
@@ -89,12 +175,12 @@ func (r *InMemoryRepository) GetByIDAndUserID(id string, userID *string) (*Topol
 		return nil, ErrTopologyNotFound
 	}
 
-	// 如果提供了 userID，檢查是否匹配
-	if userID != nil && topology.UserID != nil && *topology.UserID != *userID {
+	// 如果提供了 userID，只回傳擁有或共用給該用戶的拓樸
+	if userID != nil && r.roleFor(topology, Principal{UserID: userID}) == RoleNone {
 		return nil, ErrTopologyNotFound
 	}
 
-	return topology, nil
+	return topology.Clone(), nil
 }
 
 func (r *InMemoryRepository) ListByUserID(userID *string) ([]*Topology, error) {
@@ -103,16 +189,59 @@ func (r *InMemoryRepository) ListByUserID(userID *string) ([]*Topology, error) {
 
 	topologies := make([]*Topology, 0, len(r.topologies))
 	for _, topology := range r.topologies {
-		// 如果提供了 userID，只返回匹配的拓樸
+		// 如果提供了 userID，只返回匹配的拓樸；匿名時只返回沒有擁有者的 demo 拓樸
 		if userID == nil {
-			topologies = append(topologies, topology)
+			if !topology.hasOwner() {
+				topologies = append(topologies, topology.Clone())
+			}
 		} else if topology.UserID != nil && *topology.UserID == *userID {
-			topologies = append(topologies, topology)
+			topologies = append(topologies, topology.Clone())
 		}
 	}
 	return topologies, nil
 }
 
+func (r *InMemoryRepository) ListByBBox(userID *string, bbox BBox) ([]*Topology, error) {
+	topologies, err := r.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*Topology, 0, len(topologies))
+	for _, topology := range topologies {
+		if bounds, ok := topology.BBox(); ok && bounds.Intersects(bbox) {
+			matched = append(matched, topology)
+		}
+	}
+	return matched, nil
+}
+
+func (r *InMemoryRepository) Query(q ListQuery) (*ListPage, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(q)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	terms := SearchTerms(q.Search)
+	now := time.Now()
+	matched := make([]*Topology, 0, len(r.topologies))
+	for _, topology := range r.topologies {
+		if q.ShareTokenHash != "" && !r.hasActiveLink(topology.ID, q.ShareTokenHash, now) {
+			continue
+		}
+		if q.matches(topology, terms) {
+			matched = append(matched, topology)
+		}
+	}
+	return paginate(q, cursor, matched), nil
+}
+
 func (r *InMemoryRepository) CountByUserID(userID *string) (int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -120,7 +249,7 @@ func (r *InMemoryRepository) CountByUserID(userID *string) (int, error) {
 	count := 0
 	for _, topology := range r.topologies {
 		if userID == nil {
-			if topology.UserID == nil {
+			if topology.UserID == nil && topology.OrganizationID == nil {
 				count++
 			}
 		} else if topology.UserID != nil && *topology.UserID == *userID {
@@ -130,3 +259,342 @@ func (r *InMemoryRepository) CountByUserID(userID *string) (int, error) {
 	return count, nil
 }
 
+func (r *InMemoryRepository) CountByOrganizationID(organizationID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, topology := range r.topologies {
+		if topology.OrganizationID != nil && *topology.OrganizationID == organizationID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *InMemoryRepository) GetOrganizationID(id string) (*string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topology, exists := r.topologies[id]
+	if !exists {
+		return nil, ErrTopologyNotFound
+	}
+	return topology.Clone().OrganizationID, nil
+}
+
+func (r *InMemoryRepository) ListRevisions(topologyID string) ([]*Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.topologies[topologyID]; !exists {
+		return nil, ErrTopologyNotFound
+	}
+
+	// 由新到舊排列，與 PostgreSQL 實作一致
+	stored := r.revisions[topologyID]
+	revisions := make([]*Revision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i].Clone())
+	}
+	return revisions, nil
+}
+
+func (r *InMemoryRepository) GetRevision(topologyID string, revision int) (*Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.topologies[topologyID]; !exists {
+		return nil, ErrTopologyNotFound
+	}
+
+	for _, stored := range r.revisions[topologyID] {
+		if stored.Revision == revision {
+			return stored.Clone(), nil
+		}
+	}
+	return nil, ErrRevisionNotFound
+}
+
+func (r *InMemoryRepository) Authorize(id string, principal Principal) (*Topology, Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topology, exists := r.topologies[id]
+	if !exists {
+		return nil, RoleNone, ErrTopologyNotFound
+	}
+	role := r.roleFor(topology, principal)
+	if role == RoleNone {
+		return nil, RoleNone, ErrTopologyNotFound
+	}
+	return topology.Clone(), role, nil
+}
+
+// hasActiveLink 拓樸是否有雜湊為 tokenHash 的有效共用連結（呼叫端需持有鎖）
+func (r *InMemoryRepository) hasActiveLink(topologyID, tokenHash string, now time.Time) bool {
+	for _, link := range r.shareLinks[topologyID] {
+		if link.TokenHash == tokenHash && link.Active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// roleFor 合併擁有者、共用對象與共用連結的角色（呼叫端需持有鎖）
+func (r *InMemoryRepository) roleFor(topology *Topology, principal Principal) Role {
+	role := topology.ownerRole(principal)
+	if principal.UserID != nil {
+		if collaborator, ok := r.collaborators[topology.ID][*principal.UserID]; ok {
+			role = maxRole(role, collaborator.Role)
+		}
+	}
+	if principal.ShareToken != "" {
+		hash := HashShareToken(principal.ShareToken)
+		now := time.Now()
+		for _, link := range r.shareLinks[topology.ID] {
+			if link.TokenHash == hash && link.Active(now) {
+				role = maxRole(role, link.Role)
+			}
+		}
+	}
+	return role
+}
+
+func (r *InMemoryRepository) ListCollaborators(topologyID string) ([]*Collaborator, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.topologies[topologyID]; !exists {
+		return nil, ErrTopologyNotFound
+	}
+
+	collaborators := make([]*Collaborator, 0, len(r.collaborators[topologyID]))
+	for _, collaborator := range r.collaborators[topologyID] {
+		copied := *collaborator
+		collaborators = append(collaborators, &copied)
+	}
+	sortCollaborators(collaborators)
+	return collaborators, nil
+}
+
+func (r *InMemoryRepository) SetCollaborator(collaborator *Collaborator) error {
+	if !collaborator.Role.Valid() {
+		return ErrInvalidRole
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topology, exists := r.topologies[collaborator.TopologyID]
+	if !exists {
+		return ErrTopologyNotFound
+	}
+	if !topology.hasOwner() {
+		return ErrNotShareable
+	}
+
+	now := time.Now()
+	grants := r.collaborators[collaborator.TopologyID]
+	if grants == nil {
+		grants = make(map[string]*Collaborator)
+		r.collaborators[collaborator.TopologyID] = grants
+	}
+	if existing, ok := grants[collaborator.UserID]; ok {
+		collaborator.CreatedAt = existing.CreatedAt
+	} else {
+		collaborator.CreatedAt = now
+	}
+	collaborator.UpdatedAt = now
+
+	copied := *collaborator
+	copied.Email = ""
+	grants[collaborator.UserID] = &copied
+	return nil
+}
+
+func (r *InMemoryRepository) RemoveCollaborator(topologyID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.topologies[topologyID]; !exists {
+		return ErrTopologyNotFound
+	}
+	if _, ok := r.collaborators[topologyID][userID]; !ok {
+		return ErrCollaboratorNotFound
+	}
+	delete(r.collaborators[topologyID], userID)
+	return nil
+}
+
+func (r *InMemoryRepository) CreateShareLink(link *ShareLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topology, exists := r.topologies[link.TopologyID]
+	if !exists {
+		return ErrTopologyNotFound
+	}
+	if !topology.hasOwner() {
+		return ErrNotShareable
+	}
+
+	if link.ID == "" {
+		link.ID = uuid.New().String()
+	}
+	link.CreatedAt = time.Now()
+
+	copied := *link
+	copied.Token = ""
+	r.shareLinks[link.TopologyID] = append(r.shareLinks[link.TopologyID], &copied)
+	return nil
+}
+
+func (r *InMemoryRepository) ListShareLinks(topologyID string) ([]*ShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.topologies[topologyID]; !exists {
+		return nil, ErrTopologyNotFound
+	}
+
+	// 由新到舊排列，與 PostgreSQL 實作一致
+	stored := r.shareLinks[topologyID]
+	links := make([]*ShareLink, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		copied := *stored[i]
+		links = append(links, &copied)
+	}
+	return links, nil
+}
+
+func (r *InMemoryRepository) RevokeShareLink(topologyID, linkID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.topologies[topologyID]; !exists {
+		return ErrTopologyNotFound
+	}
+	for _, link := range r.shareLinks[topologyID] {
+		if link.ID == linkID {
+			if link.RevokedAt == nil {
+				now := time.Now()
+				link.RevokedAt = &now
+			}
+			return nil
+		}
+	}
+	return ErrShareLinkNotFound
+}
+
+func (r *InMemoryRepository) TransferOwnership(topologyID, newOwnerID string, previousOwnerRole Role) error {
+	if previousOwnerRole != RoleNone && !previousOwnerRole.Valid() {
+		return ErrInvalidRole
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topology, err := r.personalTopology(topologyID)
+	if err != nil {
+		return err
+	}
+
+	previousOwnerID := *topology.UserID
+	owner := newOwnerID
+	topology.UserID = &owner
+
+	grants := r.collaborators[topologyID]
+	if grants == nil {
+		grants = make(map[string]*Collaborator)
+		r.collaborators[topologyID] = grants
+	}
+	delete(grants, newOwnerID)
+	if previousOwnerRole != RoleNone && previousOwnerID != newOwnerID {
+		now := time.Now()
+		grants[previousOwnerID] = &Collaborator{
+			TopologyID: topologyID,
+			UserID:     previousOwnerID,
+			Role:       previousOwnerRole,
+			GrantedBy:  &owner,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) TransferToOrganization(topologyID, organizationID string, previousOwnerRole Role) error {
+	if previousOwnerRole != RoleNone && !previousOwnerRole.Valid() {
+		return ErrInvalidRole
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topology, err := r.personalTopology(topologyID)
+	if err != nil {
+		return err
+	}
+
+	previousOwnerID := *topology.UserID
+	organization := organizationID
+	topology.UserID = nil
+	topology.OrganizationID = &organization
+
+	if previousOwnerRole != RoleNone {
+		grants := r.collaborators[topologyID]
+		if grants == nil {
+			grants = make(map[string]*Collaborator)
+			r.collaborators[topologyID] = grants
+		}
+		now := time.Now()
+		grants[previousOwnerID] = &Collaborator{
+			TopologyID: topologyID,
+			UserID:     previousOwnerID,
+			Role:       previousOwnerRole,
+			GrantedBy:  &previousOwnerID,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+	return nil
+}
+
+// personalTopology 取得可轉移的個人拓樸（呼叫端需持有寫入鎖）
+func (r *InMemoryRepository) personalTopology(topologyID string) (*Topology, error) {
+	topology, exists := r.topologies[topologyID]
+	if !exists {
+		return nil, ErrTopologyNotFound
+	}
+	if topology.OrganizationID != nil {
+		return nil, ErrOrganizationOwned
+	}
+	if topology.UserID == nil {
+		return nil, ErrNotShareable
+	}
+	return topology, nil
+}
+
+func (r *InMemoryRepository) ListSharedWith(userID string) ([]*SharedTopology, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shared := []*SharedTopology{}
+	for topologyID, grants := range r.collaborators {
+		collaborator, ok := grants[userID]
+		if !ok {
+			continue
+		}
+		if topology, exists := r.topologies[topologyID]; exists {
+			shared = append(shared, &SharedTopology{Summary: topology.Summarize(), Role: collaborator.Role})
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if !shared[i].UpdatedAt.Equal(shared[j].UpdatedAt) {
+			return shared[i].UpdatedAt.After(shared[j].UpdatedAt)
+		}
+		return shared[i].ID > shared[j].ID
+	})
+	return shared, nil
+}
@@ -0,0 +1,102 @@
+package topology
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision 拓樸的不可變版本快照（每次更新都會產生一筆）
+type Revision struct {
+	ID          string    `json:"id"`
+	TopologyID  string    `json:"topology_id"`
+	Revision    int       `json:"revision"` // 每個拓樸從 1 開始遞增
+	AuthorID    *string   `json:"author_id,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	ProfileType string    `json:"profile_type"`
+	Nodes       []Node    `json:"nodes"`
+	Lines       []Line    `json:"lines"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RevisionSummary 版本列表用的摘要（不含節點與線路內容）
+type RevisionSummary struct {
+	Revision  int       `json:"revision"`
+	AuthorID  *string   `json:"author_id,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	NodeCount int       `json:"node_count"`
+	LineCount int       `json:"line_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionInfo 描述一次修改的作者與說明
+type RevisionInfo struct {
+	AuthorID *string
+	Message  string
+}
+
+// InitialRevisionMessage 建立拓樸時第一個版本的說明
+const InitialRevisionMessage = "Initial revision"
+
+// newRevision 由拓樸目前狀態建立版本快照
+func newRevision(t *Topology, number int, info RevisionInfo) *Revision {
+	snapshot := t.Clone()
+	createdAt := t.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return &Revision{
+		ID:          uuid.New().String(),
+		TopologyID:  t.ID,
+		Revision:    number,
+		AuthorID:    info.AuthorID,
+		Message:     info.Message,
+		Name:        snapshot.Name,
+		Description: snapshot.Description,
+		ProfileType: snapshot.ProfileType,
+		Nodes:       snapshot.Nodes,
+		Lines:       snapshot.Lines,
+		CreatedAt:   createdAt,
+	}
+}
+
+// Clone 深拷貝版本
+func (r *Revision) Clone() *Revision {
+	clone := *r
+	if r.AuthorID != nil {
+		authorID := *r.AuthorID
+		clone.AuthorID = &authorID
+	}
+	snapshot := r.Snapshot()
+	clone.Nodes = snapshot.Nodes
+	clone.Lines = snapshot.Lines
+	return &clone
+}
+
+// Summary 取得版本摘要
+func (r *Revision) Summary() RevisionSummary {
+	return RevisionSummary{
+		Revision:  r.Revision,
+		AuthorID:  r.AuthorID,
+		Message:   r.Message,
+		NodeCount: len(r.Nodes),
+		LineCount: len(r.Lines),
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// Snapshot 將版本內容還原為拓樸（ID 為原拓樸 ID，其他中繼資料需由呼叫端補上）
+func (r *Revision) Snapshot() *Topology {
+	t := &Topology{
+		ID:          r.TopologyID,
+		Name:        r.Name,
+		Description: r.Description,
+		ProfileType: r.ProfileType,
+		Nodes:       r.Nodes,
+		Lines:       r.Lines,
+		UpdatedAt:   r.CreatedAt,
+	}
+	return t.Clone()
+}
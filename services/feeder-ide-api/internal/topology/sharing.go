@@ -0,0 +1,156 @@
+package topology
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Role 用戶對拓樸的角色，權限由低到高為 viewer、editor、owner
+type Role string
+
+// 拓樸角色
+const (
+	RoleNone   Role = ""
+	RoleViewer Role = "viewer" // 讀取、匯出與執行分析
+	RoleEditor Role = "editor" // 另可修改拓樸與還原版本
+	RoleOwner  Role = "owner"  // 另可刪除、管理共用與轉移擁有權
+)
+
+// rank 角色等級，用於比較權限
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Valid 是否為可授予的角色
+func (r Role) Valid() bool {
+	return r.rank() > 0
+}
+
+// Allows 是否具備 required 角色以上的權限
+func (r Role) Allows(required Role) bool {
+	return r.rank() > 0 && r.rank() >= required.rank()
+}
+
+// maxRole 取權限較高的角色
+func maxRole(a, b Role) Role {
+	if b.rank() > a.rank() {
+		return b
+	}
+	return a
+}
+
+// Principal 存取拓樸的身分：登入用戶及（或）共用連結 token
+type Principal struct {
+	UserID     *string
+	ShareToken string
+	// OrganizationRoles 用戶在所屬組織中對應的拓樸角色（組織 ID -> 角色），用於組織擁有的拓樸
+	OrganizationRoles map[string]Role
+}
+
+// ownerRole 依拓樸擁有者判斷的角色：擁有者為 owner，組織擁有的拓樸依成員在組織中的角色；
+// 無擁有者的 demo 拓樸只開放給未登入的呼叫者（demo 模式），登入用戶需透過共用取得權限
+func (t *Topology) ownerRole(principal Principal) Role {
+	if t.OrganizationID != nil {
+		return principal.OrganizationRoles[*t.OrganizationID]
+	}
+	if t.UserID == nil {
+		if principal.UserID == nil {
+			return RoleOwner
+		}
+		return RoleNone
+	}
+	if principal.UserID != nil && *principal.UserID == *t.UserID {
+		return RoleOwner
+	}
+	return RoleNone
+}
+
+// hasOwner 是否有擁有者（用戶或組織）；沒有擁有者的 demo 拓樸不能共用
+func (t *Topology) hasOwner() bool {
+	return t.UserID != nil || t.OrganizationID != nil
+}
+
+// Collaborator 拓樸的共用對象
+type Collaborator struct {
+	TopologyID string    `json:"topology_id"`
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email,omitempty"` // 由 handler 填入
+	Role       Role      `json:"role"`
+	GrantedBy  *string   `json:"granted_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ShareLink 唯讀共用連結；只儲存 token 的雜湊，token 僅在建立時回傳一次
+type ShareLink struct {
+	ID         string     `json:"id"`
+	TopologyID string     `json:"topology_id"`
+	Token      string     `json:"token,omitempty"`
+	TokenHash  string     `json:"-"`
+	Role       Role       `json:"role"` // 固定為 viewer
+	CreatedBy  *string    `json:"created_by,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active 連結在 now 時是否有效（未撤銷且未過期）
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+// NewShareLink 建立新的唯讀共用連結（含隨機 token）
+func NewShareLink(topologyID string, createdBy *string, expiresAt *time.Time) (*ShareLink, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return &ShareLink{
+		TopologyID: topologyID,
+		Token:      token,
+		TokenHash:  HashShareToken(token),
+		Role:       RoleViewer,
+		CreatedBy:  createdBy,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// HashShareToken 共用連結 token 的儲存雜湊
+func HashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SharedTopology 共用給用戶的拓樸摘要與角色
+type SharedTopology struct {
+	*Summary
+	Role Role `json:"role"`
+}
+
+// sortCollaborators 依角色（高到低）與共用時間排列
+func sortCollaborators(collaborators []*Collaborator) {
+	sort.Slice(collaborators, func(i, j int) bool {
+		if collaborators[i].Role.rank() != collaborators[j].Role.rank() {
+			return collaborators[i].Role.rank() > collaborators[j].Role.rank()
+		}
+		if !collaborators[i].CreatedAt.Equal(collaborators[j].CreatedAt) {
+			return collaborators[i].CreatedAt.Before(collaborators[j].CreatedAt)
+		}
+		return collaborators[i].UserID < collaborators[j].UserID
+	})
+}
@@ -0,0 +1,354 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 違規嚴重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// 驗證規則代碼
+const (
+	RuleMissingID           = "missing_id"
+	RuleDuplicateNodeID     = "duplicate_node_id"
+	RuleDuplicateLineID     = "duplicate_line_id"
+	RuleDanglingLine        = "dangling_line"
+	RuleSelfLoop            = "self_loop"
+	RuleUnknownNodeType     = "unknown_node_type"
+	RuleIslandWithoutSource = "island_without_source"
+	RuleInvalidProperties   = "invalid_properties"
+	RuleInvalidGeometry     = "invalid_geometry"
+	RuleCatalogReference    = "catalog_reference"
+)
+
+// 元素類型
+const (
+	ElementNode     = "node"
+	ElementLine     = "line"
+	ElementTopology = "topology"
+)
+
+var validNodeTypes = map[string]bool{
+	NodeTypeBus:         true,
+	NodeTypeTransformer: true,
+	NodeTypeSwitch:      true,
+	NodeTypeEVCharger:   true,
+	NodeTypeDER:         true,
+}
+
+var validSwitchTypes = map[string]bool{
+	"sectionalizer": true,
+	"recloser":      true,
+	"breaker":       true,
+	"fuse":          true,
+}
+
+var validDERTypes = map[string]bool{
+	"pv":      true,
+	"battery": true,
+	"wind":    true,
+}
+
+// Violation 代表一筆驗證違規
+type Violation struct {
+	Severity    string `json:"severity"`
+	ElementID   string `json:"element_id,omitempty"`
+	ElementType string `json:"element_type"` // node, line, topology
+	Rule        string `json:"rule"`
+	Message     string `json:"message"`
+}
+
+// ValidationResult 驗證結果
+type ValidationResult struct {
+	Valid      bool        `json:"valid"`
+	Violations []Violation `json:"violations"`
+}
+
+// Errors 回傳嚴重程度為 error 的違規
+func (r *ValidationResult) Errors() []Violation {
+	errs := []Violation{}
+	for _, v := range r.Violations {
+		if v.Severity == SeverityError {
+			errs = append(errs, v)
+		}
+	}
+	return errs
+}
+
+func (r *ValidationResult) add(severity, elementType, elementID, rule, message string) {
+	r.Violations = append(r.Violations, Violation{
+		Severity:    severity,
+		ElementID:   elementID,
+		ElementType: elementType,
+		Rule:        rule,
+		Message:     message,
+	})
+	if severity == SeverityError {
+		r.Valid = false
+	}
+}
+
+// Add 加入其他來源（例如設備型錄）的違規
+func (r *ValidationResult) Add(violations ...Violation) {
+	for _, v := range violations {
+		r.add(v.Severity, v.ElementType, v.ElementID, v.Rule, v.Message)
+	}
+}
+
+// ValidationError 包裝驗證結果，可用 errors.Is(err, ErrInvalidTopology) 判斷
+type ValidationError struct {
+	Result *ValidationResult
+}
+
+func (e *ValidationError) Error() string {
+	errs := e.Result.Errors()
+	if len(errs) == 0 {
+		return ErrInvalidTopology.Error()
+	}
+	return fmt.Sprintf("%s: %s (%d violations)", ErrInvalidTopology.Error(), errs[0].Message, len(errs))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidTopology
+}
+
+// Validate 對拓樸進行結構驗證
+func Validate(t *Topology) *ValidationResult {
+	result := &ValidationResult{
+		Valid:      true,
+		Violations: []Violation{},
+	}
+
+	nodeIDs := make(map[string]bool, len(t.Nodes))
+	for _, node := range t.Nodes {
+		if node.ID == "" {
+			result.add(SeverityError, ElementNode, "", RuleMissingID,
+				fmt.Sprintf("node %q has no id", node.Name))
+			continue
+		}
+		if nodeIDs[node.ID] {
+			result.add(SeverityError, ElementNode, node.ID, RuleDuplicateNodeID,
+				fmt.Sprintf("duplicate node id %q", node.ID))
+			continue
+		}
+		nodeIDs[node.ID] = true
+
+		if !validNodeTypes[node.Type] {
+			result.add(SeverityError, ElementNode, node.ID, RuleUnknownNodeType,
+				fmt.Sprintf("unknown node type %q", node.Type))
+			continue
+		}
+
+		for _, msg := range validateNodeProperties(node) {
+			result.add(SeverityError, ElementNode, node.ID, RuleInvalidProperties, msg)
+		}
+		if node.Geo != nil {
+			if err := node.Geo.Validate(); err != nil {
+				result.add(SeverityError, ElementNode, node.ID, RuleInvalidGeometry, err.Error())
+			}
+		}
+	}
+
+	lineIDs := make(map[string]bool, len(t.Lines))
+	for _, line := range t.Lines {
+		if line.ID == "" {
+			result.add(SeverityError, ElementLine, "", RuleMissingID,
+				fmt.Sprintf("line %s -> %s has no id", line.FromNodeID, line.ToNodeID))
+			continue
+		}
+		if lineIDs[line.ID] {
+			result.add(SeverityError, ElementLine, line.ID, RuleDuplicateLineID,
+				fmt.Sprintf("duplicate line id %q", line.ID))
+			continue
+		}
+		lineIDs[line.ID] = true
+
+		if line.FromNodeID == line.ToNodeID {
+			result.add(SeverityError, ElementLine, line.ID, RuleSelfLoop,
+				fmt.Sprintf("line connects node %q to itself", line.FromNodeID))
+			continue
+		}
+		if !nodeIDs[line.FromNodeID] {
+			result.add(SeverityError, ElementLine, line.ID, RuleDanglingLine,
+				fmt.Sprintf("from_node_id %q does not exist", line.FromNodeID))
+		}
+		if !nodeIDs[line.ToNodeID] {
+			result.add(SeverityError, ElementLine, line.ID, RuleDanglingLine,
+				fmt.Sprintf("to_node_id %q does not exist", line.ToNodeID))
+		}
+
+		for _, msg := range validateLineProperties(line) {
+			result.add(SeverityError, ElementLine, line.ID, RuleInvalidProperties, msg)
+		}
+		validateLineGeometry(t, line, result)
+	}
+
+	validateIslands(t, result)
+
+	return result
+}
+
+// validateLineGeometry 檢查線路折點座標；有折點但兩端節點缺少經緯度時無法計算長度，列為警告
+func validateLineGeometry(t *Topology, line Line, result *ValidationResult) {
+	for i, point := range line.Geometry {
+		if err := point.Validate(); err != nil {
+			result.add(SeverityError, ElementLine, line.ID, RuleInvalidGeometry,
+				fmt.Sprintf("geometry[%d]: %s", i, err.Error()))
+			return
+		}
+	}
+	if len(line.Geometry) > 0 && t.LinePath(line) == nil {
+		result.add(SeverityWarning, ElementLine, line.ID, RuleInvalidGeometry,
+			"line has geometry but its end nodes have no geo coordinates")
+	}
+}
+
+// validateIslands 檢查每個連通區域是否都有電源
+func validateIslands(t *Topology, result *ValidationResult) {
+	if len(t.Nodes) == 0 {
+		return
+	}
+
+	sources := SourceNodes(t)
+	if len(sources) == 0 {
+		result.add(SeverityWarning, ElementTopology, t.ID, RuleIslandWithoutSource,
+			"topology has no source node (mark a node with is_source or add a substation transformer)")
+		return
+	}
+
+	adjacency := make(map[string][]string, len(t.Nodes))
+	for _, line := range t.Lines {
+		adjacency[line.FromNodeID] = append(adjacency[line.FromNodeID], line.ToNodeID)
+		adjacency[line.ToNodeID] = append(adjacency[line.ToNodeID], line.FromNodeID)
+	}
+
+	energized := make(map[string]bool, len(t.Nodes))
+	queue := make([]string, 0, len(t.Nodes))
+	for _, source := range sources {
+		if !energized[source.ID] {
+			energized[source.ID] = true
+			queue = append(queue, source.ID)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacency[current] {
+			if !energized[next] {
+				energized[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// 以未供電節點為起點找出各個孤島，每個孤島只回報一次
+	seen := make(map[string]bool)
+	for _, node := range t.Nodes {
+		if node.ID == "" || energized[node.ID] || seen[node.ID] {
+			continue
+		}
+
+		island := []string{}
+		seen[node.ID] = true
+		queue = append(queue[:0], node.ID)
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			island = append(island, current)
+			for _, next := range adjacency[current] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		result.add(SeverityWarning, ElementNode, node.ID, RuleIslandWithoutSource,
+			fmt.Sprintf("island of %d node(s) has no source: %s", len(island), strings.Join(island, ", ")))
+	}
+}
+
+// validateNodeProperties 依節點類型檢查屬性格式
+func validateNodeProperties(node Node) []string {
+	switch node.Type {
+	case NodeTypeTransformer:
+		var props TransformerProperties
+		if err := DecodeProperties(node.Properties, &props); err != nil {
+			return []string{err.Error()}
+		}
+		return checkNonNegative(map[string]float64{
+			"rated_voltage_kv":   props.RatedVoltageKV,
+			"rated_capacity_kva": props.RatedCapacityKVA,
+			"primary_voltage":    props.PrimaryVoltage,
+			"secondary_voltage":  props.SecondaryVoltage,
+			"impedance_percent":  props.ImpedancePercent,
+			"x_r_ratio":          props.XRRatio,
+		})
+
+	case NodeTypeSwitch:
+		var props SwitchProperties
+		if err := DecodeProperties(node.Properties, &props); err != nil {
+			return []string{err.Error()}
+		}
+		if props.Type != "" && !validSwitchTypes[props.Type] {
+			return []string{fmt.Sprintf("unknown switch type %q", props.Type)}
+		}
+
+	case NodeTypeEVCharger:
+		var props EVChargerProperties
+		if err := DecodeProperties(node.Properties, &props); err != nil {
+			return []string{err.Error()}
+		}
+		msgs := checkNonNegative(map[string]float64{
+			"rated_power_kw":    props.RatedPowerKW,
+			"max_charging_rate": props.MaxChargingRate,
+		})
+		if props.RatedPowerKW > 0 && props.MaxChargingRate > props.RatedPowerKW {
+			msgs = append(msgs, "max_charging_rate exceeds rated_power_kw")
+		}
+		return msgs
+
+	case NodeTypeDER:
+		var props DERProperties
+		if err := DecodeProperties(node.Properties, &props); err != nil {
+			return []string{err.Error()}
+		}
+		msgs := checkNonNegative(map[string]float64{
+			"rated_power_kw": props.RatedPowerKW,
+		})
+		if props.Type != "" && !validDERTypes[props.Type] {
+			msgs = append(msgs, fmt.Sprintf("unknown der type %q", props.Type))
+		}
+		return msgs
+	}
+
+	return nil
+}
+
+// validateLineProperties 檢查線路電氣參數
+func validateLineProperties(line Line) []string {
+	var props LineProperties
+	if err := DecodeProperties(line.Properties, &props); err != nil {
+		return []string{err.Error()}
+	}
+	return checkNonNegative(map[string]float64{
+		"length_km":    props.LengthKM,
+		"r_ohm_per_km": props.ROhmPerKM,
+		"x_ohm_per_km": props.XOhmPerKM,
+		"ampacity_a":   props.AmpacityA,
+	})
+}
+
+func checkNonNegative(values map[string]float64) []string {
+	msgs := []string{}
+	for _, key := range keysOf(values) {
+		if values[key] < 0 {
+			msgs = append(msgs, fmt.Sprintf("%s must not be negative", key))
+		}
+	}
+	return msgs
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -10,10 +11,19 @@ type TopologyCounter interface {
 	CountByUserID(userID *string) (int, error)
 }
 
+// OrganizationQuota 組織共用配額介面（由 organization.Service 實作）
+type OrganizationQuota interface {
+	TopologyOrganization(topologyID string) (*string, error)
+	CheckTopologyQuota(organizationID string) (bool, int, int, error)
+	CheckSimulationQuota(organizationID string) (bool, error)
+	IncrementSimulationCount(organizationID string) error
+}
+
 // Service 會員服務
 type Service struct {
-	repo            Repository
-	topologyCounter TopologyCounter // 可選，用於檢查拓樸配額
+	repo              Repository
+	topologyCounter   TopologyCounter   // 可選，用於檢查拓樸配額
+	organizationQuota OrganizationQuota // 可選，組織擁有的拓樸改用組織共用配額
 }
 
 // NewService 建立新的會員服務
@@ -28,6 +38,21 @@ func (s *Service) SetTopologyCounter(counter TopologyCounter) {
 	s.topologyCounter = counter
 }
 
+// SetOrganizationQuota 設置組織共用配額（組織擁有的拓樸改由組織配額計算）
+func (s *Service) SetOrganizationQuota(quota OrganizationQuota) {
+	s.organizationQuota = quota
+}
+
+// GetUserByID 取得用戶，不存在時回傳 ErrUserNotFound
+func (s *Service) GetUserByID(id string) (*User, error) {
+	return s.repo.GetUserByID(id)
+}
+
+// GetUserByEmail 以 email 取得用戶（忽略前後空白），不存在時回傳 ErrUserNotFound
+func (s *Service) GetUserByEmail(email string) (*User, error) {
+	return s.repo.GetUserByEmail(strings.TrimSpace(email))
+}
+
 // GetUserTier 取得用戶等級
 func (s *Service) GetUserTier(userID *string) (string, error) {
 	if userID == nil {
@@ -197,6 +222,49 @@ func (s *Service) IncrementSimulationCount(userID string) error {
 	return nil
 }
 
+// CheckTopologyQuotaFor 檢查建立拓樸的配額：organizationID 不為 nil 時檢查組織共用配額，否則為個人配額
+func (s *Service) CheckTopologyQuotaFor(userID *string, organizationID *string) (bool, int, int, error) {
+	if organizationID != nil && s.organizationQuota != nil {
+		return s.organizationQuota.CheckTopologyQuota(*organizationID)
+	}
+	return s.CheckTopologyQuota(userID)
+}
+
+// simulationOrganization 模擬配額歸屬的組織：組織擁有的拓樸回傳組織 ID，其他回傳 nil
+func (s *Service) simulationOrganization(topologyID string) (*string, error) {
+	if s.organizationQuota == nil || topologyID == "" {
+		return nil, nil
+	}
+	return s.organizationQuota.TopologyOrganization(topologyID)
+}
+
+// CheckSimulationQuotaForTopology 檢查對拓樸執行模擬的配額：組織擁有的拓樸使用組織共用配額，否則為個人配額
+func (s *Service) CheckSimulationQuotaForTopology(userID *string, topologyID string) (bool, error) {
+	organizationID, err := s.simulationOrganization(topologyID)
+	if err != nil {
+		return false, err
+	}
+	if organizationID != nil {
+		return s.organizationQuota.CheckSimulationQuota(*organizationID)
+	}
+	return s.CheckSimulationQuota(userID)
+}
+
+// ChargeSimulation 扣除一次模擬配額：組織擁有的拓樸由組織共用配額扣除，否則由登入用戶的個人配額扣除（demo 模式不扣除）
+func (s *Service) ChargeSimulation(userID *string, topologyID string) error {
+	organizationID, err := s.simulationOrganization(topologyID)
+	if err != nil {
+		return err
+	}
+	if organizationID != nil {
+		return s.organizationQuota.IncrementSimulationCount(*organizationID)
+	}
+	if userID == nil {
+		return nil
+	}
+	return s.IncrementSimulationCount(*userID)
+}
+
 // CanUseFeature 檢查用戶是否可以使用特定功能
 func (s *Service) CanUseFeature(userID *string, feature string) (bool, error) {
 	if userID == nil {
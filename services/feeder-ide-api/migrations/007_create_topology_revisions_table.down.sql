@@ -0,0 +1,3 @@
+-- 刪除拓樸版本歷史表
+DROP INDEX IF EXISTS idx_topology_revisions_topology_id;
+DROP TABLE IF EXISTS topology_revisions;
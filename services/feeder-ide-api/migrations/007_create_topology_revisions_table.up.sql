@@ -0,0 +1,22 @@
+-- 創建拓樸版本歷史表
+CREATE TABLE IF NOT EXISTS topology_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    topology_id UUID NOT NULL REFERENCES topologies(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    message TEXT,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    profile_type VARCHAR(50),
+    nodes JSONB NOT NULL DEFAULT '[]'::jsonb,
+    lines JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(topology_id, revision)
+);
+
+CREATE INDEX idx_topology_revisions_topology_id ON topology_revisions(topology_id);
+
+-- 為既有拓樸建立第一個版本
+INSERT INTO topology_revisions (topology_id, revision, author_id, message, name, description, profile_type, nodes, lines, created_at)
+SELECT id, 1, user_id, 'Initial revision', name, description, profile_type, nodes, lines, COALESCE(updated_at, CURRENT_TIMESTAMP)
+FROM topologies;
@@ -0,0 +1,2 @@
+-- 移除拓樸版本欄位
+ALTER TABLE topologies DROP COLUMN IF EXISTS version;
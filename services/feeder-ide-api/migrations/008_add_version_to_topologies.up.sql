@@ -0,0 +1,12 @@
+-- 為拓樸表添加版本欄位（樂觀鎖）
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- 以既有的最新版本號初始化
+UPDATE topologies t
+SET version = r.latest
+FROM (
+    SELECT topology_id, MAX(revision) AS latest
+    FROM topology_revisions
+    GROUP BY topology_id
+) r
+WHERE t.id = r.topology_id;
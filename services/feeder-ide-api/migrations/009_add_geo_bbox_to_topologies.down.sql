@@ -0,0 +1,6 @@
+-- 移除拓樸經緯度範圍欄位
+DROP INDEX IF EXISTS idx_topologies_geo_bbox;
+ALTER TABLE topologies DROP COLUMN IF EXISTS geo_min_lon;
+ALTER TABLE topologies DROP COLUMN IF EXISTS geo_min_lat;
+ALTER TABLE topologies DROP COLUMN IF EXISTS geo_max_lon;
+ALTER TABLE topologies DROP COLUMN IF EXISTS geo_max_lat;
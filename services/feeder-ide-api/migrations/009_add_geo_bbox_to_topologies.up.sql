@@ -0,0 +1,28 @@
+-- 為拓樸表添加經緯度範圍欄位（bbox 查詢用，以一般欄位比較，不需 PostGIS）
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS geo_min_lon DOUBLE PRECISION;
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS geo_min_lat DOUBLE PRECISION;
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS geo_max_lon DOUBLE PRECISION;
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS geo_max_lat DOUBLE PRECISION;
+
+CREATE INDEX IF NOT EXISTS idx_topologies_geo_bbox ON topologies(geo_min_lon, geo_max_lon, geo_min_lat, geo_max_lat);
+
+-- 以既有節點經緯度與線路折點初始化
+UPDATE topologies t
+SET geo_min_lon = b.min_lon,
+    geo_min_lat = b.min_lat,
+    geo_max_lon = b.max_lon,
+    geo_max_lat = b.max_lat
+FROM (
+    SELECT id, MIN(lon) AS min_lon, MIN(lat) AS min_lat, MAX(lon) AS max_lon, MAX(lat) AS max_lat
+    FROM (
+        SELECT id, (n->'geo'->>'lon')::DOUBLE PRECISION AS lon, (n->'geo'->>'lat')::DOUBLE PRECISION AS lat
+        FROM topologies, jsonb_array_elements(nodes) n
+        WHERE jsonb_typeof(n->'geo') = 'object'
+        UNION ALL
+        SELECT id, (p->>'lon')::DOUBLE PRECISION, (p->>'lat')::DOUBLE PRECISION
+        FROM topologies, jsonb_array_elements(lines) l, jsonb_array_elements(l->'geometry') p
+        WHERE jsonb_typeof(l->'geometry') = 'array'
+    ) points
+    GROUP BY id
+) b
+WHERE t.id = b.id;
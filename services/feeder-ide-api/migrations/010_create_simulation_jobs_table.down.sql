@@ -0,0 +1,5 @@
+-- 刪除非同步模擬工作表
+DROP INDEX IF EXISTS idx_simulation_jobs_status;
+DROP INDEX IF EXISTS idx_simulation_jobs_topology_id;
+DROP INDEX IF EXISTS idx_simulation_jobs_user_id;
+DROP TABLE IF EXISTS simulation_jobs;
@@ -0,0 +1,20 @@
+-- 創建非同步模擬工作表
+CREATE TABLE IF NOT EXISTS simulation_jobs (
+    id UUID PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    topology_id UUID NOT NULL REFERENCES topologies(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    progress DOUBLE PRECISION NOT NULL DEFAULT 0,
+    message TEXT NOT NULL DEFAULT '',
+    params JSONB,
+    result JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_simulation_jobs_user_id ON simulation_jobs(user_id, created_at DESC);
+CREATE INDEX idx_simulation_jobs_topology_id ON simulation_jobs(topology_id);
+CREATE INDEX idx_simulation_jobs_status ON simulation_jobs(status);
@@ -0,0 +1,5 @@
+-- 刪除設備型錄表
+DROP INDEX IF EXISTS idx_equipment_catalog_organization_id;
+DROP INDEX IF EXISTS idx_equipment_catalog_owner_id;
+DROP INDEX IF EXISTS idx_equipment_catalog_type;
+DROP TABLE IF EXISTS equipment_catalog;
@@ -0,0 +1,19 @@
+-- 創建設備型錄表（內建標準項目由程式提供，不存於資料表）
+CREATE TABLE IF NOT EXISTS equipment_catalog (
+    id UUID PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    manufacturer VARCHAR(255) NOT NULL DEFAULT '',
+    standard VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    properties JSONB NOT NULL DEFAULT '{}',
+    scope VARCHAR(20) NOT NULL,
+    owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    organization_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_equipment_catalog_type ON equipment_catalog(type);
+CREATE INDEX idx_equipment_catalog_owner_id ON equipment_catalog(owner_id);
+CREATE INDEX idx_equipment_catalog_organization_id ON equipment_catalog(organization_id);
@@ -0,0 +1,10 @@
+-- 移除拓樸列表摘要與全文搜尋欄位
+DROP INDEX IF EXISTS idx_topologies_profile_type;
+DROP INDEX IF EXISTS idx_topologies_name;
+DROP INDEX IF EXISTS idx_topologies_created_at_id;
+DROP INDEX IF EXISTS idx_topologies_updated_at;
+DROP INDEX IF EXISTS idx_topologies_search_vector;
+ALTER TABLE topologies DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE topologies DROP COLUMN IF EXISTS node_type_counts;
+ALTER TABLE topologies DROP COLUMN IF EXISTS line_count;
+ALTER TABLE topologies DROP COLUMN IF EXISTS node_count;
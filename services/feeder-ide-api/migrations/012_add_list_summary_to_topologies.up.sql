@@ -0,0 +1,27 @@
+-- 為拓樸表添加列表摘要欄位（列表時不必載入 nodes/lines JSONB）與全文搜尋欄位
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS node_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS line_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS node_type_counts JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- 名稱與描述的全文搜尋向量（simple 設定：只轉小寫，不做詞幹與停用詞處理）
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(name, '') || ' ' || COALESCE(description, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_topologies_search_vector ON topologies USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_topologies_updated_at ON topologies(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_topologies_created_at_id ON topologies(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_topologies_name ON topologies((name COLLATE "C"), id);
+CREATE INDEX IF NOT EXISTS idx_topologies_profile_type ON topologies(profile_type);
+
+-- 以既有 nodes/lines 初始化摘要欄位
+UPDATE topologies t
+SET node_count = jsonb_array_length(t.nodes),
+    line_count = jsonb_array_length(t.lines),
+    node_type_counts = COALESCE((
+        SELECT jsonb_object_agg(c.type, c.count)
+        FROM (
+            SELECT COALESCE(n->>'type', '') AS type, COUNT(*) AS count
+            FROM jsonb_array_elements(t.nodes) n
+            GROUP BY 1
+        ) c
+    ), '{}'::jsonb);
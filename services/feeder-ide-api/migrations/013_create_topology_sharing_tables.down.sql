@@ -0,0 +1,5 @@
+-- 刪除拓樸共用表
+DROP INDEX IF EXISTS idx_topology_share_links_topology_id;
+DROP TABLE IF EXISTS topology_share_links;
+DROP INDEX IF EXISTS idx_topology_collaborators_user_id;
+DROP TABLE IF EXISTS topology_collaborators;
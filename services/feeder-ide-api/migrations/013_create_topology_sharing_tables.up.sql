@@ -0,0 +1,26 @@
+-- 創建拓樸共用對象表（擁有者本身記錄於 topologies.user_id）
+CREATE TABLE IF NOT EXISTS topology_collaborators (
+    topology_id UUID NOT NULL REFERENCES topologies(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (topology_id, user_id)
+);
+
+CREATE INDEX idx_topology_collaborators_user_id ON topology_collaborators(user_id);
+
+-- 創建唯讀共用連結表（只儲存 token 的 SHA-256 雜湊）
+CREATE TABLE IF NOT EXISTS topology_share_links (
+    id UUID PRIMARY KEY,
+    topology_id UUID NOT NULL REFERENCES topologies(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL DEFAULT 'viewer' CHECK (role = 'viewer'),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_topology_share_links_topology_id ON topology_share_links(topology_id);
@@ -0,0 +1,8 @@
+-- 移除組織相關表與拓樸的組織欄位
+DROP INDEX IF EXISTS idx_topologies_organization_id;
+ALTER TABLE topologies DROP CONSTRAINT IF EXISTS chk_topologies_single_owner;
+ALTER TABLE topologies DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_quotas;
+DROP INDEX IF EXISTS idx_organization_members_user_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
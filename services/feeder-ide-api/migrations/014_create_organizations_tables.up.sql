@@ -0,0 +1,40 @@
+-- 創建組織（團隊工作區）表，訂閱等級由組織成員共用
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    subscription_tier VARCHAR(50) NOT NULL DEFAULT 'free' CHECK (subscription_tier IN ('free', 'team', 'enterprise')),
+    subscription_status VARCHAR(50) NOT NULL DEFAULT 'active',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 創建組織成員表
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'engineer', 'viewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+-- 創建組織共用配額表（所有成員共用同一份拓樸數量與每日模擬次數）
+CREATE TABLE IF NOT EXISTS organization_quotas (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    max_topologies INTEGER NOT NULL,
+    used_topologies INTEGER NOT NULL DEFAULT 0,
+    max_simulations_per_day INTEGER NOT NULL,
+    used_simulations_today INTEGER NOT NULL DEFAULT 0,
+    last_simulation_reset_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 拓樸可由組織擁有（此時 user_id 為 NULL）；組織仍擁有拓樸時不可刪除
+ALTER TABLE topologies ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE RESTRICT;
+ALTER TABLE topologies ADD CONSTRAINT chk_topologies_single_owner CHECK (user_id IS NULL OR organization_id IS NULL);
+
+CREATE INDEX IF NOT EXISTS idx_topologies_organization_id ON topologies(organization_id);
@@ -0,0 +1,2 @@
+-- 刪除協作編輯操作紀錄表
+DROP TABLE IF EXISTS topology_operations;
@@ -0,0 +1,11 @@
+-- 創建協作編輯操作紀錄表（version 為套用後的拓樸版本，重新連線的用戶端由此補齊操作）
+CREATE TABLE IF NOT EXISTS topology_operations (
+    topology_id UUID NOT NULL REFERENCES topologies(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    client_id VARCHAR(64) NOT NULL DEFAULT '',
+    client_op_id VARCHAR(128) NOT NULL DEFAULT '',
+    operation JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (topology_id, version)
+);
@@ -0,0 +1,5 @@
+-- 刪除拓樸範本表
+DROP INDEX IF EXISTS idx_topology_templates_organization_id;
+DROP INDEX IF EXISTS idx_topology_templates_owner_id;
+DROP INDEX IF EXISTS idx_topology_templates_profile_type;
+DROP TABLE IF EXISTS topology_templates;
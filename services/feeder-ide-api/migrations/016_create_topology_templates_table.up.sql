@@ -0,0 +1,22 @@
+-- 創建拓樸範本表（系統內建範本由程式提供，不存於資料表）
+CREATE TABLE IF NOT EXISTS topology_templates (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    profile_type VARCHAR(50) NOT NULL,
+    parameters JSONB NOT NULL DEFAULT '[]',
+    nodes JSONB NOT NULL DEFAULT '[]',
+    lines JSONB NOT NULL DEFAULT '[]',
+    node_count INTEGER NOT NULL DEFAULT 0,
+    line_count INTEGER NOT NULL DEFAULT 0,
+    scope VARCHAR(20) NOT NULL,
+    owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    source_topology_id UUID REFERENCES topologies(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_topology_templates_profile_type ON topology_templates(profile_type);
+CREATE INDEX idx_topology_templates_owner_id ON topology_templates(owner_id);
+CREATE INDEX idx_topology_templates_organization_id ON topology_templates(organization_id);
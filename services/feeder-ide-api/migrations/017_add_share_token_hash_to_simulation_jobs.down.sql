@@ -0,0 +1,2 @@
+-- 移除工作的共用連結 token 雜湊欄位
+ALTER TABLE simulation_jobs DROP COLUMN IF EXISTS share_token_hash;
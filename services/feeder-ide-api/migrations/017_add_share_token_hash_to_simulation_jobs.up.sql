@@ -0,0 +1,2 @@
+-- 記錄未登入者透過共用連結提交工作時的連結 token 雜湊，只有持有同一連結者可存取
+ALTER TABLE simulation_jobs ADD COLUMN IF NOT EXISTS share_token_hash VARCHAR(64) NOT NULL DEFAULT '';
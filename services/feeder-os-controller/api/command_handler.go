@@ -0,0 +1,129 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/feeder-platform/feeder-os-controller/internal/apps"
+	"github.com/feeder-platform/feeder-os-controller/internal/bus"
+	"github.com/gin-gonic/gin"
+)
+
+// CommandHandler 處理 feeder 控制命令的 HTTP 請求
+type CommandHandler struct {
+	bus bus.Bus
+}
+
+// NewCommandHandler 建立新的 CommandHandler
+func NewCommandHandler(b bus.Bus) *CommandHandler {
+	return &CommandHandler{bus: b}
+}
+
+// RequireCommandToken 驗證呼叫端的服務 token（Authorization: Bearer）；token 未設定時拒絕所有命令
+func RequireCommandToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Command endpoint is disabled: FEEDER_OS_COMMAND_TOKEN is not set"})
+			c.Abort()
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing service token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Command 單一設備控制命令
+type Command struct {
+	Sequence  int    `json:"sequence"`
+	AssetID   string `json:"asset_id" binding:"required"`
+	Action    string `json:"action" binding:"required"`
+	Phase     string `json:"phase,omitempty"`
+	Automated bool   `json:"automated"`
+}
+
+// PublishCommandsRequest 發布命令的請求
+type PublishCommandsRequest struct {
+	Source   string    `json:"source"`
+	PlanID   string    `json:"plan_id"`
+	Commands []Command `json:"commands" binding:"required,min=1,dive"`
+}
+
+// CommandMessage 發布到 commands topic 的訊息
+type CommandMessage struct {
+	Command
+	FeederID string    `json:"feeder_id"`
+	Source   string    `json:"source,omitempty"`
+	PlanID   string    `json:"plan_id,omitempty"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// PublishCommandsResponse 發布命令的結果
+type PublishCommandsResponse struct {
+	FeederID string   `json:"feeder_id"`
+	Commands int      `json:"commands"`
+	Topics   []string `json:"topics"`
+}
+
+// PublishCommands 依序發布控制命令
+// @Summary 發布控制命令
+// @Description 依序將命令發布到 feeder/{feederId}/commands/{asset_id}，任一命令發布失敗即停止
+// @Tags commands
+// @Accept json
+// @Produce json
+// @Param feederId path string true "Feeder ID"
+// @Param Authorization header string true "Bearer <FEEDER_OS_COMMAND_TOKEN>"
+// @Param request body PublishCommandsRequest true "命令批次"
+// @Success 202 {object} PublishCommandsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/feeders/{feederId}/commands [post]
+func (h *CommandHandler) PublishCommands(c *gin.Context) {
+	feederID := c.Param("feederId")
+
+	var req PublishCommandsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	issuedAt := time.Now()
+	topics := make([]string, 0, len(req.Commands))
+	for _, cmd := range req.Commands {
+		payload, err := json.Marshal(CommandMessage{
+			Command:  cmd,
+			FeederID: feederID,
+			Source:   req.Source,
+			PlanID:   req.PlanID,
+			IssuedAt: issuedAt,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		topic := apps.CommandsTopic(feederID, cmd.AssetID)
+		if err := h.bus.Publish(topic, payload); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "published": topics})
+			return
+		}
+		topics = append(topics, topic)
+	}
+
+	c.JSON(http.StatusAccepted, PublishCommandsResponse{
+		FeederID: feederID,
+		Commands: len(topics),
+		Topics:   topics,
+	})
+}
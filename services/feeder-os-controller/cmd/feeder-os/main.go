@@ -36,6 +36,7 @@ func main() {
 
 	// 初始化 handlers
 	appHandler := api.NewAppHandler(appManager)
+	commandHandler := api.NewCommandHandler(mqttBus)
 
 	// 設定 Gin router
 	router := gin.Default()
@@ -69,6 +70,9 @@ func main() {
 		v1.POST("/apps/disable", appHandler.DisableApp)
 		v1.GET("/apps", appHandler.ListApps)
 		v1.GET("/apps/:id", appHandler.GetApp)
+
+		// Feeder control commands（操作現場設備，需要服務 token）
+		v1.POST("/feeders/:feederId/commands", api.RequireCommandToken(cfg.Commands.Token), commandHandler.PublishCommands)
 	}
 
 	// 啟動 server
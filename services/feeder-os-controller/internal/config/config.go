@@ -6,8 +6,9 @@ import (
 
 // Config 應用程式配置
 type Config struct {
-	MQTT MQTTConfig
-	Apps AppsConfig
+	MQTT     MQTTConfig
+	Apps     AppsConfig
+	Commands CommandsConfig
 }
 
 // MQTTConfig MQTT broker 配置
@@ -24,6 +25,12 @@ type AppsConfig struct {
 	StoragePath string
 }
 
+// CommandsConfig 控制命令端點配置
+type CommandsConfig struct {
+	// Token 呼叫命令端點所需的服務 token（Authorization: Bearer），未設定時端點停用
+	Token string
+}
+
 // Load 載入配置（從環境變數）
 func Load() *Config {
 	return &Config{
@@ -37,6 +44,9 @@ func Load() *Config {
 		Apps: AppsConfig{
 			StoragePath: getEnv("APPS_STORAGE_PATH", "./apps"),
 		},
+		Commands: CommandsConfig{
+			Token: getEnv("FEEDER_OS_COMMAND_TOKEN", ""),
+		},
 	}
 }
 